@@ -8,7 +8,7 @@ import (
 const OK = 0
 
 type State struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 /**
@@ -0,0 +1,10 @@
+package libtool
+
+// noCopy marks the opaque structs, which must only be used through pointers.
+type noCopy struct {
+}
+
+func (*noCopy) Lock() {
+}
+func (*noCopy) Unlock() {
+}
@@ -12,7 +12,7 @@ type Module c.Pointer
 type UserData c.Pointer
 
 type Advise struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type Dladvise *Advise
 
@@ -8,7 +8,7 @@ import (
 const LTDL_H = 1
 
 type Handle struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type Dlhandle *Handle
 
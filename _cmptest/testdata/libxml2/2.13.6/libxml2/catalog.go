@@ -23,7 +23,7 @@ const (
 )
 
 type X_xmlCatalog struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type Catalog X_xmlCatalog
 type CatalogPtr *Catalog
@@ -3,7 +3,7 @@ package libxml2
 import _ "unsafe"
 
 type X_xmlGlobalState struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type GlobalState X_xmlGlobalState
 type GlobalStatePtr *GlobalState
@@ -6,7 +6,7 @@ import (
 )
 
 type X_xmlHashTable struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type HashTable X_xmlHashTable
 type HashTablePtr *HashTable
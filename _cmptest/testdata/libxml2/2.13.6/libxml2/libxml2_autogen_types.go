@@ -0,0 +1,10 @@
+package libxml2
+
+// noCopy marks the opaque structs, which must only be used through pointers.
+type noCopy struct {
+}
+
+func (*noCopy) Lock() {
+}
+func (*noCopy) Unlock() {
+}
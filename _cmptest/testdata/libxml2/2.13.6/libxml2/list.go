@@ -6,13 +6,13 @@ import (
 )
 
 type X_xmlLink struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type Link X_xmlLink
 type LinkPtr *Link
 
 type X_xmlList struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type List X_xmlList
 type ListPtr *List
@@ -67,17 +67,17 @@ const (
 )
 
 type X_xmlStartTag struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type StartTag X_xmlStartTag
 
 type X_xmlParserNsData struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type ParserNsData X_xmlParserNsData
 
 type X_xmlAttrHashBucket struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type AttrHashBucket X_xmlAttrHashBucket
 
@@ -6,7 +6,7 @@ import (
 )
 
 type X_xmlPattern struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type Pattern X_xmlPattern
 type PatternPtr *Pattern
@@ -39,7 +39,7 @@ func (recv_ *Char) PatternCompileSafe(dict *Dict, flags c.Int, namespaces **Char
 func PatternMatch(comp PatternPtr, node NodePtr) c.Int
 
 type X_xmlStreamCtxt struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type StreamCtxt X_xmlStreamCtxt
 type StreamCtxtPtr *StreamCtxt
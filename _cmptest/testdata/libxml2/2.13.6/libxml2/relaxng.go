@@ -6,7 +6,7 @@ import (
 )
 
 type X_xmlRelaxNG struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type RelaxNG X_xmlRelaxNG
 type RelaxNGPtr *RelaxNG
@@ -18,13 +18,13 @@ type RelaxNGValidityErrorFunc func(__llgo_arg_0 c.Pointer, __llgo_arg_1 *c.Char,
 type RelaxNGValidityWarningFunc func(__llgo_arg_0 c.Pointer, __llgo_arg_1 *c.Char, __llgo_va_list ...interface{})
 
 type X_xmlRelaxNGParserCtxt struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type RelaxNGParserCtxt X_xmlRelaxNGParserCtxt
 type RelaxNGParserCtxtPtr *RelaxNGParserCtxt
 
 type X_xmlRelaxNGValidCtxt struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type RelaxNGValidCtxt X_xmlRelaxNGValidCtxt
 type RelaxNGValidCtxtPtr *RelaxNGValidCtxt
@@ -130,7 +130,7 @@ const (
 )
 
 type X_xmlSchemaVal struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type SchemaVal X_xmlSchemaVal
 type SchemaValPtr *SchemaVal
@@ -18,7 +18,7 @@ const (
 )
 
 type X_xmlSchematron struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type Schematron X_xmlSchematron
 type SchematronPtr *Schematron
@@ -30,13 +30,13 @@ type SchematronValidityErrorFunc func(__llgo_arg_0 c.Pointer, __llgo_arg_1 *c.Ch
 type SchematronValidityWarningFunc func(__llgo_arg_0 c.Pointer, __llgo_arg_1 *c.Char, __llgo_va_list ...interface{})
 
 type X_xmlSchematronParserCtxt struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type SchematronParserCtxt X_xmlSchematronParserCtxt
 type SchematronParserCtxtPtr *SchematronParserCtxt
 
 type X_xmlSchematronValidCtxt struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type SchematronValidCtxt X_xmlSchematronValidCtxt
 type SchematronValidCtxtPtr *SchematronValidCtxt
@@ -6,13 +6,13 @@ import (
 )
 
 type X_xmlMutex struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type Mutex X_xmlMutex
 type MutexPtr *Mutex
 
 type X_xmlRMutex struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type RMutex X_xmlRMutex
 type RMutexPtr *RMutex
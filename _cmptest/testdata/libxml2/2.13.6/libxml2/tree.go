@@ -252,7 +252,7 @@ type Buffer X_xmlBuffer
 type BufferPtr *Buffer
 
 type X_xmlBuf struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type Buf X_xmlBuf
 type BufPtr *Buf
@@ -552,7 +552,7 @@ type Doc X_xmlDoc
 type DocPtr *Doc
 
 type X_xmlDict struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 type X_xmlDOMWrapCtxt struct {
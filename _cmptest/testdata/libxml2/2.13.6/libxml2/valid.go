@@ -6,7 +6,7 @@ import (
 )
 
 type X_xmlValidState struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type ValidState X_xmlValidState
 type ValidStatePtr *ValidState
@@ -6,7 +6,7 @@ import (
 )
 
 type X_xmlXIncludeCtxt struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type XIncludeCtxt X_xmlXIncludeCtxt
 type XIncludeCtxtPtr *XIncludeCtxt
@@ -6,13 +6,13 @@ import (
 )
 
 type X_xmlAutomata struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type Automata X_xmlAutomata
 type AutomataPtr *Automata
 
 type X_xmlAutomataState struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type AutomataState X_xmlAutomataState
 type AutomataStatePtr *AutomataState
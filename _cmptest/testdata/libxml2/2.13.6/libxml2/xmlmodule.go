@@ -6,7 +6,7 @@ import (
 )
 
 type X_xmlModule struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type Module X_xmlModule
 type ModulePtr *Module
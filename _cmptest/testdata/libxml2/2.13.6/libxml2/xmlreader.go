@@ -58,7 +58,7 @@ const (
 )
 
 type X_xmlTextReader struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type TextReader X_xmlTextReader
 type TextReaderPtr *TextReader
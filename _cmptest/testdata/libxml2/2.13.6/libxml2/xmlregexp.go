@@ -6,13 +6,13 @@ import (
 )
 
 type X_xmlRegexp struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type Regexp X_xmlRegexp
 type RegexpPtr *Regexp
 
 type X_xmlRegExecCtxt struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type RegExecCtxt X_xmlRegExecCtxt
 type RegExecCtxtPtr *RegExecCtxt
@@ -19,7 +19,7 @@ const (
 )
 
 type X_xmlSaveCtxt struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type SaveCtxt X_xmlSaveCtxt
 type SaveCtxtPtr *SaveCtxt
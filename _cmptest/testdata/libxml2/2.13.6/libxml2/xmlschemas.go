@@ -50,13 +50,13 @@ type SchemaValidityErrorFunc func(__llgo_arg_0 c.Pointer, __llgo_arg_1 *c.Char,
 type SchemaValidityWarningFunc func(__llgo_arg_0 c.Pointer, __llgo_arg_1 *c.Char, __llgo_va_list ...interface{})
 
 type X_xmlSchemaParserCtxt struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type SchemaParserCtxt X_xmlSchemaParserCtxt
 type SchemaParserCtxtPtr *SchemaParserCtxt
 
 type X_xmlSchemaValidCtxt struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type SchemaValidCtxt X_xmlSchemaValidCtxt
 type SchemaValidCtxtPtr *SchemaValidCtxt
@@ -143,7 +143,7 @@ func SchemaValidateFile(ctxt SchemaValidCtxtPtr, filename *c.Char, options c.Int
 func SchemaValidCtxtGetParserCtxt(ctxt SchemaValidCtxtPtr) ParserCtxtPtr
 
 type X_xmlSchemaSAXPlug struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type SchemaSAXPlugStruct X_xmlSchemaSAXPlug
 type SchemaSAXPlugPtr *SchemaSAXPlugStruct
@@ -6,7 +6,7 @@ import (
 )
 
 type X_xmlTextWriter struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type TextWriter X_xmlTextWriter
 type TextWriterPtr *TextWriter
@@ -184,7 +184,7 @@ type XPathVariableLookupFunc func(c.Pointer, *Char, *Char) XPathObjectPtr
 type XPathFuncLookupFunc func(c.Pointer, *Char, *Char) XPathFunction
 
 type X_xmlXPathCompExpr struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type XPathCompExpr X_xmlXPathCompExpr
 type XPathCompExprPtr *XPathCompExpr
@@ -7,7 +7,7 @@ import (
 )
 
 type X_xsltCompMatch struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 type X_xsltNumberData struct {
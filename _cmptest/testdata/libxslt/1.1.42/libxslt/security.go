@@ -7,7 +7,7 @@ import (
 )
 
 type X_xsltSecurityPrefs struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type SecurityPrefs X_xsltSecurityPrefs
 type SecurityPrefsPtr *SecurityPrefs
@@ -11,9 +11,11 @@ const MAX_SORT = 15
 type X_xsltRuntimeExtra struct {
 	Info       c.Pointer
 	Deallocate libxml2.FreeFunc
-	Val        struct {
-		Ptr c.Pointer
-	}
+	Val        X_xsltRuntimeExtra_Val
+}
+
+type X_xsltRuntimeExtra_Val struct {
+	Ptr c.Pointer
 }
 type RuntimeExtra X_xsltRuntimeExtra
 type RuntimeExtraPtr *RuntimeExtra
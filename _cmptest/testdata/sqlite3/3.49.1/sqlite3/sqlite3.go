@@ -484,7 +484,7 @@ func CompileoptionGet(N c.Int) *c.Char
 func Threadsafe() c.Int
 
 type Sqlite3 struct {
-	Unused [8]uint8
+	_ noCopy
 }
 type SqliteInt64 c.LongLong
 type SqliteUint64 c.UlongLong
@@ -638,7 +638,7 @@ type IoMethods struct {
 }
 
 type Mutex struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 type ApiRoutines struct {
@@ -2547,7 +2547,7 @@ func (recv_ *Sqlite3) ErrorOffset() c.Int {
 }
 
 type Stmt struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 /*
@@ -2910,11 +2910,11 @@ func (recv_ *Stmt) StmtBusy() c.Int {
 }
 
 type Value struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 type Context struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 /*
@@ -5801,7 +5801,7 @@ func (recv_ *Sqlite3) OverloadFunction(zFuncName *c.Char, nArg c.Int) c.Int {
 }
 
 type Blob struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 /*
@@ -6370,7 +6370,7 @@ func KeywordName(c.Int, **c.Char, *c.Int) c.Int
 func KeywordCheck(*c.Char, c.Int) c.Int
 
 type Str struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 /*
@@ -6610,7 +6610,7 @@ func (recv_ *Stmt) StmtStatus(op c.Int, resetFlg c.Int) c.Int {
 }
 
 type Pcache struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 type PcachePage struct {
@@ -6649,7 +6649,7 @@ type PcacheMethods struct {
 }
 
 type Backup struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 /*
@@ -7896,7 +7896,7 @@ type Fts5ExtensionApi struct {
 }
 
 type Fts5Context struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 type Fts5PhraseIter struct {
@@ -7908,7 +7908,7 @@ type Fts5PhraseIter struct {
 type Fts5ExtensionFunction func(*Fts5ExtensionApi, *Fts5Context, *Context, c.Int, **Value)
 
 type Fts5Tokenizer struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 type Fts5TokenizerV2 struct {
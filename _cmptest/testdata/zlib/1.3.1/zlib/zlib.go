@@ -43,7 +43,7 @@ type AllocFunc func(Voidpf, UInt, UInt) Voidpf
 type FreeFunc func(Voidpf, Voidpf)
 
 type InternalState struct {
-	Unused [8]uint8
+	_ noCopy
 }
 
 type ZStreamS struct {
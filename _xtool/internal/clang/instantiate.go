@@ -0,0 +1,249 @@
+package clang
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/goplus/lib/c"
+	clang "github.com/goplus/llcppg/_xtool/internal/libclang"
+)
+
+// InstPrefix is the name prefix of the structs of the instantiation header, the Nth struct
+// llcppg_inst_N derives from the Nth instantiated class template and refers to its members.
+const InstPrefix = "llcppg_inst_"
+
+type InstantiationConfig struct {
+	Header    string   // header including the ones of the package
+	Templates []string // template-ids to instantiate, like std::vector<int>
+	Args      []string
+}
+
+// templateMembers is the public members of a class template, which are referred
+// by the struct of its instantiation.
+type templateMembers struct {
+	name        string   // name of the template, also the one of its constructors
+	methods     []string // names of the methods
+	ctor        bool     // has a constructor with parameters
+	defaultCtor bool     // has a default constructor
+	dtor        bool     // has a destructor
+}
+
+// ComposeInstantiations writes the instantiation header to outfile, which explicitly instantiates
+// the class templates of conf and declares a struct for each instantiation, like
+//
+//	template class std::vector<int>;
+//	struct llcppg_inst_0 : std::vector<int> {
+//		static void llcppg_init(std::vector<int> *self) { new (self) std::vector<int>(); }
+//		using std::vector<int>::vector;
+//		using std::vector<int>::push_back;
+//		static void llcppg_dispose(std::vector<int> *self) { self->~vector(); }
+//	};
+//
+// libclang doesn't visit the members of an instantiation, but the declarations of the struct
+// refer to them, see VisitInstantiation. The members are the ones of the template itself,
+// the inherited ones and the implicit ones are not bound, see publicMembers.
+func ComposeInstantiations(conf *InstantiationConfig, outfile string) error {
+	members, err := collectTemplateMembers(conf)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	b.WriteString("#include <new>\n")
+	for i, tmpl := range conf.Templates {
+		m := members[i]
+		fmt.Fprintf(&b, "\ntemplate class %s;\n", tmpl)
+		fmt.Fprintf(&b, "struct %s%d : %s {\n", InstPrefix, i, tmpl)
+		if m.defaultCtor {
+			fmt.Fprintf(&b, "\tstatic void llcppg_init(%s *self) { new (self) %s(); }\n", tmpl, tmpl)
+		}
+		if m.ctor {
+			fmt.Fprintf(&b, "\tusing %s::%s;\n", tmpl, m.name)
+		}
+		for _, method := range m.methods {
+			fmt.Fprintf(&b, "\tusing %s::%s;\n", tmpl, method)
+		}
+		if m.dtor {
+			fmt.Fprintf(&b, "\tstatic void llcppg_dispose(%s *self) { self->~%s(); }\n", tmpl, m.name)
+		}
+		b.WriteString("};\n")
+	}
+	return os.WriteFile(outfile, []byte(b.String()), 0644)
+}
+
+// IncludeInstantiations writes the instantiation header of conf to a temporary file named by
+// pattern, see ComposeInstantiations, and includes it at the end of conf.Header. It returns ""
+// if there are no templates to instantiate, and the caller removes the file otherwise.
+func IncludeInstantiations(conf *InstantiationConfig, pattern string) (instFile string, err error) {
+	if len(conf.Templates) == 0 {
+		return "", nil
+	}
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	instFile = f.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(instFile)
+			instFile = ""
+		}
+	}()
+	if err = f.Close(); err != nil {
+		return
+	}
+	if err = ComposeInstantiations(conf, instFile); err != nil {
+		return
+	}
+	f, err = os.OpenFile(conf.Header, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "#include \"%s\"\n", instFile)
+	return
+}
+
+// collectTemplateMembers parses the typedefs of the template-ids to find the members
+// of the class templates they instantiate.
+func collectTemplateMembers(conf *InstantiationConfig) ([]*templateMembers, error) {
+	var src strings.Builder
+	fmt.Fprintf(&src, "#include \"%s\"\n", conf.Header)
+	for i, tmpl := range conf.Templates {
+		fmt.Fprintf(&src, "typedef %s %s%d;\n", tmpl, InstPrefix, i)
+	}
+	index, unit, err := CreateTranslationUnit(&Config{
+		File:  src.String(),
+		Temp:  true,
+		Args:  conf.Args,
+		IsCpp: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer index.Dispose()
+	defer unit.Dispose()
+
+	members := make([]*templateMembers, len(conf.Templates))
+	VisitChildren(unit.Cursor(), func(cursor, parent clang.Cursor) clang.ChildVisitResult {
+		if cursor.Kind != clang.CursorTypedefDecl {
+			return clang.ChildVisit_Continue
+		}
+		i, ok := InstantiationIndex(cursor)
+		if !ok || i >= len(members) {
+			return clang.ChildVisit_Continue
+		}
+		spec := cursor.TypedefDeclUnderlyingType().CanonicalType().TypeDeclaration()
+		if tmpl := spec.SpecializedTemplate(); tmpl.IsNull() == 0 {
+			members[i] = publicMembers(tmpl)
+		}
+		return clang.ChildVisit_Continue
+	})
+	for i, m := range members {
+		if m == nil {
+			return nil, fmt.Errorf("instantiate %s: not a class template specialization", conf.Templates[i])
+		}
+	}
+	return members, nil
+}
+
+// publicMembers returns the public members declared by a class template. The implicit
+// members, like the default constructor and the destructor of a template declaring none,
+// are not bound: the explicit instantiation defines only the declared members, and the
+// trivial ones have no symbols at all.
+func publicMembers(tmpl clang.Cursor) *templateMembers {
+	m := &templateMembers{name: clang.GoString(tmpl.String())}
+	seen := make(map[string]bool)
+	VisitChildren(tmpl, func(cursor, parent clang.Cursor) clang.ChildVisitResult {
+		if cursor.CXXAccessSpecifier() != clang.CXXPublic {
+			return clang.ChildVisit_Continue
+		}
+		switch cursor.Kind {
+		case clang.CursorConstructor:
+			if cursor.NumArguments() == 0 {
+				m.defaultCtor = true
+			} else {
+				m.ctor = true
+			}
+		case clang.CursorDestructor:
+			m.dtor = true
+		case clang.CursorCXXMethod:
+			if name := clang.GoString(cursor.String()); !seen[name] {
+				seen[name] = true
+				m.methods = append(m.methods, name)
+			}
+		}
+		return clang.ChildVisit_Continue
+	})
+	return m
+}
+
+// InstantiationIndex reports the index of the template-id instantiated by a struct
+// of the instantiation header, which is named llcppg_inst_N.
+func InstantiationIndex(cursor clang.Cursor) (int, bool) {
+	suffix, ok := strings.CutPrefix(clang.GoString(cursor.String()), InstPrefix)
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.Atoi(suffix)
+	return i, err == nil
+}
+
+// InstantiationType returns the type of the class template specialization, which is
+// the base of the struct of the instantiation header, spelled by its template-id.
+func InstantiationType(cursor clang.Cursor) (typ clang.Type) {
+	VisitChildren(cursor, func(child, parent clang.Cursor) clang.ChildVisitResult {
+		if child.Kind == clang.CursorCXXBaseSpecifier {
+			typ = child.Type()
+			return clang.ChildVisit_Break
+		}
+		return clang.ChildVisit_Continue
+	})
+	return
+}
+
+// VisitInstantiation calls fn with the members of a class template specialization
+// referred by the struct of the instantiation header, the methods named by its using
+// declarations and the constructor and destructor called by its static functions.
+func VisitInstantiation(cursor clang.Cursor, fn func(member clang.Cursor)) {
+	var seen []clang.Cursor
+	visit := func(member clang.Cursor) {
+		for _, m := range seen {
+			if m.Equal(member) != 0 {
+				return
+			}
+		}
+		seen = append(seen, member)
+		fn(member)
+	}
+	VisitChildren(cursor, func(child, parent clang.Cursor) clang.ChildVisitResult {
+		switch child.Kind {
+		case clang.CursorUsingDeclaration:
+			VisitChildren(child, func(ref, parent clang.Cursor) clang.ChildVisitResult {
+				if ref.Kind != clang.CursorOverloadedDeclRef {
+					return clang.ChildVisit_Continue
+				}
+				for i := 0; i < int(ref.NumOverloadedDecls()); i++ {
+					// the member templates are left out
+					switch decl := ref.OverloadedDecl(c.Uint(i)); decl.Kind {
+					case clang.CursorCXXMethod, clang.CursorConstructor:
+						visit(decl)
+					}
+				}
+				return clang.ChildVisit_Continue
+			})
+		case clang.CursorCXXMethod:
+			VisitChildren(child, func(expr, parent clang.Cursor) clang.ChildVisitResult {
+				if expr.Kind == clang.CursorCallExpr {
+					switch decl := expr.Referenced(); decl.Kind {
+					case clang.CursorConstructor, clang.CursorDestructor:
+						visit(decl)
+					}
+				}
+				return clang.ChildVisit_Recurse
+			})
+		}
+		return clang.ChildVisit_Continue
+	})
+}
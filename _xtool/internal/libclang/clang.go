@@ -2103,6 +2103,21 @@ func (t Type) SizeOf() (ret c.LongLong) {
 	return
 }
 
+/**
+ * Return the alignment of a type in bytes as per C++[expr.alignof]
+ *   standard.
+ *
+ * If the type declaration is invalid, CXTypeLayoutError_Invalid is returned.
+ * If the type declaration is an incomplete type, CXTypeLayoutError_Incomplete
+ *   is returned.
+ * If the type declaration is a dependent type, CXTypeLayoutError_Dependent is
+ *   returned.
+ */
+// llgo:link Type.AlignOf C.clang_Type_getAlignOf
+func (t Type) AlignOf() (ret c.LongLong) {
+	return
+}
+
 /**
  * Determine whether the given cursor represents an anonymous
  * tag or namespace
@@ -2223,6 +2238,133 @@ func (c Cursor) Referenced() (referenced Cursor) {
 	return
 }
 
+/**
+ * Determine the number of overloaded declarations referenced by a
+ * \c CXCursor_OverloadedDeclRef cursor.
+ *
+ * \returns The number of overloaded declarations referenced by \c cursor. If it
+ * is not a \c CXCursor_OverloadedDeclRef cursor, returns 0.
+ */
+// llgo:link Cursor.NumOverloadedDecls C.clang_getNumOverloadedDecls
+func (c Cursor) NumOverloadedDecls() (num c.Uint) {
+	return
+}
+
+/**
+ * Retrieve a cursor for one of the overloaded declarations referenced
+ * by a \c CXCursor_OverloadedDeclRef cursor.
+ *
+ * \param cursor The cursor whose overloaded declarations are being queried.
+ *
+ * \param index The zero-based index into the set of overloaded declarations in
+ * the cursor.
+ *
+ * \returns A cursor representing the declaration referenced by the given
+ * \c cursor at the specified \c index. If the cursor does not have an
+ * associated set of overloaded declarations, or if the index is out of bounds,
+ * returns \c clang_getNullCursor();
+ */
+// llgo:link Cursor.OverloadedDecl C.clang_getOverloadedDecl
+func (c Cursor) OverloadedDecl(index c.Uint) (decl Cursor) {
+	return
+}
+
+/**
+ * Given a cursor that may represent a specialization or instantiation
+ * of a template, retrieve the cursor that represents the template that it
+ * specializes or from which it was instantiated.
+ *
+ * This routine determines the template involved both for explicit
+ * specializations of templates and for implicit instantiations of the template,
+ * both of which are referred to as "specializations". For a class template
+ * specialization (e.g., \c std::vector<bool>), this routine will return
+ * either the primary template (\c std::vector) or, if the specialization was
+ * instantiated from a class template partial specialization, the class template
+ * partial specialization. For a class template partial specialization and a
+ * function template specialization (including instantiations), this
+ * this routine will return the specialized template.
+ *
+ * \returns If the given cursor is a specialization or instantiation of a
+ * template or a member thereof, the template or member that it specializes or
+ * from which it was instantiated. Otherwise, returns a NULL cursor.
+ */
+// llgo:link Cursor.SpecializedTemplate C.clang_getSpecializedCursorTemplate
+func (c Cursor) SpecializedTemplate() (tmpl Cursor) {
+	return
+}
+
+/**
+ * Describes the kind of a template argument.
+ *
+ * See the definition of llvm::clang::TemplateArgument::ArgKind for full
+ * element descriptions.
+ */
+type TemplateArgumentKind c.Int
+
+const (
+	TemplateArgumentKind_Null TemplateArgumentKind = iota
+	TemplateArgumentKind_Type
+	TemplateArgumentKind_Declaration
+	TemplateArgumentKind_NullPtr
+	TemplateArgumentKind_Integral
+	TemplateArgumentKind_Template
+	TemplateArgumentKind_TemplateExpansion
+	TemplateArgumentKind_Expression
+	TemplateArgumentKind_Pack
+	/* Indicates an error case, preventing the kind from being deduced. */
+	TemplateArgumentKind_Invalid
+)
+
+/**
+ * Returns the number of template args of a function, struct, or class decl
+ * representing a template specialization.
+ *
+ * If the argument cursor cannot be converted into a template function
+ * declaration, -1 is returned.
+ */
+// llgo:link Cursor.NumTemplateArguments C.clang_Cursor_getNumTemplateArguments
+func (c Cursor) NumTemplateArguments() (num c.Int) {
+	return
+}
+
+/**
+ * Retrieve the kind of the I'th template argument of the CXCursor C.
+ *
+ * If the argument CXCursor does not represent a FunctionDecl, StructDecl, or
+ * ClassTemplatePartialSpecialization, an invalid template argument kind is
+ * returned.
+ */
+// llgo:link Cursor.TemplateArgumentKind C.clang_Cursor_getTemplateArgumentKind
+func (c Cursor) TemplateArgumentKind(index c.Uint) (kind TemplateArgumentKind) {
+	return
+}
+
+/**
+ * Retrieve a CXType representing the type of a TemplateArgument of a
+ * function decl representing a template specialization.
+ *
+ * If the argument CXCursor does not represent a FunctionDecl, StructDecl,
+ * ClassDecl or ClassTemplatePartialSpecialization whose I'th template argument
+ * has a kind of CXTemplateArgKind_Integral, an invalid type is returned.
+ */
+// llgo:link Cursor.TemplateArgumentType C.clang_Cursor_getTemplateArgumentType
+func (c Cursor) TemplateArgumentType(index c.Uint) (ret Type) {
+	return
+}
+
+/**
+ * Retrieve the value of an Integral TemplateArgument (of a function
+ * decl representing a template specialization) as a signed long long.
+ *
+ * It is undefined to call this function on a CXCursor that does not represent a
+ * FunctionDecl, StructDecl, ClassDecl or ClassTemplatePartialSpecialization
+ * whose I'th template argument is not an integral value.
+ */
+// llgo:link Cursor.TemplateArgumentValue C.clang_Cursor_getTemplateArgumentValue
+func (c Cursor) TemplateArgumentValue(index c.Uint) (val c.LongLong) {
+	return
+}
+
 /**
  * Returns non-zero if the given cursor is a variadic function or method.
  */
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"strings"
+
+	clangutils "github.com/goplus/llcppg/_xtool/internal/clang"
+	clang "github.com/goplus/llcppg/_xtool/internal/libclang"
+	"github.com/goplus/llcppg/ast"
+	"github.com/goplus/llcppg/token"
+)
+
+// ProcessDefaultArg returns the default argument of a function parameter, or nil if it has none.
+// A literal is a BasicLit, true, false and nullptr are Idents, a name like Color::Black is
+// qualified by the declaration it refers to, and the other expressions are kept as their tokens.
+func (ct *Converter) ProcessDefaultArg(param clang.Cursor) ast.Expr {
+	toks := ct.initTokens(param)
+	if len(toks) == 0 {
+		return nil
+	}
+	ct.logln("ProcessDefaultArg: Tokens Length:", len(toks))
+	if lit := defaultArgLit(toks); lit != nil {
+		return lit
+	}
+	if len(toks) == 1 && toks[0].Token == token.KEYWORD {
+		switch toks[0].Lit {
+		case "true", "false", "nullptr":
+			return &ast.Ident{Name: toks[0].Lit}
+		}
+	}
+	if !isNameTokens(toks) {
+		return &ast.RawExpr{Tokens: toks}
+	}
+	if ref, ok := defaultArgRef(param); ok {
+		return ct.BuildScopingExpr(ref)
+	}
+	// a macro or a name the parser can't resolve
+	var parts []string
+	for _, tok := range toks {
+		if tok.Token == token.IDENT {
+			parts = append(parts, tok.Lit)
+		}
+	}
+	return buildScopingFromParts(parts)
+}
+
+// initTokens returns the tokens following the = of a declaration, which are the ones of the
+// default argument of a parameter or the initializer of a variable, or nil if it has none.
+// They are the tokens of its last expression, which end the declaration after the =, so the
+// < and > of the templates and the ones of the expressions, like a < b ? x : y and n >> 1,
+// are not told apart by counting them.
+func (ct *Converter) initTokens(cursor clang.Cursor) []*ast.Token {
+	expr, ok := lastExpr(cursor)
+	if !ok {
+		return nil
+	}
+	toks, init := ct.GetTokens(cursor), ct.GetTokens(expr)
+	return initSuffix(toks, init)
+}
+
+// initSuffix returns init if the tokens of a declaration end with = init, or nil.
+func initSuffix(toks, init []*ast.Token) []*ast.Token {
+	n := len(toks) - len(init)
+	if len(init) == 0 || n < 1 {
+		return nil
+	}
+	if eq := toks[n-1]; eq.Token != token.PUNCT || eq.Lit != "=" {
+		return nil
+	}
+	for i, tok := range init {
+		if toks[n+i].Token != tok.Token || toks[n+i].Lit != tok.Lit {
+			return nil
+		}
+	}
+	return toks[n:]
+}
+
+// defaultArgLit returns the BasicLit of a literal, which may be negative, or nil.
+func defaultArgLit(toks []*ast.Token) *ast.BasicLit {
+	sign := ""
+	if len(toks) == 2 && toks[0].Token == token.PUNCT && toks[0].Lit == "-" {
+		sign, toks = "-", toks[1:]
+	}
+	if len(toks) != 1 || toks[0].Token != token.LITERAL {
+		return nil
+	}
+	lit := toks[0].Lit
+	switch {
+	case strings.HasSuffix(lit, `"`):
+		if sign != "" {
+			return nil
+		}
+		return &ast.BasicLit{Kind: ast.StringLit, Value: lit}
+	case strings.HasSuffix(lit, "'"):
+		return &ast.BasicLit{Kind: ast.CharLit, Value: sign + lit}
+	case !strings.HasPrefix(lit, "0x") && !strings.HasPrefix(lit, "0X") && strings.ContainsAny(lit, ".eE"),
+		strings.HasPrefix(lit, "0x") && strings.ContainsAny(lit, ".pP"):
+		return &ast.BasicLit{Kind: ast.FloatLit, Value: sign + lit}
+	}
+	return &ast.BasicLit{Kind: ast.IntLit, Value: sign + lit}
+}
+
+// isNameTokens reports whether the tokens are a possibly qualified name, like ::a::b.
+func isNameTokens(toks []*ast.Token) bool {
+	ident := false
+	for _, tok := range toks {
+		switch {
+		case tok.Token == token.IDENT && !ident:
+			ident = true
+		case tok.Token == token.PUNCT && tok.Lit == "::" && (ident || tok == toks[0]):
+			ident = false
+		default:
+			return false
+		}
+	}
+	return ident
+}
+
+// lastExpr returns the last expression of the children of a declaration, which is its
+// default argument or initializer if it has one, like the size of an array type otherwise.
+func lastExpr(decl clang.Cursor) (expr clang.Cursor, ok bool) {
+	clangutils.VisitChildren(decl, func(cursor, parent clang.Cursor) clang.ChildVisitResult {
+		if cursor.Kind >= clang.CursorFirstExpr && cursor.Kind <= clang.CursorLastExpr {
+			expr, ok = cursor, true
+		}
+		return clang.ChildVisit_Continue
+	})
+	return
+}
+
+// defaultArgRef returns the declaration referred by the default argument of a parameter,
+// which is the last expression of its children, through the implicit conversions.
+func defaultArgRef(param clang.Cursor) (ref clang.Cursor, ok bool) {
+	expr, _ := lastExpr(param)
+	for expr.Kind == clang.CursorUnexposedExpr {
+		var inner clang.Cursor
+		clangutils.VisitChildren(expr, func(cursor, parent clang.Cursor) clang.ChildVisitResult {
+			inner = cursor
+			return clang.ChildVisit_Break
+		})
+		expr = inner
+	}
+	if expr.Kind != clang.CursorDeclRefExpr {
+		return
+	}
+	ref = expr.Referenced()
+	return ref, ref.IsNull() == 0
+}
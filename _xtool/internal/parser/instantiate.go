@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"strconv"
+
+	"github.com/goplus/lib/c"
+	clangutils "github.com/goplus/llcppg/_xtool/internal/clang"
+	clang "github.com/goplus/llcppg/_xtool/internal/libclang"
+	"github.com/goplus/llcppg/ast"
+	"github.com/goplus/llcppg/internal/name"
+)
+
+// findInstantiations records the class template specializations instantiated by the structs
+// of the instantiation header, see clangutils.ComposeInstantiations, by their canonical types.
+func (ct *Converter) findInstantiations(cursor clang.Cursor) {
+	clangutils.VisitChildren(cursor, func(child, parent clang.Cursor) clang.ChildVisitResult {
+		if child.Kind != clang.CursorStructDecl {
+			return clang.ChildVisit_Continue
+		}
+		if i, ok := clangutils.InstantiationIndex(child); ok && i < len(ct.instantiate) {
+			if ct.insts == nil {
+				ct.insts = make(map[string]string)
+			}
+			ct.insts[toStr(clangutils.InstantiationType(child).CanonicalType().String())] = ct.instantiate[i]
+		}
+		return clang.ChildVisit_Continue
+	})
+}
+
+// instantiationName returns the expression of the template-id of an instantiated
+// class template specialization, like std::vector<int>, or nil for the other types.
+func (ct *Converter) instantiationName(t clang.Type) ast.Expr {
+	cname, ok := ct.insts[toStr(t.CanonicalType().String())]
+	if !ok {
+		return nil
+	}
+	scopes, tmplName := name.SplitScope(cname)
+	return buildScopingFromParts(append(scopes, tmplName))
+}
+
+// isSpecialization reports whether a record declaration is a class template specialization,
+// like std::vector<int>, whose template arguments are not in its name.
+func isSpecialization(decl clang.Cursor) bool {
+	switch decl.Kind {
+	case clang.CursorStructDecl, clang.CursorClassDecl:
+		return decl.SpecializedTemplate().IsNull() == 0 && decl.NumTemplateArguments() > 0
+	}
+	return false
+}
+
+// ProcessSpecializationType converts a reference to a class template specialization to
+// an InstantiationType of the template and the type and integral arguments, like the
+// template std::vector with the arguments int and std::allocator<int> of std::vector<int>.
+func (ct *Converter) ProcessSpecializationType(decl clang.Cursor) *ast.InstantiationType {
+	expr := &ast.InstantiationType{
+		Template: ct.BuildScopingExpr(decl.SpecializedTemplate()),
+		Args:     &ast.FieldList{},
+	}
+	for i := c.Uint(0); i < c.Uint(decl.NumTemplateArguments()); i++ {
+		var arg ast.Expr
+		switch decl.TemplateArgumentKind(i) {
+		case clang.TemplateArgumentKind_Type:
+			arg = ct.ProcessType(decl.TemplateArgumentType(i))
+		case clang.TemplateArgumentKind_Integral:
+			arg = &ast.BasicLit{Kind: ast.IntLit, Value: strconv.FormatInt(int64(decl.TemplateArgumentValue(i)), 10)}
+		default:
+			ct.logln("ProcessSpecializationType: unsupported template argument", i, "of", toStr(decl.String()))
+			continue
+		}
+		expr.Args.List = append(expr.Args.List, &ast.Field{Type: arg})
+	}
+	return expr
+}
+
+// ProcessInstantiation converts a struct of the instantiation header to the declaration of
+// the class template specialization it instantiates, which is named by its template-id.
+// The layout of the specialization is opaque, and its methods are the instantiated members
+// referred by the struct, declared in the package with the instantiation.
+func (ct *Converter) ProcessInstantiation(cursor clang.Cursor) *ast.TypeDecl {
+	typ := clangutils.InstantiationType(cursor)
+	ref := ct.instantiationName(typ)
+	decl := &ast.TypeDecl{
+		Object: ast.Object{Loc: createLoc(cursor)},
+		Type: &ast.RecordType{
+			Tag:    toTag(typ.TypeDeclaration().Kind),
+			HasDef: true,
+			Fields: instantiationFields(typ),
+		},
+	}
+	switch ref := ref.(type) {
+	case *ast.ScopingExpr:
+		decl.Parent, decl.Name = ref.Parent, ref.X
+	case *ast.Ident:
+		decl.Name = ref
+	}
+
+	ct.inInst = true
+	defer func() { ct.inInst = false }()
+	clangutils.VisitInstantiation(cursor, func(member clang.Cursor) {
+		if member.CXXAccessSpecifier() != clang.CXXPublic {
+			return
+		}
+		method := ct.ProcessFuncDecl(member)
+		if method == nil {
+			return
+		}
+		method.Loc, method.Parent = decl.Loc, ref
+		// the opaque layout holds the vtable pointer, the virtual methods are called directly
+		method.IsVirtual, method.IsPureVirtual, method.IsOverride = false, false, false
+		decl.Type.Methods = append(decl.Type.Methods, method)
+	})
+	ct.logln("ProcessInstantiation: END", ast.QualifiedName(ref), "Methods:", len(decl.Type.Methods))
+	return decl
+}
+
+// instantiationFields returns the opaque layout of a class template specialization,
+// an array of the unsigned integers of its alignment, which is at most 8 bytes.
+func instantiationFields(typ clang.Type) *ast.FieldList {
+	size, align := int64(typ.SizeOf()), int64(typ.AlignOf())
+	if size <= 0 || align <= 0 {
+		return &ast.FieldList{}
+	}
+	elt := &ast.BuiltinType{Kind: ast.Int, Flags: ast.Unsigned | ast.LongLong}
+	switch align {
+	case 1:
+		elt = &ast.BuiltinType{Kind: ast.Char, Flags: ast.Unsigned}
+	case 2:
+		elt = &ast.BuiltinType{Kind: ast.Int, Flags: ast.Unsigned | ast.Short}
+	case 4:
+		elt = &ast.BuiltinType{Kind: ast.Int, Flags: ast.Unsigned}
+	default:
+		align = 8
+	}
+	return &ast.FieldList{List: []*ast.Field{{
+		Names: []*ast.Ident{{Name: "Unused"}},
+		Type: &ast.ArrayType{
+			Elt: elt,
+			Len: &ast.BasicLit{Kind: ast.IntLit, Value: strconv.FormatInt(size/align, 10)},
+		},
+		Access: ast.Private,
+	}}}
+}
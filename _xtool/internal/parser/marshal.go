@@ -116,6 +116,7 @@ func XMarshalASTDecl(decl ast.Decl) map[string]any {
 		root["IsConstructor"] = d.IsConstructor
 		root["IsDestructor"] = d.IsDestructor
 		root["IsVirtual"] = d.IsVirtual
+		root["IsPureVirtual"] = d.IsPureVirtual
 		root["IsOverride"] = d.IsOverride
 	case *ast.TypeDecl:
 		root["_Type"] = "TypeDecl"
@@ -157,6 +158,9 @@ func XMarshalASTExpr(t ast.Expr) map[string]any {
 			items = append(items, XMarshalASTExpr(e))
 		}
 		root["Items"] = items
+		if d.IsScoped {
+			root["IsScoped"] = true
+		}
 	case *ast.EnumItem:
 		root["_Type"] = "EnumItem"
 		root["Name"] = XMarshalASTExpr(d.Name)
@@ -165,6 +169,7 @@ func XMarshalASTExpr(t ast.Expr) map[string]any {
 		root["_Type"] = "RecordType"
 		root["Tag"] = uint(d.Tag)
 		root["Fields"] = XMarshalASTExpr(d.Fields)
+		root["HasDef"] = d.HasDef
 		var methods []map[string]any
 		for _, m := range d.Methods {
 			methods = append(methods, XMarshalASTDecl(m))
@@ -186,8 +191,16 @@ func XMarshalASTExpr(t ast.Expr) map[string]any {
 		root["Doc"] = XMarshalASTExpr(d.Doc)
 		root["Comment"] = XMarshalASTExpr(d.Comment)
 		root["IsStatic"] = d.IsStatic
+		root["IsBase"] = d.IsBase
 		root["Access"] = uint(d.Access)
 		root["Names"] = XMarshalIdentList(d.Names)
+		if d.Default != nil {
+			root["Default"] = XMarshalASTExpr(d.Default)
+		}
+		if d.IsStatic {
+			root["MangledName"] = d.MangledName
+			root["IsConstexpr"] = d.IsConstexpr
+		}
 	case *ast.Variadic:
 		root["_Type"] = "Variadic"
 	case *ast.Ident:
@@ -207,12 +220,16 @@ func XMarshalASTExpr(t ast.Expr) map[string]any {
 	case *ast.LvalueRefType:
 		root["_Type"] = "LvalueRefType"
 		root["X"] = XMarshalASTExpr(d.X)
+		root["IsConst"] = d.IsConst
 	case *ast.RvalueRefType:
 		root["_Type"] = "RvalueRefType"
 		root["X"] = XMarshalASTExpr(d.X)
 	case *ast.PointerType:
 		root["_Type"] = "PointerType"
 		root["X"] = XMarshalASTExpr(d.X)
+		if d.IsConst {
+			root["IsConst"] = true
+		}
 	case *ast.BlockPointerType:
 		root["_Type"] = "BlockPointerType"
 		root["X"] = XMarshalASTExpr(d.X)
@@ -244,6 +261,13 @@ func XMarshalASTExpr(t ast.Expr) map[string]any {
 		root["_Type"] = "ScopingExpr"
 		root["X"] = XMarshalASTExpr(d.X)
 		root["Parent"] = XMarshalASTExpr(d.Parent)
+	case *ast.RawExpr:
+		root["_Type"] = "RawExpr"
+		root["Tokens"] = XMarshalTokenList(d.Tokens)
+	case *ast.InstantiationType:
+		root["_Type"] = "InstantiationType"
+		root["Template"] = XMarshalASTExpr(d.Template)
+		root["Args"] = XMarshalASTExpr(d.Args)
 	default:
 		return nil
 	}
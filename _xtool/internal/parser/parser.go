@@ -12,6 +12,8 @@ import (
 	clangutils "github.com/goplus/llcppg/_xtool/internal/clang"
 	clang "github.com/goplus/llcppg/_xtool/internal/libclang"
 	"github.com/goplus/llcppg/ast"
+	llcppg "github.com/goplus/llcppg/config"
+	"github.com/goplus/llcppg/internal/name"
 	"github.com/goplus/llcppg/token"
 )
 
@@ -32,7 +34,12 @@ type Converter struct {
 	file   *ast.File
 	index  *clang.Index
 	unit   *clang.TranslationUnit
-	indent int // for verbose debug
+	sizes  *llcppg.TypeSizes // sizes of the target dependent builtin types seen
+	indent int               // for verbose debug
+
+	instantiate []string          // template-ids of the instantiation header
+	insts       map[string]string // canonical type of an instantiation -> template-id
+	inInst      bool              // processing the members of an instantiation
 }
 
 var tagMap = map[string]ast.Tag{
@@ -43,9 +50,10 @@ var tagMap = map[string]ast.Tag{
 }
 
 type ConverterConfig struct {
-	File  string
-	Args  []string
-	IsCpp bool
+	File        string
+	Args        []string
+	IsCpp       bool
+	Instantiate []string // template-ids of the instantiation header, see clangutils.ComposeInstantiations
 }
 
 func Do(config *ConverterConfig) (*ast.File, error) {
@@ -74,9 +82,10 @@ func NewConverter(config *ConverterConfig) (*Converter, error) {
 	}
 
 	return &Converter{
-		index: index,
-		unit:  unit,
-		file:  &ast.File{},
+		index:       index,
+		unit:        unit,
+		file:        &ast.File{},
+		instantiate: config.Instantiate,
 	}, nil
 }
 
@@ -246,6 +255,12 @@ func (ct *Converter) visitTop(cursor, parent clang.Cursor) clang.ChildVisitResul
 		// class havent anonymous situation
 		ct.logln("visitTop: ProcessClassDecl END", classDecl.Name.Name)
 	case clang.CursorStructDecl:
+		if _, ok := clangutils.InstantiationIndex(cursor); ok && parent.Kind == clang.CursorTranslationUnit {
+			instDecl := ct.ProcessInstantiation(cursor)
+			ct.file.Decls = append(ct.file.Decls, instDecl)
+			ct.logln("visitTop: ProcessInstantiation END")
+			break
+		}
 		decls := ct.ProcessStructDecl(cursor)
 		ct.file.Decls = append(ct.file.Decls, decls...)
 		ct.logf("visitTop: ProcessStructDecl END")
@@ -276,10 +291,38 @@ func (ct *Converter) visitTop(cursor, parent clang.Cursor) clang.ChildVisitResul
 // input is clang -E 's result
 func (ct *Converter) Convert() (*ast.File, error) {
 	cursor := ct.unit.Cursor()
+	if len(ct.instantiate) > 0 {
+		ct.findInstantiations(cursor)
+	}
 	clangutils.VisitChildren(cursor, ct.visitTop)
 	return ct.file, nil
 }
 
+// TypeSizes returns the sizes clang reported for the target dependent builtin
+// types (wchar_t, long double) referenced by the headers, or nil if none was seen.
+func (ct *Converter) TypeSizes() *llcppg.TypeSizes {
+	return ct.sizes
+}
+
+func (ct *Converter) recordTypeSize(t clang.Type) {
+	size := int64(t.SizeOf())
+	if size <= 0 {
+		return
+	}
+	if ct.sizes == nil {
+		ct.sizes = &llcppg.TypeSizes{}
+	}
+	switch t.Kind {
+	case clang.TypeWChar:
+		ct.sizes.WChar = size
+		ct.sizes.WCharUnsigned = llcppg.WCharUnsigned(runtime.GOOS, runtime.GOARCH)
+	case clang.TypeLongDouble:
+		ct.sizes.LongDouble = size
+	case clang.TypeTypedef:
+		ct.sizes.StdString = size
+	}
+}
+
 func (ct *Converter) ProcessType(t clang.Type) ast.Expr {
 	ct.incIndent()
 	defer ct.decIndent()
@@ -292,6 +335,18 @@ func (ct *Converter) ProcessType(t clang.Type) ast.Expr {
 		return ct.ProcessType(t.CanonicalType())
 	}
 
+	if ct.inInst && t.Kind != t.CanonicalType().Kind {
+		// the members of an instantiation refer to the member types of the specialization,
+		// which are not declared in the package, so they are resolved to the canonical types
+		return ct.ProcessType(t.CanonicalType())
+	}
+
+	if t.Kind == clang.TypeElaborated || t.Kind == clang.TypeRecord {
+		if expr := ct.instantiationName(t); expr != nil {
+			return expr
+		}
+	}
+
 	if t.Kind >= clang.TypeFirstBuiltin && t.Kind <= clang.TypeLastBuiltin {
 		return ct.ProcessBuiltinType(t)
 	}
@@ -309,7 +364,10 @@ func (ct *Converter) ProcessType(t clang.Type) ast.Expr {
 	case clang.TypePointer:
 		name, kind := getTypeDesc(t.PointeeType())
 		ct.logln("ProcessType: PointerType  Pointee TypeName:", name, "TypeKind:", kind)
-		expr = &ast.PointerType{X: ct.ProcessType(t.PointeeType())}
+		expr = &ast.PointerType{
+			X:       ct.ProcessType(t.PointeeType()),
+			IsConst: t.PointeeType().IsConstQualifiedType() != 0,
+		}
 	case clang.TypeBlockPointer:
 		name, kind := getTypeDesc(t)
 		ct.logln("ProcessType: BlockPointerType  TypeName:", name, "TypeKind:", kind)
@@ -322,7 +380,10 @@ func (ct *Converter) ProcessType(t clang.Type) ast.Expr {
 	case clang.TypeLValueReference:
 		name, kind := getTypeDesc(t.NonReferenceType())
 		ct.logln("ProcessType: LvalueRefType  NonReference TypeName:", name, "TypeKind:", kind)
-		expr = &ast.LvalueRefType{X: ct.ProcessType(t.NonReferenceType())}
+		expr = &ast.LvalueRefType{
+			X:       ct.ProcessType(t.NonReferenceType()),
+			IsConst: t.NonReferenceType().IsConstQualifiedType() != 0,
+		}
 	case clang.TypeRValueReference:
 		name, kind := getTypeDesc(t.NonReferenceType())
 		ct.logln("ProcessType: RvalueRefType  NonReference TypeName:", name, "TypeKind:", kind)
@@ -348,6 +409,13 @@ func (ct *Converter) ProcessType(t clang.Type) ast.Expr {
 				Elt: ct.ProcessType(t.ArrayElementType()),
 			}
 		}
+	case clang.TypeRecord, clang.TypeEnum:
+		if decl := t.TypeDeclaration(); isSpecialization(decl) {
+			expr = ct.ProcessSpecializationType(decl)
+		} else if ct.inInst {
+			// the canonical types of the members of an instantiation
+			expr = ct.BuildScopingExpr(decl)
+		}
 	default:
 		name, kind := getTypeDesc(t)
 		ct.logln("ProcessType: Unknown Type TypeName:", name, "TypeKind:", kind)
@@ -537,6 +605,9 @@ func (ct *Converter) ProcessFuncDecl(cursor clang.Cursor) *ast.FuncDecl {
 				field := funcType.Params.List[i]
 				field.Names = []*ast.Ident{&ast.Ident{Name: name}}
 			}
+			if i < numFields {
+				funcType.Params.List[i].Default = ct.ProcessDefaultArg(arg)
+			}
 		}
 	}
 
@@ -589,6 +660,9 @@ func (ct *Converter) ProcessMethodAttributes(cursor clang.Cursor, fn *ast.FuncDe
 	if cursor.IsVirtual() != 0 || cursor.IsPureVirtual() != 0 {
 		fn.IsVirtual = true
 	}
+	if cursor.IsPureVirtual() != 0 {
+		fn.IsPureVirtual = true
+	}
 	if cursor.IsConst() != 0 {
 		fn.IsConst = true
 	}
@@ -627,7 +701,8 @@ func (ct *Converter) ProcessEnumType(cursor clang.Cursor) *ast.EnumType {
 	})
 
 	return &ast.EnumType{
-		Items: items,
+		Items:    items,
+		IsScoped: cursor.IsScoped() != 0,
 	}
 }
 
@@ -723,12 +798,25 @@ func (ct *Converter) ProcessFieldList(cursor clang.Cursor) *ast.FieldList {
 			field := ct.createBaseField(subcsr)
 			field.Access = ast.AccessSpecifier(subcsr.CXXAccessSpecifier())
 			flds.List = append(flds.List, field)
+		case clang.CursorCXXBaseSpecifier:
+			// base classes are laid out before the fields
+			ct.logln("ProcessFieldList: CursorCXXBaseSpecifier")
+			flds.List = append(flds.List, &ast.Field{
+				Type:   ct.ProcessType(subcsr.Type()),
+				Access: ast.AccessSpecifier(subcsr.CXXAccessSpecifier()),
+				IsBase: true,
+			})
 		case clang.CursorVarDecl:
 			if subcsr.StorageClass() == clang.SCStatic {
 				// static member variable
 				field := ct.createBaseField(subcsr)
 				field.Access = ast.AccessSpecifier(subcsr.CXXAccessSpecifier())
 				field.IsStatic = true
+				field.MangledName = toStr(subcsr.Mangling())
+				if runtime.GOOS == "darwin" {
+					field.MangledName = strings.TrimPrefix(field.MangledName, "_")
+				}
+				field.IsConstexpr = ct.isConstexpr(subcsr)
 				flds.List = append(flds.List, field)
 			}
 		}
@@ -737,6 +825,18 @@ func (ct *Converter) ProcessFieldList(cursor clang.Cursor) *ast.FieldList {
 	return flds
 }
 
+// isConstexpr reports whether a static member variable is constexpr, or a const one
+// initialized in the class, whose value is known to the compiler.
+func (ct *Converter) isConstexpr(cursor clang.Cursor) bool {
+	toks := ct.GetTokens(cursor)
+	for _, tok := range toks {
+		if tok.Token == token.KEYWORD && tok.Lit == "constexpr" {
+			return true
+		}
+	}
+	return cursor.Type().IsConstQualifiedType() != 0 && ct.initTokens(cursor) != nil
+}
+
 // Note:Public Method is considered
 func (ct *Converter) ProcessMethods(cursor clang.Cursor) []*ast.FuncDecl {
 	methods := make([]*ast.FuncDecl, 0)
@@ -857,6 +957,7 @@ func (ct *Converter) ProcessRecordType(cursor clang.Cursor) *ast.RecordType {
 		return typ
 	}
 
+	typ.HasDef = true
 	ct.logln("ProcessRecordType: ProcessFieldList")
 	typ.Fields = ct.ProcessFieldList(cursor)
 
@@ -887,6 +988,9 @@ func (ct *Converter) ProcessElaboratedType(t clang.Type) ast.Expr {
 	if isAnonymousDecl && decl.Kind != clang.CursorEnumDecl {
 		return ct.ProcessRecordType(decl)
 	}
+	if isSpecialization(decl) {
+		return ct.ProcessSpecializationType(decl)
+	}
 	parts := clangutils.BuildScopingParts(decl)
 	hasParent := clangutils.HasParent(decl)
 	// NOTE(MeteorsLiu): nested enum behaves different from nested struct, for example, we can find its semantic parent
@@ -925,8 +1029,16 @@ func (ct *Converter) ProcessElaboratedType(t clang.Type) ast.Expr {
 func (ct *Converter) ProcessTypeDefType(t clang.Type) ast.Expr {
 	cursor := t.TypeDeclaration()
 	ct.logln("ProcessTypeDefType: Typedef TypeDeclaration", toStr(cursor.String()), toStr(t.String()))
-	if name := toStr(cursor.String()); name != "" {
-		return &ast.Ident{Name: name}
+	if defName := toStr(cursor.String()); defName != "" {
+		// a typedef in a namespace is referred by its qualified name, like the records
+		if cursor.SemanticParent().Kind == clang.CursorNamespace {
+			expr := ct.BuildScopingExpr(cursor)
+			if name.StdName(ast.QualifiedName(expr)) == "std::string" {
+				ct.recordTypeSize(t)
+			}
+			return expr
+		}
+		return &ast.Ident{Name: defName}
 	}
 	ct.logln("ProcessTypeDefType: typedef type have no name")
 	return nil
@@ -954,6 +1066,7 @@ func (ct *Converter) ProcessBuiltinType(t clang.Type) *ast.BuiltinType {
 		kind = ast.Char32
 	case clang.TypeWChar:
 		kind = ast.WChar
+		ct.recordTypeSize(t)
 	case clang.TypeShort, clang.TypeUShort:
 		kind = ast.Int
 		flags |= ast.Short
@@ -977,6 +1090,7 @@ func (ct *Converter) ProcessBuiltinType(t clang.Type) *ast.BuiltinType {
 	case clang.TypeLongDouble:
 		kind = ast.Float
 		flags |= ast.Long | ast.Double
+		ct.recordTypeSize(t)
 	case clang.TypeFloat128:
 		kind = ast.Float128
 	case clang.TypeComplex:
@@ -999,6 +1113,9 @@ func (ct *Converter) ProcessBuiltinType(t clang.Type) *ast.BuiltinType {
 	} else if IsExplicitUnsigned(t) {
 		flags |= ast.Unsigned
 	}
+	if t.Kind == clang.TypeSChar || t.Kind == clang.TypeUChar {
+		flags |= ast.ExplicitSign
+	}
 
 	return &ast.BuiltinType{
 		Kind:  kind,
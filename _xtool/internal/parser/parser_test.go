@@ -144,6 +144,14 @@ func TestNonBuiltinTypes(t *testing.T) {
 				X: &ast.BuiltinType{Kind: ast.Int},
 			},
 		},
+		{
+			TypeCode:      "const int&",
+			ExpectTypeStr: "const int &",
+			expr: &ast.LvalueRefType{
+				X:       &ast.BuiltinType{Kind: ast.Int},
+				IsConst: true,
+			},
+		},
 		{
 			TypeCode:      "int&&",
 			ExpectTypeStr: "int &&",
@@ -176,7 +184,8 @@ func TestNonBuiltinTypes(t *testing.T) {
 					   }`,
 			ExpectTypeStr: "struct (unnamed struct at temp.h:1:1)",
 			expr: &ast.RecordType{
-				Tag: ast.Struct,
+				Tag:    ast.Struct,
+				HasDef: true,
 				Fields: &ast.FieldList{
 					List: []*ast.Field{
 						{
@@ -216,7 +225,8 @@ func TestNonBuiltinTypes(t *testing.T) {
 					   }`,
 			ExpectTypeStr: "union (unnamed union at temp.h:1:1)",
 			expr: &ast.RecordType{
-				Tag: ast.Union,
+				Tag:    ast.Union,
+				HasDef: true,
 				Fields: &ast.FieldList{
 					List: []*ast.Field{
 						{
@@ -301,7 +311,8 @@ func TestNonBuiltinTypes(t *testing.T) {
 					   }`,
 			ExpectTypeStr: "class (unnamed class at temp.h:1:1)",
 			expr: &ast.RecordType{
-				Tag: ast.Class,
+				Tag:    ast.Class,
+				HasDef: true,
 				Fields: &ast.FieldList{
 					List: []*ast.Field{
 						{
@@ -703,3 +714,58 @@ func TestEmptyDeclVsForwardDecl(t *testing.T) {
 		return clang.ChildVisit_Recurse
 	})
 }
+
+func TestDefaultArg(t *testing.T) {
+	f, err := os.CreateTemp("", "defaultarg_*.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(`
+template <typename T> struct Box {};
+void cond(int a = 1 < 2 ? 3 : 4);
+void shift(int n = 8 >> 1);
+void box(Box<Box<int>> b = Box<Box<int>>());
+void array(int a[3]);
+void lit(int x = 2);
+`)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := parser.Do(&parser.ConverterConfig{File: f.Name(), IsCpp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]string{
+		"cond":  "1 < 2 ? 3 : 4",
+		"shift": "8 >> 1",
+		"box":   "Box < Box < int >> ( )",
+		"array": "",
+		"lit":   "2",
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		var got string
+		switch x := fn.Type.Params.List[0].Default.(type) {
+		case *ast.RawExpr:
+			var lits []string
+			for _, tok := range x.Tokens {
+				lits = append(lits, tok.Lit)
+			}
+			got = strings.Join(lits, " ")
+		case *ast.BasicLit:
+			got = x.Value
+		}
+		if got != expect[fn.Name.Name] {
+			t.Errorf("default argument of %s: expect %q, got %q", fn.Name.Name, expect[fn.Name.Name], got)
+		}
+		delete(expect, fn.Name.Name)
+	}
+	if len(expect) != 0 {
+		t.Errorf("functions not found: %v", expect)
+	}
+}
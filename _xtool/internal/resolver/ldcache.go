@@ -0,0 +1,118 @@
+package resolver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// the default file of the cache of ldconfig
+const LdCacheFile = "/etc/ld.so.cache"
+
+const (
+	ldCacheOldMagic = "ld.so-1.7.0"
+	ldCacheMagic    = "glibc-ld.so.cache1.1"
+
+	// magic, nlibs, len_strings, flags and the padding, extension_offset and unused[3]
+	ldCacheHeaderSize = len(ldCacheMagic) + 4 + 4 + 4 + 4 + 12
+	// flags, key, value, osversion and hwcap
+	ldCacheEntrySize = 4 + 4 + 4 + 4 + 8
+	// flags, key and value of the old format
+	ldCacheOldEntrySize = 4 + 4 + 4
+
+	ldCacheTypeMask = 0x00ff
+	ldCacheELFLibc6 = 0x0003
+	ldCacheArchMask = 0xff00
+)
+
+// ldCacheArch returns the arch flag of the cache entries of runtime.GOARCH, or -1 if it's unknown.
+func ldCacheArch() int {
+	switch runtime.GOARCH {
+	case "amd64":
+		return 0x0300 // FLAG_X8664_LIB64
+	case "arm64":
+		return 0x0a00 // FLAG_AARCH64_LIB64
+	case "386", "arm":
+		return 0x0000
+	case "riscv64":
+		return 0x1000 // FLAG_RISCV_FLOAT_ABI_DOUBLE
+	}
+	return -1
+}
+
+// ReadLdCache returns the libraries of the ldconfig cache of runtime.GOARCH, which maps their
+// names, like libz.so.1 and the link name libz.so, to their files. The first one of the same
+// name is kept, as what the dynamic linker does.
+func ReadLdCache(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLdCache(data)
+}
+
+// ParseLdCache parses the content of an ldconfig cache of the new format, which may follow
+// the old format, see ReadLdCache.
+func ParseLdCache(data []byte) (map[string]string, error) {
+	if bytes.HasPrefix(data, []byte(ldCacheOldMagic)) {
+		// the old format: magic, padding and nlibs followed by the entries,
+		// and the new format is aligned to 8 bytes after it
+		if len(data) < 16 {
+			return nil, fmt.Errorf("invalid ld.so.cache")
+		}
+		n := int(binary.LittleEndian.Uint32(data[12:]))
+		off := 16 + n*ldCacheOldEntrySize
+		off = (off + 7) &^ 7
+		if n < 0 || off > len(data) {
+			return nil, fmt.Errorf("invalid ld.so.cache")
+		}
+		data = data[off:]
+	}
+	if !bytes.HasPrefix(data, []byte(ldCacheMagic)) || len(data) < ldCacheHeaderSize {
+		return nil, fmt.Errorf("unsupported ld.so.cache format")
+	}
+	order := binary.ByteOrder(binary.LittleEndian)
+	nlibs := order.Uint32(data[len(ldCacheMagic):])
+	// the byte order of the cache is the one of the host, detected by its size
+	if uint64(nlibs)*ldCacheEntrySize > uint64(len(data)) {
+		order = binary.BigEndian
+		nlibs = order.Uint32(data[len(ldCacheMagic):])
+	}
+	if uint64(nlibs)*ldCacheEntrySize+uint64(ldCacheHeaderSize) > uint64(len(data)) {
+		return nil, fmt.Errorf("invalid ld.so.cache")
+	}
+
+	arch := ldCacheArch()
+	libs := make(map[string]string)
+	for i := 0; i < int(nlibs); i++ {
+		entry := data[ldCacheHeaderSize+i*ldCacheEntrySize:]
+		flags := int(order.Uint32(entry))
+		if flags&ldCacheTypeMask != ldCacheELFLibc6 || (arch >= 0 && flags&ldCacheArchMask != arch) {
+			continue
+		}
+		// the strings are relative to the start of the new format
+		key := cstring(data, order.Uint32(entry[4:]))
+		value := cstring(data, order.Uint32(entry[8:]))
+		if key == "" || value == "" {
+			continue
+		}
+		if _, ok := libs[key]; !ok {
+			libs[key] = value
+		}
+	}
+	return libs, nil
+}
+
+// cstring returns the NUL-terminated string at the offset of data.
+func cstring(data []byte, off uint32) string {
+	if int(off) >= len(data) {
+		return ""
+	}
+	end := bytes.IndexByte(data[off:], 0)
+	if end < 0 {
+		return ""
+	}
+	return string(data[off : int(off)+end])
+}
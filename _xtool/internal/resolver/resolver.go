@@ -0,0 +1,331 @@
+// Package resolver resolves the -l flags of the libraries to their files, as the linkers do.
+package resolver
+
+import (
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goplus/llcppg/_xtool/internal/ld"
+	"github.com/goplus/llcppg/_xtool/internal/symbol"
+)
+
+// the max depth of the linker scripts referring to the others
+const maxScriptDepth = 8
+
+// Flags are the flags of the libraries, like -L/opt/lib -Wl,-rpath,/opt/lib -lfoo.
+type Flags struct {
+	Paths  []string // the paths of -L
+	RPaths []string // the paths of -Wl,-rpath
+	Names  []string // the names of -l, like foo of -lfoo and :libfoo.so.1 of -l:libfoo.so.1
+}
+
+// ParseFlags parses the flags of the libraries, the others are ignored.
+func ParseFlags(libs string) *Flags {
+	flags := &Flags{}
+	rpath := false
+	for _, part := range strings.Fields(libs) {
+		switch {
+		case strings.HasPrefix(part, "-L"):
+			flags.Paths = append(flags.Paths, part[2:])
+		case strings.HasPrefix(part, "-l"):
+			flags.Names = append(flags.Names, part[2:])
+		case strings.HasPrefix(part, "-Wl,"):
+			// -Wl,-rpath,/a:/b, -Wl,-rpath=/a or -Wl,-rpath -Wl,/a
+			for _, arg := range strings.Split(part[4:], ",") {
+				switch {
+				case rpath:
+					flags.RPaths = append(flags.RPaths, filepath.SplitList(arg)...)
+					rpath = false
+				case arg == "-rpath", arg == "--rpath", arg == "-R":
+					rpath = true
+				case strings.HasPrefix(arg, "-rpath="), strings.HasPrefix(arg, "--rpath="):
+					flags.RPaths = append(flags.RPaths, filepath.SplitList(arg[strings.Index(arg, "=")+1:])...)
+				}
+			}
+		}
+	}
+	return flags
+}
+
+// Lib is a library resolved from a -l flag.
+type Lib struct {
+	Name string // the name of -l, like foo of -lfoo
+	// Files are the files satisfying it, which are several ones of a linker script,
+	// like libc.so.6 and libc_nonshared.a of libc.so.
+	Files []string
+	// Soname is the SONAME of the first shared library of the files, like libfoo.so.1.
+	Soname string
+	Err    error // why it's not resolved if Files is empty
+}
+
+// Resolver resolves the -l flags of a mode, it searches the paths of -L, -Wl,-rpath
+// and LD_LIBRARY_PATH in order, the cache of ldconfig and then the system paths.
+type Resolver struct {
+	Mode     symbol.Mode
+	Paths    []string          // the paths searched before the cache
+	Cache    map[string]string // the libraries of the cache of ldconfig, see ReadLdCache
+	SysPaths []string          // the paths searched after the cache
+}
+
+// New returns the Resolver of the flags and the environment. The cache of ldconfig,
+// LD_LIBRARY_PATH and the system paths are ignored if they are not available.
+func New(flags *Flags, mode symbol.Mode) *Resolver {
+	r := &Resolver{Mode: mode}
+	r.Paths = append(r.Paths, flags.Paths...)
+	r.Paths = append(r.Paths, flags.RPaths...)
+	if mode == symbol.ModeDynamic {
+		r.Paths = append(r.Paths, envPaths()...)
+		if runtime.GOOS == "linux" {
+			r.Cache, _ = ReadLdCache(LdCacheFile)
+		}
+	}
+	r.SysPaths = sysPaths()
+	return r
+}
+
+// envPaths returns the paths of the environment variable of the dynamic linker.
+func envPaths() []string {
+	env := "LD_LIBRARY_PATH"
+	if runtime.GOOS == "darwin" {
+		env = "DYLD_LIBRARY_PATH"
+	}
+	var paths []string
+	for _, path := range filepath.SplitList(os.Getenv(env)) {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// sysPaths returns the search paths of ld, or the common system paths if ld is not available.
+func sysPaths() []string {
+	if paths := ld.GetLibSearchPaths(); len(paths) > 0 {
+		return paths
+	}
+	switch runtime.GOOS {
+	case "linux":
+		var triple string
+		switch runtime.GOARCH {
+		case "amd64":
+			triple = "x86_64-linux-gnu"
+		case "arm64":
+			triple = "aarch64-linux-gnu"
+		}
+		var paths []string
+		if triple != "" {
+			paths = append(paths, "/usr/local/lib/"+triple, "/lib/"+triple, "/usr/lib/"+triple)
+		}
+		return append(paths, "/usr/local/lib", "/lib64", "/lib", "/usr/lib64", "/usr/lib")
+	case "darwin":
+		return []string{"/usr/local/lib", "/opt/homebrew/lib", "/usr/lib"}
+	}
+	return nil
+}
+
+// ResolveAll resolves the -l names in order.
+func (r *Resolver) ResolveAll(names []string) []*Lib {
+	libs := make([]*Lib, len(names))
+	for i, name := range names {
+		libs[i] = r.Resolve(name)
+	}
+	return libs
+}
+
+// Resolve resolves a -l name, like foo of libfoo.so and :libfoo.so.1 of the file libfoo.so.1.
+// A library installed only with its versions, like libfoo.so.3, is resolved to the latest one.
+func (r *Resolver) Resolve(name string) *Lib {
+	lib := &Lib{Name: name}
+	file := r.find(name)
+	if file == "" {
+		lib.Err = fmt.Errorf("library %s not found", name)
+		return lib
+	}
+	lib.Files, lib.Err = r.expand(file, 0)
+	for _, file := range lib.Files {
+		if lib.Soname = soname(file); lib.Soname != "" {
+			break
+		}
+	}
+	return lib
+}
+
+// find returns the file of a -l name, or "". The versions of the cache are searched
+// after the system paths, which may have the link name, like the linker script libc.so.
+func (r *Resolver) find(name string) string {
+	for _, dir := range r.Paths {
+		if file := r.findIn(dir, name); file != "" {
+			return file
+		}
+	}
+	useCache := r.Mode == symbol.ModeDynamic && r.Cache != nil
+	if useCache {
+		if file := r.findInCache(name, false); file != "" {
+			return file
+		}
+	}
+	for _, dir := range r.SysPaths {
+		if file := r.findIn(dir, name); file != "" {
+			return file
+		}
+	}
+	if useCache {
+		return r.findInCache(name, true)
+	}
+	return ""
+}
+
+// findIn returns the file of a -l name in a directory, or "".
+func (r *Resolver) findIn(dir, name string) string {
+	if file, ok := strings.CutPrefix(name, ":"); ok {
+		return existing(filepath.Join(dir, file))
+	}
+	if r.Mode == symbol.ModeStatic {
+		return existing(filepath.Join(dir, "lib"+name+".a"))
+	}
+	prefix, suffix := "lib"+name+".so", ".so"
+	if runtime.GOOS == "darwin" {
+		prefix, suffix = "lib"+name+".dylib", ".dylib"
+	}
+	if file := existing(filepath.Join(dir, prefix)); file != "" {
+		return file
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, versionedPattern(name, suffix)))
+	if file := latest(matches, name, suffix); file != "" {
+		return existing(file)
+	}
+	return ""
+}
+
+// findInCache returns the file of a -l name in the cache of ldconfig, or "".
+// The latest version is returned if versioned is true, like libfoo.so.3.
+func (r *Resolver) findInCache(name string, versioned bool) string {
+	if !versioned {
+		if file, ok := strings.CutPrefix(name, ":"); ok {
+			return existing(r.Cache[file])
+		}
+		return existing(r.Cache["lib"+name+".so"])
+	}
+	if strings.HasPrefix(name, ":") {
+		return ""
+	}
+	var matches []string
+	for key, file := range r.Cache {
+		if ok, _ := filepath.Match(versionedPattern(name, ".so"), key); ok {
+			matches = append(matches, file)
+		}
+	}
+	return existing(latest(matches, name, ".so"))
+}
+
+// expand returns the files of a library, which are the inputs of a linker script.
+func (r *Resolver) expand(file string, depth int) ([]string, error) {
+	inputs, ok := LinkerScript(file)
+	if !ok {
+		return []string{file}, nil
+	}
+	if depth >= maxScriptDepth {
+		return nil, fmt.Errorf("linker script %s is nested too deep", file)
+	}
+	var files []string
+	for _, input := range inputs {
+		var found string
+		switch {
+		case strings.HasPrefix(input, "-l"):
+			found = r.find(input[2:])
+		case filepath.IsAbs(strings.TrimPrefix(input, "=")):
+			found = existing(strings.TrimPrefix(input, "="))
+		default:
+			// a relative input is searched in the directory of the script and then the paths
+			if found = existing(filepath.Join(filepath.Dir(file), input)); found == "" {
+				found = r.find(":" + input)
+			}
+		}
+		if found == "" {
+			return nil, fmt.Errorf("input %s of linker script %s not found", input, file)
+		}
+		expanded, err := r.expand(found, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, expanded...)
+	}
+	return files, nil
+}
+
+// versionedPattern returns the glob pattern of the versioned files of a library,
+// like libfoo.so.* on linux and libfoo.*.dylib on macOS.
+func versionedPattern(name, suffix string) string {
+	if suffix == ".dylib" {
+		return "lib" + name + ".*.dylib"
+	}
+	return "lib" + name + suffix + ".*"
+}
+
+// latest returns the file of the latest version, like libfoo.so.3.1 of libfoo.so.3 and
+// libfoo.so.3.1, or "".
+func latest(files []string, name, suffix string) string {
+	version := func(file string) []int {
+		v := strings.TrimPrefix(filepath.Base(file), "lib"+name)
+		v = strings.Trim(strings.Replace(v, suffix, "", 1), ".")
+		var nums []int
+		for _, part := range strings.Split(v, ".") {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil
+			}
+			nums = append(nums, n)
+		}
+		return nums
+	}
+	var versioned []string
+	for _, file := range files {
+		if version(file) != nil {
+			versioned = append(versioned, file)
+		}
+	}
+	if len(versioned) == 0 {
+		return ""
+	}
+	sort.SliceStable(versioned, func(i, j int) bool {
+		vi, vj := version(versioned[i]), version(versioned[j])
+		for k := 0; k < len(vi) && k < len(vj); k++ {
+			if vi[k] != vj[k] {
+				return vi[k] > vj[k]
+			}
+		}
+		return len(vi) > len(vj)
+	})
+	return versioned[0]
+}
+
+// existing returns the file if it's a regular file or a link to one, or "".
+func existing(file string) string {
+	if file == "" {
+		return ""
+	}
+	if info, err := os.Stat(file); err != nil || info.IsDir() {
+		return ""
+	}
+	return file
+}
+
+// soname returns the SONAME of a shared ELF library, or "".
+func soname(file string) string {
+	f, err := elf.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	names, err := f.DynString(elf.DT_SONAME)
+	if err != nil || len(names) == 0 {
+		return ""
+	}
+	return names[0]
+}
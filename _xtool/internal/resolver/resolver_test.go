@@ -0,0 +1,262 @@
+package resolver_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/goplus/llcppg/_xtool/internal/resolver"
+	"github.com/goplus/llcppg/_xtool/internal/symbol"
+)
+
+func TestParseFlags(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		expect *resolver.Flags
+	}{
+		{
+			name:  "paths and names",
+			input: "-L/opt/lib -L/usr/lib -lfoo -l:libbar.so.1 -lm",
+			expect: &resolver.Flags{
+				Paths: []string{"/opt/lib", "/usr/lib"},
+				Names: []string{"foo", ":libbar.so.1", "m"},
+			},
+		},
+		{
+			name:  "rpaths",
+			input: "-Wl,-rpath,/opt/a:/opt/b -Wl,-rpath=/opt/c -Wl,-rpath -Wl,/opt/d -Wl,--as-needed -lfoo",
+			expect: &resolver.Flags{
+				RPaths: []string{"/opt/a", "/opt/b", "/opt/c", "/opt/d"},
+				Names:  []string{"foo"},
+			},
+		},
+		{
+			name:   "no libraries",
+			input:  "-pthread",
+			expect: &resolver.Flags{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := resolver.ParseFlags(tc.input); !reflect.DeepEqual(got, tc.expect) {
+				t.Errorf("ParseFlags(%q) = %#v, want %#v", tc.input, got, tc.expect)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the files of the libraries are named for linux")
+	}
+	dir := t.TempDir()
+	libDir := filepath.Join(dir, "lib")
+	rpathDir := filepath.Join(dir, "rpath")
+	sysDir := filepath.Join(dir, "sys")
+	cacheDir := filepath.Join(dir, "cache")
+	files := map[string]string{
+		"lib/libfoo.so":         "",
+		"lib/libfoo.a":          "",
+		"lib/libver.so.1":       "",
+		"lib/libver.so.3.1":     "",
+		"lib/libver.so.3":       "",
+		"lib/libexact.so.2":     "",
+		"lib/libscript.so":      "/* GNU ld script */\nGROUP ( libexact.so.2 AS_NEEDED ( -lbar ) )\n",
+		"rpath/libbar.so":       "",
+		"rpath/libfoo.so":       "",
+		"sys/libsys.so":         "",
+		"sys/libloop.so":        "INPUT(-lloop)\n",
+		"sys/libmissing.so":     "INPUT(libnonexistent.so.1)\n",
+		"cache/libcached.so.5":  "",
+		"cache/libcached2.so.1": "",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a real shared library reports its SONAME
+	soname := filepath.Join("..", "symbol", "testdata", "libsym.so")
+
+	r := &resolver.Resolver{
+		Mode:  symbol.ModeDynamic,
+		Paths: []string{libDir, rpathDir},
+		Cache: map[string]string{
+			"libcached.so.5":  filepath.Join(cacheDir, "libcached.so.5"),
+			"libcached2.so.1": filepath.Join(cacheDir, "libcached2.so.1"),
+			"libsys.so":       filepath.Join(cacheDir, "nonexistent.so"),
+			"libsoname.so":    soname,
+		},
+		SysPaths: []string{sysDir},
+	}
+	testCases := []struct {
+		name      string
+		mode      symbol.Mode
+		expect    []string
+		expectErr bool
+	}{
+		{name: "foo", expect: []string{"lib/libfoo.so"}},
+		{name: "foo", mode: symbol.ModeStatic, expect: []string{"lib/libfoo.a"}},
+		{name: "bar", expect: []string{"rpath/libbar.so"}},
+		{name: "ver", expect: []string{"lib/libver.so.3.1"}},
+		{name: ":libexact.so.2", expect: []string{"lib/libexact.so.2"}},
+		{name: "script", expect: []string{"lib/libexact.so.2", "rpath/libbar.so"}},
+		{name: "cached", expect: []string{"cache/libcached.so.5"}},
+		{name: ":libcached2.so.1", expect: []string{"cache/libcached2.so.1"}},
+		{name: "sys", expect: []string{"sys/libsys.so"}},
+		{name: "loop", expectErr: true},
+		{name: "missing", expectErr: true},
+		{name: "nonexistent", expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r.Mode = tc.mode
+			lib := r.Resolve(tc.name)
+			if tc.expectErr {
+				if lib.Err == nil || len(lib.Files) > 0 {
+					t.Fatalf("Resolve(%s) = %v, want an error", tc.name, lib.Files)
+				}
+				return
+			}
+			if lib.Err != nil {
+				t.Fatal(lib.Err)
+			}
+			var expect []string
+			for _, file := range tc.expect {
+				expect = append(expect, filepath.Join(dir, file))
+			}
+			if !reflect.DeepEqual(lib.Files, expect) {
+				t.Errorf("Resolve(%s) = %v, want %v", tc.name, lib.Files, expect)
+			}
+		})
+	}
+
+	r.Mode = symbol.ModeDynamic
+	libs := r.ResolveAll([]string{"soname", "nonexistent"})
+	if len(libs) != 2 || !reflect.DeepEqual(libs[0].Files, []string{soname}) || libs[1].Err == nil {
+		t.Fatalf("ResolveAll() = %v", libs)
+	}
+	if libs[0].Soname != "" {
+		t.Errorf("Soname = %q, want none of libsym.so", libs[0].Soname)
+	}
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("LD_LIBRARY_PATH", dir+string(os.PathListSeparator))
+	t.Setenv("DYLD_LIBRARY_PATH", dir)
+	flags := resolver.ParseFlags("-L/opt/lib -Wl,-rpath,/opt/rpath -lfoo")
+	r := resolver.New(flags, symbol.ModeDynamic)
+	if expect := []string{"/opt/lib", "/opt/rpath", dir}; !reflect.DeepEqual(r.Paths, expect) {
+		t.Errorf("Paths = %v, want %v", r.Paths, expect)
+	}
+	r = resolver.New(flags, symbol.ModeStatic)
+	if expect := []string{"/opt/lib", "/opt/rpath"}; !reflect.DeepEqual(r.Paths, expect) {
+		t.Errorf("Paths = %v, want %v", r.Paths, expect)
+	}
+	if r.Cache != nil {
+		t.Error("Cache of the static mode, want none")
+	}
+	if runtime.GOOS == "linux" && len(r.SysPaths) == 0 {
+		t.Error("SysPaths, want the system paths")
+	}
+}
+
+func TestParseLinkerScript(t *testing.T) {
+	script := `/* GNU ld script
+   Use the shared library, but some functions are only in
+   the static library, so try that secondarily.  */
+OUTPUT_FORMAT(elf64-x86-64)
+GROUP ( /lib/x86_64-linux-gnu/libc.so.6 /usr/lib/x86_64-linux-gnu/libc_nonshared.a  AS_NEEDED ( /lib64/ld-linux-x86-64.so.2 ) )
+INPUT(-lm, libextra.a)
+`
+	expect := []string{
+		"/lib/x86_64-linux-gnu/libc.so.6",
+		"/usr/lib/x86_64-linux-gnu/libc_nonshared.a",
+		"/lib64/ld-linux-x86-64.so.2",
+		"-lm",
+		"libextra.a",
+	}
+	if got := resolver.ParseLinkerScript(script); !reflect.DeepEqual(got, expect) {
+		t.Errorf("ParseLinkerScript() = %v, want %v", got, expect)
+	}
+	if inputs, ok := resolver.LinkerScript(filepath.Join("..", "symbol", "testdata", "libsym.so")); ok {
+		t.Errorf("LinkerScript() of a library = %v", inputs)
+	}
+}
+
+// ldCache builds an ldconfig cache of the new format of the entries.
+func ldCache(entries ...[3]any) []byte {
+	const headerSize, entrySize = 48, 24
+	strs := headerSize + len(entries)*entrySize
+	var buf, strtab bytes.Buffer
+	buf.WriteString("glibc-ld.so.cache1.1")
+	le := binary.LittleEndian
+	buf.Write(le.AppendUint32(nil, uint32(len(entries))))
+	buf.Write(make([]byte, headerSize-buf.Len()))
+	for _, entry := range entries {
+		key := strs + strtab.Len()
+		strtab.WriteString(entry[1].(string) + "\x00")
+		value := strs + strtab.Len()
+		strtab.WriteString(entry[2].(string) + "\x00")
+		buf.Write(le.AppendUint32(nil, uint32(entry[0].(int))))
+		buf.Write(le.AppendUint32(nil, uint32(key)))
+		buf.Write(le.AppendUint32(nil, uint32(value)))
+		buf.Write(make([]byte, 12))
+	}
+	buf.Write(strtab.Bytes())
+	return buf.Bytes()
+}
+
+func TestParseLdCache(t *testing.T) {
+	if runtime.GOARCH != "amd64" {
+		t.Skip("the arch flags of the entries are the ones of amd64")
+	}
+	data := ldCache(
+		[3]any{0x0303, "libz.so.1", "/lib/x86_64-linux-gnu/libz.so.1"},
+		[3]any{0x0003, "libz.so.1", "/lib/i386-linux-gnu/libz.so.1"},
+		[3]any{0x0303, "libz.so", "/lib/x86_64-linux-gnu/libz.so"},
+		[3]any{0x0303, "libz.so", "/usr/lib/x86_64-linux-gnu/libz.so"},
+	)
+	libs, err := resolver.ParseLdCache(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]string{
+		"libz.so.1": "/lib/x86_64-linux-gnu/libz.so.1",
+		"libz.so":   "/lib/x86_64-linux-gnu/libz.so",
+	}
+	if !reflect.DeepEqual(libs, expect) {
+		t.Errorf("ParseLdCache() = %v, want %v", libs, expect)
+	}
+
+	// the old format followed by the new one
+	old := append([]byte("ld.so-1.7.0\x00"), 1, 0, 0, 0)
+	old = append(old, make([]byte, 12+4)...)
+	if libs, err := resolver.ParseLdCache(append(old, data...)); err != nil || !reflect.DeepEqual(libs, expect) {
+		t.Errorf("ParseLdCache() of the old format = %v, %v", libs, err)
+	}
+
+	for _, invalid := range [][]byte{nil, []byte("ld.so-1.7.0"), []byte("glibc-ld.so.cache1.1"), data[:60]} {
+		if _, err := resolver.ParseLdCache(invalid); err == nil {
+			t.Errorf("ParseLdCache(%q) expected an error", invalid)
+		}
+	}
+	if _, err := resolver.ReadLdCache(filepath.Join(t.TempDir(), "ld.so.cache")); err == nil {
+		t.Error("ReadLdCache() expected an error of a nonexistent file")
+	}
+	if _, err := os.Stat(resolver.LdCacheFile); err == nil {
+		if _, err := resolver.ReadLdCache(resolver.LdCacheFile); err != nil {
+			t.Errorf("ReadLdCache(%s): %v", resolver.LdCacheFile, err)
+		}
+	}
+}
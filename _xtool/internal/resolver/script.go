@@ -0,0 +1,71 @@
+package resolver
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// the max size of a linker script read, the larger files are libraries
+const maxScriptSize = 64 << 10
+
+var (
+	scriptComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	scriptInputs  = regexp.MustCompile(`\b(?:GROUP|INPUT)\s*\(`)
+)
+
+// LinkerScript returns the inputs of a linker script, like the files of
+// GROUP ( /lib/libc.so.6 /usr/lib/libc_nonshared.a AS_NEEDED ( /lib/ld-linux.so.2 ) ),
+// or ok is false if the file is not a text one with inputs, which is a library instead.
+func LinkerScript(file string) (inputs []string, ok bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxScriptSize+1))
+	if err != nil || len(data) > maxScriptSize || !isText(data) {
+		return nil, false
+	}
+	inputs = ParseLinkerScript(string(data))
+	return inputs, len(inputs) > 0
+}
+
+// ParseLinkerScript returns the inputs of the GROUP and INPUT commands of a linker script,
+// including the ones of AS_NEEDED, which are file names or -l flags like -lfoo.
+func ParseLinkerScript(script string) []string {
+	script = scriptComment.ReplaceAllString(script, " ")
+	var inputs []string
+	for _, loc := range scriptInputs.FindAllStringIndex(script, -1) {
+		depth := 1
+		args := script[loc[1]:]
+		end := strings.IndexFunc(args, func(r rune) bool {
+			switch r {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			return depth == 0
+		})
+		if end < 0 {
+			end = len(args)
+		}
+		fields := strings.FieldsFunc(args[:end], func(r rune) bool {
+			return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == ',' || r == '(' || r == ')'
+		})
+		for _, field := range fields {
+			if field != "AS_NEEDED" {
+				inputs = append(inputs, field)
+			}
+		}
+	}
+	return inputs
+}
+
+// isText reports whether the data has no NUL bytes, which an object file or an archive has.
+func isText(data []byte) bool {
+	return bytes.IndexByte(data, 0) < 0
+}
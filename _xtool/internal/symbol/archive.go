@@ -0,0 +1,74 @@
+package symbol
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// the size of the header of an archive member: name[16], date[12], uid[6], gid[6],
+// mode[8], size[10] and the magic "`\n"
+const arHeaderSize = 60
+
+// readArchive returns the exported symbols of the object files of an ar archive of
+// the GNU or the BSD variant, the symbol tables and the other members are skipped.
+func readArchive(r *io.SectionReader) ([]*Symbol, error) {
+	var exports []*Symbol
+	var longNames []byte
+	header := make([]byte, arHeaderSize)
+	for off := int64(len(arMagic)); off < r.Size(); {
+		if _, err := r.ReadAt(header, off); err != nil {
+			return nil, fmt.Errorf("invalid archive header at %d: %w", off, err)
+		}
+		if string(header[58:60]) != "`\n" {
+			return nil, fmt.Errorf("invalid archive header at %d", off)
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive member size at %d: %w", off, err)
+		}
+		name := strings.TrimRight(string(header[:16]), " ")
+		data := off + arHeaderSize
+		off = data + size + size%2 // the members are aligned to 2 bytes
+
+		switch {
+		case name == "//": // the GNU long names
+			longNames = make([]byte, size)
+			if _, err := r.ReadAt(longNames, data); err != nil {
+				return nil, err
+			}
+			continue
+		case name == "/", name == "/SYM64/", strings.HasPrefix(name, "__.SYMDEF"):
+			continue
+		case strings.HasPrefix(name, "#1/"): // the BSD long name preceding the data
+			n, err := strconv.ParseInt(name[3:], 10, 64)
+			if err != nil || n > size {
+				return nil, fmt.Errorf("invalid archive member name %q", name)
+			}
+			buf := make([]byte, n)
+			if _, err := r.ReadAt(buf, data); err != nil {
+				return nil, err
+			}
+			name = strings.TrimRight(string(buf), "\x00")
+			data, size = data+n, size-n
+			if strings.HasPrefix(name, "__.SYMDEF") {
+				continue
+			}
+		case strings.HasPrefix(name, "/"): // a GNU long name, like /123
+			if i, err := strconv.Atoi(name[1:]); err == nil && i < len(longNames) {
+				name, _, _ = strings.Cut(string(longNames[i:]), "\n")
+			}
+		}
+
+		syms, err := readObject(io.NewSectionReader(r, data, size))
+		if err == errUnknownFormat {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strings.TrimSuffix(name, "/"), err)
+		}
+		exports = append(exports, syms...)
+	}
+	return exports, nil
+}
@@ -0,0 +1,158 @@
+package symbol
+
+import (
+	"debug/elf"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// the versym index of a global symbol with no version
+	verGlobal = 1
+	// the bit of a versym index of a non-default version (@)
+	verHidden = 0x8000
+	// the flag of the verdef entry of the file itself
+	verFlagBase = 0x1
+	// STB_GNU_UNIQUE, a global symbol unique in the process
+	elfGNUUnique elf.SymBind = 10
+)
+
+// readELF returns the exported symbols of an ELF file, which are its dynamic symbols
+// if it's a shared library, and the symbols of its symbol table otherwise.
+func readELF(r io.ReaderAt) ([]*Symbol, error) {
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var syms []elf.Symbol
+	var versyms []uint16
+	var verdefs map[uint16]string
+	if f.Type == elf.ET_DYN {
+		if syms, err = f.DynamicSymbols(); err != nil {
+			if err == elf.ErrNoSymbols {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if versyms, verdefs, err = elfVersions(f); err != nil {
+			return nil, err
+		}
+	} else if syms, err = f.Symbols(); err != nil {
+		if err == elf.ErrNoSymbols {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var exports []*Symbol
+	for i, sym := range syms {
+		bind := elf.ST_BIND(sym.Info)
+		if sym.Section == elf.SHN_UNDEF || (bind != elf.STB_GLOBAL && bind != elf.STB_WEAK && bind != elfGNUUnique) {
+			continue
+		}
+		exp := &Symbol{Name: sym.Name}
+		switch elf.ST_TYPE(sym.Info) {
+		case elf.STT_FUNC, elf.STT_GNU_IFUNC:
+			exp.Kind = Func
+		case elf.STT_OBJECT, elf.STT_TLS, elf.STT_COMMON:
+			exp.Kind = Data
+		default:
+			continue
+		}
+		switch elf.ST_VISIBILITY(sym.Other) {
+		case elf.STV_DEFAULT:
+			exp.Visibility = Default
+		case elf.STV_PROTECTED:
+			exp.Visibility = Protected
+		default:
+			continue
+		}
+		if bind == elf.STB_WEAK {
+			exp.Binding = Weak
+		}
+		// the null symbol is not returned by DynamicSymbols
+		if i+1 < len(versyms) {
+			index := versyms[i+1]
+			if version, ok := verdefs[index&^verHidden]; ok {
+				// the symbols of the version definitions, like VERS_1, are not exported ones
+				if sym.Section == elf.SHN_ABS && sym.Name == version {
+					continue
+				}
+				exp.Version = version
+				exp.IsDefault = index&verHidden == 0
+			}
+		}
+		// the versions of a relocatable object are a part of the names, like foo@@VERS_2
+		if name, version, ok := strings.Cut(exp.Name, "@"); ok && exp.Version == "" {
+			exp.Name = name
+			exp.Version, exp.IsDefault = strings.CutPrefix(version, "@")
+		}
+		exports = append(exports, exp)
+	}
+	return exports, nil
+}
+
+// elfVersions returns the version indices of the dynamic symbols from .gnu.version,
+// and the names of the versions defined by the file from .gnu.version_d.
+func elfVersions(f *elf.File) (versyms []uint16, verdefs map[uint16]string, err error) {
+	versym := f.SectionByType(elf.SHT_GNU_VERSYM)
+	verdef := f.SectionByType(elf.SHT_GNU_VERDEF)
+	if versym == nil || verdef == nil {
+		return nil, nil, nil
+	}
+	data, err := versym.Data()
+	if err != nil {
+		return nil, nil, err
+	}
+	versyms = make([]uint16, len(data)/2)
+	for i := range versyms {
+		versyms[i] = f.ByteOrder.Uint16(data[i*2:])
+	}
+
+	if int(verdef.Link) >= len(f.Sections) {
+		return nil, nil, fmt.Errorf("invalid link of %s", verdef.Name)
+	}
+	strtab, err := f.Sections[verdef.Link].Data()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err = verdef.Data()
+	if err != nil {
+		return nil, nil, err
+	}
+	verdefs = make(map[uint16]string)
+	// Elf_Verdef: vd_version, vd_flags, vd_ndx, vd_cnt uint16, vd_hash, vd_aux, vd_next uint32,
+	// vd_aux is the offset of the Elf_Verdaux of its name: vda_name, vda_next uint32.
+	for off := 0; off+20 <= len(data); {
+		flags := f.ByteOrder.Uint16(data[off+2:])
+		index := f.ByteOrder.Uint16(data[off+4:])
+		aux := off + int(f.ByteOrder.Uint32(data[off+12:]))
+		next := int(f.ByteOrder.Uint32(data[off+16:]))
+		if aux+4 > len(data) {
+			return nil, nil, fmt.Errorf("invalid %s", verdef.Name)
+		}
+		if flags&verFlagBase == 0 && index > verGlobal {
+			verdefs[index] = cstring(strtab, f.ByteOrder.Uint32(data[aux:]))
+		}
+		if next == 0 {
+			break
+		}
+		off += next
+	}
+	return versyms, verdefs, nil
+}
+
+// cstring returns the NUL-terminated string at the offset of a string table.
+func cstring(strtab []byte, off uint32) string {
+	if int(off) >= len(strtab) {
+		return ""
+	}
+	end := int(off)
+	for end < len(strtab) && strtab[end] != 0 {
+		end++
+	}
+	return string(strtab[off:end])
+}
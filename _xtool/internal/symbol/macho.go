@@ -0,0 +1,75 @@
+package symbol
+
+import (
+	"debug/macho"
+	"io"
+	"runtime"
+)
+
+const (
+	machoStab      = 0xe0 // N_STAB, a debugging symbol
+	machoPrivExt   = 0x10 // N_PEXT, a private external symbol
+	machoType      = 0x0e // N_TYPE
+	machoSect      = 0x0e // N_SECT, a symbol defined in a section
+	machoExt       = 0x01 // N_EXT, an external symbol
+	machoWeakDef   = 0x80 // N_WEAK_DEF of the desc
+	machoPureInstr = 0x80000000
+)
+
+// readMachO returns the exported symbols of a Mach-O file, which is the slice of
+// the architecture of runtime.GOARCH, or the first one, of a universal file.
+func readMachO(r io.ReaderAt) ([]*Symbol, error) {
+	f, err := macho.NewFile(r)
+	if err == nil {
+		defer f.Close()
+		return machoSymbols(f), nil
+	}
+	fat, fatErr := macho.NewFatFile(r)
+	if fatErr != nil {
+		return nil, err
+	}
+	defer fat.Close()
+	if len(fat.Arches) == 0 {
+		return nil, nil
+	}
+	arch := fat.Arches[0]
+	for _, a := range fat.Arches {
+		if a.Cpu == machoCpu() {
+			arch = a
+			break
+		}
+	}
+	return machoSymbols(arch.File), nil
+}
+
+func machoCpu() macho.Cpu {
+	switch runtime.GOARCH {
+	case "arm64":
+		return macho.CpuArm64
+	case "386":
+		return macho.Cpu386
+	}
+	return macho.CpuAmd64
+}
+
+func machoSymbols(f *macho.File) []*Symbol {
+	if f.Symtab == nil {
+		return nil
+	}
+	var exports []*Symbol
+	for _, sym := range f.Symtab.Syms {
+		if sym.Type&machoStab != 0 || sym.Type&machoExt == 0 || sym.Type&machoPrivExt != 0 ||
+			sym.Type&machoType != machoSect {
+			continue
+		}
+		exp := &Symbol{Name: sym.Name, Kind: Data}
+		if sym.Desc&machoWeakDef != 0 {
+			exp.Binding = Weak
+		}
+		if i := int(sym.Sect) - 1; i >= 0 && i < len(f.Sections) && f.Sections[i].Flags&machoPureInstr != 0 {
+			exp.Kind = Func
+		}
+		exports = append(exports, exp)
+	}
+	return exports
+}
@@ -0,0 +1,98 @@
+package symbol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Kind is the kind of an exported symbol.
+type Kind int
+
+const (
+	Func Kind = iota
+	Data
+)
+
+// Binding is the binding of an exported symbol.
+type Binding int
+
+const (
+	Global Binding = iota
+	Weak
+)
+
+// Visibility is the visibility of an exported symbol, the hidden ones are not exported.
+type Visibility int
+
+const (
+	Default Visibility = iota
+	Protected
+)
+
+// Symbol is a function or a data symbol exported by a library.
+type Symbol struct {
+	Name       string // name in the object file, like _foo of Mach-O
+	Kind       Kind
+	Binding    Binding
+	Visibility Visibility
+	// Version is the ELF symbol version, like VERS_2 of foo@@VERS_2, or "" if it's not versioned.
+	// IsDefault reports whether it's the default version (@@), which is linked by the name.
+	Version   string
+	IsDefault bool
+	// Lib is the -l name of the library providing it, like cjson, which is set by the callers.
+	Lib string
+}
+
+var errUnknownFormat = errors.New("unknown object file format")
+
+var (
+	elfMagic   = []byte("\x7fELF")
+	arMagic    = []byte("!<arch>\n")
+	machoMagic = [][]byte{
+		{0xfe, 0xed, 0xfa, 0xce}, {0xce, 0xfa, 0xed, 0xfe}, // 32-bit
+		{0xfe, 0xed, 0xfa, 0xcf}, {0xcf, 0xfa, 0xed, 0xfe}, // 64-bit
+		{0xca, 0xfe, 0xba, 0xbe}, // universal
+	}
+)
+
+// Read returns the exported symbols of a library, which is an ELF or a Mach-O file,
+// or an ar archive of them. The dynamic symbols of a shared library are read, as
+// what the dynamic linker resolves.
+func Read(file string) ([]*Symbol, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	syms, err := readObject(io.NewSectionReader(f, 0, info.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return syms, nil
+}
+
+// readObject returns the exported symbols of an object file by its magic number.
+func readObject(r *io.SectionReader) ([]*Symbol, error) {
+	magic := make([]byte, len(arMagic))
+	n, _ := r.ReadAt(magic, 0)
+	magic = magic[:n]
+	switch {
+	case bytes.HasPrefix(magic, elfMagic):
+		return readELF(r)
+	case bytes.HasPrefix(magic, arMagic):
+		return readArchive(r)
+	}
+	for _, m := range machoMagic {
+		if bytes.HasPrefix(magic, m) {
+			return readMachO(r)
+		}
+	}
+	return nil, errUnknownFormat
+}
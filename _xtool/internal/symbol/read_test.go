@@ -0,0 +1,102 @@
+package symbol
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	testCases := []struct {
+		name   string
+		file   string
+		expect []*Symbol
+	}{
+		{
+			name: "shared library",
+			file: "libsym.so",
+			expect: []*Symbol{
+				{Name: "sym_add", Kind: Func, Version: "VERS_2", IsDefault: true},
+				{Name: "sym_call", Kind: Func, Version: "VERS_2", IsDefault: true},
+				{Name: "sym_count", Kind: Data, Version: "VERS_2", IsDefault: true},
+				{Name: "sym_name", Kind: Data, Version: "VERS_2", IsDefault: true},
+				{Name: "sym_old", Kind: Func, Version: "VERS_1"},
+				{Name: "sym_old", Kind: Func, Version: "VERS_2", IsDefault: true},
+				{Name: "sym_protected", Kind: Func, Visibility: Protected, Version: "VERS_2", IsDefault: true},
+				{Name: "sym_weak", Kind: Func, Binding: Weak, Version: "VERS_2", IsDefault: true},
+			},
+		},
+		{
+			name: "static library",
+			file: "libsym.a",
+			expect: []*Symbol{
+				{Name: "sym_add", Kind: Func},
+				{Name: "sym_call", Kind: Func},
+				{Name: "sym_count", Kind: Data},
+				{Name: "sym_name", Kind: Data},
+				{Name: "sym_next", Kind: Func},
+				{Name: "sym_old", Kind: Func, Version: "VERS_1"},
+				{Name: "sym_old", Kind: Func, Version: "VERS_2", IsDefault: true},
+				{Name: "sym_old_v1", Kind: Func},
+				{Name: "sym_old_v2", Kind: Func},
+				{Name: "sym_protected", Kind: Func, Visibility: Protected},
+				{Name: "sym_weak", Kind: Func, Binding: Weak},
+			},
+		},
+		{
+			name: "mach-o object",
+			file: "hello-amd64-darwin.o",
+			expect: []*Symbol{
+				{Name: "_main", Kind: Func},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			syms, err := Read(filepath.Join("testdata", tc.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Slice(syms, func(i, j int) bool {
+				if syms[i].Name != syms[j].Name {
+					return syms[i].Name < syms[j].Name
+				}
+				return syms[i].Version < syms[j].Version
+			})
+			if !reflect.DeepEqual(syms, tc.expect) {
+				t.Errorf("Read(%s) =", tc.file)
+				for _, sym := range syms {
+					t.Errorf("\t%+v", *sym)
+				}
+			}
+		})
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tempDir := t.TempDir()
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown format", content: "INPUT(libfoo.so.1)\n"},
+		{name: "truncated elf", content: "\x7fELF\x02\x01"},
+		{name: "truncated archive", content: "!<arch>\nfoo.o/"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(tempDir, "lib.so")
+			if err := os.WriteFile(file, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Read(file); err == nil {
+				t.Error("Read() expected an error")
+			}
+		})
+	}
+	if _, err := Read(filepath.Join(tempDir, "nonexistent.so")); err == nil {
+		t.Error("Read() expected an error of a nonexistent file")
+	}
+}
@@ -0,0 +1,13 @@
+#!/bin/sh
+# Rebuilds the fixtures of the symbol reader on linux.
+set -e
+cd "$(dirname "$0")"
+gcc -shared -fPIC -nostdlib -Wl,--version-script=libsym.map -o libsym.so libsym.c
+gcc -c -fPIC -o libsym.o libsym.c
+gcc -c -fPIC -o libsym2.o libsym2.c
+rm -f libsym.a
+ar rcs libsym.a libsym.o libsym2.o
+rm libsym.o libsym2.o
+# hello-amd64-darwin.o is the Mach-O object of hello.c from the testdata of debug/macho:
+#	#include <stdio.h>
+#	int main(void) { printf("hello, world\n"); return 0; }
@@ -0,0 +1,22 @@
+// The fixtures of the symbol reader, rebuilt by gen.sh.
+
+int sym_count = 1;
+const char *sym_name = "sym";
+
+int sym_add(int a, int b) { return a + b; }
+
+__attribute__((weak)) int sym_weak(void) { return 0; }
+
+__attribute__((visibility("protected"))) int sym_protected(void) { return 1; }
+
+__attribute__((visibility("hidden"))) int sym_hidden(void) { return 2; }
+
+static int sym_local(void) { return 3; }
+
+int sym_call(void) { return sym_local() + sym_hidden(); }
+
+// sym_old@VERS_1 and the default sym_old@@VERS_2 of libsymver.so
+int sym_old_v1(void) { return 1; }
+int sym_old_v2(void) { return 2; }
+__asm__(".symver sym_old_v1, sym_old@VERS_1");
+__asm__(".symver sym_old_v2, sym_old@@VERS_2");
@@ -0,0 +1,5 @@
+// The second object of libsym.a.
+
+extern int sym_count;
+
+int sym_next(void) { return ++sym_count; }
@@ -68,6 +68,11 @@ func main() {
 	if conf.StaticLib {
 		libMode = symg.ModeStatic
 	}
+	var lock *llcppg.NameLock
+	if conf.LockNames {
+		lock, err = llcppg.ReadNameLock(llcppg.LLCPPG_LOCK)
+		check(err)
+	}
 	symbolTable, err := symg.Do(&symg.Config{
 		Libs:         conf.Libs,
 		CFlags:       conf.CFlags,
@@ -78,6 +83,16 @@ func main() {
 		IsCpp:        conf.Cplusplus,
 		HeaderOnly:   conf.HeaderOnly,
 		LibMode:      libMode,
+		Namespaces: symg.Namespaces{
+			Mode:  conf.NamespaceMode,
+			Strip: conf.StripNamespaces,
+		},
+		Instantiate: conf.Instantiate,
+		SymVersions: conf.SymVersions,
+		Locked:      lockedSymbols(lock),
+		Rules:       conf.Rules,
+		Naming:      conf.Naming,
+		Collisions:  conf.Collisions,
 	})
 	check(err)
 
@@ -86,6 +101,20 @@ func main() {
 
 	err = os.WriteFile(llcppg.LLCPPG_SYMB, jsonData, os.ModePerm)
 	check(err)
+
+	if lock != nil {
+		lock.Symbols = symg.GoNames(symbolTable)
+		err = llcppg.WriteNameLock(llcppg.LLCPPG_LOCK, lock)
+		check(err)
+	}
+}
+
+// lockedSymbols returns the Go names of the symbols locked, or nil if the names are not locked.
+func lockedSymbols(lock *llcppg.NameLock) map[string]string {
+	if lock == nil {
+		return nil
+	}
+	return lock.Symbols
 }
 
 func check(err error) {
@@ -6,10 +6,28 @@ import (
 )
 
 func MarshalPkg(pkg *llcppg.Pkg) map[string]any {
-	return map[string]any{
+	root := map[string]any{
 		"File":    parser.XMarshalASTFile(pkg.File),
 		"FileMap": MarshalFileMap(pkg.FileMap),
 	}
+	if pkg.TypeSizes != nil {
+		root["TypeSizes"] = MarshalTypeSizes(pkg.TypeSizes)
+	}
+	return root
+}
+
+func MarshalTypeSizes(sizes *llcppg.TypeSizes) map[string]any {
+	root := make(map[string]any)
+	if sizes.WChar != 0 {
+		root["wchar"] = float64(sizes.WChar)
+	}
+	if sizes.WCharUnsigned {
+		root["wcharUnsigned"] = true
+	}
+	if sizes.LongDouble != 0 {
+		root["longDouble"] = float64(sizes.LongDouble)
+	}
+	return root
 }
 
 func MarshalFileMap(fmap map[string]*llcppg.FileInfo) map[string]any {
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	clangutils "github.com/goplus/llcppg/_xtool/internal/clang"
 	"github.com/goplus/llcppg/_xtool/internal/clangtool"
 	"github.com/goplus/llcppg/_xtool/internal/header"
 	"github.com/goplus/llcppg/_xtool/internal/parser"
@@ -77,6 +78,17 @@ func Do(conf *Config) error {
 	if err != nil {
 		return err
 	}
+	instFile, err := clangutils.IncludeInstantiations(&clangutils.InstantiationConfig{
+		Header:    conf.CombinedFile,
+		Templates: conf.Conf.Instantiate,
+		Args:      strings.Fields(conf.Conf.CFlags),
+	}, conf.Conf.Name+"_inst*.h")
+	if err != nil {
+		return err
+	}
+	if instFile != "" {
+		defer os.Remove(instFile)
+	}
 
 	// prepare clang flags to preprocess the combined file
 	clangFlags := strings.Fields(conf.Conf.CFlags)
@@ -128,18 +140,24 @@ func Do(conf *Config) error {
 		fmt.Fprintln(os.Stderr, "thirdhfile", pkgHfiles.Thirds)
 	}
 	libclangFlags = append(libclangFlags, strings.Fields(conf.Conf.CFlags)...)
-	file, err := parser.Do(&parser.ConverterConfig{
-		File:  conf.PreprocessedFile,
-		Args:  libclangFlags,
-		IsCpp: isCpp,
+	converter, err := parser.NewConverter(&parser.ConverterConfig{
+		File:        conf.PreprocessedFile,
+		Args:        libclangFlags,
+		IsCpp:       isCpp,
+		Instantiate: conf.Conf.Instantiate,
 	})
 	if err != nil {
 		return err
 	}
+	file, err := converter.Convert()
+	if err != nil {
+		return err
+	}
 
 	pkg := &llcppg.Pkg{
-		File:    file,
-		FileMap: make(map[string]*llcppg.FileInfo),
+		File:      file,
+		FileMap:   make(map[string]*llcppg.FileInfo),
+		TypeSizes: converter.TypeSizes(),
 	}
 
 	fileTypeMappings := []struct {
@@ -159,8 +177,15 @@ func Do(conf *Config) error {
 		}
 	}
 
+	// the instantiations are declared in the package, like the implementation headers
+	if instFile != "" {
+		pkg.FileMap[instFile] = &llcppg.FileInfo{
+			FileType: llcppg.Impl,
+		}
+	}
+
 	if debugParse {
-		fmt.Fprintln(os.Stderr, "Have %d Macros", len(pkg.File.Macros))
+		fmt.Fprintf(os.Stderr, "Have %d Macros\n", len(pkg.File.Macros))
 		for _, macro := range pkg.File.Macros {
 			fmt.Fprintf(os.Stderr, "Macro %s", macro.Name)
 		}
@@ -2,8 +2,8 @@ package symg
 
 import (
 	"fmt"
-	"strings"
 
+	"github.com/goplus/llcppg/_xtool/internal/resolver"
 	"github.com/goplus/llcppg/_xtool/internal/symbol"
 )
 
@@ -13,16 +13,8 @@ type Libs struct {
 }
 
 func ParseLibs(libs string) *Libs {
-	parts := strings.Fields(libs)
-	lbs := &Libs{}
-	for _, part := range parts {
-		if strings.HasPrefix(part, "-L") {
-			lbs.Paths = append(lbs.Paths, part[2:])
-		} else if strings.HasPrefix(part, "-l") {
-			lbs.Names = append(lbs.Names, part[2:])
-		}
-	}
-	return lbs
+	flags := resolver.ParseFlags(libs)
+	return &Libs{Paths: flags.Paths, Names: flags.Names}
 }
 
 type LibMode = symbol.Mode
@@ -32,34 +24,23 @@ const (
 	ModeStatic  LibMode = symbol.ModeStatic
 )
 
-// searches for each library name in the provided paths and default paths,
-// appending the appropriate file extension (.dylib for macOS, .so for Linux at dylib mode, .a for static mode).
+// Files resolves the libraries by resolver.New, searching the paths of the libraries and
+// findPaths before the ones of the system, see resolver.Resolver.
 //
 // Example: For "-L/opt/homebrew/lib -llua -lm" and at dylib mode:
 // - It will search for liblua.dylib (on macOS) or liblua.so (on Linux)
-// - System libs like -lm are ignored and included in notFound
+// - The libraries not found, like -lm without libm.so, are included in notFound
 //
 // So error is returned if no libraries found at all.
 func (l *Libs) Files(findPaths []string, mode LibMode) ([]string, []string, error) {
 	var foundPaths []string
 	var notFound []string
-	searchPaths := append(l.Paths, findPaths...)
-	for _, name := range l.Names {
-		var foundPath string
-		for _, path := range searchPaths {
-			libPath, err := symbol.FindLibFile(path, name, mode)
-			if err != nil {
-				continue
-			}
-			if libPath != "" {
-				foundPath = libPath
-				break
-			}
-		}
-		if foundPath != "" {
-			foundPaths = append(foundPaths, foundPath)
+	r := resolver.New(&resolver.Flags{Paths: append(l.Paths, findPaths...), Names: l.Names}, mode)
+	for _, lib := range r.ResolveAll(l.Names) {
+		if len(lib.Files) > 0 {
+			foundPaths = append(foundPaths, lib.Files...)
 		} else {
-			notFound = append(notFound, name)
+			notFound = append(notFound, lib.Name)
 		}
 	}
 	if len(foundPaths) == 0 {
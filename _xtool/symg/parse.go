@@ -2,11 +2,13 @@ package symg
 
 import (
 	"fmt"
+	"os"
 	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/goplus/lib/c"
 	clangutils "github.com/goplus/llcppg/_xtool/internal/clang"
 	clang "github.com/goplus/llcppg/_xtool/internal/libclang"
 	"github.com/goplus/llcppg/internal/name"
@@ -28,12 +30,22 @@ func (h HeaderSymbols) ToSymbolTable() (table []*llcppg.SymbolInfo) {
 }
 
 type SymbolInfo struct {
-	GoName    string
-	ProtoName string
+	GoName       string
+	ProtoName    string
+	Instantiated bool   // a member of a class template instantiation
+	File         string // file of the declaration
+	Line         int    // line of the declaration
+}
+
+// Namespaces is the policy of mapping the C++ namespaces to the Go names.
+type Namespaces struct {
+	Mode  string   // llcppg.NamespaceFlatten (default) or llcppg.NamespacePackage
+	Strip []string // namespaces left out of the Go names
 }
 
 type collect struct {
 	symName    string             // symbol name
+	custom     bool               // named by symMap or the naming rules
 	getSymInfo func() *SymbolInfo // get symbol info
 }
 
@@ -46,8 +58,24 @@ type SymbolProcessor struct {
 	// "sqlite3_finalize":".Close" -> method
 	// "sqlite3_open":"Open" -> function
 	customSymMap map[string]string
+	namespaces   Namespaces
+	// canonical type of a class template instantiation -> its Go name
+	insts map[string]string
 	// register queue
 	collectQueue []*collect
+	// Go names of the symbols locked by the name lock file, mangled name -> Go name,
+	// which are kept and reserved from the suffixes of the other symbols
+	locked   map[string]string
+	reserved map[string]bool
+	// naming rules of llcppg.cfg, tried after the exact entries of customSymMap
+	rules *llcppg.NameRules
+	// naming policy of llcppg.cfg, nil for the default one
+	naming *name.Policy
+	// collisions of llcppg.cfg, whose renames are tried after symMap, and whose preferred
+	// C names keep the Go names, Go name -> C name
+	collisions llcppg.Collisions
+	preferred  map[string]string
+	taken      map[string]bool
 }
 
 func NewSymbolProcessor(curPkgFiles []string, prefixes []string, symMap map[string]string) *SymbolProcessor {
@@ -110,8 +138,7 @@ func (p *SymbolProcessor) beRecv(cur clang.Cursor) (ok bool, isPtr bool, typeNam
 	isInCurPkg := p.inCurPkg(underCursor(cur))
 
 	if typ.Kind == clang.TypePointer {
-		underTypeName := clang.GoString(typ.PointeeType().NamedType().String())
-		return isInCurPkg, true, name.GoName(underTypeName, p.prefixes, isInCurPkg)
+		return isInCurPkg, true, p.typeGoName(typ.PointeeType(), isInCurPkg)
 	}
 
 	// Check if the type is an elaborated type (e.g., struct/class with full qualification)
@@ -123,74 +150,270 @@ func (p *SymbolProcessor) beRecv(cur clang.Cursor) (ok bool, isPtr bool, typeNam
 			return p.beRecv(underTyp.TypeDeclaration())
 		}
 	}
-	namedType := clang.GoString(typ.NamedType().String())
-	goName := name.GoName(namedType, p.prefixes, isInCurPkg)
-	return isInCurPkg, false, goName
+	return isInCurPkg, false, p.typeGoName(typ, isInCurPkg)
+}
+
+// beRefRecv checks the first operand of a free operator can be a pointer receiver, which is
+// a reference to a class of the current package, declared in the Go package of the operator.
+func (p *SymbolProcessor) beRefRecv(operator clang.Cursor) (ok bool, typeName string) {
+	typ := operator.Argument(0).Type()
+	if typ.Kind != clang.TypeLValueReference && typ.Kind != clang.TypeRValueReference {
+		return false, ""
+	}
+	decl := typ.NonReferenceType().TypeDeclaration()
+	if !isClass(decl) || !p.inCurPkg(decl) || p.namespaceOf(operator) != p.namespaceOf(decl) {
+		return false, ""
+	}
+	return true, p.namespacePrefix(decl) + p.typeName(clang.GoString(decl.String()), true)
+}
+
+// typeGoName returns the Go name of a named type, a type in a namespace or a class is named
+// after its declaration with the namespace prefix.
+func (p *SymbolProcessor) typeGoName(typ clang.Type, isInCurPkg bool) string {
+	decl := typ.TypeDeclaration()
+	if len(scopesOf(decl)) > 0 {
+		return p.namespacePrefix(decl) + p.typeName(clang.GoString(decl.String()), isInCurPkg)
+	}
+	return p.typeName(clang.GoString(typ.NamedType().String()), isInCurPkg)
+}
+
+// typeName returns the Go name of a type by the naming policy, whose prefixes are
+// trimmed if it's in the current package.
+func (p *SymbolProcessor) typeName(cname string, isInCurPkg bool) string {
+	if isInCurPkg {
+		cname = name.RemovePrefixedName(cname, p.prefixes)
+	}
+	return p.naming.TypeName(cname)
+}
+
+// funcName returns the Go name of a function or a method by the naming policy, whose
+// prefixes are trimmed if it's in the current package.
+func (p *SymbolProcessor) funcName(cname string, isInCurPkg bool) string {
+	if isInCurPkg {
+		cname = name.RemovePrefixedName(cname, p.prefixes)
+	}
+	return p.naming.FuncName(cname)
+}
+
+// namespacePrefix returns the Go name prefix of the namespaces and the classes enclosing a
+// declaration, like OuterInner of Outer::Inner, which is empty in the package namespace mode,
+// where a namespace is a Go package. It's the prefix of ncimpl for the qualified C++ names.
+func (p *SymbolProcessor) namespacePrefix(decl clang.Cursor) string {
+	if p.namespaces.Mode == llcppg.NamespacePackage {
+		return ""
+	}
+	return p.naming.NamespacePrefix(name.Namespaces(scopesOf(decl), p.namespaces.Strip))
+}
+
+// namespaceOf returns the Go package of a declaration in the package namespace mode, like a::b.
+func (p *SymbolProcessor) namespaceOf(decl clang.Cursor) string {
+	if p.namespaces.Mode != llcppg.NamespacePackage {
+		return ""
+	}
+	return strings.Join(name.Namespaces(namespacesOf(decl), p.namespaces.Strip), "::")
+}
+
+// namespacesOf returns the enclosing namespaces of a declaration.
+func namespacesOf(decl clang.Cursor) []string {
+	var list []string
+	for parent := decl.SemanticParent(); parent.Kind == clang.CursorNamespace; parent = parent.SemanticParent() {
+		list = append([]string{clang.GoString(parent.String())}, list...)
+	}
+	return list
+}
+
+// scopesOf returns the enclosing namespaces and classes of a declaration.
+func scopesOf(decl clang.Cursor) []string {
+	var list []string
+	for parent := decl.SemanticParent(); isScope(parent); parent = parent.SemanticParent() {
+		list = append([]string{clang.GoString(parent.String())}, list...)
+	}
+	return list
+}
+
+func isScope(cursor clang.Cursor) bool {
+	return cursor.Kind == clang.CursorNamespace || isClass(cursor) || cursor.Kind == clang.CursorUnionDecl
+}
+
+// customName is the Go name of a function given by symMap or the naming rules.
+type customName struct {
+	name   string // Go name of the function or the method, - if ignored
+	method bool   // a method of the handle parameter
+	recv   string // Go type of the receiver, the one of the handle parameter if empty
+	ptr    bool   // the receiver is a pointer, like (*Doc).Save
+	value  bool   // the receiver is a value, like Doc.Save
+	handle int    // index of the handle parameter given by @N, -1 if not given
+}
+
+// parseCustomName parses a Go name of symMap:
+//
+//	sqlite3_finalize -> .Close -> method of the first parameter
+//	sqlite3_open -> Open -> function
+//	xmlSaveFile -> (*Doc).Save@1 -> method of the pointer receiver of the second parameter
+//	xmlNewDocOut -> NewDoc@0 -> constructor returning the value the first parameter points to
+func parseCustomName(goName string) *customName {
+	custom := &customName{name: goName, handle: -1}
+	if i := strings.LastIndex(goName, "@"); i > 0 {
+		if handle, err := strconv.Atoi(goName[i+1:]); err == nil && handle >= 0 {
+			custom.name, custom.handle = goName[:i], handle
+		}
+	}
+	recv, fnName, found := strings.Cut(custom.name, ".")
+	if !found {
+		return custom
+	}
+	custom.name, custom.method = fnName, true
+	if typeName, ok := strings.CutPrefix(recv, "(*"); ok {
+		custom.recv, custom.ptr = strings.TrimSuffix(typeName, ")"), true
+	} else if recv != "" {
+		custom.recv, custom.value = recv, true
+	}
+	return custom
 }
 
-// sqlite3_finalize -> .Close -> method
-// sqlite3_open -> Open -> function
-func (p *SymbolProcessor) customGoName(mangled string) (goName string, isMethod bool, isIgnore bool, ok bool) {
-	if customName, ok := p.customSymMap[mangled]; ok {
-		if customName == "-" {
-			return "-", false, true, true
+// customGoName returns the custom Go name of a function, or nil if it has none. The exact
+// entries of symMap win, then the collisions renaming or ignoring the qualified C name, and
+// then the first rule of the functions matching it.
+func (p *SymbolProcessor) customGoName(cursor clang.Cursor, mangled string) *customName {
+	if goName, ok := p.customSymMap[mangled]; ok {
+		return parseCustomName(goName)
+	}
+	if goName, ignored, ok := p.collisions.Resolve(qualifiedName(cursor)); ok {
+		if ignored {
+			return &customName{name: "-", handle: -1}
 		}
-		name, found := strings.CutPrefix(customName, ".")
-		return name, found, false, true
+		return parseCustomName(goName)
+	}
+	rule, goName, ok := p.rules.Match(llcppg.RuleFunc, qualifiedName(cursor))
+	if !ok {
+		return nil
+	}
+	switch rule.Action {
+	case llcppg.RuleIgnore:
+		return &customName{name: "-", handle: -1}
+	case llcppg.RuleMethod:
+		return &customName{name: goName, method: true, recv: rule.Recv, handle: -1}
 	}
-	return "", false, false, false
+	return &customName{name: goName, handle: -1}
+}
+
+// qualifiedName returns the C name of a declaration qualified by its scopes, like ns::Foo::bar.
+func qualifiedName(cursor clang.Cursor) string {
+	parts := clangutils.BuildScopingParts(cursor.SemanticParent())
+	return strings.Join(append(parts, clang.GoString(cursor.String())), "::")
 }
 
 func (p *SymbolProcessor) genGoName(cursor clang.Cursor, symbolName string) string {
+	cname := qualifiedName(cursor)
 	originName := clang.GoString(cursor.String())
 	isDestructor := cursor.Kind == clang.CursorDestructor
 	var convertedName string
 	if isDestructor {
-		convertedName = name.GoName(originName[1:], p.prefixes, p.inCurPkg(cursor))
+		convertedName = p.funcName(originName[1:], p.inCurPkg(cursor))
 	} else {
-		convertedName = name.GoName(originName, p.prefixes, p.inCurPkg(cursor))
+		convertedName = p.funcName(originName, p.inCurPkg(cursor))
 	}
 
-	customGoName, toMethod, isIgnore, isCustom := p.customGoName(symbolName)
+	custom := p.customGoName(cursor, symbolName)
+	isCustom := custom != nil
+	var customGoName string
+	var toMethod bool
+	handle := -1
+	if isCustom {
+		customGoName, toMethod, handle = custom.name, custom.method, custom.handle
+	}
 
 	// Early return if symbol should be ignored
-	if isIgnore {
+	if customGoName == "-" {
 		return customGoName
 	}
 
+	// an overloaded operator is named after the operation, like Add of operator+
+	if name.IsOperator(originName) {
+		arity := int(cursor.NumArguments())
+		if isMethod(cursor) && cursor.IsStatic() == 0 {
+			arity++
+		}
+		opName, ok := name.OperatorName(originName, arity)
+		if !ok && !isCustom {
+			fmt.Fprintf(os.Stderr, "llcppsymg: operator %s has no Go name, ignored, name it in symMap: %q\n", p.genProtoName(cursor), symbolName)
+			return "-"
+		}
+		convertedName = opName
+		// a free operator is a method of its first operand taken by reference, like (*Vec).Add of
+		// `Vec operator+(const Vec &a, const Vec &b)`
+		if !isMethod(cursor) && arity > 0 && (!isCustom || toMethod) {
+			if ok, typeName := p.beRefRecv(cursor); ok {
+				if isCustom {
+					convertedName = customGoName
+				}
+				return p.addScopedSuffix("", cname, p.GenMethodName(typeName, convertedName, false, true))
+			}
+		}
+	}
+
 	// 1. for class method, gen method name
-	if parent := cursor.SemanticParent(); parent.Kind == clang.CursorClassDecl {
-		class := name.GoName(clang.GoString(parent.String()), p.prefixes, p.inCurPkg(cursor))
+	if parent := cursor.SemanticParent(); isMethod(cursor) && isClass(parent) {
+		class := p.namespacePrefix(parent) + p.typeName(clang.GoString(parent.String()), p.inCurPkg(cursor))
+		if instName, ok := p.instName(parent); ok {
+			class = instName
+		}
 		// concat method name
 		if isCustom {
 			convertedName = customGoName
 		}
-		return p.AddSuffix(p.GenMethodName(class, convertedName, isDestructor, true))
+		// a static method has no this pointer, so it's a function named with the class
+		if cursor.IsStatic() != 0 {
+			return p.addScopedSuffix(p.namespaceOf(parent), cname, class+convertedName)
+		}
+		return p.addScopedSuffix("", cname, p.GenMethodName(class, convertedName, isDestructor, true))
 	}
 
 	// 2. check if can gen method name
 	numArgs := cursor.NumArguments()
 	// 3. Don't attempt to convert a variadic function to a method
 	isValist := cursor.Type().IsFunctionTypeVariadic() > 0
+	// the handle parameter given by @N is the receiver of a method or the result of a constructor
+	recvIndex := max(handle, 0)
 	// also config to gen method name, if can't gen method, use the origin function type
-	if numArgs > 0 && !isValist {
+	if int(numArgs) > recvIndex && !isValist {
 		// also can gen method name, but not want to be method, output func not method
 		if isCustom && !toMethod {
-			return p.AddSuffix(customGoName)
+			goName := p.addScopedSuffix(p.namespaceOf(cursor), cname, customGoName)
+			if handle < 0 {
+				return goName
+			}
+			if cursor.Argument(c.Uint(handle)).Type().CanonicalType().Kind == clang.TypePointer {
+				return goName + "@" + strconv.Itoa(handle)
+			}
+			fmt.Fprintf(os.Stderr, "llcppsymg: the parameter %d of %s is not a pointer, converted to a function\n", handle, p.genProtoName(cursor))
+			return goName
 		}
-		if ok, isPtr, typeName := p.beRecv(cursor.Argument(0)); ok {
-			if isCustom {
-				convertedName = customGoName
+		// a method is declared in the Go package of its receiver
+		arg := cursor.Argument(c.Uint(recvIndex))
+		if ok, isPtr, typeName := p.beRecv(arg); ok && p.namespaceOf(cursor) == p.namespaceOf(underCursor(arg)) {
+			if !isCustom || custom.recv == "" || custom.recv == typeName {
+				paramPtr := isPtr
+				if isCustom {
+					convertedName = customGoName
+					isPtr = custom.ptr || isPtr && !custom.value
+				}
+				goName := p.addScopedSuffix("", cname, p.GenMethodName(typeName, convertedName, isDestructor, isPtr))
+				// the converter reorders the parameters or converts the receiver, see GoFuncSpec
+				if recvIndex > 0 || isPtr != paramPtr {
+					goName += "@" + strconv.Itoa(recvIndex)
+				}
+				return goName
 			}
-			return p.AddSuffix(p.GenMethodName(typeName, convertedName, isDestructor, isPtr))
+			fmt.Fprintf(os.Stderr, "llcppsymg: the parameter %d of %s is not a %s, converted to a function\n", recvIndex, p.genProtoName(cursor), custom.recv)
 		}
 	}
 
 	// 3. normal function name
 	if isCustom {
-		return p.AddSuffix(customGoName)
+		return p.addScopedSuffix(p.namespaceOf(cursor), cname, customGoName)
 	}
-	return p.AddSuffix(convertedName)
+	return p.addScopedSuffix(p.namespaceOf(cursor), cname, p.namespacePrefix(cursor)+convertedName)
 }
 
 func (p *SymbolProcessor) genProtoName(cursor clang.Cursor) string {
@@ -207,11 +430,55 @@ func (p *SymbolProcessor) genProtoName(cursor clang.Cursor) string {
 }
 
 func (p *SymbolProcessor) AddSuffix(name string) string {
-	p.nameCounts[name]++
-	if count := p.nameCounts[name]; count > 1 {
-		return name + "__" + strconv.Itoa(count-1)
+	return p.countSuffix(name, name, "")
+}
+
+// addScopedSuffix is AddSuffix of the function cname for the Go package of the namespace ns,
+// whose names don't conflict with the ones of the other packages.
+func (p *SymbolProcessor) addScopedSuffix(ns, cname, name string) string {
+	if ns == "" {
+		return p.countSuffix(name, name, cname)
+	}
+	return p.countSuffix(ns+"::"+name, name, cname)
+}
+
+// countSuffix counts the names of the key and suffixes the name with the count, like Foo__1
+// of the second one. The names reserved by the locked symbols are skipped, and the first
+// function cname preferred by the collisions keeps the name, whose others are suffixed.
+func (p *SymbolProcessor) countSuffix(key, goName, cname string) string {
+	if preferred, ok := p.preferred[goName]; ok {
+		if preferred == cname && !p.taken[key] {
+			p.taken[key] = true
+			return goName
+		}
+	}
+	for {
+		p.nameCounts[key]++
+		suffixed := name.SuffixCount(goName, p.nameCounts[key])
+		if _, ok := p.preferred[suffixed]; !ok && !p.reserved[suffixed] {
+			return suffixed
+		}
+	}
+}
+
+// resolve applies the collisions of llcppg.cfg, see customGoName and countSuffix.
+func (p *SymbolProcessor) resolve(collisions llcppg.Collisions) {
+	p.collisions = collisions
+	p.preferred = collisions.Preferred()
+	p.taken = make(map[string]bool)
+}
+
+// lock keeps the Go names of the locked symbols, except the ones named by symMap.
+func (p *SymbolProcessor) lock(locked map[string]string) {
+	p.locked = make(map[string]string)
+	p.reserved = make(map[string]bool)
+	for symName, goName := range locked {
+		if _, ok := p.customSymMap[symName]; ok {
+			continue
+		}
+		p.locked[symName] = goName
+		p.reserved[goName] = true
 	}
-	return name
 }
 
 func (p *SymbolProcessor) collectFuncInfo(cursor clang.Cursor) {
@@ -234,12 +501,19 @@ func (p *SymbolProcessor) collectFuncInfo(cursor clang.Cursor) {
 		return
 	}
 	p.symbolMap[symbolName] = &SymbolInfo{}
+	custom := p.customGoName(cursor, symbolName) != nil
 	p.collectQueue = append(p.collectQueue, &collect{
 		symName: symbolName,
+		custom:  custom,
 		getSymInfo: func() *SymbolInfo {
+			_, inst := p.instName(cursor.SemanticParent())
+			file, line, _ := clangutils.GetPresumedLocation(cursor.Location())
 			return &SymbolInfo{
-				GoName:    p.genGoName(cursor, symbolName),
-				ProtoName: p.genProtoName(cursor),
+				GoName:       p.genGoName(cursor, symbolName),
+				ProtoName:    p.genProtoName(cursor),
+				Instantiated: inst,
+				File:         file,
+				Line:         int(line),
 			}
 		},
 	})
@@ -248,16 +522,26 @@ func (p *SymbolProcessor) collectFuncInfo(cursor clang.Cursor) {
 func (p *SymbolProcessor) visitTop(cursor, parent clang.Cursor) clang.ChildVisitResult {
 	filename := cursorFileName(cursor)
 	switch cursor.Kind {
+	case clang.CursorStructDecl:
+		if _, ok := clangutils.InstantiationIndex(cursor); ok && parent.Kind == clang.CursorTranslationUnit {
+			if p.isSelfFile(filename) {
+				p.collectInstantiation(cursor)
+			}
+			break
+		}
+		clangutils.VisitChildren(cursor, p.visitTop)
 	case clang.CursorNamespace, clang.CursorClassDecl:
 		clangutils.VisitChildren(cursor, p.visitTop)
 	case clang.CursorCXXMethod, clang.CursorFunctionDecl, clang.CursorConstructor, clang.CursorDestructor:
 		isPublicFunc := cursor.Kind == clang.CursorFunctionDecl &&
 			cursor.StorageClass() != clang.SCStatic
 
+		// virtual methods are called through the vtable, so only the non-virtual
+		// ones are linked, a virtual destructor can still be called directly
 		isPublicMethod := cursor.CXXAccessSpecifier() == clang.CXXPublic &&
-			cursor.Kind == clang.CursorCXXMethod ||
-			cursor.Kind == clang.CursorConstructor ||
-			cursor.Kind == clang.CursorDestructor
+			(cursor.Kind == clang.CursorCXXMethod && cursor.IsVirtual() == 0 && cursor.IsPureVirtual() == 0 ||
+				cursor.Kind == clang.CursorConstructor ||
+				cursor.Kind == clang.CursorDestructor)
 
 		if p.isSelfFile(filename) && (isPublicFunc || isPublicMethod) {
 			p.collectFuncInfo(cursor)
@@ -266,20 +550,56 @@ func (p *SymbolProcessor) visitTop(cursor, parent clang.Cursor) clang.ChildVisit
 	return clang.ChildVisit_Continue
 }
 
+// collectInstantiation collects the members of a class template specialization referred by a
+// struct of the instantiation header, see clangutils.ComposeInstantiations. They are methods of
+// the Go type named by the template-id, like (*VectorInt).PushBack of std::vector<int>::push_back,
+// the virtual ones are also linked directly.
+func (p *SymbolProcessor) collectInstantiation(cursor clang.Cursor) {
+	typ := clangutils.InstantiationType(cursor)
+	if p.insts == nil {
+		p.insts = make(map[string]string)
+	}
+	p.insts[clang.GoString(typ.CanonicalType().String())] = name.TemplateName(clang.GoString(typ.String()))
+	clangutils.VisitInstantiation(cursor, func(member clang.Cursor) {
+		if member.CXXAccessSpecifier() == clang.CXXPublic {
+			p.collectFuncInfo(member)
+		}
+	})
+}
+
+// instName returns the Go name of a class template instantiation.
+func (p *SymbolProcessor) instName(decl clang.Cursor) (string, bool) {
+	if len(p.insts) == 0 || !isClass(decl) {
+		return "", false
+	}
+	instName, ok := p.insts[clang.GoString(decl.Type().CanonicalType().String())]
+	return instName, ok
+}
+
 // processCollect processes the symbol collection queue and prioritizes custom go names.
-// Custom symbols (defined in llcppg.cfg/symMap) are processed before regular symbols
+// Custom symbols (defined in llcppg.cfg/symMap or rules) are processed before regular symbols
 // to ensure user-defined mappings take precedence.
 func (p *SymbolProcessor) processCollect() {
 	sort.SliceStable(p.collectQueue, func(i, j int) bool {
-		_, customI := p.customSymMap[p.collectQueue[i].symName]
-		_, customJ := p.customSymMap[p.collectQueue[j].symName]
-		return customI && !customJ
+		return p.collectQueue[i].custom && !p.collectQueue[j].custom
 	})
 	for _, collect := range p.collectQueue {
-		p.symbolMap[collect.symName] = collect.getSymInfo()
+		info := collect.getSymInfo()
+		if goName, ok := p.locked[collect.symName]; ok {
+			info.GoName = goName
+		}
+		p.symbolMap[collect.symName] = info
 	}
 }
 
+func isMethod(cursor clang.Cursor) bool {
+	return cursor.Kind == clang.CursorCXXMethod || cursor.Kind == clang.CursorConstructor || cursor.Kind == clang.CursorDestructor
+}
+
+func isClass(cursor clang.Cursor) bool {
+	return cursor.Kind == clang.CursorClassDecl || cursor.Kind == clang.CursorStructDecl
+}
+
 // Get the underlying cursor of the cursor
 // if cur is a pointer, return the underlying cursor
 func underCursor(arg clang.Cursor) clang.Cursor {
@@ -298,7 +618,7 @@ func cursorFileName(cursor clang.Cursor) string {
 	return filePath
 }
 
-func ParseHeaderFile(combileFile string, curPkgFiles []string, prefixes []string, cflags []string, symMap map[string]string, isCpp bool) (HeaderSymbols, error) {
+func ParseHeaderFile(combileFile string, curPkgFiles []string, prefixes []string, cflags []string, symMap map[string]string, isCpp bool, namespaces Namespaces, locked map[string]string, rules *llcppg.NameRules, naming *name.Policy, collisions llcppg.Collisions) (HeaderSymbols, error) {
 	index, unit, err := clangutils.CreateTranslationUnit(&clangutils.Config{
 		File:    combileFile,
 		IsCpp:   isCpp,
@@ -313,6 +633,11 @@ func ParseHeaderFile(combileFile string, curPkgFiles []string, prefixes []string
 	defer index.Dispose()
 	cursor := unit.Cursor()
 	processer := NewSymbolProcessor(curPkgFiles, prefixes, symMap)
+	processer.namespaces = namespaces
+	processer.rules = rules
+	processer.naming = naming
+	processer.lock(locked)
+	processer.resolve(collisions)
 	clangutils.VisitChildren(cursor, processer.visitTop)
 	processer.processCollect()
 	return HeaderSymbols(processer.symbolMap), nil
@@ -0,0 +1,144 @@
+package symg
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/goplus/llcppg/_xtool/internal/symbol"
+)
+
+// Report is the reconciliation of the functions declared by the headers of the package
+// with the symbols exported by the libraries, which are left out of the symbol table.
+type Report struct {
+	// Libs are the libraries of the -l flags, with the files satisfying them
+	Libs []*ReportLib `json:"libs,omitempty"`
+	// Unexported are the functions declared but not exported, which are likely macros or
+	// inline functions, or the ones of the other libraries
+	Unexported []*ReportSymbol `json:"unexported"`
+	// Undeclared are the functions exported but not declared, which are likely private or
+	// declared by the headers not included
+	Undeclared []*ReportSymbol `json:"undeclared"`
+	// Ignored are the functions named - by symMap, or the operators without Go names
+	Ignored []*ReportSymbol `json:"ignored"`
+}
+
+// ReportSymbol is a symbol of the report, with the location of its declaration
+// or the library exporting it.
+type ReportSymbol struct {
+	Mangle string `json:"mangle"`
+	CPP    string `json:"c++,omitempty"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Lib    string `json:"lib,omitempty"` // the -l name of the library, like cjson
+}
+
+// ReportLib is a library of the report, the files satisfying its -l flag, or why it's not
+// resolved, whose symbols are left out then.
+type ReportLib struct {
+	Name   string   `json:"name"` // the -l name of the library, like cjson
+	Files  []string `json:"files,omitempty"`
+	Soname string   `json:"soname,omitempty"`
+	Err    string   `json:"error,omitempty"`
+}
+
+// NewReport reconciles the symbols of the libraries with the ones of the headers, the
+// symbols of the report are sorted by their locations and names.
+//
+// The weak symbols of the libraries are not reported undeclared, which are the inline
+// functions and the template instantiations of C++ emitted by the objects using them.
+func NewReport(syms []*symbol.Symbol, headerSymbols HeaderSymbols) *Report {
+	r := &Report{
+		Unexported: []*ReportSymbol{},
+		Undeclared: []*ReportSymbol{},
+		Ignored:    []*ReportSymbol{},
+	}
+	exported := make(map[string]bool)
+	for _, sym := range syms {
+		symName := sym.Name
+		if runtime.GOOS == "darwin" {
+			symName = strings.TrimPrefix(symName, "_")
+		}
+		if exported[symName] {
+			continue
+		}
+		exported[symName] = true
+		if _, ok := headerSymbols[symName]; !ok && sym.Kind == symbol.Func && sym.Binding == symbol.Global {
+			r.Undeclared = append(r.Undeclared, &ReportSymbol{Mangle: symName, Lib: sym.Lib})
+		}
+	}
+	for symName, info := range headerSymbols {
+		reportSym := &ReportSymbol{Mangle: symName, CPP: info.ProtoName, File: info.File, Line: info.Line}
+		switch {
+		case info.GoName == "-":
+			r.Ignored = append(r.Ignored, reportSym)
+		case !exported[symName] && !info.Instantiated:
+			// the members of the instantiations are emitted by the C++ shim
+			r.Unexported = append(r.Unexported, reportSym)
+		}
+	}
+	for _, reportSyms := range [][]*ReportSymbol{r.Unexported, r.Undeclared, r.Ignored} {
+		sort.Slice(reportSyms, func(i, j int) bool {
+			a, b := reportSyms[i], reportSyms[j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			if a.Lib != b.Lib {
+				return a.Lib < b.Lib
+			}
+			return a.Mangle < b.Mangle
+		})
+	}
+	return r
+}
+
+// String returns the human-readable report, like
+//
+//	libraries (1):
+//		-lcjson: /usr/lib/libcjson.so libcjson.so.1
+//	declared but not exported, likely macros or inline functions (1):
+//		/usr/include/cjson/cJSON.h:120: cJSON_Inline
+//	exported but not declared, likely private or in the headers not included (1):
+//		-lcjson: cJSON_Private
+//	ignored by symMap (1):
+//		/usr/include/cjson/cJSON.h:130: cJSON_Internal
+func (r *Report) String() string {
+	var b strings.Builder
+	section := func(title string, syms []*ReportSymbol) {
+		fmt.Fprintf(&b, "%s (%d):\n", title, len(syms))
+		for _, sym := range syms {
+			switch {
+			case sym.File != "" && strings.HasPrefix(sym.CPP, sym.Mangle+"("):
+				fmt.Fprintf(&b, "\t%s:%d: %s\n", sym.File, sym.Line, sym.Mangle)
+			case sym.File != "":
+				// the C++ symbols are mangled, like _ZN3Foo3barEv of Foo::bar()
+				fmt.Fprintf(&b, "\t%s:%d: %s %s\n", sym.File, sym.Line, sym.Mangle, sym.CPP)
+			case sym.Lib != "":
+				fmt.Fprintf(&b, "\t-l%s: %s\n", sym.Lib, sym.Mangle)
+			default:
+				fmt.Fprintf(&b, "\t%s\n", sym.Mangle)
+			}
+		}
+	}
+	if len(r.Libs) > 0 {
+		fmt.Fprintf(&b, "libraries (%d):\n", len(r.Libs))
+		for _, lib := range r.Libs {
+			switch {
+			case lib.Err != "":
+				fmt.Fprintf(&b, "\t-l%s: %s\n", lib.Name, lib.Err)
+			case lib.Soname != "":
+				fmt.Fprintf(&b, "\t-l%s: %s %s\n", lib.Name, strings.Join(lib.Files, " "), lib.Soname)
+			default:
+				fmt.Fprintf(&b, "\t-l%s: %s\n", lib.Name, strings.Join(lib.Files, " "))
+			}
+		}
+	}
+	section("declared but not exported, likely macros or inline functions", r.Unexported)
+	section("exported but not declared, likely private or in the headers not included", r.Undeclared)
+	section("ignored by symMap", r.Ignored)
+	return b.String()
+}
@@ -4,15 +4,17 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 
+	clangutils "github.com/goplus/llcppg/_xtool/internal/clang"
 	"github.com/goplus/llcppg/_xtool/internal/clangtool"
 	"github.com/goplus/llcppg/_xtool/internal/header"
-	"github.com/goplus/llcppg/_xtool/internal/ld"
+	"github.com/goplus/llcppg/_xtool/internal/resolver"
 	"github.com/goplus/llcppg/_xtool/internal/symbol"
 	llcppg "github.com/goplus/llcppg/config"
-	"github.com/goplus/llgo/xtool/nm"
+	"github.com/goplus/llcppg/internal/name"
 )
 
 type dbgFlags = int
@@ -40,9 +42,83 @@ type Config struct {
 	IsCpp        bool
 	HeaderOnly   bool
 	LibMode      LibMode
+	Namespaces   Namespaces
+	Instantiate  []string          // class template instantiations to bind, like std::vector<int>
+	SymVersions  map[string]string // ELF symbol versions pinned, like foo to VERS_1 of foo@VERS_1
+	Locked       map[string]string // Go names locked by llcppg.lock.json, mangled name -> Go name
+	Rules        []llcppg.NameRule // naming rules of the functions, tried after SymMap
+	Naming       *llcppg.Naming    // naming policy of the Go names, nil for the default one
+	Collisions   llcppg.Collisions // resolutions of the Go names of several functions, keyed by the Go names
 }
 
 func Do(conf *Config) (symbolTable []*llcppg.SymbolInfo, err error) {
+	headerInfos, err := parseHeaders(conf)
+	if err != nil {
+		return
+	}
+
+	if conf.HeaderOnly {
+		symbolTable = headerInfos.ToSymbolTable()
+	} else {
+		var symbols []*symbol.Symbol
+		symbols, err = FetchSymbols(conf.Libs, conf.LibMode)
+		if err != nil {
+			return
+		}
+		symbolTable = GetCommonSymbols(symbols, headerInfos)
+		if err = CheckVersions(symbolTable, conf.SymVersions); err != nil {
+			return
+		}
+		if dups := Duplicates(symbols, symbolTable); len(dups) > 0 {
+			fmt.Fprint(os.Stderr, DuplicateReport(dups))
+		}
+	}
+
+	sort.Slice(symbolTable, func(i, j int) bool {
+		return symbolTable[i].Mangle < symbolTable[j].Mangle
+	})
+	// a locked symbol ignored by symMap is not missing
+	present := make(map[string]string, len(symbolTable))
+	for _, info := range symbolTable {
+		present[info.Mangle] = info.Go
+	}
+	err = llcppg.CheckLocked(conf.Locked, present)
+	return
+}
+
+// GoNames returns the Go names of the symbol table by the mangled names, which are
+// recorded by the name lock file. The ignored symbols named - are left out.
+func GoNames(symbolTable []*llcppg.SymbolInfo) map[string]string {
+	names := make(map[string]string)
+	for _, info := range symbolTable {
+		if info.Go != "-" {
+			names[info.Mangle] = info.Go
+		}
+	}
+	return names
+}
+
+// DoReport reconciles the functions declared by the headers of the package with the
+// symbols exported by the libraries, see Report.
+func DoReport(conf *Config) (*Report, error) {
+	if conf.HeaderOnly {
+		return nil, fmt.Errorf("the symbol report needs the libraries, not the header only mode")
+	}
+	headerInfos, err := parseHeaders(conf)
+	if err != nil {
+		return nil, err
+	}
+	symbols, libs, err := fetchSymbols(conf.Libs, conf.LibMode)
+	if err != nil {
+		return nil, err
+	}
+	report := NewReport(symbols, headerInfos)
+	report.Libs = libs
+	return report, nil
+}
+
+// parseHeaders returns the symbols of the functions declared by the headers of the package.
+func parseHeaders(conf *Config) (headerInfos HeaderSymbols, err error) {
 	pkgHfiles := header.PkgHfileInfo(&header.Config{
 		Includes: conf.Includes,
 		Args:     strings.Fields(conf.CFlags),
@@ -67,89 +143,100 @@ func Do(conf *Config) (symbolTable []*llcppg.SymbolInfo, err error) {
 	if err != nil {
 		return
 	}
+	rules, err := llcppg.CompileRules(conf.Rules)
+	if err != nil {
+		return
+	}
+	curPkgFiles := pkgHfiles.CurPkgFiles()
+	instFile, err := clangutils.IncludeInstantiations(&clangutils.InstantiationConfig{
+		Header:    tempFileName,
+		Templates: conf.Instantiate,
+		Args:      strings.Fields(conf.CFlags),
+	}, "inst*.h")
+	if err != nil {
+		return
+	}
+	if instFile != "" {
+		defer os.Remove(instFile)
+		curPkgFiles = append(curPkgFiles, instFile)
+	}
 
-	headerInfos, err := ParseHeaderFile(
+	return ParseHeaderFile(
 		tempFileName,
-		pkgHfiles.CurPkgFiles(),
+		curPkgFiles,
 		conf.TrimPrefixes,
 		strings.Fields(conf.CFlags),
 		conf.SymMap, conf.IsCpp,
+		conf.Namespaces,
+		conf.Locked,
+		rules,
+		name.NewPolicy(conf.Naming),
+		conf.Collisions,
 	)
-	if err != nil {
-		return
-	}
-
-	if conf.HeaderOnly {
-		symbolTable = headerInfos.ToSymbolTable()
-	} else {
-		var symbols []*nm.Symbol
-		symbols, err = FetchSymbols(conf.Libs, conf.LibMode)
-		if err != nil {
-			return
-		}
-		symbolTable = GetCommonSymbols(symbols, headerInfos)
-	}
-
-	sort.Slice(symbolTable, func(i, j int) bool {
-		return symbolTable[i].Mangle < symbolTable[j].Mangle
-	})
-	return
 }
 
-// fetchSymbols parses symbols from dynamic libraries specified in the lib string.
+// fetchSymbols reads the exported symbols of the libraries specified in the lib string.
 // It handles multiple libraries (e.g., -L/opt/homebrew/lib -llua -lm) and returns
-// symbols if at least one library is successfully parsed. Errors from inaccessible
+// symbols if at least one library is successfully read. Errors from inaccessible
 // libraries (like standard libs) are logged as warnings.
 //
 // Returns symbols and nil error if any symbols are found, or nil and error if none found.
-func FetchSymbols(lib string, mode LibMode) ([]*nm.Symbol, error) {
+func FetchSymbols(lib string, mode LibMode) ([]*symbol.Symbol, error) {
+	symbols, _, err := fetchSymbols(lib, mode)
+	return symbols, err
+}
+
+// fetchSymbols is FetchSymbols also returning the files satisfying each -l, or why
+// it's not resolved, which are reported by DoReport.
+func fetchSymbols(lib string, mode LibMode) ([]*symbol.Symbol, []*ReportLib, error) {
 	if dbgSymbol {
 		fmt.Println("fetchSymbols:from", lib)
 	}
-	sysPaths := ld.GetLibSearchPaths()
-	if dbgSymbol {
-		fmt.Println("fetchSymbols:sysPaths", sysPaths)
-	}
-
-	lbs := ParseLibs(lib)
+	flags := resolver.ParseFlags(lib)
 	if dbgSymbol {
 		fmt.Println("fetchSymbols:LibConfig Parse To")
-		fmt.Println("libs.Names: ", lbs.Names)
-		fmt.Println("libs.Paths: ", lbs.Paths)
+		fmt.Println("libs.Names: ", flags.Names)
+		fmt.Println("libs.Paths: ", flags.Paths)
+		fmt.Println("libs.RPaths: ", flags.RPaths)
 	}
 
-	libFiles, notFounds, err := lbs.Files(sysPaths, mode)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate some lib paths: %v", err)
+	type libFile struct {
+		file string
+		lib  string // the -l name of the file
 	}
-
-	if dbgSymbol {
-		fmt.Println("fetchSymbols:libFiles", libFiles)
-		if len(notFounds) > 0 {
-			fmt.Println("fetchSymbols:not found libname", notFounds)
-		} else {
-			fmt.Println("fetchSymbols:every library is found")
+	var libFiles []libFile
+	var libs []*ReportLib
+	for _, lib := range resolver.New(flags, mode).ResolveAll(flags.Names) {
+		if lib.Err != nil {
+			fmt.Fprintf(os.Stderr, "fetchSymbols:-l%s: %v\n", lib.Name, lib.Err)
+			libs = append(libs, &ReportLib{Name: lib.Name, Err: lib.Err.Error()})
+			continue
+		}
+		libs = append(libs, &ReportLib{Name: lib.Name, Files: lib.Files, Soname: lib.Soname})
+		if dbgSymbol {
+			fmt.Printf("fetchSymbols:-l%s => %s %s\n", lib.Name, strings.Join(lib.Files, " "), lib.Soname)
 		}
+		for _, file := range lib.Files {
+			libFiles = append(libFiles, libFile{file: file, lib: lib.Name})
+		}
+	}
+	if len(libFiles) == 0 {
+		return nil, nil, fmt.Errorf("failed to find any libraries of %s", lib)
 	}
 
-	var symbols []*nm.Symbol
+	var symbols []*symbol.Symbol
 	var parseErrors []string
 
 	for _, libFile := range libFiles {
-		args := []string{"-g"}
-		if runtime.GOOS == "linux" && mode == symbol.ModeDynamic {
-			args = append(args, "-D")
-		}
-
-		files, err := nm.New("llvm-nm").List(libFile, args...)
+		syms, err := symbol.Read(libFile.file)
 		if err != nil {
-			parseErrors = append(parseErrors, fmt.Sprintf("fetchSymbols:Failed to list symbols in lib %s: %v", libFile, err))
+			parseErrors = append(parseErrors, fmt.Sprintf("fetchSymbols:Failed to read symbols in lib %s: %v", libFile.file, err))
 			continue
 		}
-
-		for _, file := range files {
-			symbols = append(symbols, file.Symbols...)
+		for _, sym := range syms {
+			sym.Lib = libFile.lib
 		}
+		symbols = append(symbols, syms...)
 	}
 
 	if len(symbols) > 0 {
@@ -159,25 +246,33 @@ func FetchSymbols(lib string, mode LibMode) ([]*nm.Symbol, error) {
 			}
 			fmt.Println("fetchSymbols:", len(symbols), "symbols")
 		}
-		return symbols, nil
+		return symbols, libs, nil
 	}
 
-	return nil, fmt.Errorf("no symbols found in any lib. Errors: %v", parseErrors)
+	return nil, nil, fmt.Errorf("no symbols found in any lib. Errors: %v", parseErrors)
 }
 
 // todo(zzy):only public for test,when llgo test support private package test,this function should be private
 // GetCommonSymbols finds the intersection of symbols from the library symbol table and the symbols parsed from header files.
-// It returns a list of symbols that can be externally linked.
-func GetCommonSymbols(syms []*nm.Symbol, headerSymbols HeaderSymbols) []*llcppg.SymbolInfo {
+// It returns a list of symbols that can be externally linked, including the members of the class template instantiations,
+// which are emitted by the C++ shim of the package if the libraries don't have them.
+//
+// A symbol of several ELF versions, like foo@VERS_1 and foo@@VERS_2, is a single one of
+// its default version and the others. A symbol of several libraries is the one of the first
+// library, which the linker links to, see Duplicates.
+func GetCommonSymbols(syms []*symbol.Symbol, headerSymbols HeaderSymbols) []*llcppg.SymbolInfo {
 	var commonSymbols []*llcppg.SymbolInfo
-	processedSymbols := make(map[string]bool)
+	symbolInfos := make(map[string]*llcppg.SymbolInfo)
 
 	for _, sym := range syms {
 		symName := sym.Name
 		if runtime.GOOS == "darwin" {
 			symName = strings.TrimPrefix(symName, "_")
 		}
-		if _, ok := processedSymbols[symName]; ok {
+		if symbolInfo, ok := symbolInfos[symName]; ok {
+			if sym.Lib == symbolInfo.Lib {
+				addVersion(symbolInfo, sym)
+			}
 			continue
 		}
 		if symInfo, ok := headerSymbols[symName]; ok {
@@ -185,11 +280,103 @@ func GetCommonSymbols(syms []*nm.Symbol, headerSymbols HeaderSymbols) []*llcppg.
 				Mangle: symName,
 				CPP:    symInfo.ProtoName,
 				Go:     symInfo.GoName,
+				Lib:    sym.Lib,
 			}
+			addVersion(symbolInfo, sym)
 			commonSymbols = append(commonSymbols, symbolInfo)
-			processedSymbols[symName] = true
+			symbolInfos[symName] = symbolInfo
 		}
 	}
 
+	for symName, symInfo := range headerSymbols {
+		if _, ok := symbolInfos[symName]; symInfo.Instantiated && !ok {
+			commonSymbols = append(commonSymbols, &llcppg.SymbolInfo{
+				Mangle: symName,
+				CPP:    symInfo.ProtoName,
+				Go:     symInfo.GoName,
+			})
+		}
+	}
 	return commonSymbols
 }
+
+// addVersion records the ELF version of a library symbol in its symbol info.
+func addVersion(info *llcppg.SymbolInfo, sym *symbol.Symbol) {
+	switch {
+	case sym.Version == "":
+	case sym.IsDefault:
+		info.Version = sym.Version
+	case !slices.Contains(info.Versions, sym.Version):
+		info.Versions = append(info.Versions, sym.Version)
+		sort.Strings(info.Versions)
+	}
+}
+
+// CheckVersions reports an error if a symbol is pinned by symVersions to an ELF version
+// the library doesn't define, or if a symbol has only non-default versions and isn't pinned,
+// which can't be linked by its name. The pinned symbols absent from the symbol table and
+// the ignored symbols named - are left out.
+func CheckVersions(symbolTable []*llcppg.SymbolInfo, symVersions map[string]string) error {
+	for _, info := range symbolTable {
+		version, ok := symVersions[info.Mangle]
+		if !ok {
+			if info.Version == "" && len(info.Versions) > 0 && info.Go != "-" {
+				return fmt.Errorf("symbol %s has no default version, pin one of %s by symVersions",
+					info.Mangle, strings.Join(info.Versions, ", "))
+			}
+			continue
+		}
+		if version == info.Version || slices.Contains(info.Versions, version) {
+			continue
+		}
+		return fmt.Errorf("symbol %s has no version %s", info.Mangle, version)
+	}
+	return nil
+}
+
+// Duplicate is a symbol of the symbol table defined by several libraries.
+type Duplicate struct {
+	Name string
+	Libs []string // the -l names of the libraries in the link order, the first one is linked
+}
+
+// Duplicates returns the symbols of the symbol table defined by several libraries,
+// sorted by their names.
+func Duplicates(syms []*symbol.Symbol, symbolTable []*llcppg.SymbolInfo) []*Duplicate {
+	dups := make(map[string]*Duplicate)
+	for _, info := range symbolTable {
+		dups[info.Mangle] = &Duplicate{Name: info.Mangle}
+	}
+	for _, sym := range syms {
+		symName := sym.Name
+		if runtime.GOOS == "darwin" {
+			symName = strings.TrimPrefix(symName, "_")
+		}
+		if dup, ok := dups[symName]; ok && sym.Lib != "" && !slices.Contains(dup.Libs, sym.Lib) {
+			dup.Libs = append(dup.Libs, sym.Lib)
+		}
+	}
+	var result []*Duplicate
+	for _, dup := range dups {
+		if len(dup.Libs) > 1 {
+			result = append(result, dup)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// DuplicateReport returns the human-readable report of the duplicated symbols, like
+//
+//	symbols defined by several libraries, linked to the first ones:
+//		cJSON_Minify: -lcjson -lcjson_utils
+func DuplicateReport(dups []*Duplicate) string {
+	var b strings.Builder
+	b.WriteString("symbols defined by several libraries, linked to the first ones:\n")
+	for _, dup := range dups {
+		fmt.Fprintf(&b, "\t%s: -l%s\n", dup.Name, strings.Join(dup.Libs, " -l"))
+	}
+	return b.String()
+}
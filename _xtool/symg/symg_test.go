@@ -17,7 +17,6 @@ import (
 	"github.com/goplus/llcppg/_xtool/symg"
 	llcppg "github.com/goplus/llcppg/config"
 	"github.com/goplus/llcppg/internal/name"
-	"github.com/goplus/llgo/xtool/nm"
 )
 
 func TestAddSuffix(t *testing.T) {
@@ -71,13 +70,13 @@ func TestGenMethodName(t *testing.T) {
 func TestGetCommonSymbols(t *testing.T) {
 	testCases := []struct {
 		name          string
-		libSymbols    []*nm.Symbol
+		libSymbols    []*symbol.Symbol
 		headerSymbols map[string]*symg.SymbolInfo
 		expect        []*llcppg.SymbolInfo
 	}{
 		{
 			name: "Lua symbols",
-			libSymbols: []*nm.Symbol{
+			libSymbols: []*symbol.Symbol{
 				{Name: addSymbolPrefixUnder("lua_absindex", false)},
 				{Name: addSymbolPrefixUnder("lua_arith", false)},
 				{Name: addSymbolPrefixUnder("lua_atpanic", false)},
@@ -100,7 +99,7 @@ func TestGetCommonSymbols(t *testing.T) {
 		},
 		{
 			name: "INIReader and Std library symbols",
-			libSymbols: []*nm.Symbol{
+			libSymbols: []*symbol.Symbol{
 				{Name: addSymbolPrefixUnder("ZNK9INIReader12GetInteger64ERKNSt3__112basic_stringIcNS0_11char_traitsIcEENS0_9allocatorIcEEEES8_x", true)},
 				{Name: addSymbolPrefixUnder("ZNK9INIReader7GetRealERKNSt3__112basic_stringIcNS0_11char_traitsIcEENS0_9allocatorIcEEEES8_d", true)},
 				{Name: addSymbolPrefixUnder("ZNK9INIReader10ParseErrorEv", true)},
@@ -118,6 +117,57 @@ func TestGetCommonSymbols(t *testing.T) {
 				{Mangle: "_ZNK9INIReader7GetRealERKNSt3__112basic_stringIcNS0_11char_traitsIcEENS0_9allocatorIcEEEES8_d", CPP: "INIReader::GetReal(const std::string &, const std::string &, double)", Go: "(*Reader).GetReal"},
 			},
 		},
+		{
+			name: "Instantiation symbols",
+			libSymbols: []*symbol.Symbol{
+				{Name: addSymbolPrefixUnder("ZN3BoxIiEC1Ev", true)},
+			},
+			headerSymbols: map[string]*symg.SymbolInfo{
+				"_ZN3BoxIiEC1Ev":    {GoName: "(*BoxInt).Init", ProtoName: "Box<int>::Box()", Instantiated: true},
+				"_ZNK3BoxIiE3getEv": {GoName: "(*BoxInt).Get", ProtoName: "Box<int>::get()", Instantiated: true},
+				"_ZN3Box4sizeEv":    {GoName: "(*Box).Size", ProtoName: "Box::size()"},
+			},
+			expect: []*llcppg.SymbolInfo{
+				{Mangle: "_ZN3BoxIiEC1Ev", CPP: "Box<int>::Box()", Go: "(*BoxInt).Init"},
+				{Mangle: "_ZNK3BoxIiE3getEv", CPP: "Box<int>::get()", Go: "(*BoxInt).Get"},
+			},
+		},
+		{
+			name: "Versioned symbols",
+			libSymbols: []*symbol.Symbol{
+				{Name: addSymbolPrefixUnder("foo", false), Version: "VERS_1"},
+				{Name: addSymbolPrefixUnder("foo", false), Version: "VERS_3", IsDefault: true},
+				{Name: addSymbolPrefixUnder("foo", false), Version: "VERS_2"},
+				{Name: addSymbolPrefixUnder("bar", false), Version: "VERS_1", IsDefault: true},
+			},
+			headerSymbols: map[string]*symg.SymbolInfo{
+				"foo": {GoName: "Foo", ProtoName: "foo()"},
+				"bar": {GoName: "Bar", ProtoName: "bar()"},
+			},
+			expect: []*llcppg.SymbolInfo{
+				{Mangle: "bar", CPP: "bar()", Go: "Bar", Version: "VERS_1"},
+				{Mangle: "foo", CPP: "foo()", Go: "Foo", Version: "VERS_3", Versions: []string{"VERS_1", "VERS_2"}},
+			},
+		},
+		{
+			name: "Library symbols",
+			libSymbols: []*symbol.Symbol{
+				{Name: addSymbolPrefixUnder("cJSON_Parse", false), Lib: "cjson"},
+				{Name: addSymbolPrefixUnder("cJSON_Minify", false), Lib: "cjson"},
+				{Name: addSymbolPrefixUnder("cJSON_Minify", false), Lib: "cjson_utils", Version: "VERS_1", IsDefault: true},
+				{Name: addSymbolPrefixUnder("cJSONUtils_GetPointer", false), Lib: "cjson_utils"},
+			},
+			headerSymbols: map[string]*symg.SymbolInfo{
+				"cJSON_Parse":           {GoName: "Parse", ProtoName: "cJSON_Parse(const char *)"},
+				"cJSON_Minify":          {GoName: "Minify", ProtoName: "cJSON_Minify(char *)"},
+				"cJSONUtils_GetPointer": {GoName: "GetPointer", ProtoName: "cJSONUtils_GetPointer(cJSON *, const char *)"},
+			},
+			expect: []*llcppg.SymbolInfo{
+				{Mangle: "cJSONUtils_GetPointer", CPP: "cJSONUtils_GetPointer(cJSON *, const char *)", Go: "GetPointer", Lib: "cjson_utils"},
+				{Mangle: "cJSON_Minify", CPP: "cJSON_Minify(char *)", Go: "Minify", Lib: "cjson"},
+				{Mangle: "cJSON_Parse", CPP: "cJSON_Parse(const char *)", Go: "Parse", Lib: "cjson"},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -133,6 +183,126 @@ func TestGetCommonSymbols(t *testing.T) {
 	}
 }
 
+func TestDuplicates(t *testing.T) {
+	syms := []*symbol.Symbol{
+		{Name: addSymbolPrefixUnder("cJSON_Parse", false), Lib: "cjson"},
+		{Name: addSymbolPrefixUnder("cJSON_Minify", false), Lib: "cjson"},
+		{Name: addSymbolPrefixUnder("cJSON_Minify", false), Lib: "cjson", Version: "VERS_1"},
+		{Name: addSymbolPrefixUnder("cJSON_Minify", false), Lib: "cjson_utils"},
+		{Name: addSymbolPrefixUnder("cJSON_Hidden", false), Lib: "cjson"},
+		{Name: addSymbolPrefixUnder("cJSON_Hidden", false), Lib: "cjson_utils"},
+	}
+	symbolTable := []*llcppg.SymbolInfo{
+		{Mangle: "cJSON_Parse", CPP: "cJSON_Parse(const char *)", Go: "Parse", Lib: "cjson"},
+		{Mangle: "cJSON_Minify", CPP: "cJSON_Minify(char *)", Go: "Minify", Lib: "cjson"},
+	}
+	dups := symg.Duplicates(syms, symbolTable)
+	expect := []*symg.Duplicate{{Name: "cJSON_Minify", Libs: []string{"cjson", "cjson_utils"}}}
+	if !reflect.DeepEqual(dups, expect) {
+		t.Fatalf("Duplicates() = %v, want %v", dups, expect)
+	}
+	report := "symbols defined by several libraries, linked to the first ones:\n\tcJSON_Minify: -lcjson -lcjson_utils\n"
+	if got := symg.DuplicateReport(dups); got != report {
+		t.Errorf("DuplicateReport() = %q, want %q", got, report)
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	syms := []*symbol.Symbol{
+		{Name: addSymbolPrefixUnder("cJSON_Parse", false), Lib: "cjson"},
+		{Name: addSymbolPrefixUnder("cJSON_Parse", false), Lib: "cjson_utils"},
+		{Name: addSymbolPrefixUnder("cJSON_Internal", false), Lib: "cjson"},
+		{Name: addSymbolPrefixUnder("cJSON_Private", false), Lib: "cjson"},
+		{Name: addSymbolPrefixUnder("cJSON_Weak", false), Lib: "cjson", Binding: symbol.Weak},
+		{Name: addSymbolPrefixUnder("cJSON_Data", false), Lib: "cjson", Kind: symbol.Data},
+	}
+	headerSymbols := map[string]*symg.SymbolInfo{
+		"cJSON_Parse":          {GoName: "Parse", ProtoName: "cJSON_Parse(const char *)", File: "cJSON.h", Line: 10},
+		"cJSON_Inline":         {GoName: "Inline", ProtoName: "cJSON_Inline(cJSON *)", File: "cJSON.h", Line: 20},
+		"cJSON_Internal":       {GoName: "-", ProtoName: "cJSON_Internal(void)", File: "cJSON.h", Line: 30},
+		"_ZN4JSON4dumpEv":      {GoName: "(*JSON).Dump", ProtoName: "JSON::dump()", File: "JSON.h", Line: 5},
+		"_ZN6VectorIiE4sizeEv": {GoName: "(*VectorInt).Size", ProtoName: "Vector<int>::size()", Instantiated: true},
+	}
+	report := symg.NewReport(syms, headerSymbols)
+	report.Libs = []*symg.ReportLib{
+		{Name: "cjson", Files: []string{"/usr/lib/libcjson.so"}, Soname: "libcjson.so.1"},
+		{Name: "cjson_utils", Files: []string{"/usr/lib/libcjson_utils.a"}},
+		{Name: "missing", Err: "not found"},
+	}
+	expect := &symg.Report{
+		Libs: []*symg.ReportLib{
+			{Name: "cjson", Files: []string{"/usr/lib/libcjson.so"}, Soname: "libcjson.so.1"},
+			{Name: "cjson_utils", Files: []string{"/usr/lib/libcjson_utils.a"}},
+			{Name: "missing", Err: "not found"},
+		},
+		Unexported: []*symg.ReportSymbol{
+			{Mangle: "_ZN4JSON4dumpEv", CPP: "JSON::dump()", File: "JSON.h", Line: 5},
+			{Mangle: "cJSON_Inline", CPP: "cJSON_Inline(cJSON *)", File: "cJSON.h", Line: 20},
+		},
+		Undeclared: []*symg.ReportSymbol{
+			{Mangle: "cJSON_Private", Lib: "cjson"},
+		},
+		Ignored: []*symg.ReportSymbol{
+			{Mangle: "cJSON_Internal", CPP: "cJSON_Internal(void)", File: "cJSON.h", Line: 30},
+		},
+	}
+	if !reflect.DeepEqual(report, expect) {
+		t.Fatalf("NewReport() = %v, want %v", report, expect)
+	}
+	text := `libraries (3):
+	-lcjson: /usr/lib/libcjson.so libcjson.so.1
+	-lcjson_utils: /usr/lib/libcjson_utils.a
+	-lmissing: not found
+declared but not exported, likely macros or inline functions (2):
+	JSON.h:5: _ZN4JSON4dumpEv JSON::dump()
+	cJSON.h:20: cJSON_Inline
+exported but not declared, likely private or in the headers not included (1):
+	-lcjson: cJSON_Private
+ignored by symMap (1):
+	cJSON.h:30: cJSON_Internal
+`
+	if got := report.String(); got != text {
+		t.Errorf("String() = %q, want %q", got, text)
+	}
+}
+
+func TestCheckVersions(t *testing.T) {
+	symbolTable := []*llcppg.SymbolInfo{
+		{Mangle: "foo", CPP: "foo()", Go: "Foo", Version: "VERS_2", Versions: []string{"VERS_1"}},
+		{Mangle: "bar", CPP: "bar()", Go: "Bar"},
+		{Mangle: "old", CPP: "old()", Go: "-", Versions: []string{"VERS_1"}}, // ignored
+	}
+	testCases := []struct {
+		name        string
+		symVersions map[string]string
+		expectErr   bool
+	}{
+		{name: "default version", symVersions: map[string]string{"foo": "VERS_2"}},
+		{name: "other version", symVersions: map[string]string{"foo": "VERS_1"}},
+		{name: "absent symbol", symVersions: map[string]string{"baz": "VERS_1"}},
+		{name: "unknown version", symVersions: map[string]string{"foo": "VERS_3"}, expectErr: true},
+		{name: "unversioned symbol", symVersions: map[string]string{"bar": "VERS_1"}, expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := symg.CheckVersions(symbolTable, tc.symVersions)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("CheckVersions() error = %v, expectErr %v", err, tc.expectErr)
+			}
+		})
+	}
+	t.Run("no default version", func(t *testing.T) {
+		table := append(symbolTable, &llcppg.SymbolInfo{Mangle: "qux", CPP: "qux()", Go: "Qux", Versions: []string{"VERS_1", "VERS_2"}})
+		err := symg.CheckVersions(table, nil)
+		if err == nil || err.Error() != "symbol qux has no default version, pin one of VERS_1, VERS_2 by symVersions" {
+			t.Fatalf("CheckVersions() error = %v, want the versions of qux", err)
+		}
+		if err := symg.CheckVersions(table, map[string]string{"qux": "VERS_1"}); err != nil {
+			t.Fatalf("CheckVersions() of qux pinned error = %v", err)
+		}
+	})
+}
+
 func TestGenSymbolTableData(t *testing.T) {
 	commonSymbols := []*llcppg.SymbolInfo{
 		{Mangle: "lua_absindex", CPP: "lua_absindex(lua_State *, int)", Go: "Absindex"},
@@ -178,11 +348,18 @@ func TestGenSymbolTableData(t *testing.T) {
 
 func TestParseHeaderFile(t *testing.T) {
 	testCases := []struct {
-		name     string
-		content  string
-		isCpp    bool
-		prefixes []string
-		expect   []*llcppg.SymbolInfo
+		name        string
+		content     string
+		isCpp       bool
+		prefixes    []string
+		instantiate []string
+		symMap      map[string]string
+		rules       []llcppg.NameRule
+		naming      *llcppg.Naming
+		collisions  llcppg.Collisions
+		locked      map[string]string
+		expect      []*llcppg.SymbolInfo
+		expectErr   string
 	}{
 		{
 			name: "C++ Class with Methods",
@@ -222,6 +399,126 @@ class INIReader {
 				},
 			},
 		},
+		{
+			name: "C++ Operators",
+			content: `
+class Vec {
+  public:
+    Vec operator+(const Vec &other) const;
+    bool operator==(const Vec &other) const;
+    int &operator[](int i);
+    Vec operator-() const;
+    static void *operator new(unsigned long size);
+};
+bool operator<(const Vec &a, const Vec &b);
+            `,
+			isCpp: true,
+			expect: []*llcppg.SymbolInfo{
+				{
+					Go:     "(*Vec).Index",
+					CPP:    "Vec::operator[](int)",
+					Mangle: "_ZN3VecixEi",
+				},
+				{
+					Go:     "-",
+					CPP:    "Vec::operator new(unsigned long)",
+					Mangle: "_ZN3VecnwEm",
+				},
+				{
+					Go:     "(*Vec).Equal",
+					CPP:    "Vec::operator==(const Vec &)",
+					Mangle: "_ZNK3VeceqERKS_",
+				},
+				{
+					Go:     "(*Vec).Neg",
+					CPP:    "Vec::operator-()",
+					Mangle: "_ZNK3VecngEv",
+				},
+				{
+					Go:     "(*Vec).Add",
+					CPP:    "Vec::operator+(const Vec &)",
+					Mangle: "_ZNK3VecplERKS_",
+				},
+				{
+					Go:     "(*Vec).Less",
+					CPP:    "operator<(const Vec &, const Vec &)",
+					Mangle: "_ZltRK3VecS1_",
+				},
+			},
+		},
+		{
+			name: "C++ Nested Class",
+			content: `
+namespace ns {
+class Outer {
+  public:
+    void reset();
+    class Inner {
+      public:
+        int get() const;
+    };
+};
+}
+            `,
+			isCpp: true,
+			expect: []*llcppg.SymbolInfo{
+				{
+					Go:     "(*NsOuter).Reset",
+					CPP:    "ns::Outer::reset()",
+					Mangle: "_ZN2ns5Outer5resetEv",
+				},
+				{
+					Go:     "(*NsOuterInner).Get",
+					CPP:    "ns::Outer::Inner::get()",
+					Mangle: "_ZNK2ns5Outer5Inner3getEv",
+				},
+			},
+		},
+		{
+			name: "C++ Instantiations",
+			content: `
+template <typename T>
+class Box {
+  public:
+    Box();
+    Box(T v);
+    ~Box();
+    T get() const;
+    void set(const T &v);
+  private:
+    T value;
+};
+            `,
+			isCpp:       true,
+			instantiate: []string{"Box<int>"},
+			expect: []*llcppg.SymbolInfo{
+				{
+					Go:     "(*BoxInt).Set",
+					CPP:    "Box<int>::set(const int &)",
+					Mangle: "_ZN3BoxIiE3setERKi",
+				},
+				{
+					Go:     "(*BoxInt).Init__1",
+					CPP:    "Box<int>::Box(int)",
+					Mangle: "_ZN3BoxIiEC1Ei",
+				},
+				{
+					Go:     "(*BoxInt).Init",
+					CPP:    "Box<int>::Box()",
+					Mangle: "_ZN3BoxIiEC1Ev",
+				},
+				{
+					Go:     "(*BoxInt).Dispose",
+					CPP:    "Box<int>::~Box()",
+					Mangle: "_ZN3BoxIiED1Ev",
+				},
+				{
+					Go:     "(*BoxInt).Get",
+					CPP:    "Box<int>::get()",
+					Mangle: "_ZNK3BoxIiE3getEv",
+				},
+			},
+		},
 		{
 			name: "C Functions",
 			content: `
@@ -360,6 +657,206 @@ class INIReader {
 				},
 			},
 		},
+		{
+			name: "Name Rules",
+			content: `
+typedef struct xmlDoc xmlDoc;
+typedef struct xmlNode xmlNode;
+void xmlDebugDumpString(const char *s);
+void xmlDocDumpFormat(xmlDoc *doc, int format);
+void xmlNodeDumpFormat(xmlNode *node, int format);
+void xmlSaveDoc(xmlDoc *doc);
+void xml_internal_init(void);
+            `,
+			isCpp:    false,
+			prefixes: []string{"xml"},
+			symMap:   map[string]string{"xmlSaveDoc": "SaveFile"},
+			rules: []llcppg.NameRule{
+				{Match: "xml_internal_*", Action: llcppg.RuleIgnore},
+				{Match: "xmlDebug*", Kinds: []string{llcppg.RuleFunc}, Name: "Debug$1"},
+				// the first parameter of xmlNodeDumpFormat is not a Doc, which is a function
+				{Match: "xml*DumpFormat", Kinds: []string{llcppg.RuleFunc}, Action: llcppg.RuleMethod, Name: "Format", Recv: "Doc"},
+				{Match: "xmlSave*", Name: "Save$1"},
+			},
+			expect: []*llcppg.SymbolInfo{
+				{
+					Go:     "DebugDumpString",
+					CPP:    "xmlDebugDumpString(const char *)",
+					Mangle: "xmlDebugDumpString",
+				},
+				{
+					Go:     "(*Doc).Format",
+					CPP:    "xmlDocDumpFormat(xmlDoc *, int)",
+					Mangle: "xmlDocDumpFormat",
+				},
+				{
+					Go:     "Format",
+					CPP:    "xmlNodeDumpFormat(xmlNode *, int)",
+					Mangle: "xmlNodeDumpFormat",
+				},
+				{
+					Go:     "SaveFile",
+					CPP:    "xmlSaveDoc(xmlDoc *)",
+					Mangle: "xmlSaveDoc",
+				},
+				{
+					Go:     "-",
+					CPP:    "xml_internal_init()",
+					Mangle: "xml_internal_init",
+				},
+			},
+		},
+		{
+			name: "Handles",
+			content: `
+typedef struct xmlDoc xmlDoc;
+typedef struct Vec { int x; } Vec;
+int xmlSaveFile(const char *filename, xmlDoc *cur);
+int xmlNewDocOut(xmlDoc **out);
+int xmlNewDocBad(int out);
+int vec_len(Vec v);
+void xmlFreeDoc(xmlDoc *doc);
+void xmlDocDump(int fd, xmlDoc *doc);
+            `,
+			isCpp:    false,
+			prefixes: []string{"xml"},
+			symMap: map[string]string{
+				"xmlSaveFile":  ".Save@1",
+				"xmlNewDocOut": "NewDoc@0",
+				"xmlNewDocBad": "NewBad@0",
+				"vec_len":      "(*Vec).Len",
+				"xmlFreeDoc":   "(*Doc).Free",
+				"xmlDocDump":   "(*Node).Dump@1",
+			},
+			expect: []*llcppg.SymbolInfo{
+				// the pointer receiver of a value parameter is dereferenced
+				{
+					Go:     "(*Vec).Len@0",
+					CPP:    "vec_len(Vec)",
+					Mangle: "vec_len",
+				},
+				// the parameter 1 is not a Node
+				{
+					Go:     "Dump",
+					CPP:    "xmlDocDump(int, xmlDoc *)",
+					Mangle: "xmlDocDump",
+				},
+				{
+					Go:     "(*Doc).Free",
+					CPP:    "xmlFreeDoc(xmlDoc *)",
+					Mangle: "xmlFreeDoc",
+				},
+				// the parameter 0 is not a pointer
+				{
+					Go:     "NewBad",
+					CPP:    "xmlNewDocBad(int)",
+					Mangle: "xmlNewDocBad",
+				},
+				{
+					Go:     "NewDoc@0",
+					CPP:    "xmlNewDocOut(xmlDoc **)",
+					Mangle: "xmlNewDocOut",
+				},
+				{
+					Go:     "(*Doc).Save@1",
+					CPP:    "xmlSaveFile(const char *, xmlDoc *)",
+					Mangle: "xmlSaveFile",
+				},
+			},
+		},
+		{
+			name: "Naming",
+			content: `
+typedef struct xmlHttpClient xmlHttpClient;
+void xmlHttpClientSetUrl(xmlHttpClient *client, const char *url);
+int xml_parse_json_id(const char *s);
+            `,
+			isCpp:    false,
+			prefixes: []string{"xml"},
+			naming: &llcppg.Naming{
+				Initialisms: []string{"HTTP", "URL", "JSON", "ID"},
+			},
+			expect: []*llcppg.SymbolInfo{
+				{
+					Go:     "(*HTTPClient).HTTPClientSetURL",
+					CPP:    "xmlHttpClientSetUrl(xmlHttpClient *, const char *)",
+					Mangle: "xmlHttpClientSetUrl",
+				},
+				{
+					Go:     "ParseJSONID",
+					CPP:    "xml_parse_json_id(const char *)",
+					Mangle: "xml_parse_json_id",
+				},
+			},
+		},
+		{
+			name: "Collisions",
+			content: `
+void FooBar(void);
+void foo_bar(void);
+void Foo_Bar(void);
+void fooBar(void);
+            `,
+			isCpp: false,
+			collisions: llcppg.Collisions{
+				"FooBar": {Prefer: "fooBar", Rename: map[string]string{"foo_bar": "FooBarOld"}, Ignore: []string{"Foo_Bar"}},
+			},
+			expect: []*llcppg.SymbolInfo{
+				// the preferred fooBar keeps the name declared later
+				{
+					Go:     "FooBar__1",
+					CPP:    "FooBar()",
+					Mangle: "FooBar",
+				},
+				{
+					Go:     "-",
+					CPP:    "Foo_Bar()",
+					Mangle: "Foo_Bar",
+				},
+				{
+					Go:     "FooBar",
+					CPP:    "fooBar()",
+					Mangle: "fooBar",
+				},
+				{
+					Go:     "FooBarOld",
+					CPP:    "foo_bar()",
+					Mangle: "foo_bar",
+				},
+			},
+		},
+		{
+			name: "Locked Names",
+			content: `
+void foo(int a);
+void foo(double a);
+            `,
+			isCpp: true,
+			// the overload of double was declared first by the previous run
+			locked: map[string]string{"_Z3food": "Foo"},
+			expect: []*llcppg.SymbolInfo{
+				{
+					Go:     "Foo",
+					CPP:    "foo(double)",
+					Mangle: "_Z3food",
+				},
+				{
+					Go:     "Foo__1",
+					CPP:    "foo(int)",
+					Mangle: "_Z3fooi",
+				},
+			},
+		},
+		{
+			name: "Locked Name Removed",
+			content: `
+void foo(int a);
+void foo(double a);
+            `,
+			isCpp:     true,
+			locked:    map[string]string{"_Z3foos": "Foo__2"},
+			expectErr: "locked names not found, remove them from llcppg.lock.json to drop them: _Z3foos (Foo__2)",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -380,7 +877,19 @@ class INIReader {
 				Includes:     []string{f.Name()},
 				HeaderOnly:   true,
 				TrimPrefixes: tc.prefixes,
+				Instantiate:  tc.instantiate,
+				SymMap:       tc.symMap,
+				Rules:        tc.rules,
+				Naming:       tc.naming,
+				Collisions:   tc.collisions,
+				Locked:       tc.locked,
 			})
+			if tc.expectErr != "" {
+				if err == nil || err.Error() != tc.expectErr {
+					t.Fatalf("expect error %q, but got %v", tc.expectErr, err)
+				}
+				return
+			}
 			if err != nil {
 				t.Fatal(err)
 				return
@@ -393,6 +902,18 @@ class INIReader {
 	}
 }
 
+func TestGoNames(t *testing.T) {
+	symbolTable := []*llcppg.SymbolInfo{
+		{Mangle: "_Z3food", CPP: "foo(double)", Go: "Foo"},
+		{Mangle: "_Z3fooi", CPP: "foo(int)", Go: "Foo__1"},
+		{Mangle: "_Z3foos", CPP: "foo(short)", Go: "-"},
+	}
+	expect := map[string]string{"_Z3food": "Foo", "_Z3fooi": "Foo__1"}
+	if names := symg.GoNames(symbolTable); !reflect.DeepEqual(names, expect) {
+		t.Fatalf("GoNames() = %v, want %v", names, expect)
+	}
+}
+
 func TestGen(t *testing.T) {
 	testCases := []struct {
 		name string
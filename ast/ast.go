@@ -110,6 +110,9 @@ const (
 	LongLong
 	Double
 	Short
+	// ExplicitSign marks signed char and unsigned char, which are other types than the
+	// plain char of the same signedness
+	ExplicitSign
 )
 
 // [signed/unsigned/short/long/long long/double] [int]/char/float/complex/bool
@@ -167,6 +170,16 @@ func (*ParenExpr) exprNode() {}
 
 // ------------------------------------------------
 
+// an expression kept as its tokens, like a default argument
+// which is neither a literal nor a name
+type RawExpr struct {
+	Tokens []*Token
+}
+
+func (*RawExpr) exprNode() {}
+
+// ------------------------------------------------
+
 // Parent::X
 type ScopingExpr struct {
 	Parent Expr
@@ -175,11 +188,29 @@ type ScopingExpr struct {
 
 func (*ScopingExpr) exprNode() {}
 
+// QualifiedName returns the qualified C++ name of an Ident, a ScopingExpr or
+// a TagExpr, like a::b::Foo, or "" for the other expressions.
+func QualifiedName(expr Expr) string {
+	switch x := expr.(type) {
+	case *Ident:
+		return x.Name
+	case *ScopingExpr:
+		if parent := QualifiedName(x.Parent); parent != "" {
+			return parent + "::" + x.X.Name
+		}
+		return x.X.Name
+	case *TagExpr:
+		return QualifiedName(x.Name)
+	}
+	return ""
+}
+
 // ------------------------------------------------
 
 // X*
 type PointerType struct {
-	X Expr
+	X       Expr
+	IsConst bool // const X*
 }
 
 func (*PointerType) exprNode() {}
@@ -188,7 +219,8 @@ func (*PointerType) exprNode() {}
 
 // X&
 type LvalueRefType struct {
-	X Expr
+	X       Expr
+	IsConst bool // const X&
 }
 
 func (*LvalueRefType) exprNode() {}
@@ -241,6 +273,13 @@ type Field struct {
 	Comment  *CommentGroup   // line comments; or nil
 	Access   AccessSpecifier // field access(Record Type); Struct Field default is Public,Class Field default is Private
 	IsStatic bool            // static field
+	IsBase   bool            // base class of a C++ class, which has no names
+	Default  Expr            // default argument of a C++ function parameter; or nil
+
+	// symbol of a static field, which may have none if it is constexpr or a const
+	// initialized in the class, like static const int max = 8;
+	MangledName string
+	IsConstexpr bool
 }
 
 func (*Field) exprNode() {}
@@ -267,6 +306,7 @@ type RecordType struct {
 	Tag     Tag
 	Fields  *FieldList
 	Methods []*FuncDecl
+	HasDef  bool // false for a forward declaration like `struct a;`
 }
 
 func (*RecordType) exprNode() {}
@@ -295,6 +335,14 @@ type Object struct {
 	Parent Expr // namespace or class
 }
 
+// QualifiedName returns the qualified C++ name of the object, like a::b::Foo.
+func (o *Object) QualifiedName() string {
+	if o.Parent != nil {
+		return QualifiedName(&ScopingExpr{Parent: o.Parent, X: o.Name})
+	}
+	return o.Name.Name
+}
+
 // ------------------------------------------------
 
 // typedef Type Name;
@@ -317,7 +365,8 @@ type EnumItem struct {
 func (*EnumItem) exprNode() {}
 
 type EnumType struct {
-	Items []*EnumItem
+	Items    []*EnumItem
+	IsScoped bool // C++ enum class, whose items are in the scope of the enum
 }
 
 func (*EnumType) exprNode() {}
@@ -332,6 +381,15 @@ func (p *EnumTypeDecl) declNode() *Object {
 	return &p.Object
 }
 
+// ItemName returns the qualified C++ name of an item, like ns::Color::Red of a scoped
+// enum ns::Color, or ns::Red of an unscoped one.
+func (p *EnumTypeDecl) ItemName(item *EnumItem) string {
+	if p.Type != nil && p.Type.IsScoped && p.Name != nil {
+		return p.QualifiedName() + "::" + item.Name.Name
+	}
+	return QualifiedName(&ScopingExpr{Parent: p.Parent, X: item.Name})
+}
+
 // ------------------------------------------------
 
 // Ret Name(Params);
@@ -348,6 +406,7 @@ type FuncDecl struct {
 	IsConstructor bool
 	IsDestructor  bool
 	IsVirtual     bool
+	IsPureVirtual bool // declared with = 0, which has no definition
 	IsOverride    bool
 }
 
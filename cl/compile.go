@@ -40,6 +40,18 @@ var (
 type Package struct {
 	*gogen.Package
 	*convert.PkgInfo // TODO(xsw): check
+
+	ShimFile string // file name of the C++ shim, empty if the package needs no shim
+	Shim     []byte // source of the C++ shim
+
+	Dir  string    // directory of a sub-package relative to the output directory
+	Subs []Package // Go sub-packages of the C++ namespaces or the libraries
+
+	// Go names of the C names registered by the package and its sub-packages, which are
+	// recorded by the name lock file, see llcppg.NameLock
+	Names map[string]string
+	// the name collisions of the package and its sub-packages, see NameCollision
+	Collisions []*NameCollision
 }
 
 type Config struct {
@@ -1,9 +1,18 @@
 package cl
 
 import (
+	"os"
+	"path/filepath"
+
+	"github.com/goplus/gogen"
 	"github.com/goplus/llcppg/ast"
 	"github.com/goplus/llcppg/cl/internal/convert"
 	"github.com/goplus/llcppg/cl/nc"
+	"github.com/goplus/llcppg/cl/nc/ncimpl"
+	llcppg "github.com/goplus/llcppg/config"
+	"github.com/goplus/llcppg/internal/gowrite"
+	"github.com/goplus/llcppg/internal/name"
+	"github.com/qiniu/x/errors"
 )
 
 const DbgFlagAll = convert.DbgFlagAll
@@ -25,17 +34,139 @@ type ConvConfig struct {
 
 	Deps []string // dependent packages
 	Libs string   // $(pkg-config --libs xxx)
+
+	TypeSizes     *llcppg.TypeSizes // sizes of the target dependent builtin types, nil for host defaults
+	OpaqueExclude []string          // forward declared only structs not to be opaque
+	AliasTypedefs []string          // typedefs to be generated as type aliases
+	AutoAlias     bool              // generate typedefs of builtin and pointer types as type aliases
+	GoDoc         bool              // convert the Doxygen and Javadoc comments to Go doc comments
+
+	Includes   []string // headers of the C++ shim
+	CFlags     string   // compile flags of the C++ shim, like $(pkg-config --cflags xxx)
+	GoSubclass []string // C++ classes whose virtual methods can be overridden in Go
+
+	ConstRefByValue int  // max size of the trivially copyable types whose const references are passed by value
+	StdWrappers     bool // generate the Go wrappers of the functions using the standard C++ types
+
+	DefaultArgs map[string]string // how the default arguments of the C++ functions are omitted, see llcppg.DefaultArgsOverloads
+	SymVersions map[string]string // the ELF symbol versions the symbols are linked to, like foo@VERS_1
+
+	NamespaceMode   string   // how the C++ namespaces map to Go, see llcppg.NamespacePackage
+	StripNamespaces []string // namespaces left out of the Go names and packages
+
+	SplitLibs  bool              // convert the functions of each library but the first one in its own sub-package
+	SymbolLibs map[string]string // the -l names of the libraries providing the symbols, like cjson
+
+	LockedNames map[string]string // the Go names locked by the name lock file, keyed by the kinds and the C names like typedef foo
+	Collisions  llcppg.Collisions // the resolutions of the name collisions, keyed by the Go names
+}
+
+// NewConvConfig returns the ConvConfig of the package conf converting the declarations of in,
+// whose symbols are looked up in symbTable. lock is nil if the names are not locked.
+func NewConvConfig(conf *llcppg.Config, in *llcppg.Pkg, symbTable *llcppg.SymTable, lock *llcppg.NameLock) (*ConvConfig, error) {
+	rules, err := llcppg.CompileRules(conf.Rules)
+	if err != nil {
+		return nil, err
+	}
+	var lockedNames map[string]string
+	if lock != nil {
+		lockedNames = lock.Names
+	}
+	return &ConvConfig{
+		PkgName: conf.Name,
+		Pkg:     in.File,
+		NC: &ncimpl.Converter{
+			PkgName: conf.Name,
+			Pubs:    conf.TypeMap,
+			ConvSym: func(name *ast.Object, mangleName string) (goName string, err error) {
+				item, err := symbTable.LookupSymbol(mangleName)
+				if err != nil {
+					return "", err
+				}
+				return item.Go, nil
+			},
+			FileMap:        in.FileMap,
+			TrimPrefixes:   conf.TrimPrefixes,
+			KeepUnderScore: conf.KeepUnderScore,
+			NestedTypeName: conf.NestedTypeName,
+
+			EnumItemName:    conf.EnumItemName,
+			PrefixEnumItems: conf.PrefixEnumItems,
+
+			NamespaceMode:   conf.NamespaceMode,
+			StripNamespaces: conf.StripNamespaces,
+
+			Rules:  rules,
+			Naming: name.NewPolicy(conf.Naming),
+
+			Collisions: conf.Collisions,
+		},
+		Deps:          conf.Deps,
+		Libs:          conf.Libs,
+		TypeSizes:     in.TypeSizes,
+		OpaqueExclude: conf.OpaqueExclude,
+		AliasTypedefs: conf.AliasTypedefs,
+		AutoAlias:     conf.AutoAlias,
+		GoDoc:         conf.GoDoc,
+		Includes:      conf.Include,
+		CFlags:        conf.CFlags,
+		GoSubclass:    conf.GoSubclass,
+
+		ConstRefByValue: conf.ConstRefByValue,
+		StdWrappers:     conf.StdWrappers,
+		DefaultArgs:     conf.DefaultArgs,
+		SymVersions:     conf.SymVersions,
+
+		NamespaceMode:   conf.NamespaceMode,
+		StripNamespaces: conf.StripNamespaces,
+
+		SplitLibs:  conf.SplitLibs,
+		SymbolLibs: symbTable.Libs(),
+
+		LockedNames: lockedNames,
+		Collisions:  conf.Collisions,
+	}, nil
+}
+
+// NameCollision is a Go name of several C declarations, see llcppg.Collision to resolve it.
+type NameCollision = convert.NameCollision
+
+// CollisionReport returns the human-readable report of the name collisions not resolved.
+func CollisionReport(collisions []*NameCollision) string {
+	return convert.CollisionReport(collisions)
 }
 
 func Convert(config *ConvConfig) (pkg Package, err error) {
 	cvt, err := convert.NewConverter(&convert.Config{
-		OutputDir: config.OutputDir,
-		PkgPath:   config.PkgPath,
-		PkgName:   config.PkgName,
-		Pkg:       config.Pkg,
-		NC:        config.NC,
-		Deps:      config.Deps,
-		Libs:      config.Libs,
+		OutputDir:     config.OutputDir,
+		PkgPath:       config.PkgPath,
+		PkgName:       config.PkgName,
+		Pkg:           config.Pkg,
+		NC:            config.NC,
+		Deps:          config.Deps,
+		Libs:          config.Libs,
+		TypeSizes:     config.TypeSizes,
+		OpaqueExclude: config.OpaqueExclude,
+		AliasTypedefs: config.AliasTypedefs,
+		AutoAlias:     config.AutoAlias,
+		GoDoc:         config.GoDoc,
+		Includes:      config.Includes,
+		CFlags:        config.CFlags,
+		GoSubclass:    config.GoSubclass,
+
+		ConstRefByValue: config.ConstRefByValue,
+		StdWrappers:     config.StdWrappers,
+		DefaultArgs:     config.DefaultArgs,
+		SymVersions:     config.SymVersions,
+
+		NamespaceMode:   config.NamespaceMode,
+		StripNamespaces: config.StripNamespaces,
+
+		SplitLibs:  config.SplitLibs,
+		SymbolLibs: config.SymbolLibs,
+
+		LockedNames: config.LockedNames,
+		Collisions:  config.Collisions,
 	})
 	if err != nil {
 		return
@@ -44,6 +175,57 @@ func Convert(config *ConvConfig) (pkg Package, err error) {
 	if err != nil {
 		return
 	}
-	gp := cvt.GenPkg
-	return Package{gp.Pkg(), gp.PkgInfo}, nil
+	pkg = newPackage(cvt.GenPkg)
+	pkg.Names = cvt.Names()
+	pkg.Collisions = cvt.Collisions()
+	for _, sub := range cvt.SubPkgs {
+		subPkg := newPackage(sub.Package)
+		subPkg.Dir = sub.Dir
+		pkg.Subs = append(pkg.Subs, subPkg)
+	}
+	return pkg, nil
+}
+
+func newPackage(gp *convert.Package) Package {
+	pkg := Package{Package: gp.Pkg(), PkgInfo: gp.PkgInfo}
+	pkg.ShimFile, pkg.Shim = gp.ShimFile()
+	return pkg
+}
+
+// WriteOutput writes the Go files, the llcppg.pub and the C++ shim of pkg to outDir, and the
+// ones of its sub-packages to their directories in outDir.
+func WriteOutput(pkg Package, outDir string) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	if err := llcppg.WritePubFile(filepath.Join(outDir, llcppg.LLCPPG_PUB), pkg.Pubs); err != nil {
+		return err
+	}
+	if err := writePkg(pkg.Package, outDir); err != nil {
+		return err
+	}
+	if pkg.ShimFile != "" {
+		if err := os.WriteFile(filepath.Join(outDir, pkg.ShimFile), pkg.Shim, 0644); err != nil {
+			return err
+		}
+	}
+	for _, sub := range pkg.Subs {
+		if err := WriteOutput(sub, filepath.Join(outDir, sub.Dir)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePkg(pkg *gogen.Package, outDir string) error {
+	var errs errors.List
+	pkg.ForEachFile(func(fname string, _ *gogen.File) {
+		if fname != "" { // gogen default fname
+			outFile := filepath.Join(outDir, fname)
+			if err := gowrite.WriteFile(pkg, outFile, fname); err != nil {
+				errs.Add(err)
+			}
+		}
+	})
+	return errs.ToError()
 }
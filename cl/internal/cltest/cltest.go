@@ -5,6 +5,7 @@ import (
 	"github.com/goplus/llcppg/cl/nc"
 	"github.com/goplus/llcppg/cl/nc/ncimpl"
 	llcppg "github.com/goplus/llcppg/config"
+	"github.com/goplus/llcppg/internal/name"
 )
 
 func NewConvSym(syms ...llcppg.SymbolInfo) func(name *ast.Object, mangleName string) (goName string, err error) {
@@ -34,6 +35,8 @@ func fromSymbTable(symbTable *llcppg.SymTable) func(name *ast.Object, mangleName
 }
 
 func NC(cfg *llcppg.Config, fileMap map[string]*llcppg.FileInfo, convSym func(name *ast.Object, mangleName string) (goName string, err error)) nc.NodeConverter {
+	// the rules are validated by loading the config
+	rules, _ := llcppg.CompileRules(cfg.Rules)
 	return &ncimpl.Converter{
 		PkgName:        cfg.Name,
 		Pubs:           cfg.TypeMap,
@@ -41,5 +44,17 @@ func NC(cfg *llcppg.Config, fileMap map[string]*llcppg.FileInfo, convSym func(na
 		ConvSym:        convSym,
 		TrimPrefixes:   cfg.TrimPrefixes,
 		KeepUnderScore: cfg.KeepUnderScore,
+		NestedTypeName: cfg.NestedTypeName,
+
+		EnumItemName:    cfg.EnumItemName,
+		PrefixEnumItems: cfg.PrefixEnumItems,
+
+		NamespaceMode:   cfg.NamespaceMode,
+		StripNamespaces: cfg.StripNamespaces,
+
+		Rules:  rules,
+		Naming: name.NewPolicy(cfg.Naming),
+
+		Collisions: cfg.Collisions,
 	}
 }
@@ -0,0 +1,479 @@
+package convert
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/token"
+	"go/types"
+	"log"
+	"strings"
+
+	"github.com/goplus/gogen"
+	"github.com/goplus/llcppg/ast"
+	"github.com/goplus/llcppg/internal/name"
+)
+
+type bridgeKind int
+
+const (
+	bridgeString bridgeKind = iota
+	bridgeStringView
+	bridgeVector
+)
+
+// stdBridge is a standard C++ type bridged by the converter, which is declared as a Go type
+// of the same size, whose objects are initialized, accessed and destroyed through the C++ shim.
+type stdBridge struct {
+	kind   bridgeKind
+	cname  string   // C++ name, like std::vector<int>
+	goName string   // Go name, like StdVectorInt
+	elem   ast.Expr // element type of a std::vector
+}
+
+// stdBridgeOf returns the bridge of std::string, std::string_view or a std::vector,
+// or nil for the other types. The inline namespaces of the C++ library are left out.
+func stdBridgeOf(expr ast.Expr) *stdBridge {
+	switch t := expr.(type) {
+	case *ast.Ident, *ast.ScopingExpr:
+		switch name.StdName(ast.QualifiedName(t)) {
+		case "std::string":
+			return &stdBridge{kind: bridgeString, cname: "std::string", goName: "StdString"}
+		case "std::string_view":
+			return &stdBridge{kind: bridgeStringView, cname: "std::string_view", goName: "StdStringView"}
+		}
+	case *ast.TagExpr:
+		return stdBridgeOf(t.Name)
+	case *ast.InstantiationType:
+		if name.StdName(ast.QualifiedName(t.Template)) != "std::vector" || t.Args == nil || len(t.Args.List) == 0 {
+			return nil
+		}
+		elem := t.Args.List[0].Type
+		// std::vector<bool> is a bitset without data()
+		if bt, ok := elem.(*ast.BuiltinType); ok && bt.Kind == ast.Bool {
+			return nil
+		}
+		cname := "std::vector<" + cppDecl(elem, "") + ">"
+		return &stdBridge{kind: bridgeVector, cname: cname, goName: "Std" + name.TemplateName(cname), elem: elem}
+	}
+	return nil
+}
+
+// bridgeType returns the Go type of a bridged standard C++ type, which is declared
+// the first time it is referred. ok is false for the other types.
+func (p *Package) bridgeType(expr ast.Expr) (typ types.Type, ok bool, err error) {
+	b := stdBridgeOf(expr)
+	if b == nil {
+		return nil, false, nil
+	}
+	if typ, ok := p.extTypes[b.goName]; ok {
+		return typ, true, nil
+	}
+	typ, err = p.newBridge(b)
+	return typ, true, err
+}
+
+// bridgeWords returns the size of a bridged type in words, the size of std::string is
+// the one reported by clang, or the one of the default C++ library of the host.
+func (p *Package) bridgeWords(b *stdBridge) int64 {
+	switch b.kind {
+	case bridgeString:
+		return typeSizesOrDefault(p.conf.TypeSizes).StdString / Sizeof(types.Typ[types.Uintptr])
+	case bridgeStringView:
+		return 2
+	}
+	return 3
+}
+
+// bridgeMethod is a method of a bridged type linked to a function of the C++ shim.
+type bridgeMethod struct {
+	name    string
+	params  []*types.Var
+	ret     types.Type // nil for no result
+	decl    string     // C++ declaration with %s for the symbol and the parameters
+	cparams string     // C++ parameters following self
+	body    string
+}
+
+// newBridge declares the Go type of a bridged standard C++ type in the autogen types file,
+// along with its methods linked to the C++ shim, like
+//
+//	type StdString struct {
+//		_      noCopy
+//		Unused [4]uintptr
+//	}
+//	// llgo:link (*StdString).Init C.llcppg_StdString_Init
+//	func (recv_ *StdString) Init(data *c.Char, n uintptr) {}
+//
+// The strings have Init, CStr (std::string only), Data, Size and Dispose, and the vectors
+// have Init, InitFrom a C array, Data, Size, PushBack and Dispose.
+func (p *Package) newBridge(b *stdBridge) (types.Type, error) {
+	var elem types.Type
+	if b.kind == bridgeVector {
+		var err error
+		if elem, err = p.ToType(b.elem); err != nil {
+			return nil, fmt.Errorf("%s: %w", b.cname, err)
+		}
+	}
+	pkg := p.p
+	defer pkg.RestoreCurFile(pkg.CurFile())
+	p.setGoFile(p.autoTypesFile())
+
+	typeBlock := pkg.NewTypeDefs()
+	typeBlock.SetComments(NewCommentGroup(&goast.Comment{
+		Text: "// " + b.goName + " is the C++ " + b.cname + ", whose objects are initialized by Init and destroyed by Dispose.",
+	}))
+	named := typeBlock.NewType(b.goName).InitType(pkg, types.NewStruct([]*types.Var{
+		types.NewField(token.NoPos, pkg.Types, "_", p.noCopyType(), false),
+		types.NewField(token.NoPos, pkg.Types, "Unused", types.NewArray(types.Typ[types.Uintptr], p.bridgeWords(b)), false),
+	}, nil))
+	if p.extTypes == nil {
+		p.extTypes = make(map[string]types.Type)
+	}
+	p.extTypes[b.goName] = named
+
+	cppType := shimPrefix + b.goName
+	p.shim.include("new")
+	p.shim.add("typedef %s %s;", b.cname, cppType)
+	param := func(name string, typ types.Type) *types.Var {
+		return pkg.NewParam(token.NoPos, name, typ)
+	}
+	size := types.Typ[types.Uintptr]
+	var methods []*bridgeMethod
+	switch b.kind {
+	case bridgeString, bridgeStringView:
+		if b.kind == bridgeString {
+			p.shim.include("string")
+		} else {
+			p.shim.include("string_view")
+		}
+		char, err := p.ToType(&ast.PointerType{X: &ast.BuiltinType{Kind: ast.Char, Flags: ast.Signed}})
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, &bridgeMethod{
+			name: "Init", params: []*types.Var{param("data", char), param("n", size)},
+			decl: "void %s", cparams: "const char *data, size_t n", body: "new (self) " + cppType + "(data, n)",
+		})
+		if b.kind == bridgeString {
+			methods = append(methods, &bridgeMethod{name: "CStr", ret: char, decl: "const char *%s", body: "return self->c_str()"})
+		}
+		methods = append(methods, &bridgeMethod{name: "Data", ret: char, decl: "const char *%s", body: "return self->data()"})
+	case bridgeVector:
+		p.shim.include("vector")
+		elemPtr := types.NewPointer(elem)
+		elemDecl := func(name string) string { return cppDecl(b.elem, name) }
+		methods = append(methods,
+			&bridgeMethod{name: "Init", decl: "void %s", body: "new (self) " + cppType + "()"},
+			&bridgeMethod{
+				name: "InitFrom", params: []*types.Var{param("data", elemPtr), param("n", size)},
+				decl: "void %s", cparams: elemDecl("const *data") + ", size_t n", body: "new (self) " + cppType + "(data, data + n)",
+			},
+			&bridgeMethod{name: "Data", ret: elemPtr, decl: elemDecl("*%s"), body: "return self->data()"},
+		)
+		// the elements which are not trivially copyable are pushed by const references
+		push := &bridgeMethod{name: "PushBack", decl: "void %s", cparams: elemDecl("v"), body: "self->push_back(v)"}
+		if p.isTrivial(elem) {
+			push.params = []*types.Var{param("v", elem)}
+		} else {
+			push.params = []*types.Var{param("v", elemPtr)}
+			push.cparams = elemDecl("const &v")
+		}
+		methods = append(methods, push)
+	}
+	methods = append(methods,
+		&bridgeMethod{name: "Size", ret: size, decl: "size_t %s", body: "return self->size()"},
+		&bridgeMethod{name: "Dispose", decl: "void %s", body: "self->~" + cppType + "()"},
+	)
+
+	p.newShimMethods(named, cppType, methods)
+	return named, nil
+}
+
+// newShimMethods declares the methods of a Go type linked to the functions of the C++ shim,
+// which take the pointer to the C++ object of cppType as self.
+func (p *Package) newShimMethods(named *types.Named, cppType string, methods []*bridgeMethod) {
+	pkg := p.p
+	goName := named.Obj().Name()
+	for _, m := range methods {
+		symbol := shimPrefix + goName + "_" + m.name
+		recv := pkg.NewParam(token.NoPos, "recv_", types.NewPointer(named))
+		var results *types.Tuple
+		if m.ret != nil {
+			results = types.NewTuple(pkg.NewParam(token.NoPos, "", m.ret))
+		}
+		decl := pkg.NewFuncDecl(token.NoPos, m.name, types.NewSignatureType(recv, nil, nil, types.NewTuple(m.params...), results, false))
+		if m.ret != nil {
+			decl.BodyStart(pkg).ZeroLit(m.ret).Return(1).End()
+		} else {
+			decl.BodyStart(pkg).End()
+		}
+		decl.SetComments(pkg, NewCommentGroup(NewFuncDocComment(symbol, "(*"+goName+")."+m.name)))
+
+		params := cppType + " *self"
+		if m.cparams != "" {
+			params += ", " + m.cparams
+		}
+		p.shim.add("extern \"C\" %s {\n\t%s;\n}", fmt.Sprintf(m.decl, symbol+"("+params+")"), m.body)
+	}
+}
+
+// bridgeConv is how a parameter or the result of a Go wrapper is converted, see NewGoWrapper.
+type bridgeConv struct {
+	bridge  *stdBridge
+	goType  types.Type // Go string or slice
+	dispose bool       // the C++ object is created or returned by value
+}
+
+// goBridgeConv returns the conversion of a parameter or the result of a C++ function to the Go
+// string or slice, which is nil if it is not a bridged type. ok is false if it is a bridged type
+// which can't be converted, like a mutable reference or a vector of not trivially copyable elements.
+func (p *Package) goBridgeConv(typ ast.Expr, isRet bool) (conv *bridgeConv, ok bool) {
+	conv = &bridgeConv{dispose: true}
+	switch t := typ.(type) {
+	case *ast.LvalueRefType:
+		if stdBridgeOf(t.X) == nil {
+			return nil, true
+		}
+		if !t.IsConst {
+			return nil, false
+		}
+		conv.bridge, conv.dispose = stdBridgeOf(t.X), !isRet
+	case *ast.RvalueRefType:
+		if stdBridgeOf(t.X) == nil {
+			return nil, true
+		}
+		conv.bridge, conv.dispose = stdBridgeOf(t.X), !isRet
+	case *ast.PointerType:
+		return nil, stdBridgeOf(t.X) == nil
+	default:
+		if conv.bridge = stdBridgeOf(typ); conv.bridge == nil {
+			return nil, true
+		}
+	}
+	if conv.bridge.kind != bridgeVector {
+		conv.goType = types.Typ[types.String]
+		return conv, true
+	}
+	elem, err := p.ToType(conv.bridge.elem)
+	if err != nil || !p.isTrivial(elem) {
+		return nil, false
+	}
+	conv.goType = types.NewSlice(elem)
+	return conv, true
+}
+
+// NewGoWrapper declares the Go wrapper of a function or a method taking or returning std::string,
+// std::string_view or std::vector with the stdWrappers option, which is named with a Go suffix
+// and uses the Go strings and slices instead, like
+//
+//	func GreetGo(name string) string {
+//		var name_ StdString
+//		name_.Init(c.GoStringData(name), uintptr(len(name)))
+//		defer name_.Dispose()
+//		ret_ := Greet(&name_)
+//		defer ret_.Dispose()
+//		return c.GoString(ret_.Data(), ret_.Size())
+//	}
+//
+// goName is the Go name of the function fn, which is not wrapped if it takes a mutable
+// reference or a pointer to a bridged type.
+func (p *Package) NewGoWrapper(goName string, fn *ast.FuncDecl) error {
+	if !p.conf.StdWrappers || NewGoFuncSpec(goName, nil).Handle >= 0 {
+		// the parameters of a handle function are reordered, see newHandleFuncDecl
+		return nil
+	}
+	target := p.goFunc(goName)
+	if target == nil {
+		return nil
+	}
+	sig := target.Type().(*types.Signature)
+	params := paramList(fn.Type)
+	if sig.Recv() != nil && len(params) == sig.Params().Len()+1 {
+		// the receiver of a function converted to a method
+		params = params[1:]
+	}
+	if len(params) != sig.Params().Len() || sig.Variadic() {
+		return nil
+	}
+	convs := make([]*bridgeConv, len(params))
+	wrap := false
+	for i, param := range params {
+		conv, ok := p.goBridgeConv(param.Type, false)
+		if !ok {
+			return nil
+		}
+		convs[i], wrap = conv, wrap || conv != nil
+	}
+	var retConv *bridgeConv
+	if sig.Results().Len() == 1 {
+		conv, ok := p.goBridgeConv(fn.Type.Ret, true)
+		if !ok {
+			return nil
+		}
+		retConv, wrap = conv, wrap || conv != nil
+	}
+	if !wrap {
+		return nil
+	}
+
+	pkg := p.p
+	wrapperName := target.Name() + "Go"
+	if sig.Recv() != nil {
+		if named := getNamedType(sig.Recv().Type()); named != nil && hasMethod(named, wrapperName) {
+			log.Printf("NewGoWrapper: %s of %s is defined, ignored\n", wrapperName, named.Obj().Name())
+			return nil
+		}
+	} else if p.Lookup(wrapperName) != nil {
+		log.Printf("NewGoWrapper: %s is defined, ignored\n", wrapperName)
+		return nil
+	}
+	var recv *types.Var
+	if sig.Recv() != nil {
+		recv = pkg.NewParam(token.NoPos, "recv_", sig.Recv().Type())
+	}
+	var wrapperParams []*types.Var
+	for i := 0; i < sig.Params().Len(); i++ {
+		param := sig.Params().At(i)
+		if convs[i] != nil {
+			param = pkg.NewParam(token.NoPos, param.Name(), convs[i].goType)
+		}
+		wrapperParams = append(wrapperParams, param)
+	}
+	results := sig.Results()
+	if retConv != nil {
+		results = types.NewTuple(pkg.NewParam(token.NoPos, "", retConv.goType))
+	}
+	decl := pkg.NewFuncDecl(token.NoPos, wrapperName, types.NewSignatureType(recv, nil, nil, types.NewTuple(wrapperParams...), results, false))
+	decl.SetComments(pkg, NewCommentGroup(&goast.Comment{
+		Text: "// " + wrapperName + " calls " + target.Name() + " with the Go " + strings.Join(goBridgeNames(convs, retConv), " and ") + ".",
+	}))
+
+	clib := pkg.Import("github.com/goplus/lib/c")
+	unsafe := pkg.Unsafe()
+	length := func(cb *gogen.CodeBuilder, v *types.Var) {
+		cb.Typ(types.Typ[types.Uintptr]).Val(pkg.Builtin().Ref("len")).Val(v).Call(1).Call(1)
+	}
+	cb := decl.BodyStart(pkg)
+	var args []*types.Var
+	for i, param := range wrapperParams {
+		conv := convs[i]
+		if conv == nil {
+			args = append(args, param)
+			continue
+		}
+		bridgeType, err := p.ToType(conv.bridge.cppType())
+		if err != nil {
+			return err
+		}
+		tmpName := param.Name() + "_"
+		cb.NewVar(bridgeType, tmpName)
+		tmp := cb.Scope().Lookup(tmpName).(*types.Var)
+		if conv.bridge.kind == bridgeVector {
+			cb.Val(tmp).MemberVal("InitFrom").Val(unsafe.Ref("SliceData")).Val(param).Call(1)
+		} else {
+			cb.Val(tmp).MemberVal("Init").Val(clib.Ref("GoStringData")).Val(param).Call(1)
+		}
+		length(cb, param)
+		cb.Call(2).EndStmt()
+		cb.Val(tmp).MemberVal("Dispose").Call(0).Defer()
+		args = append(args, tmp)
+	}
+
+	if recv != nil {
+		cb.Val(recv).MemberVal(target.Name())
+	} else {
+		cb.Val(target)
+	}
+	for i, arg := range args {
+		cb.Val(arg)
+		if _, ok := sig.Params().At(i).Type().(*types.Pointer); ok && convs[i] != nil {
+			cb.UnaryOp(token.AND)
+		}
+	}
+	cb.Call(len(args))
+	switch {
+	case sig.Results().Len() == 0:
+		cb.EndStmt()
+	case retConv == nil:
+		cb.Return(1)
+	default:
+		cb.DefineVarStart(token.NoPos, "ret_").EndInit(1)
+		ret := cb.Scope().Lookup("ret_").(*types.Var)
+		if retConv.dispose {
+			cb.Val(ret).MemberVal("Dispose").Call(0).Defer()
+		}
+		if retConv.bridge.kind == bridgeVector {
+			cb.Val(pkg.Builtin().Ref("append")).Typ(retConv.goType).Val(nil).Call(1)
+			cb.Val(unsafe.Ref("Slice")).Val(ret).MemberVal("Data").Call(0).Val(ret).MemberVal("Size").Call(0).Call(2)
+			cb.CallWith(2, gogen.InstrFlagEllipsis)
+		} else {
+			cb.Val(clib.Ref("GoString")).Val(ret).MemberVal("Data").Call(0).Val(ret).MemberVal("Size").Call(0).Call(2)
+		}
+		cb.Return(1)
+	}
+	cb.End()
+	return nil
+}
+
+// goFunc returns the Go function or method of a Go name like (*Foo).Bar, or nil if it's not declared.
+func (p *Package) goFunc(goName string) *types.Func {
+	spec := NewGoFuncSpec(goName, nil)
+	if spec.RecvName == "" {
+		fn, _ := p.Lookup(spec.FnName).(*types.Func)
+		return fn
+	}
+	obj := p.Lookup(spec.RecvName)
+	if obj == nil {
+		return nil
+	}
+	if named := getNamedType(obj.Type()); named != nil {
+		if i := methodIndex(named, spec.FnName); i >= 0 {
+			return named.Method(i)
+		}
+	}
+	// a function whose receiver type is not declared in the package
+	fn, _ := p.Lookup(spec.FnName).(*types.Func)
+	return fn
+}
+
+// cppType returns the expression of the bridged type, which is declared by bridgeType.
+func (b *stdBridge) cppType() ast.Expr {
+	if b.kind != bridgeVector {
+		return &ast.Ident{Name: b.cname}
+	}
+	return &ast.InstantiationType{
+		Template: &ast.ScopingExpr{Parent: &ast.Ident{Name: "std"}, X: &ast.Ident{Name: "vector"}},
+		Args:     &ast.FieldList{List: []*ast.Field{{Type: b.elem}}},
+	}
+}
+
+func goBridgeNames(convs []*bridgeConv, ret *bridgeConv) []string {
+	var hasString, hasSlice bool
+	for _, conv := range append(convs, ret) {
+		if conv != nil {
+			hasString = hasString || conv.bridge.kind != bridgeVector
+			hasSlice = hasSlice || conv.bridge.kind == bridgeVector
+		}
+	}
+	var names []string
+	if hasString {
+		names = append(names, "strings")
+	}
+	if hasSlice {
+		names = append(names, "slices")
+	}
+	return names
+}
+
+func hasMethod(named *types.Named, name string) bool {
+	return methodIndex(named, name) >= 0
+}
+
+func methodIndex(named *types.Named, name string) int {
+	for i := 0; i < named.NumMethods(); i++ {
+		if named.Method(i).Name() == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -3,34 +3,88 @@ package convert
 import (
 	"fmt"
 	"go/types"
+	"runtime"
 
 	"github.com/goplus/gogen"
 	"github.com/goplus/llcppg/ast"
+	llcppg "github.com/goplus/llcppg/config"
 )
 
 type BuiltinTypeMap struct {
 	pkgMap         map[string]gogen.PkgRef
 	builtinTypeMap map[ast.BuiltinType]types.Type
+	extTypeMap     map[ast.BuiltinType]*ExtType
 }
 
-func NewBuiltinTypeMapWithPkgRefS(pkgs ...gogen.PkgRef) *BuiltinTypeMap {
+// ExtType describes a C builtin type that has no Go counterpart of the same
+// size, such as __int128 or an 80-bit long double. It is emitted as an opaque
+// named type with the given underlying type in the generated package, the
+// records containing it are padded to its C alignment.
+type ExtType struct {
+	Name       string
+	CName      string
+	Underlying types.Type
+	Align      int64 // alignment in C
+}
+
+func NewBuiltinTypeMapWithPkgRefS(sizes *llcppg.TypeSizes, pkgs ...gogen.PkgRef) *BuiltinTypeMap {
 	builtinTypeMap := &BuiltinTypeMap{}
 	builtinTypeMap.pkgMap = make(map[string]gogen.PkgRef)
 	for _, pkg := range pkgs {
 		builtinTypeMap.pkgMap[pkg.Types.Name()] = pkg
 		builtinTypeMap.pkgMap[pkg.Path()] = pkg
 	}
-	builtinTypeMap.initBuiltinTypeMap()
+	builtinTypeMap.initBuiltinTypeMap(typeSizesOrDefault(sizes))
 	return builtinTypeMap
 }
 
-func NewBuiltinTypeMap(pkgPath, name string, conf *gogen.Config) *BuiltinTypeMap {
+func NewBuiltinTypeMap(pkgPath, name string, conf *gogen.Config, sizes *llcppg.TypeSizes) *BuiltinTypeMap {
 	p := gogen.NewPackage(pkgPath, name, conf)
 	clib := p.Import("github.com/goplus/lib/c")
-	builtinTypeMap := NewBuiltinTypeMapWithPkgRefS(clib, p.Unsafe())
+	builtinTypeMap := NewBuiltinTypeMapWithPkgRefS(sizes, clib, p.Unsafe())
 	return builtinTypeMap
 }
 
+// DefaultTypeSizes returns the sizes of the target dependent builtin types
+// for the host platform, used when the headers did not report them.
+func DefaultTypeSizes() *llcppg.TypeSizes {
+	sizes := &llcppg.TypeSizes{WChar: 4, LongDouble: 16, StdString: 32}
+	sizes.WCharUnsigned = llcppg.WCharUnsigned(runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		sizes.WChar = 2
+	}
+	if runtime.GOOS == "darwin" {
+		sizes.StdString = 24 // libc++
+	}
+	switch {
+	case runtime.GOOS == "windows", runtime.GOOS == "darwin" && runtime.GOARCH == "arm64":
+		sizes.LongDouble = 8
+	case runtime.GOARCH == "386":
+		sizes.LongDouble = 12
+	case runtime.GOARCH == "arm":
+		sizes.LongDouble = 8
+	}
+	return sizes
+}
+
+func typeSizesOrDefault(sizes *llcppg.TypeSizes) *llcppg.TypeSizes {
+	def := DefaultTypeSizes()
+	if sizes == nil {
+		return def
+	}
+	ret := *sizes
+	if ret.WChar == 0 {
+		ret.WChar, ret.WCharUnsigned = def.WChar, def.WCharUnsigned
+	}
+	if ret.LongDouble == 0 {
+		ret.LongDouble = def.LongDouble
+	}
+	if ret.StdString == 0 {
+		ret.StdString = def.StdString
+	}
+	return &ret
+}
+
 func (p *BuiltinTypeMap) CType(typ string) types.Type {
 	clib, ok := p.pkgMap["c"]
 	if ok {
@@ -52,28 +106,62 @@ func (p *BuiltinTypeMap) FindBuiltinType(builtinType ast.BuiltinType) (types.Typ
 	return nil, fmt.Errorf("%s", "not found in type map")
 }
 
-func (p *BuiltinTypeMap) initBuiltinTypeMap() {
-	// todo(zzy): int128/uint128  half(float16),long double,float 128
+// FindExtType reports the opaque type used for a builtin type that can't be
+// expressed by a Go basic type.
+func (p *BuiltinTypeMap) FindExtType(builtinType ast.BuiltinType) (*ExtType, bool) {
+	t, ok := p.extTypeMap[builtinType]
+	return t, ok
+}
+
+func (p *BuiltinTypeMap) initBuiltinTypeMap(sizes *llcppg.TypeSizes) {
 	p.builtinTypeMap = map[ast.BuiltinType]types.Type{
-		{Kind: ast.Void}:                                    p.CType("Void"),             // [0]byte
-		{Kind: ast.Bool}:                                    types.Typ[types.Bool],       // Bool
-		{Kind: ast.Char, Flags: ast.Signed}:                 p.CType("Char"),             // Char_S
-		{Kind: ast.Char, Flags: ast.Unsigned}:               p.CType("Char"),             // Char_U
-		{Kind: ast.WChar}:                                   types.Typ[types.Int16],      // WChar
-		{Kind: ast.Char16}:                                  types.Typ[types.Int16],      // Char16
-		{Kind: ast.Char32}:                                  types.Typ[types.Int32],      // Char32
-		{Kind: ast.Int, Flags: ast.Short}:                   types.Typ[types.Int16],      // Short
-		{Kind: ast.Int, Flags: ast.Short | ast.Unsigned}:    types.Typ[types.Uint16],     // UShort
-		{Kind: ast.Int}:                                     p.CType("Int"),              // Int
-		{Kind: ast.Int, Flags: ast.Unsigned}:                p.CType("Uint"),             // UInt
-		{Kind: ast.Int, Flags: ast.Long}:                    p.CType("Long"),             // Long
-		{Kind: ast.Int, Flags: ast.Long | ast.Unsigned}:     p.CType("Ulong"),            // Ulong
-		{Kind: ast.Int, Flags: ast.LongLong}:                p.CType("LongLong"),         // LongLong
-		{Kind: ast.Int, Flags: ast.LongLong | ast.Unsigned}: p.CType("UlongLong"),        // ULongLong
-		{Kind: ast.Float}:                                   p.CType("Float"),            // Float
-		{Kind: ast.Float, Flags: ast.Double}:                p.CType("Double"),           // Double
-		{Kind: ast.Float, Flags: ast.Double | ast.Long}:     p.CType("Double"),           // Long Double (same as double,need more precision)
-		{Kind: ast.Complex}:                                 types.Typ[types.Complex64],  // ComplexFloat
-		{Kind: ast.Complex, Flags: ast.Double}:              types.Typ[types.Complex128], // ComplexDouble
+		{Kind: ast.Void}:                                         p.CType("Void"),             // [0]byte
+		{Kind: ast.Bool}:                                         types.Typ[types.Bool],       // Bool
+		{Kind: ast.Char, Flags: ast.Signed}:                      p.CType("Char"),             // Char_S
+		{Kind: ast.Char, Flags: ast.Unsigned}:                    p.CType("Char"),             // Char_U
+		{Kind: ast.Char, Flags: ast.Signed | ast.ExplicitSign}:   p.CType("Char"),             // SChar
+		{Kind: ast.Char, Flags: ast.Unsigned | ast.ExplicitSign}: p.CType("Char"),             // UChar
+		{Kind: ast.Char16}:                                       types.Typ[types.Uint16],     // Char16
+		{Kind: ast.Char32}:                                       types.Typ[types.Uint32],     // Char32
+		{Kind: ast.Int, Flags: ast.Short}:                        types.Typ[types.Int16],      // Short
+		{Kind: ast.Int, Flags: ast.Short | ast.Unsigned}:         types.Typ[types.Uint16],     // UShort
+		{Kind: ast.Int}:                                          p.CType("Int"),              // Int
+		{Kind: ast.Int, Flags: ast.Unsigned}:                     p.CType("Uint"),             // UInt
+		{Kind: ast.Int, Flags: ast.Long}:                         p.CType("Long"),             // Long
+		{Kind: ast.Int, Flags: ast.Long | ast.Unsigned}:          p.CType("Ulong"),            // Ulong
+		{Kind: ast.Int, Flags: ast.LongLong}:                     p.CType("LongLong"),         // LongLong
+		{Kind: ast.Int, Flags: ast.LongLong | ast.Unsigned}:      p.CType("UlongLong"),        // ULongLong
+		{Kind: ast.Float}:                                        p.CType("Float"),            // Float
+		{Kind: ast.Float, Flags: ast.Double}:                     p.CType("Double"),           // Double
+		{Kind: ast.Complex}:                                      types.Typ[types.Complex64],  // ComplexFloat
+		{Kind: ast.Complex, Flags: ast.Double}:                   types.Typ[types.Complex128], // ComplexDouble
+	}
+
+	// wchar_t is 32-bit signed on most unix targets, 32-bit unsigned on linux/arm64
+	// and 16-bit unsigned on windows
+	switch {
+	case sizes.WChar == 2:
+		p.builtinTypeMap[ast.BuiltinType{Kind: ast.WChar}] = types.Typ[types.Uint16]
+	case sizes.WCharUnsigned:
+		p.builtinTypeMap[ast.BuiltinType{Kind: ast.WChar}] = types.Typ[types.Uint32]
+	default:
+		p.builtinTypeMap[ast.BuiltinType{Kind: ast.WChar}] = types.Typ[types.Int32]
+	}
+
+	p.extTypeMap = map[ast.BuiltinType]*ExtType{
+		{Kind: ast.Int128}:                      {"CInt128", "__int128", types.NewArray(types.Typ[types.Uint64], 2), 16},           // Int128
+		{Kind: ast.Int128, Flags: ast.Unsigned}: {"CUint128", "unsigned __int128", types.NewArray(types.Typ[types.Uint64], 2), 16}, // UInt128
+		{Kind: ast.Float16}:                     {"CFloat16", "_Float16", types.Typ[types.Uint16], 2},                              // Half
+		{Kind: ast.Float128}:                    {"CFloat128", "__float128", types.NewArray(types.Typ[types.Byte], 16), 16},        // Float128
+	}
+	longDouble := ast.BuiltinType{Kind: ast.Float, Flags: ast.Double | ast.Long}
+	switch sizes.LongDouble {
+	case 8:
+		p.builtinTypeMap[longDouble] = p.CType("Double")
+	case 12:
+		// the 80-bit long double of 386 is 4-byte aligned
+		p.extTypeMap[longDouble] = &ExtType{"CLongDouble", "long double", types.NewArray(types.Typ[types.Byte], 12), 4}
+	default:
+		p.extTypeMap[longDouble] = &ExtType{"CLongDouble", "long double", types.NewArray(types.Typ[types.Byte], sizes.LongDouble), 16}
 	}
 }
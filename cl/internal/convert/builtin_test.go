@@ -6,10 +6,11 @@ import (
 
 	"github.com/goplus/llcppg/ast"
 	"github.com/goplus/llcppg/cl/internal/convert"
+	llcppg "github.com/goplus/llcppg/config"
 )
 
 func TestBuiltinType(t *testing.T) {
-	typmap := convert.NewBuiltinTypeMap(".", "temp", nil)
+	typmap := convert.NewBuiltinTypeMap(".", "temp", nil, &llcppg.TypeSizes{WChar: 4, LongDouble: 16})
 	testCases := []struct {
 		name     string
 		input    *ast.BuiltinType
@@ -20,9 +21,9 @@ func TestBuiltinType(t *testing.T) {
 		{"Bool", &ast.BuiltinType{Kind: ast.Bool}, "bool", false},
 		{"Char_S", &ast.BuiltinType{Kind: ast.Char, Flags: ast.Signed}, "github.com/goplus/lib/c.Char", false},
 		{"Char_U", &ast.BuiltinType{Kind: ast.Char, Flags: ast.Unsigned}, "github.com/goplus/lib/c.Char", false},
-		{"WChar", &ast.BuiltinType{Kind: ast.WChar}, "int16", false},
-		{"Char16", &ast.BuiltinType{Kind: ast.Char16}, "int16", false},
-		{"Char32", &ast.BuiltinType{Kind: ast.Char32}, "int32", false},
+		{"WChar", &ast.BuiltinType{Kind: ast.WChar}, "int32", false},
+		{"Char16", &ast.BuiltinType{Kind: ast.Char16}, "uint16", false},
+		{"Char32", &ast.BuiltinType{Kind: ast.Char32}, "uint32", false},
 		{"Short", &ast.BuiltinType{Kind: ast.Int, Flags: ast.Short}, "int16", false},
 		{"UShort", &ast.BuiltinType{Kind: ast.Int, Flags: ast.Short | ast.Unsigned}, "uint16", false},
 		{"Int", &ast.BuiltinType{Kind: ast.Int}, "github.com/goplus/lib/c.Int", false},
@@ -57,8 +58,81 @@ func TestBuiltinType(t *testing.T) {
 	}
 }
 
+func TestBuiltinTypeSizes(t *testing.T) {
+	longDouble := ast.BuiltinType{Kind: ast.Float, Flags: ast.Double | ast.Long}
+	testCases := []struct {
+		name       string
+		sizes      *llcppg.TypeSizes
+		wchar      string
+		longDouble string // empty means an extended type
+	}{
+		{"Linux", &llcppg.TypeSizes{WChar: 4, LongDouble: 16}, "int32", ""},
+		{"Windows", &llcppg.TypeSizes{WChar: 2, LongDouble: 8}, "uint16", "github.com/goplus/lib/c.Double"},
+		{"DarwinArm64", &llcppg.TypeSizes{WChar: 4, LongDouble: 8}, "int32", "github.com/goplus/lib/c.Double"},
+		{"LinuxArm64", &llcppg.TypeSizes{WChar: 4, LongDouble: 16, WCharUnsigned: true}, "uint32", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			typmap := convert.NewBuiltinTypeMap(".", "temp", nil, tc.sizes)
+			wchar, err := typmap.FindBuiltinType(ast.BuiltinType{Kind: ast.WChar})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wchar.String() != tc.wchar {
+				t.Errorf("unexpected wchar_t:%s expected:%s", wchar.String(), tc.wchar)
+			}
+			if tc.longDouble == "" {
+				ext, ok := typmap.FindExtType(longDouble)
+				if !ok {
+					t.Fatal("expect long double to be an extended type")
+				}
+				if ext.Name != "CLongDouble" || ext.Underlying.String() != "[16]uint8" {
+					t.Errorf("unexpected long double:%s %s", ext.Name, ext.Underlying)
+				}
+				return
+			}
+			typ, err := typmap.FindBuiltinType(longDouble)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if typ.String() != tc.longDouble {
+				t.Errorf("unexpected long double:%s expected:%s", typ.String(), tc.longDouble)
+			}
+		})
+	}
+}
+
+func TestExtType(t *testing.T) {
+	typmap := convert.NewBuiltinTypeMap(".", "temp", nil, nil)
+	testCases := []struct {
+		name       string
+		input      ast.BuiltinType
+		expected   string
+		underlying string
+	}{
+		{"Int128", ast.BuiltinType{Kind: ast.Int128}, "CInt128", "[2]uint64"},
+		{"UInt128", ast.BuiltinType{Kind: ast.Int128, Flags: ast.Unsigned}, "CUint128", "[2]uint64"},
+		{"Float16", ast.BuiltinType{Kind: ast.Float16}, "CFloat16", "uint16"},
+		{"Float128", ast.BuiltinType{Kind: ast.Float128}, "CFloat128", "[16]uint8"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ext, ok := typmap.FindExtType(tc.input)
+			if !ok {
+				t.Fatal("expect extended type")
+			}
+			if ext.Name != tc.expected || ext.Underlying.String() != tc.underlying {
+				t.Errorf("unexpected result:%s %s expected:%s %s", ext.Name, ext.Underlying, tc.expected, tc.underlying)
+			}
+		})
+	}
+	if _, ok := typmap.FindExtType(ast.BuiltinType{Kind: ast.Int}); ok {
+		t.Error("expect int not to be an extended type")
+	}
+}
+
 func TestIsVoidType(t *testing.T) {
-	typmap := convert.NewBuiltinTypeMap(".", "temp", nil)
+	typmap := convert.NewBuiltinTypeMap(".", "temp", nil, nil)
 	if !typmap.IsVoidType(typmap.CType("Void")) {
 		t.Error("Expect return true")
 	}
@@ -68,7 +142,7 @@ func TestIsVoidType(t *testing.T) {
 }
 
 func TestCType(t *testing.T) {
-	typmap := convert.NewBuiltinTypeMap(".", "temp", nil)
+	typmap := convert.NewBuiltinTypeMap(".", "temp", nil, nil)
 	ptrType := typmap.CType("Pointer")
 	if ptrType == nil {
 		t.Error("Expect a non nil pointer type")
@@ -0,0 +1,181 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Origin is a C declaration registered with a Go name.
+type Origin struct {
+	Name string // C name
+	Kind string // kind of the declaration, like type, typedef and macro
+	File string // C header declaring it
+	// GoName is the Go name it got, like Foo__1 of the suffixed ones, which is empty if it
+	// conflicts with a Go declaration already defined and fails the conversion
+	GoName string
+}
+
+// NameCollision is a Go name of several C declarations, or of one conflicting with a Go
+// declaration already defined, see llcppg.Collision to resolve it.
+type NameCollision struct {
+	GoName   string
+	Origins  []Origin // in the order of the declarations
+	Resolved bool     // if the C name keeping the Go name is preferred by llcppg.cfg
+}
+
+func (k nodeKind) String() string {
+	switch k {
+	case FuncDecl:
+		return "func"
+	case TypeDecl:
+		return "type"
+	case TypedefDecl:
+		return "typedef"
+	case EnumTypeDecl:
+		return "enum"
+	case EnumItem:
+		return "enum item"
+	case Macro:
+		return "macro"
+	case VarDecl:
+		return "var"
+	}
+	return "unknown"
+}
+
+// setOrigin sets the C header of the declarations converting, which are recorded by the
+// name collisions.
+func (p *Package) setOrigin(file string) {
+	p.symbols.file = file
+}
+
+// claim records the declaration of node registered with pubName, which got goName.
+func (p *ProcessSymbol) claim(node Node, pubName, goName string) {
+	p.claims[pubName] = append(p.claims[pubName], &Origin{Name: node.name, Kind: node.kind.String(), File: p.file, GoName: goName})
+}
+
+// claimFunc records the function cname registered with pubName, which got goName from the
+// symbol table. The functions are claimed by the Go names llcppsymg suffixes, like Foo of
+// Foo__1 and (*Foo).Bar of (*Foo).Bar__1, to report the ones named alike.
+func (p *ProcessSymbol) claimFunc(cname, pubName, goName string) {
+	pubName = cutSuffix(pubName)
+	p.claims[pubName] = append(p.claims[pubName], &Origin{Name: cname, Kind: FuncDecl.String(), File: p.file, GoName: goName})
+}
+
+// cutSuffix cuts the suffix of the count of a name, like Foo of Foo__1, see name.SuffixCount.
+func cutSuffix(name string) string {
+	i := strings.LastIndex(name, "__")
+	if i <= 0 || i+2 == len(name) {
+		return name
+	}
+	for _, c := range name[i+2:] {
+		if c < '0' || c > '9' {
+			return name
+		}
+	}
+	return name[:i]
+}
+
+// conflict records the declaration of node registered with pubName conflicts with a Go
+// declaration already defined.
+func (p *ProcessSymbol) conflict(node Node, pubName string) {
+	claims := p.claims[pubName]
+	for i := len(claims) - 1; i >= 0; i-- {
+		if claims[i].Name == node.name && claims[i].Kind == node.kind.String() {
+			claims[i].GoName = ""
+			return
+		}
+	}
+}
+
+// Collisions returns the name collisions of the registered declarations by the Go names.
+func (p *ProcessSymbol) Collisions() []*NameCollision {
+	var collisions []*NameCollision
+	for goName, claims := range p.claims {
+		if len(claims) < 2 && claims[0].GoName != "" {
+			continue
+		}
+		coll := &NameCollision{GoName: goName}
+		for _, origin := range claims {
+			coll.Origins = append(coll.Origins, *origin)
+		}
+		coll.Resolved = p.resolved(goName, claims)
+		collisions = append(collisions, coll)
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].GoName < collisions[j].GoName
+	})
+	return collisions
+}
+
+// resolved reports whether the declarations of a Go name are all converted, and the C name
+// keeping it is preferred, or their Go names are all locked by the name lock file, or they're
+// the overloads of a C++ function, which can only be suffixed.
+func (p *ProcessSymbol) resolved(goName string, claims []*Origin) bool {
+	locked, overloads := true, true
+	for _, origin := range claims {
+		if origin.GoName == "" {
+			return false
+		}
+		if _, ok := p.locked[lockKey(origin.Kind, origin.Name)]; !ok {
+			locked = false
+		}
+		if origin.Kind != FuncDecl.String() || origin.Name != claims[0].Name {
+			overloads = false
+		}
+	}
+	_, preferred := p.preferred[goName]
+	return preferred || locked || overloads
+}
+
+// Collisions returns the name collisions of the packages, see NameCollision.
+func (p *Converter) Collisions() []*NameCollision {
+	collisions := p.GenPkg.symbols.Collisions()
+	for _, sub := range p.SubPkgs {
+		collisions = append(collisions, sub.symbols.Collisions()...)
+	}
+	return collisions
+}
+
+// CollisionReport returns the human-readable report of the name collisions not resolved
+// by llcppg.cfg, like
+//
+//	Go names of several C declarations, resolve them by the collisions of llcppg.cfg:
+//		Foo:
+//			type foo (foo.h) -> Foo
+//			macro FOO (foo.h) -> Foo__1
+func CollisionReport(collisions []*NameCollision) string {
+	var b strings.Builder
+	for _, coll := range collisions {
+		if coll.Resolved {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString("Go names of several C declarations, resolve them by the collisions of llcppg.cfg:\n")
+		}
+		fmt.Fprintf(&b, "\t%s:\n", coll.GoName)
+		for _, origin := range coll.Origins {
+			goName := origin.GoName
+			if goName == "" {
+				goName = "already defined"
+			}
+			fmt.Fprintf(&b, "\t\t%s %s (%s) -> %s\n", origin.Kind, origin.Name, origin.File, goName)
+		}
+	}
+	return b.String()
+}
+
+// collisionError adds the report of the name collisions to the error of a declaration
+// conflicting with a Go declaration already defined, which fails the conversion.
+func (p *Converter) collisionError(err error) error {
+	collisions := p.Collisions()
+	for _, coll := range collisions {
+		for _, origin := range coll.Origins {
+			if origin.GoName == "" {
+				return fmt.Errorf("%w\n%s", err, CollisionReport(collisions))
+			}
+		}
+	}
+	return err
+}
@@ -2,11 +2,15 @@ package convert
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
+	"slices"
+	"strings"
 
 	"github.com/goplus/llcppg/ast"
 	"github.com/goplus/llcppg/cl/nc"
+	llcppg "github.com/goplus/llcppg/config"
 )
 
 type dbgFlags = int
@@ -31,6 +35,31 @@ type Config struct {
 
 	Deps []string // dependent packages
 	Libs string
+
+	TypeSizes     *llcppg.TypeSizes // sizes of the target dependent builtin types
+	OpaqueExclude []string          // forward declared only structs not to be opaque
+	AliasTypedefs []string          // typedefs to be generated as type aliases
+	AutoAlias     bool              // generate typedefs of builtin and pointer types as type aliases
+	GoDoc         bool              // convert the Doxygen and Javadoc comments to Go doc comments
+
+	Includes   []string // headers of the C++ shim
+	CFlags     string   // compile flags of the C++ shim, like $(pkg-config --cflags xxx)
+	GoSubclass []string // C++ classes whose virtual methods can be overridden in Go
+
+	ConstRefByValue int  // max size of the trivially copyable types whose const references are passed by value
+	StdWrappers     bool // generate the Go wrappers of the functions using the standard C++ types
+
+	DefaultArgs map[string]string // how the default arguments of the C++ functions are omitted, see llcppg.DefaultArgsOverloads
+	SymVersions map[string]string // the ELF symbol versions the symbols are linked to, like foo@VERS_1
+
+	NamespaceMode   string   // how the C++ namespaces map to Go, see llcppg.NamespacePackage
+	StripNamespaces []string // namespaces left out of the Go names and packages
+
+	SplitLibs  bool              // convert the functions of each library but the first one in its own sub-package
+	SymbolLibs map[string]string // the -l names of the libraries providing the symbols, like cjson
+
+	LockedNames map[string]string // the Go names locked by the name lock file, keyed by the kinds and the C names like typedef foo
+	Collisions  llcppg.Collisions // the resolutions of the name collisions, keyed by the Go names
 }
 
 // if modulePath is not empty, init the module by modulePath
@@ -61,32 +90,73 @@ func ModInit(deps []string, outputDir string, modulePath string) error {
 }
 
 type Converter struct {
-	Pkg    *ast.File
-	GenPkg *Package
-	Conf   *Config
-	NC     nc.NodeConverter
+	Pkg     *ast.File
+	GenPkg  *Package
+	SubPkgs []*SubPackage // Go sub-packages of the C++ namespaces or the libraries
+	Conf    *Config
+	NC      nc.NodeConverter
+
+	namespaces map[string]*SubPackage         // namespace -> sub-package, nil if not in the package namespace mode
+	imports    map[*Package]map[*Package]bool // imports between the sub-packages and the main package
+	classes    map[string]bool                // qualified names of the classes, which are not namespaces
+
+	libNames []string                     // the -l names of the libraries in the split libs mode
+	libFlags []string                     // the other link flags of the libraries, like -L/opt/lib
+	libPkgs  map[string]*SubPackage       // -l name -> sub-package, nil if not in the split libs mode
+	pkgLibs  map[*Package]map[string]bool // the libraries linked by the packages
 }
 
 func NewConverter(config *Config) (*Converter, error) {
+	split := config.SplitLibs && splitLibs(config.Libs)
+	if (config.NamespaceMode == llcppg.NamespacePackage || split) && config.PkgPath == "" {
+		// the sub-packages of the namespaces and the libraries are imported by their paths in the module
+		out, err := runGoCommand(config.OutputDir, "list", "-m")
+		if err != nil {
+			return nil, fmt.Errorf("the sub-packages need the module of the output: %s", out)
+		}
+		config.PkgPath = strings.TrimSpace(string(out))
+	}
+	libCommand := config.Libs
+	if split {
+		// each package links the libraries of its symbols, see Converter.Complete
+		libCommand = ""
+	}
 	pkg, err := NewPackage(config.NC, &PackageConfig{
 		PkgBase: PkgBase{
 			PkgPath: config.PkgPath,
 			Deps:    config.Deps,
 			Pubs:    make(map[string]string),
 		},
-		Name:       config.PkgName,
-		OutputDir:  config.OutputDir,
-		LibCommand: config.Libs,
+		Name:          config.PkgName,
+		OutputDir:     config.OutputDir,
+		LibCommand:    libCommand,
+		TypeSizes:     config.TypeSizes,
+		OpaqueExclude: config.OpaqueExclude,
+		AliasTypedefs: config.AliasTypedefs,
+		AutoAlias:     config.AutoAlias,
+		GoDoc:         config.GoDoc,
+		Includes:      config.Includes,
+		CFlags:        config.CFlags,
+
+		ConstRefByValue: config.ConstRefByValue,
+		StdWrappers:     config.StdWrappers,
+		DefaultArgs:     config.DefaultArgs,
+		SymVersions:     config.SymVersions,
+		LockedNames:     config.LockedNames,
+		Preferred:       config.Collisions.Preferred(),
 	})
 	if err != nil {
 		return nil, err
 	}
-	return &Converter{
+	p := &Converter{
 		Pkg:    config.Pkg,
 		GenPkg: pkg,
 		Conf:   config,
 		NC:     config.NC,
-	}, nil
+	}
+	p.initNamespaces()
+	p.initLibs()
+	return p, nil
 }
 
 func (p *Converter) Convert() error {
@@ -100,6 +170,7 @@ func (p *Converter) Convert() error {
 func (p *Converter) Process() error {
 	pnc := p.NC
 	ctx := p.GenPkg
+	// the macros have no namespace
 	for _, macro := range p.Pkg.Macros {
 		goName, goFile, err := pnc.ConvMacro(macro.Loc.File, macro)
 		if err != nil {
@@ -109,12 +180,15 @@ func (p *Converter) Process() error {
 			return fmt.Errorf("ConvMacro: %w", err)
 		}
 		ctx.setGoFile(goFile)
+		ctx.setOrigin(macro.Loc.File)
 		err = ctx.NewMacro(goName, macro)
 		if err != nil {
 			return err
 		}
 	}
 
+	methods := make(map[string]struct{})      // mangled names of the converted class methods
+	classes := make(map[string]*ast.TypeDecl) // converted classes, to look up the bases
 	for _, decl := range p.Pkg.Decls {
 		obj := ast.ObjectOf(decl)
 		goName, goFile, err := pnc.ConvDecl(obj.Loc.File, decl)
@@ -124,32 +198,135 @@ func (p *Converter) Process() error {
 			}
 			return fmt.Errorf("ConvDecl: %w", err)
 		}
+		ctx, err := p.declPackage(obj)
+		if err != nil {
+			return err
+		}
 		ctx.setGoFile(goFile)
+		ctx.setOrigin(obj.Loc.File)
 		switch decl := decl.(type) {
 		case *ast.TypeDecl:
 			err = ctx.NewTypeDecl(goName, decl, pnc)
+			if err == nil {
+				classes[decl.QualifiedName()] = decl
+				err = p.processMethods(ctx, goName, decl, classes, methods)
+			}
 		case *ast.EnumTypeDecl:
 			err = ctx.NewEnumTypeDecl(goName, decl, pnc)
 		case *ast.TypedefDecl:
 			err = ctx.NewTypedefDecl(goName, decl, pnc)
 		case *ast.FuncDecl:
-			err = ctx.NewFuncDecl(goName, decl)
+			// the out-of-class definition of a method is converted with its class
+			if _, ok := methods[decl.MangledName]; ok {
+				continue
+			}
+			ctx, goName, err = p.funcPackage(ctx, decl, goName)
+			if err != nil {
+				return err
+			}
+			ctx.setGoFile(goFile)
+			ctx.setOrigin(obj.Loc.File)
+			err = ctx.NewFuncDecl(goName, ctx.refWrapper(decl, nil))
+			if err == nil {
+				err = ctx.NewDefaultArgsDecl(goName, decl, nil, p.NC)
+			}
+			if err == nil {
+				err = ctx.NewGoWrapper(goName, decl)
+			}
+		}
+		if err != nil {
+			return p.collisionError(err)
+		}
+	}
+	return nil
+}
+
+// processMethods converts the methods of a C++ class, whose Go names come from the symbol table.
+// The virtual methods are called through the C++ shim, and named after the methods if they have
+// no symbols, like the pure virtual methods.
+func (p *Converter) processMethods(ctx *Package, className string, decl *ast.TypeDecl, classes map[string]*ast.TypeDecl, methods map[string]struct{}) error {
+	var virtuals []*ast.FuncDecl
+	for _, method := range decl.Type.Methods {
+		if isInstantiation(decl) && !ctx.bindable(method) {
+			log.Printf("processMethods: %s of %s refers to an unbound type, ignored\n", method.Name.Name, decl.QualifiedName())
+			continue
+		}
+		if method.IsVirtual && !method.IsDestructor {
+			virtuals = append(virtuals, method)
+			continue
 		}
+		goName, _, err := p.NC.ConvDecl(method.Loc.File, method)
 		if err != nil {
+			if err == nc.ErrSkip {
+				continue
+			}
+			return fmt.Errorf("ConvDecl: %w", err)
+		}
+		methods[method.MangledName] = struct{}{}
+		p.useLib(ctx, p.symbolLib(method))
+		if err := ctx.NewMethodDecl(goName, decl, method); err != nil {
 			return err
 		}
+		if err := ctx.NewDefaultArgsDecl(goName, method, decl, p.NC); err != nil {
+			return err
+		}
+		if err := ctx.NewGoWrapper(goName, method); err != nil {
+			return err
+		}
+	}
+	// the overloads named by llcppsymg are declared first to keep their names
+	for _, method := range virtuals {
+		methods[method.MangledName] = struct{}{}
+		fnName, _ := virtualGoName(method, p.NC)
+		if err := ctx.NewVirtualMethodDecl(className, fnName, decl, method, p.NC); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(p.Conf.GoSubclass, decl.QualifiedName()) {
+		return ctx.NewSubclassDecl(className, decl, VirtualMethods(decl, classes), p.NC)
 	}
 	return nil
 }
 
 func (p *Converter) Complete() error {
-	err := p.GenPkg.Complete()
-	if err != nil {
-		return fmt.Errorf("Complete Fail: %w", err)
+	pkgs := []*Package{p.GenPkg}
+	if len(p.SubPkgs) > 0 {
+		sorted, err := p.sortNamespaces()
+		if err != nil {
+			return err
+		}
+		pkgs = sorted
+	}
+	if p.Conf.LockedNames != nil {
+		if err := llcppg.CheckLocked(p.Conf.LockedNames, p.Names()); err != nil {
+			return err
+		}
+	}
+	for _, pkg := range pkgs {
+		if p.libPkgs != nil {
+			if link := p.libLink(pkg); link != "" {
+				pkg.initLink(link)
+			}
+		}
+		if err := pkg.Complete(); err != nil {
+			return fmt.Errorf("Complete Fail: %w", err)
+		}
 	}
 	return nil
 }
 
+// Names returns the Go names of the C names registered by the packages, which are
+// recorded by the name lock file, see llcppg.NameLock.
+func (p *Converter) Names() map[string]string {
+	names := p.GenPkg.symbols.Names()
+	for _, sub := range p.SubPkgs {
+		for cname, goName := range sub.symbols.Names() {
+			names[cname] = goName
+		}
+	}
+	return names
+}
+
 func runCommand(dir, cmdName string, args ...string) error {
 	execCmd := exec.Command(cmdName, args...)
 	execCmd.Stdout = os.Stdout
@@ -188,7 +188,8 @@ func TestIdentRefer(t *testing.T) {
 						Name: &ast.Ident{Name: "Foo"},
 					},
 					Type: &ast.RecordType{
-						Tag: ast.Struct,
+						Tag:    ast.Struct,
+						HasDef: true,
 						Fields: &ast.FieldList{
 							List: []*ast.Field{
 								{
@@ -217,7 +218,8 @@ func TestIdentRefer(t *testing.T) {
 						Name: &ast.Ident{Name: "Foo"},
 					},
 					Type: &ast.RecordType{
-						Tag: ast.Struct,
+						Tag:    ast.Struct,
+						HasDef: true,
 						Fields: &ast.FieldList{
 							List: []*ast.Field{
 								{
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/goplus/llcppg/cmd/gogensig/unmarshal"
 	llcppg "github.com/goplus/llcppg/config"
 	"github.com/goplus/llcppg/internal/gowrite"
+	"github.com/goplus/llcppg/token"
 	"github.com/goplus/llgo/xtool/env"
 )
 
@@ -226,13 +228,24 @@ func testFrom(t *testing.T, dir string, gen bool, validateFunc func(t *testing.T
 	}
 
 	cvt, err := convert.NewConverter(&convert.Config{
-		PkgPath:   ".",
-		PkgName:   cfg.Name,
-		OutputDir: outputDir,
-		Pkg:       convertPkg.File,
-		NC:        cltest.NC(&cfg, convertPkg.FileMap, cltest.GetConvSym(symbPath)),
-		Deps:      cfg.Deps,
-		Libs:      cfg.Libs,
+		PkgPath:       ".",
+		PkgName:       cfg.Name,
+		OutputDir:     outputDir,
+		Pkg:           convertPkg.File,
+		NC:            cltest.NC(&cfg, convertPkg.FileMap, cltest.GetConvSym(symbPath)),
+		Deps:          cfg.Deps,
+		Libs:          cfg.Libs,
+		TypeSizes:     convertPkg.TypeSizes,
+		OpaqueExclude: cfg.OpaqueExclude,
+		AliasTypedefs: cfg.AliasTypedefs,
+		AutoAlias:     cfg.AutoAlias,
+		GoDoc:         cfg.GoDoc,
+		Includes:      cfg.Include,
+		CFlags:        cfg.CFlags,
+		GoSubclass:    cfg.GoSubclass,
+
+		NamespaceMode:   cfg.NamespaceMode,
+		StripNamespaces: cfg.StripNamespaces,
 	})
 	if err != nil {
 		t.Fatal(err)
@@ -329,6 +342,1872 @@ func TestNewConvert(t *testing.T) {
 	}
 }
 
+type convertTestCase struct {
+	name           string
+	file           *ast.File
+	symbs          []llcppg.SymbolInfo
+	cppgconf       *llcppg.Config
+	fileMap        map[string]*llcppg.FileInfo // the headers of the declarations, nil for temp.h
+	conf           *convert.Config             // the options of the converter, nil for the default ones
+	expected       string                      // temp.go
+	expectedShim   string
+	expectedFiles  map[string]string // the other Go files by their paths, like ns/temp.go of a sub-package
+	expectedErr    string
+	expectedNames  map[string]string // the names of Converter.Names, unchecked when nil
+	expectedReport string            // the report of Converter.Collisions
+}
+
+// testConvert converts the declarations by a converter of the package temp, and
+// compares its Go files and C++ shim with the expected ones.
+func testConvert(t *testing.T, tc convertTestCase) {
+	t.Helper()
+	if tc.cppgconf == nil {
+		tc.cppgconf = &llcppg.Config{Name: "temp"}
+	}
+	if tc.fileMap == nil {
+		tc.fileMap = map[string]*llcppg.FileInfo{"temp.h": {FileType: llcppg.Inter}}
+	}
+	conf := tc.conf
+	if conf == nil {
+		conf = &convert.Config{}
+	}
+	if conf.PkgPath == "" {
+		conf.PkgPath = "."
+	}
+	conf.PkgName = "temp"
+	conf.Pkg = tc.file
+	conf.NC = cltest.NC(tc.cppgconf, tc.fileMap, cltest.NewConvSym(tc.symbs...))
+	cvt, err := convert.NewConverter(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cvt.Convert()
+	if tc.expectedErr != "" {
+		compareError(t, err, tc.expectedErr)
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.expectedNames != nil {
+		if names := cvt.Names(); !reflect.DeepEqual(names, tc.expectedNames) {
+			t.Errorf("Names() = %v, want %v", names, tc.expectedNames)
+		}
+	}
+	if report := convert.CollisionReport(cvt.Collisions()); report != tc.expectedReport {
+		t.Errorf("CollisionReport() =\n%s\nwant:\n%s", report, tc.expectedReport)
+	}
+	expectedFiles := map[string]string{}
+	if tc.expected != "" {
+		expectedFiles["temp.go"] = tc.expected
+	}
+	for path, expected := range tc.expectedFiles {
+		expectedFiles[path] = expected
+	}
+	for path, expected := range expectedFiles {
+		pkg, file := cvt.GenPkg, path
+		if dir, name, ok := strings.Cut(path, "/"); ok {
+			pkg, file = nil, name
+			for _, sub := range cvt.SubPkgs {
+				if sub.Dir == dir {
+					pkg = sub.Package
+				}
+			}
+			if pkg == nil {
+				t.Errorf("no sub-package of %s", path)
+				continue
+			}
+		}
+		var buf bytes.Buffer
+		if err := pkg.Pkg().WriteTo(&buf, file); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != expected {
+			t.Errorf("%s does not match expected.\nExpected:\n%s\nGot:\n%s", path, expected, got)
+		}
+	}
+	if tc.expectedShim != "" {
+		if _, src := cvt.GenPkg.ShimFile(); string(src) != tc.expectedShim {
+			t.Errorf("ShimFile() =\n%s\nwant:\n%s", src, tc.expectedShim)
+		}
+	}
+}
+
+func TestConvertClassMethods(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "methods",
+			// class Counter {
+			//   int n;
+			// public:
+			//   int next();
+			//   int reset();
+			// };
+			// inline int Counter::reset() { return n = 0; }
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Counter"}},
+					Type: &ast.RecordType{
+						Tag:    ast.Class,
+						HasDef: true,
+						Fields: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "n"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Private},
+						}},
+						Methods: []*ast.FuncDecl{
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "next"}, Parent: &ast.Ident{Name: "Counter"}},
+								MangledName: "_ZN7Counter4nextEv",
+								Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int}},
+							},
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "reset"}, Parent: &ast.Ident{Name: "Counter"}},
+								MangledName: "_ZN7Counter5resetEv",
+								Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int}},
+							},
+						},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "reset"}, Parent: &ast.Ident{Name: "Counter"}},
+					MangledName: "_ZN7Counter5resetEv",
+					Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int}},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_ZN7Counter4nextEv", CPP: "Counter::next()", Go: "(*Counter).Next"},
+				{Mangle: "_ZN7Counter5resetEv", CPP: "Counter::reset()", Go: "(*Counter).Reset"},
+			},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Counter struct {
+	N c.Int
+}
+// llgo:link (*Counter).Next C._ZN7Counter4nextEv
+func (recv_ *Counter) Next() c.Int {
+	return 0
+}
+// llgo:link (*Counter).Reset C._ZN7Counter5resetEv
+func (recv_ *Counter) Reset() c.Int {
+	return 0
+}
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertRefParams(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "namespace functions",
+			// namespace text {
+			// struct Str { char *data; };
+			// int length(const Str &s);
+			// int consume(Str &&s, Str &&t);
+			// }
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Str"}, Parent: &ast.Ident{Name: "text"}},
+					Type: &ast.RecordType{
+						Tag:    ast.Struct,
+						HasDef: true,
+						Fields: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "data"}}, Type: &ast.PointerType{X: &ast.BuiltinType{Kind: ast.Char, Flags: ast.Signed}}, Access: ast.Public},
+						}},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "length"}, Parent: &ast.Ident{Name: "text"}},
+					MangledName: "_ZN4text6lengthERKNS_3StrE",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "s"}}, Type: &ast.LvalueRefType{X: &ast.ScopingExpr{Parent: &ast.Ident{Name: "text"}, X: &ast.Ident{Name: "Str"}}, IsConst: true}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Int},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "consume"}, Parent: &ast.Ident{Name: "text"}},
+					MangledName: "_ZN4text7consumeEONS_3StrES1_",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "s"}}, Type: &ast.RvalueRefType{X: &ast.ScopingExpr{Parent: &ast.Ident{Name: "text"}, X: &ast.Ident{Name: "Str"}}}},
+							{Names: []*ast.Ident{{Name: "t"}}, Type: &ast.RvalueRefType{X: &ast.ScopingExpr{Parent: &ast.Ident{Name: "text"}, X: &ast.Ident{Name: "Str"}}}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Int},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_ZN4text6lengthERKNS_3StrE", CPP: "text::length(const text::Str &)", Go: "TextLength"},
+				{Mangle: "_ZN4text7consumeEONS_3StrES1_", CPP: "text::consume(text::Str &&, text::Str &&)", Go: "TextConsume"},
+			},
+			conf: &convert.Config{Includes: []string{"text.h"}},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type TextStr struct {
+	Data *c.Char
+}
+// The reference parameter s must not be nil.
+//go:linkname TextLength C._ZN4text6lengthERKNS_3StrE
+func TextLength(s *TextStr) c.Int
+// The rvalue reference parameters s and t must not be nil, they may be moved from.
+//go:linkname TextConsume C.llcppg__ZN4text7consumeEONS_3StrES1_
+func TextConsume(s *TextStr, t *TextStr) c.Int
+`,
+			expectedShim: `#include <text.h>
+
+extern "C" int llcppg__ZN4text7consumeEONS_3StrES1_(text::Str &&p0, text::Str &&p1) {
+	return text::consume(static_cast<text::Str &&>(p0), static_cast<text::Str &&>(p1));
+}
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertInstantiation(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "vector",
+			// "instantiate": ["std::vector<int>"]
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp_inst.h"}, Name: &ast.Ident{Name: "vector<int>"}, Parent: &ast.Ident{Name: "std"}},
+					Type: &ast.RecordType{
+						Tag:    ast.Class,
+						HasDef: true,
+						Fields: &ast.FieldList{List: []*ast.Field{
+							{
+								Names:  []*ast.Ident{{Name: "Unused"}},
+								Type:   &ast.ArrayType{Elt: &ast.BuiltinType{Kind: ast.Int, Flags: ast.Unsigned | ast.LongLong}, Len: &ast.BasicLit{Kind: ast.IntLit, Value: "3"}},
+								Access: ast.Private,
+							},
+						}},
+						Methods: []*ast.FuncDecl{
+							{
+								Object:        ast.Object{Loc: &ast.Location{File: "temp_inst.h"}, Name: &ast.Ident{Name: "vector"}, Parent: &ast.ScopingExpr{Parent: &ast.Ident{Name: "std"}, X: &ast.Ident{Name: "vector<int>"}}},
+								MangledName:   "_ZNSt6vectorIiSaIiEEC1Ev",
+								Type:          &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Void}},
+								IsConstructor: true,
+							},
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp_inst.h"}, Name: &ast.Ident{Name: "resize"}, Parent: &ast.ScopingExpr{Parent: &ast.Ident{Name: "std"}, X: &ast.Ident{Name: "vector<int>"}}},
+								MangledName: "_ZNSt6vectorIiSaIiEE6resizeEm",
+								Type: &ast.FuncType{
+									Params: &ast.FieldList{List: []*ast.Field{
+										{Names: []*ast.Ident{{Name: "n"}}, Type: &ast.BuiltinType{Kind: ast.Int, Flags: ast.Unsigned | ast.Long}},
+									}},
+									Ret: &ast.BuiltinType{Kind: ast.Void},
+								},
+							},
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp_inst.h"}, Name: &ast.Ident{Name: "size"}, Parent: &ast.ScopingExpr{Parent: &ast.Ident{Name: "std"}, X: &ast.Ident{Name: "vector<int>"}}},
+								MangledName: "_ZNKSt6vectorIiSaIiEE4sizeEv",
+								Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int, Flags: ast.Unsigned | ast.Long}},
+							},
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp_inst.h"}, Name: &ast.Ident{Name: "get_allocator"}, Parent: &ast.ScopingExpr{Parent: &ast.Ident{Name: "std"}, X: &ast.Ident{Name: "vector<int>"}}},
+								MangledName: "_ZNKSt6vectorIiSaIiEE13get_allocatorEv",
+								Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.ScopingExpr{Parent: &ast.Ident{Name: "std"}, X: &ast.Ident{Name: "allocator<int>"}}},
+							},
+							{
+								Object:       ast.Object{Loc: &ast.Location{File: "temp_inst.h"}, Name: &ast.Ident{Name: "~vector"}, Parent: &ast.ScopingExpr{Parent: &ast.Ident{Name: "std"}, X: &ast.Ident{Name: "vector<int>"}}},
+								MangledName:  "_ZNSt6vectorIiSaIiEED1Ev",
+								Type:         &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Void}},
+								IsDestructor: true,
+							},
+						},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_ZNSt6vectorIiSaIiEEC1Ev", CPP: "std::vector<int>::vector()", Go: "(*VectorInt).Init"},
+				{Mangle: "_ZNSt6vectorIiSaIiEE6resizeEm", CPP: "std::vector<int>::resize(unsigned long)", Go: "(*VectorInt).Resize"},
+				{Mangle: "_ZNKSt6vectorIiSaIiEE4sizeEv", CPP: "std::vector<int>::size()", Go: "(*VectorInt).Size"},
+				{Mangle: "_ZNKSt6vectorIiSaIiEE13get_allocatorEv", CPP: "std::vector<int>::get_allocator()", Go: "(*VectorInt).GetAllocator"},
+				{Mangle: "_ZNSt6vectorIiSaIiEED1Ev", CPP: "std::vector<int>::~vector()", Go: "(*VectorInt).Dispose"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true},
+			fileMap:  map[string]*llcppg.FileInfo{"temp_inst.h": {FileType: llcppg.Impl}},
+			conf:     &convert.Config{Includes: []string{"vector"}},
+			expectedFiles: map[string]string{"temp_autogen.go": `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type VectorInt struct {
+	Unused [3]c.UlongLong
+}
+// llgo:link (*VectorInt).Init C._ZNSt6vectorIiSaIiEEC1Ev
+func (recv_ *VectorInt) Init() {
+}
+// llgo:link (*VectorInt).Resize C._ZNSt6vectorIiSaIiEE6resizeEm
+func (recv_ *VectorInt) Resize(n c.Ulong) {
+}
+// llgo:link (*VectorInt).Size C._ZNKSt6vectorIiSaIiEE4sizeEv
+func (recv_ *VectorInt) Size() c.Ulong {
+	return 0
+}
+// llgo:link (*VectorInt).Dispose C._ZNSt6vectorIiSaIiEED1Ev
+func (recv_ *VectorInt) Dispose() {
+}
+`},
+			expectedShim: `#include <vector>
+
+template class std::vector<int>;
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertStdBridge(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "string and vector",
+			// std::string greet(const std::string &name, std::vector<int> nums);
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "greet"}},
+					MangledName: "_Z5greetRKSsSt6vectorIiSaIiEE",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "name"}}, Type: &ast.LvalueRefType{X: &ast.ScopingExpr{Parent: &ast.Ident{Name: "std"}, X: &ast.Ident{Name: "string"}}, IsConst: true}},
+							{Names: []*ast.Ident{{Name: "nums"}}, Type: &ast.InstantiationType{
+								Template: &ast.ScopingExpr{Parent: &ast.Ident{Name: "std"}, X: &ast.Ident{Name: "vector"}},
+								Args:     &ast.FieldList{List: []*ast.Field{{Type: &ast.BuiltinType{Kind: ast.Int}}}},
+							}},
+						}},
+						Ret: &ast.ScopingExpr{Parent: &ast.Ident{Name: "std"}, X: &ast.Ident{Name: "string"}},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_Z5greetRKSsSt6vectorIiSaIiEE", CPP: "greet(const std::string&, std::vector<int>)", Go: "Greet"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true},
+			conf: &convert.Config{
+				TypeSizes:   &llcppg.TypeSizes{StdString: 32},
+				Includes:    []string{"temp.h"},
+				StdWrappers: true,
+			},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	"unsafe"
+)
+// The reference parameters name and nums must not be nil.
+//go:linkname Greet C.llcppg__Z5greetRKSsSt6vectorIiSaIiEE
+func Greet(name *StdString, nums *StdVectorInt) StdString
+// GreetGo calls Greet with the Go strings and slices.
+func GreetGo(name string, nums []c.Int) string {
+	var name_ StdString
+	name_.Init(c.GoStringData(name), uintptr(len(name)))
+	defer name_.Dispose()
+	var nums_ StdVectorInt
+	nums_.InitFrom(unsafe.SliceData(nums), uintptr(len(nums)))
+	defer nums_.Dispose()
+	ret_ := Greet(&name_, &nums_)
+	defer ret_.Dispose()
+	return c.GoString(ret_.Data(), ret_.Size())
+}
+`,
+			expectedFiles: map[string]string{"temp_autogen_types.go": `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+// StdString is the C++ std::string, whose objects are initialized by Init and destroyed by Dispose.
+type StdString struct {
+	_      noCopy
+	Unused [4]uintptr
+}
+// noCopy marks the opaque structs, which must only be used through pointers.
+type noCopy struct {
+}
+
+func (*noCopy) Lock() {
+}
+func (*noCopy) Unlock() {
+}
+// llgo:link (*StdString).Init C.llcppg_StdString_Init
+func (recv_ *StdString) Init(data *c.Char, n uintptr) {
+}
+// llgo:link (*StdString).CStr C.llcppg_StdString_CStr
+func (recv_ *StdString) CStr() *c.Char {
+	return nil
+}
+// llgo:link (*StdString).Data C.llcppg_StdString_Data
+func (recv_ *StdString) Data() *c.Char {
+	return nil
+}
+// llgo:link (*StdString).Size C.llcppg_StdString_Size
+func (recv_ *StdString) Size() uintptr {
+	return 0
+}
+// llgo:link (*StdString).Dispose C.llcppg_StdString_Dispose
+func (recv_ *StdString) Dispose() {
+}
+// StdVectorInt is the C++ std::vector<int>, whose objects are initialized by Init and destroyed by Dispose.
+type StdVectorInt struct {
+	_      noCopy
+	Unused [3]uintptr
+}
+// llgo:link (*StdVectorInt).Init C.llcppg_StdVectorInt_Init
+func (recv_ *StdVectorInt) Init() {
+}
+// llgo:link (*StdVectorInt).InitFrom C.llcppg_StdVectorInt_InitFrom
+func (recv_ *StdVectorInt) InitFrom(data *c.Int, n uintptr) {
+}
+// llgo:link (*StdVectorInt).Data C.llcppg_StdVectorInt_Data
+func (recv_ *StdVectorInt) Data() *c.Int {
+	return nil
+}
+// llgo:link (*StdVectorInt).PushBack C.llcppg_StdVectorInt_PushBack
+func (recv_ *StdVectorInt) PushBack(v c.Int) {
+}
+// llgo:link (*StdVectorInt).Size C.llcppg_StdVectorInt_Size
+func (recv_ *StdVectorInt) Size() uintptr {
+	return 0
+}
+// llgo:link (*StdVectorInt).Dispose C.llcppg_StdVectorInt_Dispose
+func (recv_ *StdVectorInt) Dispose() {
+}
+`},
+			expectedShim: `#include <temp.h>
+#include <new>
+#include <string>
+#include <vector>
+
+extern "C" std::string llcppg__Z5greetRKSsSt6vectorIiSaIiEE(std::string const &p0, std::vector<int> const &p1) {
+	return greet(p0, p1);
+}
+
+typedef std::string llcppg_StdString;
+
+extern "C" void llcppg_StdString_Init(llcppg_StdString *self, const char *data, size_t n) {
+	new (self) llcppg_StdString(data, n);
+}
+
+extern "C" const char *llcppg_StdString_CStr(llcppg_StdString *self) {
+	return self->c_str();
+}
+
+extern "C" const char *llcppg_StdString_Data(llcppg_StdString *self) {
+	return self->data();
+}
+
+extern "C" size_t llcppg_StdString_Size(llcppg_StdString *self) {
+	return self->size();
+}
+
+extern "C" void llcppg_StdString_Dispose(llcppg_StdString *self) {
+	self->~llcppg_StdString();
+}
+
+typedef std::vector<int> llcppg_StdVectorInt;
+
+extern "C" void llcppg_StdVectorInt_Init(llcppg_StdVectorInt *self) {
+	new (self) llcppg_StdVectorInt();
+}
+
+extern "C" void llcppg_StdVectorInt_InitFrom(llcppg_StdVectorInt *self, int const *data, size_t n) {
+	new (self) llcppg_StdVectorInt(data, data + n);
+}
+
+extern "C" int *llcppg_StdVectorInt_Data(llcppg_StdVectorInt *self) {
+	return self->data();
+}
+
+extern "C" void llcppg_StdVectorInt_PushBack(llcppg_StdVectorInt *self, int v) {
+	self->push_back(v);
+}
+
+extern "C" size_t llcppg_StdVectorInt_Size(llcppg_StdVectorInt *self) {
+	return self->size();
+}
+
+extern "C" void llcppg_StdVectorInt_Dispose(llcppg_StdVectorInt *self) {
+	self->~llcppg_StdVectorInt();
+}
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertDefaultArgs(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "overloads",
+			// enum Color { Black };
+			// void draw(int x, int y_id = 0, Color c = Color::Black);
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.EnumTypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Color"}},
+					Type: &ast.EnumType{Items: []*ast.EnumItem{
+						{Name: &ast.Ident{Name: "Black"}, Value: &ast.BasicLit{Kind: ast.IntLit, Value: "0"}},
+					}},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "draw"}},
+					MangledName: "_Z4drawii5Color",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "x"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+							{Names: []*ast.Ident{{Name: "y_id"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Default: &ast.BasicLit{Kind: ast.IntLit, Value: "0"}},
+							{Names: []*ast.Ident{{Name: "c"}}, Type: &ast.Ident{Name: "Color"}, Default: &ast.ScopingExpr{Parent: &ast.Ident{Name: "Color"}, X: &ast.Ident{Name: "Black"}}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_Z4drawii5Color", CPP: "draw(int, int, Color)", Go: "Draw"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true, Naming: &llcppg.Naming{Initialisms: []string{"ID"}}},
+			conf: &convert.Config{
+				Includes:    []string{"temp.h"},
+				DefaultArgs: map[string]string{"draw": llcppg.DefaultArgsOverloads},
+			},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Color c.Int
+
+const Black Color = 0
+//go:linkname Draw C._Z4drawii5Color
+func Draw(x c.Int, y_id c.Int, c Color)
+//go:linkname DrawWithX C.llcppg__Z4drawii5Color_1
+func DrawWithX(x c.Int)
+//go:linkname DrawWithYID C.llcppg__Z4drawii5Color_2
+func DrawWithYID(x c.Int, y_id c.Int)
+`,
+			expectedShim: `#include <temp.h>
+
+extern "C" void llcppg__Z4drawii5Color_1(int p0) {
+	draw(p0);
+}
+
+extern "C" void llcppg__Z4drawii5Color_2(int p0, int p1) {
+	draw(p0, p1);
+}
+`,
+		},
+		{
+			name: "overloads of no arguments",
+			// void clear(int color = 0);
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "clear"}},
+					MangledName: "_Z5cleari",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "color"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Default: &ast.BasicLit{Kind: ast.IntLit, Value: "0"}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_Z5cleari", CPP: "clear(int)", Go: "Clear"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true},
+			conf: &convert.Config{
+				Includes:    []string{"temp.h"},
+				DefaultArgs: map[string]string{"clear": llcppg.DefaultArgsOverloads},
+			},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+//go:linkname Clear C._Z5cleari
+func Clear(color c.Int)
+//go:linkname Clear0 C.llcppg__Z5cleari_0
+func Clear0()
+`,
+			expectedShim: `#include <temp.h>
+
+extern "C" void llcppg__Z5cleari_0() {
+	clear();
+}
+`,
+		},
+		{
+			name: "options",
+			// enum Color { Black };
+			// class Canvas {
+			//   int id;
+			// public:
+			//   void fill(const Color &c = Color::Black, double alpha = 1.0);
+			// };
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.EnumTypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Color"}},
+					Type: &ast.EnumType{Items: []*ast.EnumItem{
+						{Name: &ast.Ident{Name: "Black"}, Value: &ast.BasicLit{Kind: ast.IntLit, Value: "0"}},
+					}},
+				},
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Canvas"}},
+					Type: &ast.RecordType{
+						Tag:    ast.Class,
+						HasDef: true,
+						Fields: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "id"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Private},
+						}},
+						Methods: []*ast.FuncDecl{
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "fill"}, Parent: &ast.Ident{Name: "Canvas"}},
+								MangledName: "_ZN6Canvas4fillERK5Colord",
+								Type: &ast.FuncType{
+									Params: &ast.FieldList{List: []*ast.Field{
+										{Names: []*ast.Ident{{Name: "c"}}, Type: &ast.LvalueRefType{X: &ast.Ident{Name: "Color"}, IsConst: true}, Default: &ast.ScopingExpr{Parent: &ast.Ident{Name: "Color"}, X: &ast.Ident{Name: "Black"}}},
+										{Names: []*ast.Ident{{Name: "alpha"}}, Type: &ast.BuiltinType{Kind: ast.Float, Flags: ast.Double}, Default: &ast.BasicLit{Kind: ast.FloatLit, Value: "1.0"}},
+									}},
+									Ret: &ast.BuiltinType{Kind: ast.Void},
+								},
+							},
+						},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_ZN6Canvas4fillERK5Colord", CPP: "Canvas::fill(const Color&, double)", Go: "(*Canvas).Fill"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true, Naming: &llcppg.Naming{Initialisms: []string{"ID"}}},
+			conf: &convert.Config{
+				Includes:    []string{"temp.h"},
+				DefaultArgs: map[string]string{"Canvas::fill": llcppg.DefaultArgsOptions},
+			},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Color c.Int
+
+const Black Color = 0
+
+type Canvas struct {
+	ID c.Int
+}
+// The reference parameter c must not be nil.
+// llgo:link (*Canvas).Fill C._ZN6Canvas4fillERK5Colord
+func (recv_ *Canvas) Fill(c *Color, alpha c.Double) {
+}
+// CanvasFillOptions holds the default arguments of FillWithOptions, a nil field passes the default one.
+type CanvasFillOptions struct {
+	C     *Color
+	Alpha *c.Double
+}
+// llgo:link (*Canvas).FillWithOptions C.llcppg__ZN6Canvas4fillERK5Colord_options
+func (recv_ *Canvas) FillWithOptions(opts *CanvasFillOptions) {
+}
+`,
+			expectedShim: `#include <temp.h>
+
+struct llcppg__ZN6Canvas4fillERK5Colord_options_t {
+	Color *c;
+	double *alpha;
+};
+
+extern "C" void llcppg__ZN6Canvas4fillERK5Colord_options(Canvas *self, const llcppg__ZN6Canvas4fillERK5Colord_options_t *opts) {
+	self->fill(opts && opts->c ? *opts->c : Color::Black, opts && opts->alpha ? *opts->alpha : 1.0);
+}
+`,
+		},
+		{
+			name: "options of a class-scope enum",
+			// class Canvas {
+			// public:
+			//   enum Mode { Fast };
+			//   void fill(Mode m = Fast);
+			// };
+			// where Fast is qualified by the parser.
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.EnumTypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Mode"}, Parent: &ast.Ident{Name: "Canvas"}},
+					Type: &ast.EnumType{Items: []*ast.EnumItem{
+						{Name: &ast.Ident{Name: "Fast"}, Value: &ast.BasicLit{Kind: ast.IntLit, Value: "0"}},
+					}},
+				},
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Canvas"}},
+					Type: &ast.RecordType{
+						Tag:    ast.Class,
+						HasDef: true,
+						Fields: &ast.FieldList{},
+						Methods: []*ast.FuncDecl{
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "fill"}, Parent: &ast.Ident{Name: "Canvas"}},
+								MangledName: "_ZN6Canvas4fillENS_4ModeE",
+								Type: &ast.FuncType{
+									Params: &ast.FieldList{List: []*ast.Field{
+										{
+											Names:   []*ast.Ident{{Name: "m"}},
+											Type:    &ast.ScopingExpr{Parent: &ast.Ident{Name: "Canvas"}, X: &ast.Ident{Name: "Mode"}},
+											Default: &ast.ScopingExpr{Parent: &ast.ScopingExpr{Parent: &ast.Ident{Name: "Canvas"}, X: &ast.Ident{Name: "Mode"}}, X: &ast.Ident{Name: "Fast"}},
+										},
+									}},
+									Ret: &ast.BuiltinType{Kind: ast.Void},
+								},
+							},
+						},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_ZN6Canvas4fillENS_4ModeE", CPP: "Canvas::fill(Canvas::Mode)", Go: "(*Canvas).Fill"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true},
+			conf: &convert.Config{
+				Includes:    []string{"temp.h"},
+				DefaultArgs: map[string]string{"Canvas::fill": llcppg.DefaultArgsOptions},
+			},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type CanvasMode c.Int
+
+const CanvasFast CanvasMode = 0
+
+type Canvas struct {
+}
+// llgo:link (*Canvas).Fill C._ZN6Canvas4fillENS_4ModeE
+func (recv_ *Canvas) Fill(m CanvasMode) {
+}
+// CanvasFillOptions holds the default arguments of FillWithOptions, a nil field passes the default one.
+type CanvasFillOptions struct {
+	M *CanvasMode
+}
+// llgo:link (*Canvas).FillWithOptions C.llcppg__ZN6Canvas4fillENS_4ModeE_options
+func (recv_ *Canvas) FillWithOptions(opts *CanvasFillOptions) {
+}
+`,
+			expectedShim: `#include <temp.h>
+
+struct llcppg__ZN6Canvas4fillENS_4ModeE_options_t {
+	Canvas::Mode *m;
+};
+
+extern "C" void llcppg__ZN6Canvas4fillENS_4ModeE_options(Canvas *self, const llcppg__ZN6Canvas4fillENS_4ModeE_options_t *opts) {
+	self->fill(opts && opts->m ? *opts->m : Canvas::Mode::Fast);
+}
+`,
+		},
+		{
+			name: "options of an expression of names",
+			// const int kScale = 2;
+			// void zoom(int factor = kScale * 2);
+			// where kScale * 2 is kept as its tokens.
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "zoom"}},
+					MangledName: "_Z4zoomi",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{
+								Names: []*ast.Ident{{Name: "factor"}},
+								Type:  &ast.BuiltinType{Kind: ast.Int},
+								Default: &ast.RawExpr{Tokens: []*ast.Token{
+									{Token: token.IDENT, Lit: "kScale"}, {Token: token.PUNCT, Lit: "*"}, {Token: token.LITERAL, Lit: "2"},
+								}},
+							},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_Z4zoomi", CPP: "zoom(int)", Go: "Zoom"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true},
+			conf: &convert.Config{
+				Includes:    []string{"temp.h"},
+				DefaultArgs: map[string]string{"zoom": llcppg.DefaultArgsOptions},
+			},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+//go:linkname Zoom C._Z4zoomi
+func Zoom(factor c.Int)
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertScopedEnum(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "same items",
+			// enum class Color { None, Red }; enum class Shape { None, Circle };
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.EnumTypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Color"}},
+					Type: &ast.EnumType{IsScoped: true, Items: []*ast.EnumItem{
+						{Name: &ast.Ident{Name: "None"}, Value: &ast.BasicLit{Kind: ast.IntLit, Value: "0"}},
+						{Name: &ast.Ident{Name: "Red"}, Value: &ast.BasicLit{Kind: ast.IntLit, Value: "1"}},
+					}},
+				},
+				&ast.EnumTypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Shape"}},
+					Type: &ast.EnumType{IsScoped: true, Items: []*ast.EnumItem{
+						{Name: &ast.Ident{Name: "None"}, Value: &ast.BasicLit{Kind: ast.IntLit, Value: "0"}},
+						{Name: &ast.Ident{Name: "Circle"}, Value: &ast.BasicLit{Kind: ast.IntLit, Value: "1"}},
+					}},
+				},
+			}},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Color c.Int
+
+const (
+	ColorNone Color = 0
+	ColorRed  Color = 1
+)
+
+type Shape c.Int
+
+const (
+	ShapeNone   Shape = 0
+	ShapeCircle Shape = 1
+)
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertStaticMembers(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "variables and constexpr",
+			// class Shape {
+			// public:
+			//   static int count;
+			//   static constexpr int max = 8;
+			// private:
+			//   static int seed;
+			//   int id;
+			// };
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Shape"}},
+					Type: &ast.RecordType{
+						Tag:    ast.Class,
+						HasDef: true,
+						Fields: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "count"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Public, IsStatic: true, MangledName: "_ZN5Shape5countE"},
+							{Names: []*ast.Ident{{Name: "max"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Public, IsStatic: true, IsConstexpr: true},
+							{Names: []*ast.Ident{{Name: "seed"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Private, IsStatic: true, MangledName: "_ZN5Shape4seedE"},
+							{Names: []*ast.Ident{{Name: "id"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Private},
+						}},
+					},
+				},
+			}},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true},
+			conf:     &convert.Config{Includes: []string{"temp.h"}},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Shape struct {
+	Id c.Int
+}
+//go:linkname ShapeCount _ZN5Shape5countE
+var ShapeCount c.Int
+//go:linkname ShapeMax C.llcppg_Shape_max
+func ShapeMax() c.Int
+`,
+			expectedShim: `#include <temp.h>
+
+extern "C" int llcppg_Shape_max() {
+	return Shape::max;
+}
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertSymVersions(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "pinned",
+			// int foo(void); int bar(void);
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "foo"}},
+					MangledName: "foo",
+					Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int}},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "bar"}},
+					MangledName: "bar",
+					Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int}},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "foo", CPP: "foo()", Go: "Foo", Version: "VERS_2", Versions: []string{"VERS_1"}},
+				{Mangle: "bar", CPP: "bar()", Go: "Bar", Version: "VERS_1"},
+			},
+			conf: &convert.Config{SymVersions: map[string]string{"foo": "VERS_1"}},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+//go:linkname Foo C.foo@VERS_1
+func Foo() c.Int
+//go:linkname Bar C.bar
+func Bar() c.Int
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertSplitLibs(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "sub-package of a library",
+			// struct Item { int v; };
+			// int item_size(struct Item *item); // -ltemp
+			// int item_print(struct Item *item); // -ltemp_utils
+			// int utils_version(void); // -ltemp_utils
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Item"}},
+					Type: &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+					}}},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "item_size"}},
+					MangledName: "item_size",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "item"}}, Type: &ast.PointerType{X: &ast.Ident{Name: "Item"}}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Int},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "item_print"}},
+					MangledName: "item_print",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "item"}}, Type: &ast.PointerType{X: &ast.Ident{Name: "Item"}}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Int},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "utils_version"}},
+					MangledName: "utils_version",
+					Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int}},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "item_size", CPP: "item_size(Item *)", Go: "(*Item).Size", Lib: "temp"},
+				{Mangle: "item_print", CPP: "item_print(Item *)", Go: "(*Item).Print", Lib: "temp_utils"},
+				{Mangle: "utils_version", CPP: "utils_version()", Go: "UtilsVersion", Lib: "temp_utils"},
+			},
+			conf: &convert.Config{
+				PkgPath:   "example.com/temp",
+				Libs:      "-L/opt/lib -ltemp -ltemp_utils",
+				SplitLibs: true,
+				SymbolLibs: map[string]string{
+					"item_size":     "temp",
+					"item_print":    "temp_utils",
+					"utils_version": "temp_utils",
+				},
+			},
+			expectedFiles: map[string]string{
+				"temp_autogen_link.go": `package temp
+
+import _ "github.com/goplus/lib/c"
+
+const LLGoPackage string = "link: -L/opt/lib -ltemp;"
+`,
+				"temp_utils/temp_utils_autogen_link.go": `package temp_utils
+
+import _ "github.com/goplus/lib/c"
+
+const LLGoPackage string = "link: -L/opt/lib -ltemp_utils;"
+`,
+				"temp_utils/temp.go": `package temp_utils
+
+import (
+	"example.com/temp"
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+//go:linkname Print C.item_print
+func Print(item *temp.Item) c.Int
+//go:linkname UtilsVersion C.utils_version
+func UtilsVersion() c.Int
+`,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertLockedNames(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "unlocked",
+			// struct foo_bar { int v; }; struct fooBar { int v; };
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "foo_bar"}},
+					Type: &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+					}}},
+				},
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "fooBar"}},
+					Type: &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+					}}},
+				},
+			}},
+			expectedNames: map[string]string{"type foo_bar": "FooBar", "type fooBar": "FooBar__1"},
+			expectedReport: `Go names of several C declarations, resolve them by the collisions of llcppg.cfg:
+	FooBar:
+		type foo_bar (temp.h) -> FooBar
+		type fooBar (temp.h) -> FooBar__1
+`,
+		},
+		{
+			name: "locked",
+			// struct foo_bar { int v; }; struct fooBar { int v; };
+			// where struct fooBar was declared before struct foo_bar in the previous run
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "foo_bar"}},
+					Type: &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+					}}},
+				},
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "fooBar"}},
+					Type: &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+					}}},
+				},
+			}},
+			conf:          &convert.Config{LockedNames: map[string]string{"type fooBar": "FooBar", "type foo_bar": "FooBar__1"}},
+			expectedNames: map[string]string{"type fooBar": "FooBar", "type foo_bar": "FooBar__1"},
+		},
+		{
+			name: "locked name removed",
+			// struct foo_bar { int v; };
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "foo_bar"}},
+					Type: &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+					}}},
+				},
+			}},
+			conf:        &convert.Config{LockedNames: map[string]string{"type foo_bar": "FooBar", "type gone": "Gone"}},
+			expectedErr: "locked names not found, remove them from llcppg.lock.json to drop them: type gone (Gone)",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertCollisions(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "reported",
+			// struct foo { int v; }; in foo.h and #define Foo 1 in bar.h, where the macros
+			// are converted first
+			file: &ast.File{
+				Decls: []ast.Decl{&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "foo.h"}, Name: &ast.Ident{Name: "foo"}},
+					Type: &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+					}}},
+				}},
+				Macros: []*ast.Macro{{
+					Loc:    &ast.Location{File: "bar.h"},
+					Name:   "Foo",
+					Tokens: []*ast.Token{{Token: token.IDENT, Lit: "Foo"}, {Token: token.LITERAL, Lit: "1"}},
+				}},
+			},
+			fileMap:       map[string]*llcppg.FileInfo{"foo.h": {FileType: llcppg.Inter}, "bar.h": {FileType: llcppg.Inter}},
+			expectedNames: map[string]string{"macro Foo": "Foo", "type foo": "Foo__1"},
+			expectedReport: `Go names of several C declarations, resolve them by the collisions of llcppg.cfg:
+	Foo:
+		macro Foo (bar.h) -> Foo
+		type foo (foo.h) -> Foo__1
+`,
+		},
+		{
+			name: "prefer",
+			// struct foo { int v; }; in foo.h and #define Foo 1 in bar.h
+			file: &ast.File{
+				Decls: []ast.Decl{&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "foo.h"}, Name: &ast.Ident{Name: "foo"}},
+					Type: &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+					}}},
+				}},
+				Macros: []*ast.Macro{{
+					Loc:    &ast.Location{File: "bar.h"},
+					Name:   "Foo",
+					Tokens: []*ast.Token{{Token: token.IDENT, Lit: "Foo"}, {Token: token.LITERAL, Lit: "1"}},
+				}},
+			},
+			fileMap:       map[string]*llcppg.FileInfo{"foo.h": {FileType: llcppg.Inter}, "bar.h": {FileType: llcppg.Inter}},
+			cppgconf:      &llcppg.Config{Name: "temp", Collisions: llcppg.Collisions{"Foo": {Prefer: "foo"}}},
+			conf:          &convert.Config{Collisions: llcppg.Collisions{"Foo": {Prefer: "foo"}}},
+			expectedNames: map[string]string{"macro Foo": "Foo__1", "type foo": "Foo"},
+		},
+		{
+			name: "rename",
+			// struct foo { int v; }; in foo.h and #define Foo 1 in bar.h
+			file: &ast.File{
+				Decls: []ast.Decl{&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "foo.h"}, Name: &ast.Ident{Name: "foo"}},
+					Type: &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+					}}},
+				}},
+				Macros: []*ast.Macro{{
+					Loc:    &ast.Location{File: "bar.h"},
+					Name:   "Foo",
+					Tokens: []*ast.Token{{Token: token.IDENT, Lit: "Foo"}, {Token: token.LITERAL, Lit: "1"}},
+				}},
+			},
+			fileMap:       map[string]*llcppg.FileInfo{"foo.h": {FileType: llcppg.Inter}, "bar.h": {FileType: llcppg.Inter}},
+			cppgconf:      &llcppg.Config{Name: "temp", Collisions: llcppg.Collisions{"Foo": {Rename: map[string]string{"Foo": "FooValue"}}}},
+			conf:          &convert.Config{Collisions: llcppg.Collisions{"Foo": {Rename: map[string]string{"Foo": "FooValue"}}}},
+			expectedNames: map[string]string{"macro Foo": "FooValue", "type foo": "Foo"},
+		},
+		{
+			name: "ignore",
+			// struct foo { int v; }; in foo.h and #define Foo 1 in bar.h
+			file: &ast.File{
+				Decls: []ast.Decl{&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "foo.h"}, Name: &ast.Ident{Name: "foo"}},
+					Type: &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+					}}},
+				}},
+				Macros: []*ast.Macro{{
+					Loc:    &ast.Location{File: "bar.h"},
+					Name:   "Foo",
+					Tokens: []*ast.Token{{Token: token.IDENT, Lit: "Foo"}, {Token: token.LITERAL, Lit: "1"}},
+				}},
+			},
+			fileMap:       map[string]*llcppg.FileInfo{"foo.h": {FileType: llcppg.Inter}, "bar.h": {FileType: llcppg.Inter}},
+			cppgconf:      &llcppg.Config{Name: "temp", Collisions: llcppg.Collisions{"Foo": {Ignore: []string{"Foo"}}}},
+			conf:          &convert.Config{Collisions: llcppg.Collisions{"Foo": {Ignore: []string{"Foo"}}}},
+			expectedNames: map[string]string{"type foo": "Foo"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertFuncCollisions(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "overloads and another function",
+			// void draw(int x); void draw(float x); void Draw(int x);
+			// where the overloads are all converted, and claim the Go names suffixed by llcppsymg
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "draw"}},
+					MangledName: "_Z4drawi",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{{Name: "x"}}, Type: &ast.BuiltinType{Kind: ast.Int}}}},
+						Ret:    &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "draw"}},
+					MangledName: "_Z4drawf",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{{Name: "x"}}, Type: &ast.BuiltinType{Kind: ast.Float}}}},
+						Ret:    &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Draw"}},
+					MangledName: "_Z4Drawi",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{{Name: "x"}}, Type: &ast.BuiltinType{Kind: ast.Int}}}},
+						Ret:    &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_Z4drawi", CPP: "draw(int)", Go: "Draw"},
+				{Mangle: "_Z4drawf", CPP: "draw(float)", Go: "Draw__1"},
+				{Mangle: "_Z4Drawi", CPP: "Draw(int)", Go: "Draw__2"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+//go:linkname Draw C._Z4drawi
+func Draw(x c.Int)
+//go:linkname Draw__1 C._Z4drawf
+func Draw__1(x c.Float)
+//go:linkname Draw__2 C._Z4Drawi
+func Draw__2(x c.Int)
+`,
+			expectedReport: `Go names of several C declarations, resolve them by the collisions of llcppg.cfg:
+	Draw:
+		func draw (temp.h) -> Draw
+		func draw (temp.h) -> Draw__1
+		func Draw (temp.h) -> Draw__2
+`,
+		},
+		{
+			name: "overloads",
+			// void draw(int x); void draw(float x);
+			// which can only be suffixed, and are not reported
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "draw"}},
+					MangledName: "_Z4drawi",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{{Name: "x"}}, Type: &ast.BuiltinType{Kind: ast.Int}}}},
+						Ret:    &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "draw"}},
+					MangledName: "_Z4drawf",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{{Name: "x"}}, Type: &ast.BuiltinType{Kind: ast.Float}}}},
+						Ret:    &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_Z4drawi", CPP: "draw(int)", Go: "Draw"},
+				{Mangle: "_Z4drawf", CPP: "draw(float)", Go: "Draw__1"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true},
+		},
+		{
+			name: "preferred",
+			// void draw(int x); void draw(float x); void Draw(int x);
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "draw"}},
+					MangledName: "_Z4drawi",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{{Name: "x"}}, Type: &ast.BuiltinType{Kind: ast.Int}}}},
+						Ret:    &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "draw"}},
+					MangledName: "_Z4drawf",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{{Name: "x"}}, Type: &ast.BuiltinType{Kind: ast.Float}}}},
+						Ret:    &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Draw"}},
+					MangledName: "_Z4Drawi",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{{Name: "x"}}, Type: &ast.BuiltinType{Kind: ast.Int}}}},
+						Ret:    &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_Z4drawi", CPP: "draw(int)", Go: "Draw"},
+				{Mangle: "_Z4drawf", CPP: "draw(float)", Go: "Draw__1"},
+				{Mangle: "_Z4Drawi", CPP: "Draw(int)", Go: "Draw__2"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", Cplusplus: true, Collisions: llcppg.Collisions{"Draw": {Prefer: "Draw"}}},
+			conf:     &convert.Config{Collisions: llcppg.Collisions{"Draw": {Prefer: "Draw"}}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+func TestConvertGoSubclass(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "overrides",
+			// class Base {
+			// public:
+			//   virtual int next();
+			//   virtual int reset();
+			// };
+			// class Derived : public Base {
+			// public:
+			//   int next() override;
+			// };
+			// whose reset is renamed Restart by the symbol table.
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Base"}},
+					Type: &ast.RecordType{
+						Tag:    ast.Class,
+						HasDef: true,
+						Fields: &ast.FieldList{},
+						Methods: []*ast.FuncDecl{
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "next"}},
+								MangledName: "_ZN4Base4nextEv",
+								Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int}},
+								IsVirtual:   true,
+							},
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "reset"}},
+								MangledName: "_ZN4Base5resetEv",
+								Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int}},
+								IsVirtual:   true,
+							},
+						},
+					},
+				},
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Derived"}},
+					Type: &ast.RecordType{
+						Tag:    ast.Class,
+						HasDef: true,
+						Fields: &ast.FieldList{List: []*ast.Field{
+							{Type: &ast.Ident{Name: "Base"}, Access: ast.Public, IsBase: true},
+						}},
+						Methods: []*ast.FuncDecl{
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "next"}},
+								MangledName: "_ZN7Derived4nextEv",
+								Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int}},
+								IsVirtual:   true,
+								IsOverride:  true,
+							},
+						},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_ZN4Base4nextEv", CPP: "Base::next()", Go: "(*Base).Next"},
+				{Mangle: "_ZN4Base5resetEv", CPP: "Base::reset()", Go: "(*Base).Restart"},
+				{Mangle: "_ZN7Derived4nextEv", CPP: "Derived::next()", Go: "(*Derived).Next"},
+			},
+			conf: &convert.Config{Includes: []string{"temp.h"}, GoSubclass: []string{"Derived"}},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Base struct {
+	_ c.Pointer
+}
+// llgo:link (*Base).Next C.llcppg__ZN4Base4nextEv
+func (recv_ *Base) Next() c.Int {
+	return 0
+}
+// llgo:link (*Base).Restart C.llcppg__ZN4Base5resetEv
+func (recv_ *Base) Restart() c.Int {
+	return 0
+}
+
+type Derived struct {
+	Base
+}
+// llgo:link (*Derived).Next C.llcppg__ZN7Derived4nextEv
+func (recv_ *Derived) Next() c.Int {
+	return 0
+}
+// llgo:type C
+type DerivedNextFunc func(obj c.Pointer) c.Int
+// llgo:type C
+type DerivedRestartFunc func(obj c.Pointer) c.Int
+
+type DerivedOverrides struct {
+	Next    DerivedNextFunc
+	Restart DerivedRestartFunc
+}
+//go:linkname NewDerivedSubclass C.llcppg_Derived_subclass_new
+func NewDerivedSubclass(overrides *DerivedOverrides, obj c.Pointer) *Derived
+//go:linkname DeleteDerivedSubclass C.llcppg_Derived_subclass_delete
+func DeleteDerivedSubclass(self *Derived)
+`,
+			expectedShim: `#include <temp.h>
+
+extern "C" int llcppg__ZN4Base4nextEv(Base *self) {
+	return self->next();
+}
+
+extern "C" int llcppg__ZN4Base5resetEv(Base *self) {
+	return self->reset();
+}
+
+extern "C" int llcppg__ZN7Derived4nextEv(Derived *self) {
+	return self->next();
+}
+
+typedef int (*llcppg_Derived_Next_fn)(void *);
+
+typedef int (*llcppg_Derived_Restart_fn)(void *);
+
+struct llcppg_Derived_overrides {
+	llcppg_Derived_Next_fn Next;
+	llcppg_Derived_Restart_fn Restart;
+};
+
+class llcppg_Derived_subclass : public Derived {
+public:
+	llcppg_Derived_subclass(const llcppg_Derived_overrides *overrides, void *obj) : Derived(), overrides_(overrides), obj_(obj) {}
+	int next() override {
+		if (overrides_->Next) {
+			return overrides_->Next(obj_);
+		}
+		return Derived::next();
+	}
+	int reset() override {
+		if (overrides_->Restart) {
+			return overrides_->Restart(obj_);
+		}
+		return Derived::reset();
+	}
+
+private:
+	const llcppg_Derived_overrides *overrides_;
+	void *obj_;
+};
+
+extern "C" Derived *llcppg_Derived_subclass_new(const llcppg_Derived_overrides *overrides, void *obj) {
+	return new llcppg_Derived_subclass(overrides, obj);
+}
+
+extern "C" void llcppg_Derived_subclass_delete(Derived *self) {
+	delete static_cast<llcppg_Derived_subclass *>(self);
+}
+`,
+		},
+		{
+			name: "constructors",
+			// struct Options { int size; };
+			// class Widget {
+			// public:
+			//   Widget(const Options &opts);
+			//   Widget(const Widget &other);
+			//   Widget(Widget &&other);
+			//   virtual void draw();
+			// };
+			// whose copy and move constructors are not forwarded.
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Options"}},
+					Type: &ast.RecordType{Tag: ast.Struct, HasDef: true, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "size"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Public},
+					}}},
+				},
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Widget"}},
+					Type: &ast.RecordType{
+						Tag:    ast.Class,
+						HasDef: true,
+						Fields: &ast.FieldList{},
+						Methods: []*ast.FuncDecl{
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Widget"}},
+								MangledName: "_ZN6WidgetC1ERK7Options",
+								Type: &ast.FuncType{
+									Params: &ast.FieldList{List: []*ast.Field{
+										{Names: []*ast.Ident{{Name: "opts"}}, Type: &ast.LvalueRefType{X: &ast.Ident{Name: "Options"}, IsConst: true}},
+									}},
+									Ret: &ast.BuiltinType{Kind: ast.Void},
+								},
+								IsConstructor: true,
+							},
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Widget"}},
+								MangledName: "_ZN6WidgetC1ERKS_",
+								Type: &ast.FuncType{
+									Params: &ast.FieldList{List: []*ast.Field{
+										{Names: []*ast.Ident{{Name: "other"}}, Type: &ast.LvalueRefType{X: &ast.Ident{Name: "Widget"}, IsConst: true}},
+									}},
+									Ret: &ast.BuiltinType{Kind: ast.Void},
+								},
+								IsConstructor: true,
+							},
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Widget"}},
+								MangledName: "_ZN6WidgetC1EOS_",
+								Type: &ast.FuncType{
+									Params: &ast.FieldList{List: []*ast.Field{
+										{Names: []*ast.Ident{{Name: "other"}}, Type: &ast.RvalueRefType{X: &ast.Ident{Name: "Widget"}}},
+									}},
+									Ret: &ast.BuiltinType{Kind: ast.Void},
+								},
+								IsConstructor: true,
+							},
+							{
+								Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "draw"}},
+								MangledName: "_ZN6Widget4drawEv",
+								Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Void}},
+								IsVirtual:   true,
+							},
+						},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_ZN6WidgetC1ERK7Options", CPP: "Widget::Widget(const Options &)", Go: "(*Widget).Init"},
+				{Mangle: "_ZN6Widget4drawEv", CPP: "Widget::draw()", Go: "(*Widget).Draw"},
+			},
+			conf: &convert.Config{Includes: []string{"temp.h"}, GoSubclass: []string{"Widget"}},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Options struct {
+	Size c.Int
+}
+
+type Widget struct {
+	_ c.Pointer
+}
+// The reference parameter opts must not be nil.
+// llgo:link (*Widget).Init C._ZN6WidgetC1ERK7Options
+func (recv_ *Widget) Init(opts *Options) {
+}
+// llgo:link (*Widget).Draw C.llcppg__ZN6Widget4drawEv
+func (recv_ *Widget) Draw() {
+}
+// llgo:type C
+type WidgetDrawFunc func(obj c.Pointer)
+
+type WidgetOverrides struct {
+	Draw WidgetDrawFunc
+}
+// The reference parameter opts must not be nil.
+//go:linkname NewWidgetSubclass C.llcppg_Widget_subclass_new
+func NewWidgetSubclass(overrides *WidgetOverrides, obj c.Pointer, opts *Options) *Widget
+//go:linkname DeleteWidgetSubclass C.llcppg_Widget_subclass_delete
+func DeleteWidgetSubclass(self *Widget)
+`,
+			expectedShim: `#include <temp.h>
+
+extern "C" void llcppg__ZN6Widget4drawEv(Widget *self) {
+	self->draw();
+}
+
+typedef void (*llcppg_Widget_Draw_fn)(void *);
+
+struct llcppg_Widget_overrides {
+	llcppg_Widget_Draw_fn Draw;
+};
+
+class llcppg_Widget_subclass : public Widget {
+public:
+	llcppg_Widget_subclass(const llcppg_Widget_overrides *overrides, void *obj, Options const &p0) : Widget(p0), overrides_(overrides), obj_(obj) {}
+	void draw() override {
+		if (overrides_->Draw) {
+			overrides_->Draw(obj_);
+		}
+		Widget::draw();
+	}
+
+private:
+	const llcppg_Widget_overrides *overrides_;
+	void *obj_;
+};
+
+extern "C" Widget *llcppg_Widget_subclass_new(const llcppg_Widget_overrides *overrides, void *obj, Options const &p0) {
+	return new llcppg_Widget_subclass(overrides, obj, p0);
+}
+
+extern "C" void llcppg_Widget_subclass_delete(Widget *self) {
+	delete static_cast<llcppg_Widget_subclass *>(self);
+}
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
+// namespaceFile returns the declarations of:
+//
+//	namespace ns {
+//	struct Point { int x; };
+//	typedef int Id;
+//	enum Color { Red };
+//	Id get_id(Point *p);
+//	}
+//	struct Point { ns::Point inner; };
+func namespaceFile() *ast.File {
+	loc := &ast.Location{File: "temp.h"}
+	ns := &ast.Ident{Name: "ns"}
+	field := func(name string, typ ast.Expr) *ast.FieldList {
+		return &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{{Name: name}}, Type: typ, Access: ast.Public}}}
+	}
+	return &ast.File{Decls: []ast.Decl{
+		&ast.TypeDecl{
+			Object: ast.Object{Loc: loc, Parent: ns, Name: &ast.Ident{Name: "Point"}},
+			Type:   &ast.RecordType{Tag: ast.Struct, HasDef: true, Fields: field("x", &ast.BuiltinType{Kind: ast.Int})},
+		},
+		&ast.TypedefDecl{
+			Object: ast.Object{Loc: loc, Parent: ns, Name: &ast.Ident{Name: "Id"}},
+			Type:   &ast.BuiltinType{Kind: ast.Int},
+		},
+		&ast.EnumTypeDecl{
+			Object: ast.Object{Loc: loc, Parent: ns, Name: &ast.Ident{Name: "Color"}},
+			Type:   &ast.EnumType{Items: []*ast.EnumItem{{Name: &ast.Ident{Name: "Red"}, Value: &ast.BasicLit{Kind: ast.IntLit, Value: "0"}}}},
+		},
+		&ast.FuncDecl{
+			Object:      ast.Object{Loc: loc, Parent: ns, Name: &ast.Ident{Name: "get_id"}},
+			MangledName: "_ZN2ns6get_idEPNS_5PointE",
+			Type: &ast.FuncType{
+				Params: field("p", &ast.PointerType{X: &ast.TagExpr{Tag: ast.Struct, Name: &ast.ScopingExpr{Parent: ns, X: &ast.Ident{Name: "Point"}}}}),
+				Ret:    &ast.ScopingExpr{Parent: ns, X: &ast.Ident{Name: "Id"}},
+			},
+		},
+		&ast.TypeDecl{
+			Object: ast.Object{Loc: loc, Name: &ast.Ident{Name: "Point"}},
+			Type: &ast.RecordType{Tag: ast.Struct, HasDef: true,
+				Fields: field("inner", &ast.TagExpr{Tag: ast.Struct, Name: &ast.ScopingExpr{Parent: ns, X: &ast.Ident{Name: "Point"}}})},
+		},
+	}}
+}
+
+func TestConvertNamespaces(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "flatten",
+			file: namespaceFile(),
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_ZN2ns6get_idEPNS_5PointE", CPP: "ns::get_id(ns::Point *)", Go: "(*NsPoint).GetId"},
+			},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type NsPoint struct {
+	X c.Int
+}
+type NsId c.Int
+type NsColor c.Int
+
+const NsRed NsColor = 0
+// llgo:link (*NsPoint).GetId C._ZN2ns6get_idEPNS_5PointE
+func (recv_ *NsPoint) GetId() NsId {
+	return 0
+}
+
+type Point struct {
+	Inner NsPoint
+}
+`,
+		},
+		{
+			name: "strip",
+			// the global Point conflicts with the stripped ns::Point
+			file: &ast.File{Decls: namespaceFile().Decls[:4]},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_ZN2ns6get_idEPNS_5PointE", CPP: "ns::get_id(ns::Point *)", Go: "GetId"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", StripNamespaces: []string{"ns"}},
+			conf:     &convert.Config{StripNamespaces: []string{"ns"}},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Point struct {
+	X c.Int
+}
+type Id c.Int
+type Color c.Int
+
+const Red Color = 0
+//go:linkname GetId C._ZN2ns6get_idEPNS_5PointE
+func GetId(p *Point) Id
+`,
+		},
+		{
+			name: "package",
+			file: namespaceFile(),
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "_ZN2ns6get_idEPNS_5PointE", CPP: "ns::get_id(ns::Point *)", Go: "(*Point).GetId"},
+			},
+			cppgconf: &llcppg.Config{Name: "temp", NamespaceMode: llcppg.NamespacePackage},
+			conf:     &convert.Config{PkgPath: "example.com/temp", NamespaceMode: llcppg.NamespacePackage},
+			expected: `package temp
+
+import (
+	"example.com/temp/ns"
+	_ "unsafe"
+)
+
+type Point struct {
+	Inner ns.Point
+}
+`,
+			expectedFiles: map[string]string{"ns/temp.go": `package ns
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Point struct {
+	X c.Int
+}
+type Id c.Int
+type Color c.Int
+
+const Red Color = 0
+// llgo:link (*Point).GetId C._ZN2ns6get_idEPNS_5PointE
+func (recv_ *Point) GetId() Id {
+	return 0
+}
+`},
+		},
+		{
+			name: "import cycle",
+			// namespace a { struct Node { struct Tree *tree; }; }
+			// struct Tree { a::Node *root; };
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Parent: &ast.Ident{Name: "a"}, Name: &ast.Ident{Name: "Node"}},
+					Type: &ast.RecordType{Tag: ast.Struct, HasDef: true, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "tree"}}, Type: &ast.PointerType{X: &ast.TagExpr{Tag: ast.Struct, Name: &ast.Ident{Name: "Tree"}}}, Access: ast.Public},
+					}}},
+				},
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Tree"}},
+					Type: &ast.RecordType{Tag: ast.Struct, HasDef: true, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "root"}}, Type: &ast.PointerType{X: &ast.ScopingExpr{Parent: &ast.Ident{Name: "a"}, X: &ast.Ident{Name: "Node"}}}, Access: ast.Public},
+					}}},
+				},
+			}},
+			cppgconf:    &llcppg.Config{Name: "temp", NamespaceMode: llcppg.NamespacePackage},
+			conf:        &convert.Config{PkgPath: "example.com/temp", NamespaceMode: llcppg.NamespacePackage},
+			expectedErr: "import cycle between the namespace packages: example.com/temp -> example.com/temp/a -> example.com/temp",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
+
 func TestModInitFail(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "gogensig-test")
 	if err != nil {
@@ -430,3 +2309,207 @@ func callSigfetch(configFile string, dir string) ([]byte, error) {
 	}
 	return out.Bytes(), nil
 }
+
+func TestConvertHandles(t *testing.T) {
+	testCases := []convertTestCase{
+		{
+			name: "receivers",
+			// struct xmlDoc { int v; };
+			// int xmlSaveFile(int options, xmlDoc *cur);
+			// void xmlFreeDoc(xmlDoc *doc);
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "xmlDoc"}},
+					Type: &ast.RecordType{Tag: ast.Struct, HasDef: true, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Public},
+					}}},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "xmlSaveFile"}},
+					MangledName: "xmlSaveFile",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "options"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+							{Names: []*ast.Ident{{Name: "cur"}}, Type: &ast.PointerType{X: &ast.Ident{Name: "xmlDoc"}}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Int},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "xmlFreeDoc"}},
+					MangledName: "xmlFreeDoc",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "doc"}}, Type: &ast.PointerType{X: &ast.Ident{Name: "xmlDoc"}}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "xmlSaveFile", Go: "(*XmlDoc).Save@1"},
+				{Mangle: "xmlFreeDoc", Go: "(*XmlDoc).Free@0"},
+			},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type XmlDoc struct {
+	V c.Int
+}
+//go:linkname c_xmlSaveFile C.xmlSaveFile
+func c_xmlSaveFile(options c.Int, cur *XmlDoc) c.Int {
+	return 0
+}
+
+func (recv_ *XmlDoc) Save(options c.Int) c.Int {
+	return c_xmlSaveFile(options, recv_)
+}
+// llgo:link (*XmlDoc).Free C.xmlFreeDoc
+func (recv_ *XmlDoc) Free() {
+}
+`,
+		},
+		{
+			name: "out parameters",
+			// struct xmlDoc { int v; };
+			// int xmlNewDocOut(xmlDoc **out);
+			// void xmlNewDocInto(int options, xmlDoc **out);
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "xmlDoc"}},
+					Type: &ast.RecordType{Tag: ast.Struct, HasDef: true, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Public},
+					}}},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "xmlNewDocOut"}},
+					MangledName: "xmlNewDocOut",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "out"}}, Type: &ast.PointerType{X: &ast.PointerType{X: &ast.Ident{Name: "xmlDoc"}}}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Int},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "xmlNewDocInto"}},
+					MangledName: "xmlNewDocInto",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "options"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+							{Names: []*ast.Ident{{Name: "out"}}, Type: &ast.PointerType{X: &ast.PointerType{X: &ast.Ident{Name: "xmlDoc"}}}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "xmlNewDocOut", Go: "NewDoc@0"},
+				{Mangle: "xmlNewDocInto", Go: "NewDocInto@1"},
+			},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type XmlDoc struct {
+	V c.Int
+}
+//go:linkname c_xmlNewDocOut C.xmlNewDocOut
+func c_xmlNewDocOut(out **XmlDoc) c.Int {
+	return 0
+}
+
+func NewDoc() (*XmlDoc, c.Int) {
+	var out *XmlDoc
+	ret_ := c_xmlNewDocOut(&out)
+	return out, ret_
+}
+//go:linkname c_xmlNewDocInto C.xmlNewDocInto
+func c_xmlNewDocInto(options c.Int, out **XmlDoc) {
+}
+
+func NewDocInto(options c.Int) *XmlDoc {
+	var out *XmlDoc
+	c_xmlNewDocInto(options, &out)
+	return out
+}
+`,
+		},
+		{
+			name: "value receivers",
+			// struct Vec { int v; };
+			// int vec_len(Vec v);
+			// void vec_reset(Vec *v);
+			file: &ast.File{Decls: []ast.Decl{
+				&ast.TypeDecl{
+					Object: ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "Vec"}},
+					Type: &ast.RecordType{Tag: ast.Struct, HasDef: true, Fields: &ast.FieldList{List: []*ast.Field{
+						{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Public},
+					}}},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "vec_len"}},
+					MangledName: "vec_len",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.Ident{Name: "Vec"}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Int},
+					},
+				},
+				&ast.FuncDecl{
+					Object:      ast.Object{Loc: &ast.Location{File: "temp.h"}, Name: &ast.Ident{Name: "vec_reset"}},
+					MangledName: "vec_reset",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "v"}}, Type: &ast.PointerType{X: &ast.Ident{Name: "Vec"}}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Void},
+					},
+				},
+			}},
+			symbs: []llcppg.SymbolInfo{
+				{Mangle: "vec_len", Go: "(*Vec).Len@0"},
+				{Mangle: "vec_reset", Go: "Vec.Reset@0"},
+			},
+			expected: `package temp
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Vec struct {
+	V c.Int
+}
+//go:linkname c_vec_len C.vec_len
+func c_vec_len(v Vec) c.Int {
+	return 0
+}
+
+func (recv_ *Vec) Len() c.Int {
+	return c_vec_len(*recv_)
+}
+//go:linkname c_vec_reset C.vec_reset
+func c_vec_reset(v *Vec) {
+}
+
+func (recv_ Vec) Reset() {
+	c_vec_reset(&recv_)
+}
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testConvert(t, tc)
+		})
+	}
+}
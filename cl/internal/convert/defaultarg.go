@@ -0,0 +1,185 @@
+package convert
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/goplus/llcppg/ast"
+	"github.com/goplus/llcppg/cl/nc"
+	llcppg "github.com/goplus/llcppg/config"
+	"github.com/goplus/llcppg/token"
+)
+
+// defaultArgsMode returns how the default arguments of a function are omitted in Go, configured
+// by defaultArgs with its qualified name, or "" if it has none or none is configured.
+func (p *Package) defaultArgsMode(fn *ast.FuncDecl) string {
+	if firstDefault(fn.Type) < 0 {
+		return ""
+	}
+	return p.conf.DefaultArgs[fn.QualifiedName()]
+}
+
+// firstDefault returns the index of the first parameter with a default argument, or -1.
+func firstDefault(typ *ast.FuncType) int {
+	for i, param := range paramList(typ) {
+		if param.Default != nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// withParam returns goName suffixed by With and the last of the n parameters, like DrawWithColor,
+// or by n if the parameter is unnamed or n is 0.
+func withParam(goName string, params []*ast.Field, n int, pnc nc.NodeConverter) string {
+	if n == 0 {
+		return goName + "0"
+	}
+	if names := params[n-1].Names; len(names) > 0 && names[0].Name != "" {
+		return goName + "With" + pnc.ConvFuncName(names[0].Name)
+	}
+	return goName + strconv.Itoa(n)
+}
+
+// NewDefaultArgsDecl declares the Go functions omitting the default arguments of a function
+// or a method of the class, as configured by defaultArgs. goName is the Go name of the function
+// from the symbol table, which keeps taking all the arguments.
+//
+// The overloads take each smaller number of the arguments, and are named by withParam, like
+// DrawWithY for the ones up to the parameter y. The options take them as a trailing options struct,
+// whose nil fields are the default arguments, like
+//
+//	type DrawOptions struct {
+//		C *Color
+//	}
+//	func DrawWithOptions(x c.Int, y c.Int, opts *DrawOptions)
+//
+// Both are linked to the wrappers of the C++ shim, where the C++ compiler passes the default
+// arguments, or where they are spelled for the options.
+func (p *Package) NewDefaultArgsDecl(goName string, fn *ast.FuncDecl, class *ast.TypeDecl, pnc nc.NodeConverter) error {
+	switch p.defaultArgsMode(fn) {
+	case llcppg.DefaultArgsOverloads:
+		params := paramList(fn.Type)
+		first := firstDefault(fn.Type)
+		for n := first; n < len(params); n++ {
+			fnType := *fn.Type
+			fnType.Params = &ast.FieldList{List: params[:n]}
+			short := *fn
+			short.Type = &fnType
+			refs, _ := p.refParams(&fnType)
+			wrapper := p.shimWrapper(&short, class, refs, shimPrefix+fn.MangledName+"_"+strconv.Itoa(n))
+			if err := p.newWrapperDecl(withParam(goName, params, n, pnc), wrapper, fn, class); err != nil {
+				return err
+			}
+		}
+	case llcppg.DefaultArgsOptions:
+		return p.newDefaultArgsOptions(goName, fn, class, pnc)
+	}
+	return nil
+}
+
+// newDefaultArgsOptions declares the options struct of the default arguments of a function
+// and the function taking it, see NewDefaultArgsDecl.
+func (p *Package) newDefaultArgsOptions(goName string, fn *ast.FuncDecl, class *ast.TypeDecl, pnc nc.NodeConverter) error {
+	params := paramList(fn.Type)
+	first := firstDefault(fn.Type)
+	symbol := shimPrefix + fn.MangledName + "_options"
+	options := symbol + "_t"
+
+	var fields []*ast.Field
+	var cppFields, optArgs []string
+	for i, param := range params[first:] {
+		typ := param.Type
+		switch t := typ.(type) {
+		case *ast.LvalueRefType:
+			typ = t.X
+		case *ast.RvalueRefType:
+			log.Printf("NewDefaultArgsDecl: %s takes an rvalue reference with a default argument, ignored\n", fn.QualifiedName())
+			return nil
+		}
+		arg := cppExpr(param.Default)
+		if arg == "" {
+			log.Printf("NewDefaultArgsDecl: %s has an unsupported default argument, ignored\n", fn.QualifiedName())
+			return nil
+		}
+		field := "arg" + strconv.Itoa(first+i)
+		if len(param.Names) > 0 && param.Names[0].Name != "" {
+			field = param.Names[0].Name
+		}
+		fieldType := &ast.PointerType{X: typ}
+		fields = append(fields, &ast.Field{Names: []*ast.Ident{{Name: field}}, Type: fieldType, Access: ast.Public})
+		cppFields = append(cppFields, "\t"+cppDecl(fieldType, field)+";\n")
+		optArgs = append(optArgs, "opts && opts->"+field+" ? *opts->"+field+" : "+arg)
+	}
+
+	spec := NewGoFuncSpec(goName, nil)
+	optionsName := spec.RecvName + spec.FnName + "Options"
+	optionsDecl := &ast.TypeDecl{
+		Object: ast.Object{
+			Loc:  fn.Loc,
+			Name: &ast.Ident{Name: options},
+			Doc: &ast.CommentGroup{List: []*ast.Comment{{
+				Text: "// " + optionsName + " holds the default arguments of " + spec.FnName + "WithOptions, a nil field passes the default one.",
+			}}},
+		},
+		Type: &ast.RecordType{Tag: ast.Struct, HasDef: true, Fields: &ast.FieldList{List: fields}},
+	}
+	if err := p.NewTypeDecl(optionsName, optionsDecl, pnc); err != nil {
+		return err
+	}
+	p.shim.add("struct %s {\n%s};", options, strings.Join(cppFields, ""))
+
+	fnType := *fn.Type
+	refs, _ := p.refParams(&ast.FuncType{Params: &ast.FieldList{List: params[:first]}})
+	fnType.Params = &ast.FieldList{List: append(refs, &ast.Field{
+		Names: []*ast.Ident{{Name: "opts"}},
+		Type:  &ast.PointerType{X: &ast.Ident{Name: options}},
+	})}
+	withOpts := *fn
+	withOpts.Type = &fnType
+	withOpts.MangledName = symbol
+	if err := p.newWrapperDecl(goName+"WithOptions", &withOpts, fn, class); err != nil {
+		return err
+	}
+	decls, args := cppParams(&ast.FieldList{List: refs})
+	p.addShimCall(fn, class, append(decls, "const "+options+" *opts"), append(args, optArgs...), symbol)
+	return nil
+}
+
+// newWrapperDecl declares the Go function of a wrapper of the C++ shim calling fn,
+// which is a method of the class if it's not nil.
+func (p *Package) newWrapperDecl(goName string, wrapper, fn *ast.FuncDecl, class *ast.TypeDecl) error {
+	if class != nil {
+		method := *wrapper
+		method.MangledName = fn.MangledName
+		return p.newMethodDecl(goName, wrapper.MangledName, class, &method)
+	}
+	// the functions are recorded by their names, which are the symbols of the wrappers
+	decl := *wrapper
+	decl.Name = &ast.Ident{Name: wrapper.MangledName}
+	return p.NewFuncDecl(goName, &decl)
+}
+
+// cppExpr spells a default argument in C++, or returns "" if it's not supported. The names
+// are qualified by the parser with the declarations they refer to, but the other expressions
+// are kept as their tokens, whose names may only resolve in the scope of the function, like
+// kDefault * 2 in a class, so the ones with names are not supported.
+func cppExpr(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return e.Value
+	case *ast.Ident, *ast.ScopingExpr:
+		return ast.QualifiedName(e)
+	case *ast.RawExpr:
+		lits := make([]string, len(e.Tokens))
+		for i, tok := range e.Tokens {
+			if tok.Token == token.IDENT {
+				return ""
+			}
+			lits[i] = tok.Lit
+		}
+		return strings.Join(lits, " ")
+	}
+	return ""
+}
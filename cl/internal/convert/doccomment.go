@@ -0,0 +1,370 @@
+package convert
+
+import (
+	goast "go/ast"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goplus/llcppg/ast"
+)
+
+// docComments collects the C doc comments of the declarations. With goDoc enabled,
+// they are rewritten to Go doc comments on Complete, when the Go names of all the
+// referenced C identifiers are known.
+type docComments struct {
+	names map[string]string // C name -> Go name of the converted functions
+	list  []*docComment
+}
+
+type docComment struct {
+	goName string
+	doc    *ast.CommentGroup
+	group  *goast.CommentGroup // the generated comments, the C comments are the first n of them
+	n      int
+}
+
+// newDocComment returns the Go comments of the C doc comment of a declaration named goName.
+func (p *Package) newDocComment(goName string, doc *ast.CommentGroup) *goast.CommentGroup {
+	group := NewCommentGroupFromC(doc)
+	if p.conf.GoDoc && len(group.List) > 0 {
+		p.docs.list = append(p.docs.list, &docComment{goName: goName, doc: doc, group: group, n: len(group.List)})
+	}
+	return group
+}
+
+// completeDocs rewrites the collected C doc comments to Go doc comments.
+func (p *Package) completeDocs() {
+	names := make(map[string]string, len(p.Pubs)+len(p.docs.names))
+	for cname, goName := range p.Pubs {
+		if goName == "" {
+			goName = cname
+		}
+		names[cname] = goName
+	}
+	for cname, goName := range p.docs.names {
+		names[cname] = goName
+	}
+	for _, doc := range p.docs.list {
+		var list []*goast.Comment
+		for _, line := range GoDocLines(doc.goName, doc.doc, names) {
+			text := "//"
+			if line != "" {
+				text += " " + line
+			}
+			list = append(list, &goast.Comment{Text: text})
+		}
+		if len(list) > 0 && len(doc.group.List) > doc.n {
+			// the directives like go:linkname are separated from the doc
+			list = append(list, &goast.Comment{Text: "//"})
+		}
+		doc.group.List = append(list, doc.group.List[doc.n:]...)
+	}
+	p.docs.list = nil
+}
+
+var (
+	docCommandRe  = regexp.MustCompile(`^[@\\]([a-zA-Z]+)(\[[^\]]*\])?(?:\s+|$)(.*)$`)
+	docInlineRe   = regexp.MustCompile(`[@\\](?:c|p|a|e|b|em|ref)\s+`)
+	docHTMLRe     = regexp.MustCompile(`</?(?:b|i|em|tt|code|strong)>`)
+	docIdentRe    = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(?:\(\))?`)
+	docDecoration = regexp.MustCompile(`^[*=\-/#]+$`)
+)
+
+type docParam struct {
+	name string
+	dir  string
+	text []string
+}
+
+type docNote struct {
+	label string
+	text  []string
+}
+
+// GoDocLines converts a C doc comment to the lines of a Go doc comment for the declaration
+// goName. The Doxygen and Javadoc commands are rewritten to the Go doc format:
+// the summary starts with goName, @param becomes a parameter list, @deprecated becomes
+// a "Deprecated:" paragraph, and the C identifiers found in names are replaced with
+// links to their Go names. A comment without any commands falls back to its cleaned up text.
+func GoDocLines(goName string, doc *ast.CommentGroup, names map[string]string) []string {
+	lines, isDoxygen := cleanDocLines(doc)
+	if !isDoxygen {
+		for i, line := range lines {
+			lines[i] = renameDocIdents(line, names)
+		}
+		return lines
+	}
+
+	var desc, returns, deprecated []string
+	var params, retvals []*docParam
+	var notes []*docNote
+	target := &desc
+	inCode, isDeprecated := false, false
+	for _, line := range lines {
+		m := docCommandRe.FindStringSubmatch(line)
+		if inCode {
+			if m != nil && (m[1] == "endcode" || m[1] == "endverbatim") {
+				inCode = false
+				*target = append(*target, "")
+				continue
+			}
+			*target = append(*target, "\t"+line)
+			continue
+		}
+		if m == nil {
+			if line == "" {
+				// a blank line ends the paragraph of a command
+				target = &desc
+			}
+			*target = append(*target, inlineDoc(line, names))
+			continue
+		}
+		cmd, rest := strings.ToLower(m[1]), inlineDoc(m[3], names)
+		switch cmd {
+		case "brief", "short":
+			target = &desc
+		case "details":
+			target = &desc
+			if len(desc) > 0 {
+				desc = append(desc, "")
+			}
+		case "code", "verbatim":
+			inCode = true
+			*target = append(*target, "")
+			continue
+		case "param", "tparam":
+			name, text, _ := strings.Cut(m[3], " ")
+			param := &docParam{name: name, dir: strings.Trim(m[2], "[]")}
+			params = append(params, param)
+			target, rest = &param.text, inlineDoc(strings.TrimSpace(text), names)
+		case "retval":
+			name, text, _ := strings.Cut(m[3], " ")
+			retval := &docParam{name: name}
+			retvals = append(retvals, retval)
+			target, rest = &retval.text, inlineDoc(strings.TrimSpace(text), names)
+		case "return", "returns", "result":
+			target = &returns
+		case "deprecated":
+			target, isDeprecated = &deprecated, true
+		case "file", "fn", "struct", "union", "enum", "typedef", "def", "var", "class", "namespace",
+			"defgroup", "ingroup", "addtogroup", "weakgroup", "name", "private", "public", "protected":
+			// structural commands have no meaning for the Go declaration
+			target = &desc
+			continue
+		default:
+			note := &docNote{label: docNoteLabel(cmd)}
+			notes = append(notes, note)
+			target = &note.text
+		}
+		if rest != "" {
+			*target = append(*target, rest)
+		}
+	}
+
+	var out []string
+	paragraph := func(lines ...string) {
+		if len(lines) == 0 {
+			return
+		}
+		if len(out) > 0 {
+			out = append(out, "")
+		}
+		out = append(out, lines...)
+	}
+	if desc = trimDocLines(desc); len(desc) > 0 {
+		desc[0] = docSubject(goName, desc[0])
+		paragraph(desc...)
+	}
+	if len(params) > 0 {
+		list := []string{"Parameters:"}
+		for _, param := range params {
+			item := "  - " + param.name
+			if param.dir != "" {
+				item += " (" + param.dir + ")"
+			}
+			list = append(list, item+": "+joinDocLines(param.text))
+		}
+		paragraph(list...)
+	}
+	if len(returns) > 0 {
+		paragraph("It returns " + lowerFirst(joinDocLines(returns)))
+	}
+	if len(retvals) > 0 {
+		list := []string{"Return values:"}
+		for _, retval := range retvals {
+			list = append(list, "  - "+retval.name+": "+joinDocLines(retval.text))
+		}
+		paragraph(list...)
+	}
+	for _, note := range notes {
+		paragraph(note.label + ": " + joinDocLines(note.text))
+	}
+	if isDeprecated {
+		text := joinDocLines(deprecated)
+		if text == "" {
+			text = goName + " is deprecated."
+		}
+		paragraph("Deprecated: " + text)
+	}
+	return out
+}
+
+// cleanDocLines strips the comment markers and the leading asterisks of a C comment group,
+// and reports whether it is a Doxygen or Javadoc comment.
+func cleanDocLines(doc *ast.CommentGroup) (lines []string, isDoxygen bool) {
+	if doc == nil {
+		return nil, false
+	}
+	for _, comment := range doc.List {
+		text := comment.Text
+		switch {
+		case strings.HasPrefix(text, "//"):
+			for _, prefix := range []string{"///<", "//!<", "///", "//!"} {
+				if strings.HasPrefix(text, prefix) {
+					text, isDoxygen = text[len(prefix):], true
+					break
+				}
+			}
+			lines = append(lines, strings.TrimPrefix(text, "//"))
+		case strings.HasPrefix(text, "/*"):
+			text = strings.TrimSuffix(text, "*/")
+			for _, prefix := range []string{"/**<", "/*!<", "/**", "/*!"} {
+				if strings.HasPrefix(text, prefix) && !strings.HasPrefix(text, "/***") {
+					text, isDoxygen = text[len(prefix):], true
+					break
+				}
+			}
+			text = strings.TrimPrefix(text, "/*")
+			for _, line := range strings.Split(text, "\n") {
+				if trimmed := strings.TrimLeft(line, " \t"); strings.HasPrefix(trimmed, "*") {
+					line = strings.TrimPrefix(trimmed, "*")
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	indent := -1
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t*")
+		if docDecoration.MatchString(strings.TrimSpace(line)) {
+			line = ""
+		}
+		lines[i] = line
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+			continue
+		}
+		if n := len(line) - len(strings.TrimLeft(line, " \t")); indent < 0 || n < indent {
+			indent = n
+		}
+		if docCommandRe.MatchString(strings.TrimSpace(line)) {
+			isDoxygen = true
+		}
+	}
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = line[indent:]
+		}
+	}
+	return trimDocLines(lines), isDoxygen
+}
+
+// trimDocLines removes the leading and trailing blank lines, and merges the consecutive ones.
+func trimDocLines(lines []string) []string {
+	var out []string
+	for _, line := range lines {
+		if line == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, line)
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+func joinDocLines(lines []string) string {
+	var words []string
+	for _, line := range lines {
+		words = append(words, strings.Fields(line)...)
+	}
+	return strings.Join(words, " ")
+}
+
+// inlineDoc removes the inline commands and the html tags of a line, and renames the C identifiers.
+func inlineDoc(line string, names map[string]string) string {
+	line = docInlineRe.ReplaceAllString(line, "")
+	line = docHTMLRe.ReplaceAllString(line, "")
+	return renameDocIdents(line, names)
+}
+
+// renameDocIdents replaces the C identifiers of a line with links to their Go names.
+// To avoid the false matches of plain words, a lowercase word without underscores
+// is only renamed when it is called like `name()`.
+func renameDocIdents(line string, names map[string]string) string {
+	return docIdentRe.ReplaceAllStringFunc(line, func(ident string) string {
+		cname, call := strings.CutSuffix(ident, "()")
+		goName, ok := names[cname]
+		if !ok {
+			return ident
+		}
+		if !call && strings.ToLower(cname) == cname && !strings.Contains(cname, "_") {
+			return ident
+		}
+		return "[" + goName + "]"
+	})
+}
+
+// docSubject makes the first line of a doc comment start with goName.
+func docSubject(goName, line string) string {
+	if rest, ok := strings.CutPrefix(line, "["+goName+"]"); ok {
+		return goName + rest
+	}
+	if line == goName || strings.HasPrefix(line, goName+" ") {
+		return line
+	}
+	first, _, _ := strings.Cut(line, " ")
+	switch strings.ToLower(first) {
+	case "a", "an", "the":
+		return goName + " is " + lowerFirst(line)
+	}
+	return goName + " " + lowerFirst(line)
+}
+
+// lowerFirst lowercases the first letter of a sentence, unless it starts with an acronym.
+func lowerFirst(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	if !unicode.IsUpper(r) {
+		return s
+	}
+	if next, _ := utf8.DecodeRuneInString(s[n:]); unicode.IsUpper(next) || unicode.IsDigit(next) {
+		return s
+	}
+	return string(unicode.ToLower(r)) + s[n:]
+}
+
+func docNoteLabel(cmd string) string {
+	switch cmd {
+	case "remark", "remarks":
+		return "Note"
+	case "sa", "see":
+		return "See also"
+	case "attention":
+		return "Warning"
+	case "pre":
+		return "Precondition"
+	case "post":
+		return "Postcondition"
+	case "throw", "throws", "exception":
+		return "Throws"
+	case "todo":
+		return "TODO"
+	case "bug":
+		return "BUG"
+	}
+	return strings.ToUpper(cmd[:1]) + cmd[1:]
+}
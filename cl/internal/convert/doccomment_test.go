@@ -0,0 +1,108 @@
+package convert_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goplus/llcppg/ast"
+	"github.com/goplus/llcppg/cl/internal/convert"
+)
+
+func TestGoDocLines(t *testing.T) {
+	names := map[string]string{
+		"cJSON":        "JSON",
+		"cJSON_Delete": "JSONDelete",
+		"cJSON_Parse":  "JSONParse",
+		"free":         "Free",
+	}
+	testCases := []struct {
+		name     string
+		goName   string
+		comments []string
+		expect   string
+	}{
+		{
+			name:     "plain",
+			goName:   "Foo",
+			comments: []string{"// Foo comment"},
+			expect:   "Foo comment",
+		},
+		{
+			name:   "plain block",
+			goName: "Foo",
+			comments: []string{`/*
+ * Use cJSON_Delete to free the
+ * returned cJSON.
+ */`},
+			expect: `Use [JSONDelete] to free the
+returned [JSON].`,
+		},
+		{
+			name:   "javadoc",
+			goName: "JSONParse",
+			comments: []string{`/**
+ * Parses a JSON string.
+ *
+ * @param value the string to parse
+ * @param[out] end the end of the parsed text
+ * @return a cJSON, which must be released by cJSON_Delete(),
+ *         or NULL on error
+ * @see cJSON_Delete
+ * @deprecated use cJSON_ParseWithOpts instead
+ */`},
+			expect: `JSONParse parses a JSON string.
+
+Parameters:
+  - value: the string to parse
+  - end (out): the end of the parsed text
+
+It returns a [JSON], which must be released by [JSONDelete], or NULL on error
+
+See also: [JSONDelete]
+
+Deprecated: use cJSON_ParseWithOpts instead`,
+		},
+		{
+			name:   "doxygen",
+			goName: "Version",
+			comments: []string{
+				`/*! \brief Returns the version of \c cJSON_Parse().`,
+				`/// \retval 0 no version`,
+				`/// \note free() is never needed`,
+				`/// \deprecated`,
+			},
+			expect: `Version returns the version of [JSONParse].
+
+Return values:
+  - 0: no version
+
+Note: [Free] is never needed
+
+Deprecated: Version is deprecated.`,
+		},
+		{
+			name:   "subject",
+			goName: "JSON",
+			comments: []string{`/**
+ * cJSON is the JSON value.
+ *
+ * @code
+ * cJSON *json = cJSON_Parse(text);
+ * @endcode
+ */`},
+			expect: "JSON is the JSON value.\n\n\tcJSON *json = cJSON_Parse(text);",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := &ast.CommentGroup{}
+			for _, text := range tc.comments {
+				doc.List = append(doc.List, &ast.Comment{Text: text})
+			}
+			got := strings.Join(convert.GoDocLines(tc.goName, doc, names), "\n")
+			if got != tc.expect {
+				t.Errorf("GoDocLines() =\n%s\nwant:\n%s", got, tc.expect)
+			}
+		})
+	}
+}
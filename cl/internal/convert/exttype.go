@@ -0,0 +1,127 @@
+package convert
+
+import (
+	goast "go/ast"
+	"go/token"
+	"go/types"
+)
+
+func (p *Package) autoTypesFile() string {
+	return p.conf.Name + "_autogen_types.go"
+}
+
+// extType declares the opaque Go type of a C builtin type that has no Go
+// counterpart the first time it is referenced, along with its conversion helpers.
+// The floating-point types are converted by the C++ shim, see newFloatHelpers.
+func (p *Package) extType(ext *ExtType) types.Type {
+	if typ, ok := p.extTypes[ext.Name]; ok {
+		return typ
+	}
+	pkg := p.p
+	defer pkg.RestoreCurFile(pkg.CurFile())
+	p.setCurFile(p.autoTypesFile())
+
+	typeBlock := pkg.NewTypeDefs()
+	typeBlock.SetComments(NewCommentGroup(&goast.Comment{
+		Text: "// " + ext.Name + " is an opaque representation of the C " + ext.CName + " type with the same size.",
+	}))
+	decl := typeBlock.NewType(ext.Name)
+	named := decl.InitType(pkg, ext.Underlying)
+	switch ext.Name {
+	case "CInt128":
+		p.newInt128Helpers(named, types.Typ[types.Int64], "Int64", true)
+	case "CUint128":
+		p.newInt128Helpers(named, types.Typ[types.Uint64], "Uint64", false)
+	default:
+		p.newFloatHelpers(named, ext.CName)
+	}
+	if p.extTypes == nil {
+		p.extTypes = make(map[string]types.Type)
+	}
+	p.extTypes[ext.Name] = named
+	return named
+}
+
+// noCopyType declares the unexported noCopy type used as the marker of opaque
+// structs, the copylocks check of go vet reports copying a value that contains it.
+//
+//	type noCopy struct{}
+//	func (*noCopy) Lock()   {}
+//	func (*noCopy) Unlock() {}
+func (p *Package) noCopyType() types.Type {
+	const name = "noCopy"
+	if typ, ok := p.extTypes[name]; ok {
+		return typ
+	}
+	pkg := p.p
+	defer pkg.RestoreCurFile(pkg.CurFile())
+	p.setCurFile(p.autoTypesFile())
+
+	typeBlock := pkg.NewTypeDefs()
+	typeBlock.SetComments(NewCommentGroup(&goast.Comment{
+		Text: "// noCopy marks the opaque structs, which must only be used through pointers.",
+	}))
+	named := typeBlock.NewType(name).InitType(pkg, types.NewStruct(nil, nil))
+	for _, method := range []string{"Lock", "Unlock"} {
+		recv := pkg.NewParam(token.NoPos, "", types.NewPointer(named))
+		pkg.NewFunc(recv, method, nil, nil, false).BodyStart(pkg).End()
+	}
+	if p.extTypes == nil {
+		p.extTypes = make(map[string]types.Type)
+	}
+	p.extTypes[name] = named
+	return named
+}
+
+// newInt128Helpers generates the conversions between a 128-bit integer type and
+// its 64-bit Go counterpart, the low word comes first like in little endian memory.
+//
+//	func CInt128FromInt64(v int64) CInt128 { return CInt128{uint64(v), uint64(v >> 63)} }
+//	func (v CInt128) Int64() int64 { return int64(v[0]) }
+func (p *Package) newInt128Helpers(named *types.Named, from types.Type, fromName string, signed bool) {
+	pkg := p.p
+	u64 := types.Typ[types.Uint64]
+
+	v := pkg.NewParam(token.NoPos, "v", from)
+	ret := pkg.NewParam(token.NoPos, "", named)
+	fn := pkg.NewFunc(nil, named.Obj().Name()+"From"+fromName, types.NewTuple(v), types.NewTuple(ret), false)
+	cb := fn.BodyStart(pkg)
+	if signed {
+		cb.Typ(u64).Val(v).Call(1)
+		cb.Typ(u64).Val(v).Val(63).BinaryOp(token.SHR).Call(1)
+	} else {
+		cb.Val(v).Val(0)
+	}
+	cb.ArrayLit(named, 2).Return(1).End()
+
+	recv := pkg.NewParam(token.NoPos, "v", named)
+	res := pkg.NewParam(token.NoPos, "", from)
+	fn = pkg.NewFunc(recv, fromName, nil, types.NewTuple(res), false)
+	cb = fn.BodyStart(pkg)
+	if signed {
+		cb.Typ(from).Val(recv).Val(0).Index(1, false).Call(1)
+	} else {
+		cb.Val(recv).Val(0).Index(1, false)
+	}
+	cb.Return(1).End()
+}
+
+// newFloatHelpers generates the conversions between a floating-point type and float64,
+// which are linked to the C++ shim converting them as C does.
+//
+//	// llgo:link (*CLongDouble).SetFloat64 C.llcppg_CLongDouble_SetFloat64
+//	func (recv_ *CLongDouble) SetFloat64(v float64) {}
+//	// llgo:link (*CLongDouble).Float64 C.llcppg_CLongDouble_Float64
+//	func (recv_ *CLongDouble) Float64() float64 { return 0 }
+func (p *Package) newFloatHelpers(named *types.Named, cname string) {
+	f64 := types.Typ[types.Float64]
+	cppType := shimPrefix + named.Obj().Name()
+	p.shim.add("typedef %s %s;", cname, cppType)
+	p.newShimMethods(named, cppType, []*bridgeMethod{
+		{
+			name: "SetFloat64", params: []*types.Var{p.p.NewParam(token.NoPos, "v", f64)},
+			decl: "void %s", cparams: "double v", body: "*self = (" + cppType + ")v",
+		},
+		{name: "Float64", ret: f64, decl: "double %s", body: "return (double)*self"},
+	})
+}
@@ -1,6 +1,7 @@
 package convert
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/goplus/llcppg/ast"
@@ -11,21 +12,31 @@ import (
 // 1. Simple function: "AddPatchToArray"
 // 2. Method with pointer receiver: "(*Sqlite3Stmt).Sqlite3BindParameterIndex"
 // 3. Method with value receiver: "CJSONBool.CJSONCreateBool"
+// 4. Method of the second parameter: "(*XmlDoc).Save@1"
+// 5. Constructor of the first parameter taking **XmlDoc: "NewDoc@0"
 type GoFuncSpec struct {
-	GoSymbName string // original full name from input
+	GoSymbName string // original full name from input, without the handle index
 	FnName     string // function name without receiver
 	IsMethod   bool   // if the function can be a method
 	RecvName   string // receiver name
 	PtrRecv    bool   // if the receiver is a pointer
+
+	// Handle is the index of the handle parameter given by @N, the receiver of a method
+	// or the result of a constructor, which is -1 if not given
+	Handle int
+	IsCtor bool // if the function returns the handle parameter instead of taking it
 }
 
 // - "AddPatchToArray" -> {goSymbolName: "AddPatchToArray", funcName: "AddPatchToArray"}
 // - "(*Sqlite3Stmt).Sqlite3BindParameterIndex" -> {goSymbolName: "...", recvName: "Sqlite3Stmt", funcName: "Sqlite3BindParameterIndex", ptrRecv: true}
 // - "CJSONBool.CJSONCreateBool" -> {goSymbolName: "...", recvName: "CJSONBool", funcName: "CJSONCreateBool", ptrRecv: false}
+// - "NewDoc@0" -> {goSymbolName: "NewDoc", funcName: "NewDoc", handle: 0, isCtor: true}
 func NewGoFuncSpec(name string, field []*ast.Field) *GoFuncSpec {
+	name, handle := cutHandle(name)
 	l := strings.Split(name, ".")
 	if len(l) < 2 {
-		return &GoFuncSpec{GoSymbName: name, FnName: name, IsMethod: false}
+		isCtor := handle >= 0 && canBeMethod(field, handle)
+		return &GoFuncSpec{GoSymbName: name, FnName: name, IsMethod: false, Handle: handle, IsCtor: isCtor}
 	}
 	recvName := l[0]
 	ptrRecv := false
@@ -39,7 +50,7 @@ func NewGoFuncSpec(name string, field []*ast.Field) *GoFuncSpec {
 	// not use the receiver style to link
 	fnName := l[1]
 	goSymbName := name
-	beMethod := canBeMethod(field)
+	beMethod := canBeMethod(field, max(handle, 0))
 	if !beMethod {
 		goSymbName = fnName
 	}
@@ -50,15 +61,36 @@ func NewGoFuncSpec(name string, field []*ast.Field) *GoFuncSpec {
 		FnName:     fnName,
 		PtrRecv:    ptrRecv,
 		IsMethod:   beMethod,
+		Handle:     handle,
+	}
+}
+
+// cutHandle cuts the index of the handle parameter, like 1 of (*Doc).Save@1, or -1 if none.
+func cutHandle(name string) (string, int) {
+	i := strings.LastIndex(name, "@")
+	if i < 0 {
+		return name, -1
 	}
+	handle, err := strconv.Atoi(name[i+1:])
+	if err != nil || handle < 0 {
+		return name, -1
+	}
+	return name[:i], handle
+}
+
+// RecvIndex returns the index of the parameter of the receiver of a method.
+func (g *GoFuncSpec) RecvIndex() int {
+	return max(g.Handle, 0)
 }
 
 func (g *GoFuncSpec) IsIgnore() bool {
 	return g.GoSymbName == "-"
 }
 
-func canBeMethod(fieldList []*ast.Field) bool {
-	if len(fieldList) == 0 {
+// canBeMethod reports whether a function can be a method of the parameter at the index recv,
+// or a constructor of it.
+func canBeMethod(fieldList []*ast.Field, recv int) bool {
+	if len(fieldList) <= recv {
 		return false
 	}
 	lastField := fieldList[len(fieldList)-1]
@@ -0,0 +1,163 @@
+package convert
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"log"
+	"strconv"
+
+	"github.com/goplus/llcppg/ast"
+)
+
+// newHandleFuncDecl converts a function whose handle parameter is given by @N of its Go name,
+// which is the receiver of a method, like (*Doc).Save@1 of
+// `int xmlSaveFile(const char *filename, xmlDocPtr cur)`, or the result of a constructor taking
+// a pointer to it, like NewDoc@0 of `int xmlNewDocOut(xmlDocPtr *out)`.
+//
+// The C function is linked to an unexported Go function, which is called with the arguments
+// in the C order by the Go method or the constructor, like
+//
+//	func (recv_ *Doc) Save(filename *c.Char) c.Int {
+//		return c_xmlSaveFile(filename, recv_)
+//	}
+//
+//	func NewDoc() (*Doc, c.Int) {
+//		var out *Doc
+//		ret_ := c_xmlNewDocOut(&out)
+//		return out, ret_
+//	}
+//
+// A method of the first parameter taken as is, like (*Doc).Free@0 of `void xmlFreeDoc(xmlDoc *)`,
+// is linked directly, and the pointer or the value receiver of the others is taken by its
+// address or dereferenced.
+func (p *Package) newHandleFuncDecl(fnSpec *GoFuncSpec, cname string, funcDecl *ast.FuncDecl) error {
+	node := funcNode(funcDecl)
+	if _, exist := p.symbols.Lookup(node); exist {
+		return nil
+	}
+	sig, err := p.ToSigSignature(nil, funcDecl)
+	if err != nil {
+		return fmt.Errorf("NewFuncDecl: fail convert signature %s: %w", funcDecl.Name.Name, err)
+	}
+	handle := fnSpec.Handle
+	handleType := sig.Params().At(handle).Type()
+
+	var recv *types.Var
+	var results *types.Tuple
+	var deref, addr bool
+	if fnSpec.IsCtor {
+		ptr, ok := types.Unalias(handleType).(*types.Pointer)
+		if !ok {
+			log.Printf("NewFuncDecl: the handle of %s is not a pointer, converted to a function\n", funcDecl.Name.Name)
+			return p.newFuncDecl(fnSpec.FnName, cname, funcDecl)
+		}
+		if p.Lookup(fnSpec.FnName) != nil {
+			return fmt.Errorf("NewFuncDecl: %s already defined", fnSpec.FnName)
+		}
+		out := []*types.Var{p.p.NewParam(token.NoPos, "", ptr.Elem())}
+		for i := 0; i < sig.Results().Len(); i++ {
+			out = append(out, sig.Results().At(i))
+		}
+		results = types.NewTuple(out...)
+	} else {
+		elem := types.Unalias(handleType)
+		ptr, isPtr := elem.(*types.Pointer)
+		if isPtr {
+			elem = types.Unalias(ptr.Elem())
+		}
+		named, _ := elem.(*types.Named)
+		if named == nil || named.Obj().Pkg() != p.p.Types {
+			// the receiver is not a type defined in this package, which can't have methods
+			return p.newFuncDecl(fnSpec.FnName, cname, funcDecl)
+		}
+		if handle == 0 && isPtr == fnSpec.PtrRecv {
+			return p.newFuncDecl(fnSpec.GoSymbName, cname, funcDecl)
+		}
+		if hasMethod(named, fnSpec.FnName) {
+			return fmt.Errorf("NewFuncDecl: %s already defined", fnSpec.GoSymbName)
+		}
+		recvType := types.Type(named)
+		if fnSpec.PtrRecv {
+			recvType = types.NewPointer(named)
+		}
+		recv = p.p.NewParam(token.NoPos, "recv_", recvType)
+		deref, addr = !isPtr && fnSpec.PtrRecv, isPtr && !fnSpec.PtrRecv
+		results = sig.Results()
+	}
+	p.symbols.Register(node, fnSpec.FnName)
+	p.symbols.claimFunc(cname, fnSpec.GoSymbName, fnSpec.GoSymbName)
+
+	// the C function in the C order
+	linkName := "c_" + funcDecl.Name.Name
+	if funcDecl.MangledName != "" {
+		linkName = "c_" + funcDecl.MangledName
+	}
+	link := p.p.NewFuncDecl(token.NoPos, linkName, sig)
+	if err := p.bodyStart(link, funcDecl.Type.Ret); err != nil {
+		return err
+	}
+	linkDoc := NewCommentGroup(refDocComments(funcDecl.Type, sig)...)
+	linkDoc.List = append(linkDoc.List, NewFuncDocComment(p.linkSymbol(funcDecl), linkName))
+	link.SetComments(p.p, linkDoc)
+
+	params := make([]*types.Var, sig.Params().Len())
+	var wrapperParams []*types.Var
+	for i := range params {
+		param := sig.Params().At(i)
+		paramName := param.Name()
+		if paramName == "" || paramName == "_" {
+			paramName = "arg" + strconv.Itoa(i)
+		}
+		params[i] = p.p.NewParam(token.NoPos, paramName, param.Type())
+		if i != handle {
+			wrapperParams = append(wrapperParams, params[i])
+		}
+	}
+	decl := p.p.NewFuncDecl(token.NoPos, fnSpec.FnName, types.NewSignatureType(recv, nil, nil, types.NewTuple(wrapperParams...), results, false))
+	docName := fnSpec.FnName
+	if recv != nil {
+		p.docs.names[funcDecl.Name.Name] = fnSpec.RecvName + "." + fnSpec.FnName
+	} else {
+		p.docs.names[funcDecl.Name.Name] = fnSpec.FnName
+	}
+	decl.SetComments(p.p, p.newDocComment(docName, funcDecl.Doc))
+
+	cb := decl.BodyStart(p.p)
+	var out *types.Var
+	if fnSpec.IsCtor {
+		cb.NewVar(results.At(0).Type(), params[handle].Name())
+		out = cb.Scope().Lookup(params[handle].Name()).(*types.Var)
+	}
+	cb.Val(link.Func)
+	for i, param := range params {
+		switch {
+		case i != handle:
+			cb.Val(param)
+		case out != nil:
+			cb.Val(out).UnaryOp(token.AND)
+		case deref:
+			cb.Val(recv).Elem()
+		case addr:
+			cb.Val(recv).UnaryOp(token.AND)
+		default:
+			cb.Val(recv)
+		}
+	}
+	cb.Call(len(params))
+	switch {
+	case out == nil && sig.Results().Len() == 0:
+		cb.EndStmt()
+	case out == nil:
+		cb.Return(1)
+	case sig.Results().Len() == 0:
+		cb.EndStmt()
+		cb.Val(out).Return(1)
+	default:
+		cb.DefineVarStart(token.NoPos, "ret_").EndInit(1)
+		ret := cb.Scope().Lookup("ret_").(*types.Var)
+		cb.Val(out).Val(ret).Return(2)
+	}
+	cb.End()
+	return nil
+}
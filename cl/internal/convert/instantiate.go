@@ -0,0 +1,64 @@
+package convert
+
+import (
+	"github.com/goplus/llcppg/ast"
+	"github.com/goplus/llcppg/internal/name"
+)
+
+// isInstantiation reports whether a C++ class is a class template instantiation of
+// the instantiate option, which is declared by its template-id like std::vector<int>.
+func isInstantiation(typeDecl *ast.TypeDecl) bool {
+	return typeDecl.Name != nil && name.IsTemplateID(typeDecl.Name.Name)
+}
+
+// instantiate explicitly instantiates a class template specialization in the C++ shim,
+// which emits the members linked by the Go methods of the instantiation.
+func (p *Package) instantiate(typeDecl *ast.TypeDecl) {
+	p.shim.add("template class %s;", typeDecl.QualifiedName())
+}
+
+// bindable reports whether the types of a method of an instantiation are declared, the
+// instantiated members may refer to the types of the template library not in the package,
+// which are not declared implicitly like the forward declarations.
+func (p *Package) bindable(method *ast.FuncDecl) bool {
+	return p.boundType(method.Type)
+}
+
+func (p *Package) boundType(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.PointerType:
+		return p.boundType(t.X)
+	case *ast.LvalueRefType:
+		return p.boundType(t.X)
+	case *ast.RvalueRefType:
+		return p.boundType(t.X)
+	case *ast.ArrayType:
+		return p.boundType(t.Elt)
+	case *ast.FuncType:
+		for _, param := range paramList(t) {
+			if !p.boundType(param.Type) {
+				return false
+			}
+		}
+		return t.Ret == nil || p.boundType(t.Ret)
+	case *ast.TagExpr:
+		return p.boundType(t.Name)
+	case *ast.InstantiationType:
+		b := stdBridgeOf(t)
+		return b != nil && p.boundType(b.elem)
+	case *ast.Ident, *ast.ScopingExpr:
+		if stdBridgeOf(t) != nil {
+			return true
+		}
+		cname := ast.QualifiedName(t)
+		pkg := p
+		if p.route != nil {
+			var err error
+			if pkg, err = p.route(p, cname); err != nil {
+				return false
+			}
+		}
+		return pkg.Lookup(cname) != nil
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package convert
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/goplus/llcppg/ast"
+	"github.com/goplus/llcppg/internal/name"
+)
+
+// splitLibs reports whether the libraries can be split to the sub-packages, which needs
+// the plain flags of them, like -L/opt/lib -lfoo -lbar, rather than the commands like
+// $(pkg-config --libs foo).
+func splitLibs(libs string) bool {
+	if strings.ContainsAny(libs, "$`") {
+		log.Printf("splitLibs: %s is not plain flags, not split\n", libs)
+		return false
+	}
+	return strings.Contains(libs, "-l")
+}
+
+// initLibs prepares the split libs mode, where the functions of a library are converted
+// in its own Go sub-package, except the ones of the first library, which are converted in
+// the main package with the types and the methods. Each package links only the libraries
+// of its symbols, and the sub-packages import the main package.
+func (p *Converter) initLibs() {
+	if !p.Conf.SplitLibs || !splitLibs(p.Conf.Libs) {
+		return
+	}
+	for _, flag := range strings.Fields(p.Conf.Libs) {
+		if lib, ok := strings.CutPrefix(flag, "-l"); ok {
+			p.libNames = append(p.libNames, lib)
+		} else {
+			p.libFlags = append(p.libFlags, flag)
+		}
+	}
+	p.libPkgs = make(map[string]*SubPackage)
+	p.pkgLibs = make(map[*Package]map[string]bool)
+	if p.imports == nil {
+		p.imports = make(map[*Package]map[*Package]bool)
+	}
+}
+
+// funcPackage returns the package of a function and its Go name, which is the sub-package of
+// its library in the split libs mode. A method style name like (*Foo).Bar is a function Bar of
+// the sub-package, whose types are the ones of the main package.
+func (p *Converter) funcPackage(ctx *Package, fn *ast.FuncDecl, goName string) (*Package, string, error) {
+	if p.libPkgs == nil {
+		return ctx, goName, nil
+	}
+	lib := p.symbolLib(fn)
+	if lib == "" {
+		// the library is unknown, like the one of an old symbol table, so all are linked
+		for _, lib := range p.libNames {
+			p.useLib(ctx, lib)
+		}
+		return ctx, goName, nil
+	}
+	if lib == p.libNames[0] {
+		p.useLib(ctx, lib)
+		return ctx, goName, nil
+	}
+	pkg, err := p.libPackage(lib)
+	if err != nil {
+		return nil, "", err
+	}
+	p.useLib(pkg, lib)
+	if spec := NewGoFuncSpec(goName, nil); spec.RecvName != "" {
+		goName = spec.FnName
+	}
+	return pkg, goName, nil
+}
+
+// symbolLib returns the -l name of the library providing a function, or "" if it's
+// unknown or not one of the libraries.
+func (p *Converter) symbolLib(fn *ast.FuncDecl) string {
+	symbol := fn.Name.Name
+	if fn.MangledName != "" {
+		symbol = fn.MangledName
+	}
+	lib := p.Conf.SymbolLibs[symbol]
+	for _, name := range p.libNames {
+		if name == lib {
+			return lib
+		}
+	}
+	return ""
+}
+
+// useLib records the library linked by a package in the split libs mode.
+func (p *Converter) useLib(pkg *Package, lib string) {
+	if p.libPkgs == nil || lib == "" {
+		return
+	}
+	if p.pkgLibs[pkg] == nil {
+		p.pkgLibs[pkg] = make(map[string]bool)
+	}
+	p.pkgLibs[pkg][lib] = true
+}
+
+// libPackage returns the Go sub-package of a library, which is created on the first use.
+func (p *Converter) libPackage(lib string) (*Package, error) {
+	if sub, ok := p.libPkgs[lib]; ok {
+		return sub.Package, nil
+	}
+	dir := libPackageName(lib)
+	conf := p.GenPkg.conf
+	pkg, err := NewPackage(p.NC, &PackageConfig{
+		PkgBase: PkgBase{
+			PkgPath: path.Join(conf.PkgPath, dir),
+			Deps:    p.Conf.Deps,
+			Pubs:    make(map[string]string),
+		},
+		Name:          dir,
+		OutputDir:     conf.OutputDir,
+		TypeSizes:     conf.TypeSizes,
+		OpaqueExclude: conf.OpaqueExclude,
+		AliasTypedefs: conf.AliasTypedefs,
+		AutoAlias:     conf.AutoAlias,
+		GoDoc:         conf.GoDoc,
+		Includes:      conf.Includes,
+		CFlags:        conf.CFlags,
+
+		ConstRefByValue: conf.ConstRefByValue,
+		StdWrappers:     conf.StdWrappers,
+		DefaultArgs:     conf.DefaultArgs,
+		SymVersions:     conf.SymVersions,
+		LockedNames:     conf.LockedNames,
+		Preferred:       conf.Preferred,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("library %s: %w", lib, err)
+	}
+	pkg.route = p.routeLibType
+	pkg.trivial = p.GenPkg.trivial
+	pkg.cvt.classes = p.GenPkg.cvt.classes
+	p.libPkgs[lib] = &SubPackage{Package: pkg, Lib: lib, Dir: dir}
+	p.SubPkgs = append(p.SubPkgs, p.libPkgs[lib])
+	return pkg, nil
+}
+
+// routeLibType returns the main package, which declares all the types in the split libs mode,
+// and records the import of it.
+func (p *Converter) routeLibType(from *Package, _ string) (*Package, error) {
+	if from != p.GenPkg {
+		if p.imports[from] == nil {
+			p.imports[from] = make(map[*Package]bool)
+		}
+		p.imports[from][p.GenPkg] = true
+	}
+	return p.GenPkg, nil
+}
+
+// libLink returns the link flags of a package in the split libs mode, which are the ones of
+// the libraries it uses, or "" if it uses none.
+func (p *Converter) libLink(pkg *Package) string {
+	var libs []string
+	for _, lib := range p.libNames {
+		if p.pkgLibs[pkg][lib] {
+			libs = append(libs, "-l"+lib)
+		}
+	}
+	if len(libs) == 0 {
+		return ""
+	}
+	return strings.Join(append(append([]string{}, p.libFlags...), libs...), " ")
+}
+
+// libPackageName returns the name of the Go sub-package of a library, like foo of
+// -lfoo and -l:libfoo.so.1.
+func libPackageName(lib string) string {
+	if file, ok := strings.CutPrefix(lib, ":"); ok {
+		lib = strings.TrimPrefix(file, "lib")
+		if i := strings.IndexAny(lib, "."); i > 0 {
+			lib = lib[:i]
+		}
+	}
+	return name.PackageName(strings.NewReplacer("-", "_", ".", "_", "+", "x").Replace(lib))
+}
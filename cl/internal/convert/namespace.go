@@ -0,0 +1,177 @@
+package convert
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/goplus/llcppg/ast"
+	llcppg "github.com/goplus/llcppg/config"
+	"github.com/goplus/llcppg/internal/name"
+)
+
+// SubPackage is the Go sub-package of a C++ namespace in the package namespace mode,
+// or the one of a library in the split libs mode.
+type SubPackage struct {
+	*Package
+	Namespace string // qualified name of the namespace, like a::b
+	Lib       string // -l name of the library, like cjson of -lcjson
+	Dir       string // directory relative to the output directory, like a/b
+}
+
+// initNamespaces prepares the package namespace mode, where the declarations of a namespace
+// are converted in its own Go sub-package. The sub-packages are imported by their paths
+// in the module of the output directory.
+func (p *Converter) initNamespaces() {
+	if p.Conf.NamespaceMode != llcppg.NamespacePackage {
+		return
+	}
+	p.namespaces = make(map[string]*SubPackage)
+	p.imports = make(map[*Package]map[*Package]bool)
+	p.classes = make(map[string]bool)
+	for _, decl := range p.Pkg.Decls {
+		if decl, ok := decl.(*ast.TypeDecl); ok {
+			p.classes[decl.QualifiedName()] = true
+		}
+	}
+	p.GenPkg.route = p.routeType
+}
+
+// namespaceOf returns the namespace whose Go package holds the C++ name, like a::b of a::b::Foo,
+// which is empty for the names of the global namespace and the ones of the stripped namespaces.
+func (p *Converter) namespaceOf(cname string) string {
+	scopes, _ := name.SplitScope(cname)
+	for i := range scopes {
+		// the nested names of a class belong to the package of the class
+		if p.classes[strings.Join(scopes[:i+1], "::")] {
+			scopes = scopes[:i]
+			break
+		}
+	}
+	return strings.Join(name.Namespaces(scopes, p.Conf.StripNamespaces), "::")
+}
+
+// declPackage returns the package of a declaration, which is the main package
+// except for the namespaces in the package namespace mode.
+func (p *Converter) declPackage(obj *ast.Object) (*Package, error) {
+	if p.namespaces == nil || obj.Name == nil {
+		return p.GenPkg, nil
+	}
+	return p.namespacePackage(p.namespaceOf(obj.QualifiedName()))
+}
+
+// namespacePackage returns the Go sub-package of a namespace, which is created on the first use.
+func (p *Converter) namespacePackage(ns string) (*Package, error) {
+	if ns == "" {
+		return p.GenPkg, nil
+	}
+	if sub, ok := p.namespaces[ns]; ok {
+		return sub.Package, nil
+	}
+	var dirs []string
+	for _, part := range strings.Split(ns, "::") {
+		dirs = append(dirs, name.PackageName(part))
+	}
+	dir := path.Join(dirs...)
+	conf := p.GenPkg.conf
+	pkg, err := NewPackage(p.NC, &PackageConfig{
+		PkgBase: PkgBase{
+			PkgPath: path.Join(conf.PkgPath, dir),
+			Deps:    p.Conf.Deps,
+			Pubs:    make(map[string]string),
+		},
+		Name:          dirs[len(dirs)-1],
+		OutputDir:     conf.OutputDir,
+		LibCommand:    conf.LibCommand,
+		TypeSizes:     conf.TypeSizes,
+		OpaqueExclude: conf.OpaqueExclude,
+		AliasTypedefs: conf.AliasTypedefs,
+		AutoAlias:     conf.AutoAlias,
+		GoDoc:         conf.GoDoc,
+		Includes:      conf.Includes,
+		CFlags:        conf.CFlags,
+
+		ConstRefByValue: conf.ConstRefByValue,
+		StdWrappers:     conf.StdWrappers,
+		DefaultArgs:     conf.DefaultArgs,
+		SymVersions:     conf.SymVersions,
+		LockedNames:     conf.LockedNames,
+		Preferred:       conf.Preferred,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("namespace %s: %w", ns, err)
+	}
+	// the implicit forward declarations of the package may come before its first declaration
+	pkg.setCurFile(pkg.conf.Name + "_autogen.go")
+	pkg.route = p.routeType
+	pkg.trivial = p.GenPkg.trivial
+	pkg.cvt.classes = p.GenPkg.cvt.classes
+	p.namespaces[ns] = &SubPackage{Package: pkg, Namespace: ns, Dir: dir}
+	p.SubPkgs = append(p.SubPkgs, p.namespaces[ns])
+	return pkg, nil
+}
+
+// routeType returns the package declaring the C++ type cname referred in the package from,
+// and records the import between the packages.
+func (p *Converter) routeType(from *Package, cname string) (*Package, error) {
+	pkg, err := p.namespacePackage(p.namespaceOf(cname))
+	if err != nil {
+		return nil, err
+	}
+	if pkg != from {
+		if p.imports[from] == nil {
+			p.imports[from] = make(map[*Package]bool)
+		}
+		p.imports[from][pkg] = true
+	}
+	return pkg, nil
+}
+
+// sortNamespaces returns the packages of the package namespace mode, the imported ones
+// come first. Go packages can't import each other, so an import cycle is an error.
+func (p *Converter) sortNamespaces() ([]*Package, error) {
+	all := []*Package{p.GenPkg}
+	for _, sub := range p.SubPkgs {
+		all = append(all, sub.Package)
+	}
+	var sorted []*Package
+	state := make(map[*Package]int) // 1: visiting, 2: visited
+	var stack []*Package
+	var visit func(pkg *Package) error
+	visit = func(pkg *Package) error {
+		switch state[pkg] {
+		case 1:
+			var cycle []string
+			for i := len(stack) - 1; i >= 0; i-- {
+				cycle = append([]string{stack[i].conf.PkgPath}, cycle...)
+				if stack[i] == pkg {
+					break
+				}
+			}
+			return fmt.Errorf("import cycle between the namespace packages: %s -> %s",
+				strings.Join(cycle, " -> "), pkg.conf.PkgPath)
+		case 2:
+			return nil
+		}
+		state[pkg] = 1
+		stack = append(stack, pkg)
+		// visit the imports in the creation order to keep the output stable
+		for _, dep := range all {
+			if p.imports[pkg][dep] {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[pkg] = 2
+		sorted = append(sorted, pkg)
+		return nil
+	}
+	for _, pkg := range all {
+		if err := visit(pkg); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
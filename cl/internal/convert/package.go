@@ -5,12 +5,14 @@ import (
 	"go/token"
 	"go/types"
 	"log"
+	"slices"
 
 	goast "go/ast"
 
 	"github.com/goplus/gogen"
 	"github.com/goplus/llcppg/ast"
 	"github.com/goplus/llcppg/cl/nc"
+	llcppg "github.com/goplus/llcppg/config"
 	"github.com/goplus/llcppg/internal/name"
 	ctoken "github.com/goplus/llcppg/token"
 )
@@ -41,6 +43,20 @@ type Package struct {
 	incompleteTypes *IncompleteTypes
 
 	symbols *ProcessSymbol // record the processed node
+
+	extTypes map[string]types.Type // declared types of the autogen types file
+
+	docs *docComments // C doc comments to be converted to Go doc comments
+
+	shim cppShim // C++ shim of the virtual methods and the Go subclasses
+
+	// trivial records the C++ records which are trivially copyable, shared by
+	// the packages of the namespaces, see constRefByValue
+	trivial map[*types.TypeName]bool
+
+	// route returns the package declaring a C++ type in the package namespace mode,
+	// where the types of a namespace are declared in its Go sub-package
+	route func(from *Package, cname string) (*Package, error)
 }
 
 type PackageConfig struct {
@@ -49,6 +65,47 @@ type PackageConfig struct {
 	OutputDir string
 	GenConf   *gogen.Config
 
+	// sizes of the target dependent builtin types, nil means the host defaults
+	TypeSizes *llcppg.TypeSizes
+
+	// C names of forward declared only structs that keep a placeholder field
+	// instead of becoming opaque types
+	OpaqueExclude []string
+
+	// C names of typedefs that are generated as type aliases instead of defined types
+	AliasTypedefs []string
+	// generate all typedefs of builtin and pointer types as type aliases
+	AutoAlias bool
+
+	// convert the Doxygen and Javadoc comments to Go doc comments
+	GoDoc bool
+
+	// headers and compile flags of the C++ shim, which is generated for
+	// the virtual methods and the Go subclasses of the C++ classes
+	Includes []string
+	CFlags   string
+
+	// max size in bytes of the trivially copyable types whose const reference
+	// parameters are passed by value through the C++ shim, 0 keeps them pointers
+	ConstRefByValue int
+
+	// generate the Go wrappers using Go strings and slices of the functions
+	// taking or returning std::string, std::string_view and std::vector
+	StdWrappers bool
+
+	// how the default arguments of the C++ functions are omitted, keyed by their qualified names,
+	// see llcppg.DefaultArgsOverloads and llcppg.DefaultArgsOptions
+	DefaultArgs map[string]string
+
+	// the ELF symbol versions the symbols are linked to, keyed by the symbols, like VERS_1 of
+	// foo@VERS_1, the others are linked by the names to their default versions
+	SymVersions map[string]string
+
+	// the Go names locked by the name lock file, keyed by the kinds and the C names, see llcppg.NameLock
+	LockedNames map[string]string
+	// the C names keeping the Go names they collide on, keyed by the Go names, see llcppg.Collision
+	Preferred map[string]string
+
 	// use to gen link command like $(pkg-config --libs xxx)
 	// if have this field, will generate a const named LLGoPackage, and it's not necessary
 	LibCommand string
@@ -68,6 +125,14 @@ func NewPackage(pnc nc.NodeConverter, config *PackageConfig) (*Package, error) {
 		conf:            config,
 		incompleteTypes: NewIncompleteTypes(),
 		symbols:         NewProcessSymbol(),
+		docs:            &docComments{names: make(map[string]string)},
+		trivial:         make(map[*types.TypeName]bool),
+	}
+	if config.LockedNames != nil {
+		p.symbols.Lock(config.LockedNames)
+	}
+	if config.Preferred != nil {
+		p.symbols.Prefer(config.Preferred)
 	}
 
 	// default have load llgo/c
@@ -99,7 +164,9 @@ func NewPackage(pnc nc.NodeConverter, config *PackageConfig) (*Package, error) {
 	}
 
 	p.markUseDeps(pkgManager)
-	p.cvt = NewConv(p.p, p.p.Types, pnc, p.lookupType)
+	p.cvt = NewConv(p.p, p.p.Types, config.TypeSizes, pnc, p.lookupType, p.extType)
+	p.cvt.nested = p.newNestedRecord
+	p.cvt.bridge = p.bridgeType
 	return p, nil
 }
 
@@ -136,7 +203,13 @@ func (p *Package) newReceiver(typ *ast.FuncType) (*types.Var, error) {
 	if err != nil {
 		return nil, fmt.Errorf("newReceiver:failed to convert type: %w", err)
 	}
-	return p.p.NewParam(token.NoPos, "recv_", recvType), nil
+	// a receiver can't be a type alias, so use the aliased type
+	if ptr, ok := recvType.(*types.Pointer); ok {
+		if _, ok := ptr.Elem().(*types.Alias); ok {
+			recvType = types.NewPointer(types.Unalias(ptr.Elem()))
+		}
+	}
+	return p.p.NewParam(token.NoPos, "recv_", types.Unalias(recvType)), nil
 }
 
 func (p *Package) ToSigSignature(recv *types.Var, funcDecl *ast.FuncDecl) (*types.Signature, error) {
@@ -193,12 +266,33 @@ func (p *Package) handleFuncDecl(fnSpec *GoFuncSpec, sig *types.Signature, funcD
 		decl = p.p.NewFuncDecl(token.NoPos, fnPubName, sig)
 	}
 
-	doc := NewCommentGroupFromC(funcDecl.Doc)
-	doc.List = append(doc.List, NewFuncDocComment(funcDecl.Name.Name, fnPubName))
+	docName := fnPubName
+	if fnSpec.IsMethod {
+		docName = fnSpec.FnName
+		p.docs.names[funcDecl.Name.Name] = fnSpec.RecvName + "." + fnSpec.FnName
+	} else {
+		p.docs.names[funcDecl.Name.Name] = fnPubName
+	}
+	doc := p.newDocComment(docName, funcDecl.Doc)
+	doc.List = append(doc.List, refDocComments(funcDecl.Type, sig)...)
+	doc.List = append(doc.List, NewFuncDocComment(p.linkSymbol(funcDecl), fnPubName))
 	decl.SetComments(p.p, doc)
 	return nil
 }
 
+// linkSymbol returns the C symbol a function is linked to, the C++ functions are linked
+// through their mangled names, and the ones of symVersions to their versions.
+func (p *Package) linkSymbol(funcDecl *ast.FuncDecl) string {
+	symbol := funcDecl.Name.Name
+	if funcDecl.MangledName != "" {
+		symbol = funcDecl.MangledName
+	}
+	if version, ok := p.conf.SymVersions[symbol]; ok {
+		symbol += "@" + version
+	}
+	return symbol
+}
+
 type TypeDefinedError struct {
 	Name       string
 	OriginName string
@@ -216,11 +310,11 @@ func NewTypeDefinedError(name, originName string) *TypeDefinedError {
 }
 
 func getNamedType(recvType types.Type) *types.Named {
-	switch t := recvType.(type) {
+	switch t := types.Unalias(recvType).(type) {
 	case *types.Named:
 		return t
 	case *types.Pointer:
-		if named, ok := t.Elem().(*types.Named); ok {
+		if named, ok := types.Unalias(t.Elem()).(*types.Named); ok {
 			return named
 		}
 	}
@@ -249,7 +343,59 @@ func pubMethodName(recv types.Type, fnSpec *GoFuncSpec) string {
 	return named.Obj().Name() + "." + fnSpec.FnName
 }
 
+// NewMethodDecl converts a public method of the C++ class to a Go method, with the this
+// pointer as the receiver, and links it through the mangled name. Constructors and destructors
+// are named Init and Dispose by llcppsymg, and static methods are converted to functions.
+// The virtual methods are skipped, because they are called through the vtable.
+func (p *Package) NewMethodDecl(goName string, class *ast.TypeDecl, method *ast.FuncDecl) error {
+	if method.IsVirtual && !method.IsDestructor {
+		if debugLog {
+			log.Printf("NewMethodDecl: %s is virtual, skip\n", method.MangledName)
+		}
+		return nil
+	}
+	return p.newMethodDecl(goName, method.MangledName, class, method)
+}
+
+// newMethodDecl converts a method of the C++ class to a Go method linked to the symbol.
+func (p *Package) newMethodDecl(goName, symbol string, class *ast.TypeDecl, method *ast.FuncDecl) error {
+	if symbol == method.MangledName {
+		method = p.refWrapper(method, class)
+		symbol = method.MangledName
+	}
+	fn := *method
+	fn.Name = &ast.Ident{Name: symbol}
+	fn.MangledName = symbol
+	if !method.IsStatic {
+		this := &ast.Field{
+			Names: []*ast.Ident{{Name: "this"}},
+			Type:  &ast.PointerType{X: objectRef(&class.Object)},
+		}
+		var params []*ast.Field
+		if method.Type.Params != nil {
+			params = method.Type.Params.List
+		}
+		fnType := *method.Type
+		fnType.Params = &ast.FieldList{List: append([]*ast.Field{this}, params...)}
+		fn.Type = &fnType
+	}
+	return p.newFuncDecl(goName, method.QualifiedName(), &fn)
+}
+
+// objectRef returns the expression referring to a declared object, like a::Foo.
+func objectRef(obj *ast.Object) ast.Expr {
+	if obj.Parent != nil {
+		return &ast.ScopingExpr{Parent: obj.Parent, X: obj.Name}
+	}
+	return obj.Name
+}
+
 func (p *Package) NewFuncDecl(goName string, funcDecl *ast.FuncDecl) error {
+	return p.newFuncDecl(goName, funcDecl.QualifiedName(), funcDecl)
+}
+
+// newFuncDecl is NewFuncDecl of the function cname, which is recorded by the name collisions.
+func (p *Package) newFuncDecl(goName, cname string, funcDecl *ast.FuncDecl) error {
 	if debugLog {
 		log.Printf("NewFuncDecl: %v\n", funcDecl.Name)
 	}
@@ -259,8 +405,11 @@ func (p *Package) NewFuncDecl(goName string, funcDecl *ast.FuncDecl) error {
 		log.Printf("NewFuncDecl: %v is ignored\n", funcDecl.Name)
 		return nil
 	}
+	if fnSpec.IsCtor || fnSpec.IsMethod && fnSpec.Handle >= 0 {
+		return p.newHandleFuncDecl(fnSpec, cname, funcDecl)
+	}
 
-	recv, exist, err := p.funcIsDefined(fnSpec, funcDecl)
+	recv, exist, err := p.funcIsDefined(fnSpec, cname, funcDecl)
 	if err != nil {
 		return fmt.Errorf("NewFuncDecl: %s fail: %w", funcDecl.Name.Name, err)
 	}
@@ -278,11 +427,8 @@ func (p *Package) NewFuncDecl(goName string, funcDecl *ast.FuncDecl) error {
 	return p.handleFuncDecl(fnSpec, sig, funcDecl)
 }
 
-func (p *Package) funcIsDefined(fnSpec *GoFuncSpec, funcDecl *ast.FuncDecl) (recv *types.Var, exist bool, err error) {
-	node := Node{
-		name: funcDecl.Name.Name,
-		kind: FuncDecl,
-	}
+func (p *Package) funcIsDefined(fnSpec *GoFuncSpec, cname string, funcDecl *ast.FuncDecl) (recv *types.Var, exist bool, err error) {
+	node := funcNode(funcDecl)
 	// if already processed, return
 	_, exist = p.symbols.Lookup(node)
 	if exist {
@@ -294,6 +440,13 @@ func (p *Package) funcIsDefined(fnSpec *GoFuncSpec, funcDecl *ast.FuncDecl) (rec
 			return nil, false, err
 		}
 		var namedType = getNamedType(recv.Type())
+		if namedType == nil || namedType.Obj().Pkg() != p.p.Types {
+			// the receiver is a type alias of a type not defined in this package,
+			// which can't have methods, so generate a function instead
+			fnSpec.IsMethod = false
+			fnSpec.GoSymbName = fnSpec.FnName
+			return p.funcIsDefined(fnSpec, cname, funcDecl)
+		}
 		methodName := fnSpec.FnName
 		for i := 0; i < namedType.NumMethods(); i++ {
 			if namedType.Method(i).Name() == methodName { // unreachable, because if have the same method name, will return in p.symbols.Lookup(node)
@@ -302,14 +455,25 @@ func (p *Package) funcIsDefined(fnSpec *GoFuncSpec, funcDecl *ast.FuncDecl) (rec
 		}
 	} else {
 		if obj := p.Lookup(fnSpec.FnName); obj != nil {
+			p.symbols.claimFunc(cname, fnSpec.FnName, "")
 			return nil, true, fmt.Errorf("NewFuncDecl: %s already defined", fnSpec.GoSymbName)
 		}
 	}
 	// register the function
 	p.symbols.Register(node, fnSpec.FnName)
+	p.symbols.claimFunc(cname, fnSpec.GoSymbName, fnSpec.GoSymbName)
 	return
 }
 
+// funcNode returns the node of a function registered, the overloads of a C++ function
+// are told apart by their symbols.
+func funcNode(funcDecl *ast.FuncDecl) Node {
+	if funcDecl.MangledName != "" {
+		return Node{name: funcDecl.MangledName, kind: FuncDecl}
+	}
+	return Node{name: funcDecl.Name.Name, kind: FuncDecl}
+}
+
 func (p *Package) Lookup(name string) types.Object {
 	return gogen.Lookup(p.p.Types.Scope(), name)
 }
@@ -332,6 +496,15 @@ func (p *Package) lookupPub(_ string, pubName string) types.Object {
 // If the type is from a third-party header file and not yet converted, it will return an error.
 // When the type doesn't exist, it creates an implicit forward declaration.
 func (p *Package) lookupType(name string, pnc nc.NodeConverter) (types.Type, error) {
+	if p.route != nil {
+		pkg, err := p.route(p, name)
+		if err != nil {
+			return nil, err
+		}
+		if pkg != p {
+			return pkg.lookupType(name, pnc)
+		}
+	}
 	obj := p.Lookup(name)
 	if obj != nil {
 		return obj.Type(), nil
@@ -350,23 +523,23 @@ func (p *Package) lookupType(name string, pnc nc.NodeConverter) (types.Type, err
 // - Forward declarations: Pre-registers incomplete types for later definition
 // - Self-referential types: Handles types that reference themselves (like linked lists)
 func (p *Package) NewTypeDecl(goName string, typeDecl *ast.TypeDecl, pnc nc.NodeConverter) error {
+	cname := typeDecl.QualifiedName()
 	if debugLog {
-		log.Printf("NewTypeDecl: %s\n", typeDecl.Name.Name)
+		log.Printf("NewTypeDecl: %s\n", cname)
 	}
 
-	cname := typeDecl.Name.Name
 	isForward := p.cvt.inComplete(typeDecl.Type)
 	name, changed, exist, err := p.RegisterNode(Node{name: cname, kind: TypeDecl}, goName, p.lookupOrigin)
 	if err != nil {
-		return fmt.Errorf("NewTypeDecl: %s fail: %w", typeDecl.Name.Name, err)
+		return fmt.Errorf("NewTypeDecl: %s fail: %w", cname, err)
 	}
 
 	// if the type is already defined, we don't need to process again
 	// but if the previous processed node is a forward declaration, we need to complete the type
-	_, isIncom := p.incompleteTypes.Lookup(typeDecl.Name.Name)
+	_, isIncom := p.incompleteTypes.Lookup(cname)
 	if exist && (isForward || !isIncom) {
 		if debugLog {
-			log.Printf("NewTypeDecl: %s is processed\n", typeDecl.Name.Name)
+			log.Printf("NewTypeDecl: %s is processed\n", cname)
 		}
 		return nil
 	}
@@ -379,8 +552,15 @@ func (p *Package) NewTypeDecl(goName string, typeDecl *ast.TypeDecl, pnc nc.Node
 
 	if !isForward {
 		if err := p.handleCompleteType(incom, typeDecl.Type, cname); err != nil {
-			return fmt.Errorf("NewTypeDecl: fail to complete type %s: %w", typeDecl.Name.Name, err)
+			return fmt.Errorf("NewTypeDecl: fail to complete type %s: %w", cname, err)
+		}
+		if isInstantiation(typeDecl) {
+			// not all the special members of an instantiation are referred
+			p.instantiate(typeDecl)
+		} else {
+			p.markTrivial(incom.decl.Type(), typeDecl.Type)
 		}
+		return p.newStaticMembers(name, typeDecl)
 	}
 	return nil
 }
@@ -396,7 +576,7 @@ func (p *Package) handleTypeDecl(pubname string, cname string, typeDecl *ast.Typ
 		file:  p.p.CurFile(),
 		decl:  decl,
 		getType: func() (types.Type, error) {
-			return types.NewStruct(p.cvt.defaultRecordField(), nil), nil
+			return p.opaqueType(cname), nil
 		},
 	}
 	p.incompleteTypes.Add(inc)
@@ -410,7 +590,7 @@ func (p *Package) handleCompleteType(incom *Incomplete, typ *ast.RecordType, nam
 	defer p.incompleteTypes.Complete(name)
 	defer func() { pkg.RestoreCurFile(curFile) }()
 	curFile = pkg.RestoreCurFile(incom.file)
-	structType, err := p.cvt.RecordTypeToStruct(typ)
+	structType, err := p.cvt.NamedRecordTypeToStruct(name, incom.decl.Type().Obj().Name(), typ)
 	if err != nil {
 		// For incomplete type's conerter error, we use default struct type
 		return err
@@ -419,6 +599,28 @@ func (p *Package) handleCompleteType(incom *Incomplete, typ *ast.RecordType, nam
 	return nil
 }
 
+// newNestedRecord declares an anonymous record nested in a field as a named type,
+// so that it can be used in signatures and variable declarations. The name
+// mapping is recorded with the dotted C path of the field like `outer.field`.
+func (p *Package) newNestedRecord(cname, goName string, typ *ast.RecordType, pnc nc.NodeConverter) (types.Type, error) {
+	name, _, exist, err := p.RegisterNode(Node{name: cname, kind: TypeDecl}, goName, p.lookupOrigin)
+	if err != nil {
+		return nil, fmt.Errorf("newNestedRecord: %s fail: %w", cname, err)
+	}
+	if exist {
+		if obj := p.p.Types.Scope().Lookup(name); obj != nil {
+			return obj.Type(), nil
+		}
+	}
+	p.CollectNameMapping(cname, name, pnc)
+	decl := p.emptyTypeDecl(name, nil)
+	structType, err := p.cvt.NamedRecordTypeToStruct(cname, name, typ)
+	if err != nil {
+		return nil, err
+	}
+	return decl.InitType(p.p, structType), nil
+}
+
 // handleImplicitForwardDecl handles type references that cannot be found in the current scope.
 // For such declarations, create a empty type decl and store it in the
 // incomplete map, but not in the public symbol table.
@@ -437,46 +639,62 @@ func (p *Package) handleImplicitForwardDecl(name string, pnc nc.NodeConverter) *
 		file:  p.p.CurFile(),
 		decl:  decl,
 		getType: func() (types.Type, error) {
-			return types.NewStruct(p.cvt.defaultRecordField(), nil), nil
+			return p.opaqueType(name), nil
 		},
 	}
 	p.incompleteTypes.Add(inc)
 	return decl
 }
 
+// opaqueType returns the type of a struct that is never defined, it has no size
+// and carries a no-copy marker, so it can only be used through pointers.
+func (p *Package) opaqueType(cname string) types.Type {
+	if slices.Contains(p.conf.OpaqueExclude, cname) {
+		return types.NewStruct(p.cvt.defaultRecordField(), nil)
+	}
+	return types.NewStruct([]*types.Var{
+		types.NewField(token.NoPos, p.p.Types, "_", p.noCopyType(), false),
+	}, nil)
+}
+
 func (p *Package) emptyTypeDecl(name string, doc *ast.CommentGroup) *gogen.TypeDecl {
 	typeBlock := p.p.NewTypeDefs()
-	typeBlock.SetComments(NewCommentGroupFromC(doc))
+	typeBlock.SetComments(p.newDocComment(name, doc))
 	return typeBlock.NewType(name)
 }
 
 func (p *Package) NewTypedefDecl(goName string, typedefDecl *ast.TypedefDecl, pnc nc.NodeConverter) error {
+	cname := typedefDecl.QualifiedName()
 	if debugLog {
-		log.Printf("NewTypedefDecl: %s\n", typedefDecl.Name.Name)
+		log.Printf("NewTypedefDecl: %s\n", cname)
 	}
 
-	node := Node{name: typedefDecl.Name.Name, kind: TypedefDecl}
+	node := Node{name: cname, kind: TypedefDecl}
 	name, changed, exist, err := p.RegisterNode(node, goName, p.lookupOrigin)
 	if err != nil {
-		return fmt.Errorf("NewTypedefDecl: %s fail: %w", typedefDecl.Name.Name, err)
+		return fmt.Errorf("NewTypedefDecl: %s fail: %w", cname, err)
 	}
 	if exist {
 		if debugLog {
-			log.Printf("NewTypedefDecl: %s is processed\n", typedefDecl.Name.Name)
+			log.Printf("NewTypedefDecl: %s is processed\n", cname)
 		}
 		return nil
 	}
 
-	p.CollectNameMapping(typedefDecl.Name.Name, name, pnc)
+	p.CollectNameMapping(cname, name, pnc)
+
+	if p.isAliasTypedef(typedefDecl) && !p.isIncompleteRef(typedefDecl.Type) {
+		return p.newAliasTypedef(name, changed, typedefDecl)
+	}
 
 	genDecl := p.p.NewTypeDefs()
 	typeSpecdecl := genDecl.NewType(name)
 
 	if changed {
-		substObj(p.p.Types, p.p.Types.Scope(), typedefDecl.Name.Name, typeSpecdecl.Type().Obj())
+		substObj(p.p.Types, p.p.Types.Scope(), cname, typeSpecdecl.Type().Obj())
 	}
 
-	deferInit := p.handleTyperefIncomplete(typedefDecl.Type, typeSpecdecl, typedefDecl.Name.Name)
+	deferInit := p.handleTyperefIncomplete(typedefDecl.Type, typeSpecdecl, cname)
 	if deferInit {
 		if debugLog {
 			log.Printf("NewTypedefDecl: %s defer init\n", name)
@@ -486,7 +704,7 @@ func (p *Package) NewTypedefDecl(goName string, typedefDecl *ast.TypedefDecl, pn
 
 	typ, err := p.ToType(typedefDecl.Type)
 	if err != nil {
-		return fmt.Errorf("NewTypedefDecl:fail to convert type %v: %w", typedefDecl.Name.Name, err)
+		return fmt.Errorf("NewTypedefDecl:fail to convert type %v: %w", cname, err)
 	}
 
 	typeSpecdecl.InitType(p.p, typ)
@@ -497,23 +715,51 @@ func (p *Package) NewTypedefDecl(goName string, typedefDecl *ast.TypedefDecl, pn
 	return nil
 }
 
-func (p *Package) handleTyperefIncomplete(typeRef ast.Expr, typeSpecdecl *gogen.TypeDecl, namedName string) bool {
-	var name string
-	switch expr := typeRef.(type) {
-	case *ast.TagExpr:
-		if n, ok := expr.Name.(*ast.Ident); ok {
-			name = n.Name
-		} else {
-			panic("todo:scoping expr not supported")
+// isAliasTypedef reports whether the typedef is generated as a type alias like `type Bytef = c.Char`,
+// which is the case for the typedefs listed in aliasTypedefs, and with autoAlias, for all typedefs
+// of builtin and pointer types. Function types always keep a defined type for the llgo:type C mark,
+// and the typedefs of the types not complete yet keep a defined type initialized once the type is
+// complete, see handleTyperefIncomplete.
+func (p *Package) isAliasTypedef(typedefDecl *ast.TypedefDecl) bool {
+	typ := typedefDecl.Type
+	if ptr, ok := typ.(*ast.PointerType); ok {
+		if _, ok := ptr.X.(*ast.FuncType); ok {
+			return false
 		}
-	case *ast.Ident:
-		name = expr.Name
-	default:
+	}
+	if _, ok := typ.(*ast.FuncType); ok {
 		return false
 	}
+	if slices.Contains(p.conf.AliasTypedefs, typedefDecl.QualifiedName()) {
+		return true
+	}
+	if !p.conf.AutoAlias {
+		return false
+	}
+	switch typ.(type) {
+	case *ast.BuiltinType, *ast.PointerType:
+		return true
+	}
+	return false
+}
 
-	_, inc := p.incompleteTypes.Lookup(name)
-	if !inc {
+func (p *Package) newAliasTypedef(name string, changed bool, typedefDecl *ast.TypedefDecl) error {
+	cname := typedefDecl.QualifiedName()
+	typ, err := p.ToType(typedefDecl.Type)
+	if err != nil {
+		return fmt.Errorf("NewTypedefDecl:fail to convert type %v: %w", cname, err)
+	}
+	typeBlock := p.p.NewTypeDefs()
+	typeBlock.SetComments(p.newDocComment(name, typedefDecl.Doc))
+	typeBlock.AliasType(name, typ)
+	if changed {
+		substObj(p.p.Types, p.p.Types.Scope(), cname, p.Lookup(name))
+	}
+	return nil
+}
+
+func (p *Package) handleTyperefIncomplete(typeRef ast.Expr, typeSpecdecl *gogen.TypeDecl, namedName string) bool {
+	if !p.isIncompleteRef(typeRef) {
 		return false
 	}
 
@@ -529,6 +775,23 @@ func (p *Package) handleTyperefIncomplete(typeRef ast.Expr, typeSpecdecl *gogen.
 	return true
 }
 
+// isIncompleteRef reports whether typeRef refers to a type not complete yet, which may be
+// declared by another package of the route.
+func (p *Package) isIncompleteRef(typeRef ast.Expr) bool {
+	name := ast.QualifiedName(typeRef)
+	if name == "" {
+		return false
+	}
+	owner := p
+	if p.route != nil {
+		if pkg, err := p.route(p, name); err == nil {
+			owner = pkg
+		}
+	}
+	_, inc := owner.incompleteTypes.Lookup(name)
+	return inc
+}
+
 // Convert ast.Expr to types.Type
 func (p *Package) ToType(expr ast.Expr) (types.Type, error) {
 	return p.cvt.ToType(expr)
@@ -546,7 +809,11 @@ func (p *Package) NewEnumTypeDecl(goName string, enumTypeDecl *ast.EnumTypeDecl,
 	if debugLog {
 		log.Printf("NewEnumTypeDecl: %v\n", enumTypeDecl.Name)
 	}
-	enumType, exist, err := p.createEnumType(goName, enumTypeDecl.Name, pnc)
+	var cname string
+	if enumTypeDecl.Name != nil {
+		cname = enumTypeDecl.QualifiedName()
+	}
+	enumType, exist, err := p.createEnumType(goName, cname, pnc)
 	if err != nil {
 		return fmt.Errorf("NewEnumTypeDecl: %v fail: %w", enumTypeDecl.Name, err)
 	}
@@ -565,14 +832,14 @@ func (p *Package) NewEnumTypeDecl(goName string, enumTypeDecl *ast.EnumTypeDecl,
 	return nil
 }
 
-func (p *Package) createEnumType(goName string, enumName *ast.Ident, pnc nc.NodeConverter) (types.Type, bool, error) {
+func (p *Package) createEnumType(goName string, cname string, pnc nc.NodeConverter) (types.Type, bool, error) {
 	var name string
 	var changed bool
 	var err error
 	var exist bool
 	var t *gogen.TypeDecl
-	if enumName != nil {
-		node := Node{name: enumName.Name, kind: EnumTypeDecl}
+	if cname != "" {
+		node := Node{name: cname, kind: EnumTypeDecl}
 		name, changed, exist, err = p.RegisterNode(node, goName, p.lookupOrigin)
 		if err != nil {
 			return nil, false, err
@@ -580,7 +847,7 @@ func (p *Package) createEnumType(goName string, enumName *ast.Ident, pnc nc.Node
 		if exist {
 			return nil, true, nil
 		}
-		p.CollectNameMapping(enumName.Name, name, pnc)
+		p.CollectNameMapping(cname, name, pnc)
 	}
 	enumType := p.cvt.ToDefaultEnumType()
 	if name != "" {
@@ -588,7 +855,7 @@ func (p *Package) createEnumType(goName string, enumName *ast.Ident, pnc nc.Node
 		enumType = p.Lookup(name).Type()
 	}
 	if changed {
-		substObj(p.p.Types, p.p.Types.Scope(), enumName.Name, t.Type().Obj())
+		substObj(p.p.Types, p.p.Types.Scope(), cname, t.Type().Obj())
 	}
 	return enumType, false, nil
 }
@@ -614,7 +881,8 @@ func (p *Package) createEnumItems(pnc nc.NodeConverter, decl *ast.EnumTypeDecl,
 		// The 'changed' parameter is intentionally ignored here because enum items are used as constant values, not type identifiers.
 		// In C/C++ code, there are no type references to enum items, so there's no need to establish a cname->pubName mapping in the scope.
 		// This is similar to how macro constants (Macro) are handled, as both are value-level symbols rather than type-level.
-		name, _, exist, err := p.RegisterNode(Node{name: item.Name.Name, kind: EnumItem}, goName, p.lookupPub)
+		cname := decl.ItemName(item)
+		name, _, exist, err := p.RegisterNode(Node{name: cname, kind: EnumItem}, goName, p.lookupPub)
 		if err != nil {
 			return err
 		}
@@ -703,6 +971,8 @@ func (p *Package) Complete() error {
 	if err != nil {
 		return err
 	}
+	p.completeDocs()
+	p.completeShim()
 	return nil
 }
 
@@ -748,6 +1018,7 @@ func (p *Package) RegisterNode(node Node, goName string, lookup func(name string
 	pubName, changed = p.GetUniqueName(node, goName)
 	obj := lookup(node.name, pubName)
 	if obj != nil {
+		p.symbols.conflict(node, goName)
 		return "", false, exist, NewTypeDefinedError(pubName, node.name)
 	}
 	return pubName, changed, exist, nil
@@ -840,6 +1111,7 @@ const (
 	EnumTypeDecl
 	EnumItem
 	Macro
+	VarDecl
 )
 
 type Node struct {
@@ -847,16 +1119,39 @@ type Node struct {
 	kind nodeKind
 }
 
+// lockKey is the key of the node in the name lock, like typedef foo, which tells apart
+// the C names of different kinds, like the ones of struct foo and typedef struct foo foo.
+func (n Node) lockKey() string {
+	return lockKey(n.kind.String(), n.name)
+}
+
+func lockKey(kind, name string) string {
+	return kind + " " + name
+}
+
 type ProcessSymbol struct {
 	// not same node can have same name, so use the Node as key
 	info  map[Node]string
 	count map[string]int
+	// the Go names locked by the name lock file, lock key -> Go name, which are kept
+	// and reserved from the suffixes of the other names, see Node.lockKey
+	locked   map[string]string
+	reserved map[string]bool
+	taken    map[string]bool // the locked and preferred names registered
+	// the C names keeping the Go names they collide on, Go name -> C name
+	preferred map[string]string
+
+	claims map[string][]*Origin // Go name -> the declarations registered with it, see Collisions
+	file   string               // the C header of the declarations registering
 }
 
 func NewProcessSymbol() *ProcessSymbol {
 	return &ProcessSymbol{
-		info:  make(map[Node]string),
-		count: make(map[string]int),
+		info:     make(map[Node]string),
+		count:    make(map[string]int),
+		reserved: make(map[string]bool),
+		taken:    make(map[string]bool),
+		claims:   make(map[string][]*Origin),
 	}
 }
 
@@ -866,9 +1161,56 @@ func (p *ProcessSymbol) Lookup(node Node) (string, bool) {
 }
 
 func (p *ProcessSymbol) Register(node Node, pubName string) string {
-	p.count[pubName]++
-	count := p.count[pubName]
-	pubName = name.SuffixCount(pubName, count)
-	p.info[node] = pubName
-	return pubName
+	if locked, ok := p.locked[node.lockKey()]; ok && node.kind != FuncDecl && !p.taken[locked] {
+		p.taken[locked] = true
+		p.info[node] = locked
+		p.claim(node, pubName, locked)
+		return locked
+	}
+	if p.preferred[pubName] == node.name && node.kind != FuncDecl && !p.taken[pubName] {
+		p.taken[pubName] = true
+		p.info[node] = pubName
+		p.claim(node, pubName, pubName)
+		return pubName
+	}
+	for {
+		p.count[pubName]++
+		if suffixed := name.SuffixCount(pubName, p.count[pubName]); !p.reserved[suffixed] {
+			p.info[node] = suffixed
+			// the functions are named by the symbol table, see claimFunc
+			if node.kind != FuncDecl {
+				p.claim(node, pubName, suffixed)
+			}
+			return suffixed
+		}
+	}
+}
+
+// Lock keeps the Go names of the locked C names, see llcppg.NameLock.
+func (p *ProcessSymbol) Lock(locked map[string]string) {
+	p.locked = locked
+	for _, goName := range locked {
+		p.reserved[goName] = true
+	}
+}
+
+// Prefer keeps the Go names of the preferred C names, keyed by the Go names, the other
+// declarations of the Go names are suffixed, see llcppg.Collision.
+func (p *ProcessSymbol) Prefer(preferred map[string]string) {
+	p.preferred = preferred
+	for goName := range preferred {
+		p.reserved[goName] = true
+	}
+}
+
+// Names returns the Go names of the registered C names by their lock keys, except the
+// ones of the functions, which are named by the symbol table, see Node.lockKey.
+func (p *ProcessSymbol) Names() map[string]string {
+	names := make(map[string]string)
+	for node, pubName := range p.info {
+		if node.kind != FuncDecl {
+			names[node.lockKey()] = pubName
+		}
+	}
+	return names
 }
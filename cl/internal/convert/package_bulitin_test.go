@@ -5,6 +5,7 @@ import (
 	goast "go/ast"
 	"go/token"
 	"go/types"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -39,26 +40,27 @@ func emptyPkg(nc nc.NodeConverter) *Package {
 	return pkg
 }
 
-func TestTypeRefIncompleteFail(t *testing.T) {
-	t.Run("ref tag incomplete fail", func(t *testing.T) {
-		pkg := emptyPkg(nil)
-		tempFile := &ncimpl.HeaderFile{
-			File:     "temp.h",
-			FileType: llcppg.Inter,
-		}
-		pkg.p.SetCurFile(tempFile.ToGoFileName("testpkg"), true)
-		defer func() {
-			if r := recover(); r == nil {
-				t.Fatal("Expected panic, got nil")
-			}
-		}()
-		pkg.handleTyperefIncomplete(&ast.TagExpr{
-			Tag: 0,
-			Name: &ast.ScopingExpr{
-				X: &ast.Ident{Name: "Bar"},
-			},
-		}, nil, "NewBar")
-	})
+func TestTypeRefIncompleteScoping(t *testing.T) {
+	pkg := emptyPkg(nil)
+	tempFile := &ncimpl.HeaderFile{
+		File:     "temp.h",
+		FileType: llcppg.Inter,
+	}
+	pkg.p.SetCurFile(tempFile.ToGoFileName("testpkg"), true)
+	ref := &ast.TagExpr{
+		Tag: 0,
+		Name: &ast.ScopingExpr{
+			Parent: &ast.Ident{Name: "a"},
+			X:      &ast.Ident{Name: "Bar"},
+		},
+	}
+	if pkg.handleTyperefIncomplete(ref, nil, "NewBar") {
+		t.Fatal("Expected a::Bar to be complete")
+	}
+	pkg.incompleteTypes.Add(&Incomplete{cname: "a::Bar"})
+	if !pkg.handleTyperefIncomplete(ref, nil, "NewBar") {
+		t.Fatal("Expected NewBar to be deferred until a::Bar is complete")
+	}
 }
 
 func TestRedefPubName(t *testing.T) {
@@ -201,6 +203,65 @@ func TestProcessSymbol(t *testing.T) {
 	}
 }
 
+func TestProcessSymbolLock(t *testing.T) {
+	sym := NewProcessSymbol()
+	sym.Lock(map[string]string{"type foo": "Foo__1", "type Foo": "Foo", "type bar": "Bar__1", "typedef bar": "Bar"})
+	testCases := []struct {
+		node     Node
+		pubName  string
+		expected string
+	}{
+		{Node{name: "foo", kind: TypeDecl}, "Foo", "Foo__1"},
+		{Node{name: "FOO", kind: Macro}, "Foo", "Foo__2"},
+		{Node{name: "Foo", kind: TypeDecl}, "Foo", "Foo"},
+		// the functions are named by the symbol table
+		{Node{name: "Foo", kind: FuncDecl}, "Foo", "Foo__3"},
+		// the tag and the typedef of a C name are locked apart
+		{Node{name: "bar", kind: TypeDecl}, "Bar", "Bar__1"},
+		{Node{name: "bar", kind: TypedefDecl}, "Bar", "Bar"},
+	}
+	for _, tc := range testCases {
+		if pubName := sym.Register(tc.node, tc.pubName); pubName != tc.expected {
+			t.Errorf("Register(%s) = %s, want %s", tc.node.name, pubName, tc.expected)
+		}
+	}
+	expect := map[string]string{
+		"type foo": "Foo__1", "macro FOO": "Foo__2", "type Foo": "Foo",
+		"type bar": "Bar__1", "typedef bar": "Bar",
+	}
+	if names := sym.Names(); !reflect.DeepEqual(names, expect) {
+		t.Errorf("Names() = %v, want %v", names, expect)
+	}
+}
+
+func TestProcessSymbolPrefer(t *testing.T) {
+	sym := NewProcessSymbol()
+	sym.Prefer(map[string]string{"Foo": "foo"})
+	testCases := []struct {
+		node     Node
+		expected string
+	}{
+		{Node{name: "FOO", kind: Macro}, "Foo__1"},
+		{Node{name: "foo", kind: TypeDecl}, "Foo"},
+		{Node{name: "Foo", kind: EnumItem}, "Foo__2"},
+	}
+	for _, tc := range testCases {
+		if pubName := sym.Register(tc.node, "Foo"); pubName != tc.expected {
+			t.Errorf("Register(%s) = %s, want %s", tc.node.name, pubName, tc.expected)
+		}
+	}
+	collisions := sym.Collisions()
+	if len(collisions) != 1 || !collisions[0].Resolved || len(collisions[0].Origins) != 3 {
+		t.Fatalf("Collisions() = %v, want the resolved collision of Foo", collisions)
+	}
+	sym.Register(Node{name: "bar", kind: TypeDecl}, "Bar")
+	sym.conflict(Node{name: "bar", kind: TypeDecl}, "Bar")
+	collisions = sym.Collisions()
+	if len(collisions) != 2 || collisions[0].GoName != "Bar" || collisions[0].Resolved || collisions[0].Origins[0].GoName != "" {
+		t.Fatalf("Collisions() = %v, want the conflict of Bar", collisions)
+	}
+}
+
 func TestNoEmptyConstGroupWhenAllEnumItemsSkipped(t *testing.T) {
 	pnc := cltest.NC(&llcppg.Config{}, nil, cltest.NewConvSym())
 	pkg := emptyPkg(pnc)
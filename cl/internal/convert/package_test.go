@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -64,7 +65,8 @@ func TestUnionDecl(t *testing.T) {
 					Name: &ast.Ident{Name: "u"},
 				},
 				Type: &ast.RecordType{
-					Tag: ast.Union,
+					Tag:    ast.Union,
+					HasDef: true,
 					Fields: &ast.FieldList{
 						List: []*ast.Field{
 							{
@@ -129,6 +131,7 @@ func TestToType(t *testing.T) {
 	pkg, err := createTestPkg(nil, &convert.PackageConfig{
 		OutputDir:  "",
 		LibCommand: "${pkg-config --libs libcjson}",
+		TypeSizes:  &llcppg.TypeSizes{WChar: 4, LongDouble: 16},
 	})
 	if err != nil {
 		t.Fatal("NewPackage failed:", err)
@@ -143,9 +146,9 @@ func TestToType(t *testing.T) {
 		{"Bool", &ast.BuiltinType{Kind: ast.Bool}, "bool"},
 		{"Char_S", &ast.BuiltinType{Kind: ast.Char, Flags: ast.Signed}, "github.com/goplus/lib/c.Char"},
 		{"Char_U", &ast.BuiltinType{Kind: ast.Char, Flags: ast.Unsigned}, "github.com/goplus/lib/c.Char"},
-		{"WChar", &ast.BuiltinType{Kind: ast.WChar}, "int16"},
-		{"Char16", &ast.BuiltinType{Kind: ast.Char16}, "int16"},
-		{"Char32", &ast.BuiltinType{Kind: ast.Char32}, "int32"},
+		{"WChar", &ast.BuiltinType{Kind: ast.WChar}, "int32"},
+		{"Char16", &ast.BuiltinType{Kind: ast.Char16}, "uint16"},
+		{"Char32", &ast.BuiltinType{Kind: ast.Char32}, "uint32"},
 		{"Short", &ast.BuiltinType{Kind: ast.Int, Flags: ast.Short}, "int16"},
 		{"UShort", &ast.BuiltinType{Kind: ast.Int, Flags: ast.Short | ast.Unsigned}, "uint16"},
 		{"Int", &ast.BuiltinType{Kind: ast.Int}, "github.com/goplus/lib/c.Int"},
@@ -158,6 +161,11 @@ func TestToType(t *testing.T) {
 		{"Double", &ast.BuiltinType{Kind: ast.Float, Flags: ast.Double}, "github.com/goplus/lib/c.Double"},
 		{"ComplexFloat", &ast.BuiltinType{Kind: ast.Complex}, "complex64"},
 		{"ComplexDouble", &ast.BuiltinType{Kind: ast.Complex, Flags: ast.Double}, "complex128"},
+		{"LongDouble", &ast.BuiltinType{Kind: ast.Float, Flags: ast.Double | ast.Long}, "..CLongDouble"},
+		{"Int128", &ast.BuiltinType{Kind: ast.Int128}, "..CInt128"},
+		{"UInt128", &ast.BuiltinType{Kind: ast.Int128, Flags: ast.Unsigned}, "..CUint128"},
+		{"Float16", &ast.BuiltinType{Kind: ast.Float16}, "..CFloat16"},
+		{"Float128", &ast.BuiltinType{Kind: ast.Float128}, "..CFloat128"},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -169,6 +177,118 @@ func TestToType(t *testing.T) {
 	}
 }
 
+func TestExtTypeFile(t *testing.T) {
+	pkg, err := createTestPkg(nil, &convert.PackageConfig{
+		TypeSizes: &llcppg.TypeSizes{WChar: 4, LongDouble: 16},
+	})
+	if err != nil {
+		t.Fatal("NewPackage failed:", err)
+	}
+	for _, typ := range []*ast.BuiltinType{
+		{Kind: ast.Int128},
+		{Kind: ast.Int128, Flags: ast.Unsigned},
+		{Kind: ast.Float, Flags: ast.Double | ast.Long},
+		{Kind: ast.Int128},
+	} {
+		if _, err := pkg.ToType(typ); err != nil {
+			t.Fatal("ToType failed:", err)
+		}
+	}
+	var buf bytes.Buffer
+	err = pkg.Pkg().WriteTo(&buf, "testpkg_autogen_types.go")
+	if err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	expect := `
+package testpkg
+// CInt128 is an opaque representation of the C __int128 type with the same size.
+type CInt128 [2]uint64
+
+func CInt128FromInt64(v int64) CInt128 {
+	return CInt128{uint64(v), uint64(v >> 63)}
+}
+func (v CInt128) Int64() int64 {
+	return int64(v[0])
+}
+// CUint128 is an opaque representation of the C unsigned __int128 type with the same size.
+type CUint128 [2]uint64
+
+func CUint128FromUint64(v uint64) CUint128 {
+	return CUint128{v, 0}
+}
+func (v CUint128) Uint64() uint64 {
+	return v[0]
+}
+// CLongDouble is an opaque representation of the C long double type with the same size.
+type CLongDouble [16]uint8
+// llgo:link (*CLongDouble).SetFloat64 C.llcppg_CLongDouble_SetFloat64
+func (recv_ *CLongDouble) SetFloat64(v float64) {
+}
+// llgo:link (*CLongDouble).Float64 C.llcppg_CLongDouble_Float64
+func (recv_ *CLongDouble) Float64() float64 {
+	return 0
+}
+`
+	if strings.TrimSpace(expect) != strings.TrimSpace(buf.String()) {
+		t.Errorf("does not match expected.\nExpected:\n%s\nGot:\n%s", expect, buf.String())
+	}
+	_, shim := pkg.ShimFile()
+	expectShim := `
+typedef long double llcppg_CLongDouble;
+
+extern "C" void llcppg_CLongDouble_SetFloat64(llcppg_CLongDouble *self, double v) {
+	*self = (llcppg_CLongDouble)v;
+}
+
+extern "C" double llcppg_CLongDouble_Float64(llcppg_CLongDouble *self) {
+	return (double)*self;
+}
+`
+	if strings.TrimSpace(expectShim) != strings.TrimSpace(string(shim)) {
+		t.Errorf("does not match expected shim.\nExpected:\n%s\nGot:\n%s", expectShim, shim)
+	}
+}
+
+func TestExtTypeAlign(t *testing.T) {
+	pkg, err := createTestPkg(nil, &convert.PackageConfig{
+		TypeSizes: &llcppg.TypeSizes{WChar: 4, LongDouble: 16},
+	})
+	if err != nil {
+		t.Fatal("NewPackage failed:", err)
+	}
+	field := func(name string, typ ast.Expr) *ast.Field {
+		return &ast.Field{Names: []*ast.Ident{{Name: name}}, Type: typ}
+	}
+	char := &ast.BuiltinType{Kind: ast.Char, Flags: ast.Signed}
+	int128 := &ast.BuiltinType{Kind: ast.Int128}
+	testCases := []struct {
+		name   string
+		record *ast.RecordType
+		expect string
+	}{
+		{"Field", &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+			field("c", char), field("v", int128),
+		}}}, "struct{C github.com/goplus/lib/c.Char; _ [15]uint8; V ..CInt128}"},
+		{"Tail", &ast.RecordType{Tag: ast.Struct, Fields: &ast.FieldList{List: []*ast.Field{
+			field("v", int128), field("c", char),
+		}}}, "struct{V ..CInt128; C github.com/goplus/lib/c.Char; _ [15]uint8}"},
+		{"Union", &ast.RecordType{Tag: ast.Union, Fields: &ast.FieldList{List: []*ast.Field{
+			field("c", &ast.ArrayType{Elt: char, Len: &ast.BasicLit{Kind: ast.IntLit, Value: "20"}}), field("v", int128),
+		}}}, "struct{C [20]github.com/goplus/lib/c.Char; _ [12]uint8}"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			typ, err := pkg.ToType(tc.record)
+			if err != nil {
+				t.Fatal("ToType failed:", err)
+			}
+			if typ.String() != tc.expect {
+				t.Errorf("unexpected struct:%s expected:%s", typ, tc.expect)
+			}
+		})
+	}
+}
+
 func TestToTypeFail(t *testing.T) {
 	pkg, err := createTestPkg(nil, &convert.PackageConfig{
 		OutputDir: "",
@@ -707,6 +827,187 @@ func Foo(a *c.Uint, b *c.Double) **c.Char
 	}
 }
 
+func TestNestedRecordDecl(t *testing.T) {
+	/*
+		struct value {
+			int kind;
+			union {
+				int i;
+				double d;
+			} u;
+			struct {
+				struct {
+					int x;
+				} pos;
+			} pts[2];
+		};
+	*/
+	decl := &ast.TypeDecl{
+		Object: ast.Object{
+			Name: &ast.Ident{Name: "value"},
+		},
+		Type: &ast.RecordType{
+			Tag:    ast.Struct,
+			HasDef: true,
+			Fields: &ast.FieldList{
+				List: []*ast.Field{
+					{
+						Names: []*ast.Ident{{Name: "kind"}},
+						Type:  &ast.BuiltinType{Kind: ast.Int},
+					},
+					{
+						Names: []*ast.Ident{{Name: "u"}},
+						Type: &ast.RecordType{
+							Tag:    ast.Union,
+							HasDef: true,
+							Fields: &ast.FieldList{
+								List: []*ast.Field{
+									{
+										Names: []*ast.Ident{{Name: "i"}},
+										Type:  &ast.BuiltinType{Kind: ast.Int},
+									},
+									{
+										Names: []*ast.Ident{{Name: "d"}},
+										Type:  &ast.BuiltinType{Kind: ast.Float, Flags: ast.Double},
+									},
+								},
+							},
+						},
+					},
+					{
+						Names: []*ast.Ident{{Name: "pts"}},
+						Type: &ast.ArrayType{
+							Elt: &ast.RecordType{
+								Tag:    ast.Struct,
+								HasDef: true,
+								Fields: &ast.FieldList{
+									List: []*ast.Field{
+										{
+											Names: []*ast.Ident{{Name: "pos"}},
+											Type: &ast.RecordType{
+												Tag:    ast.Struct,
+												HasDef: true,
+												Fields: &ast.FieldList{
+													List: []*ast.Field{
+														{
+															Names: []*ast.Ident{{Name: "x"}},
+															Type:  &ast.BuiltinType{Kind: ast.Int},
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+							Len: &ast.BasicLit{Kind: ast.IntLit, Value: "2"},
+						},
+					},
+				},
+			},
+		},
+	}
+	testCases := []struct {
+		name     string
+		conf     *llcppg.Config
+		expected string
+		pubs     map[string]string
+	}{
+		{
+			name: "default",
+			conf: &llcppg.Config{},
+			expected: `
+package testpkg
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Value struct {
+	Kind c.Int
+	U    Value_U
+	Pts  [2]Value_Pts
+}
+
+type Value_U struct {
+	D c.Double
+}
+
+type Value_Pts struct {
+	Pos Value_Pts_Pos
+}
+
+type Value_Pts_Pos struct {
+	X c.Int
+}
+`,
+			pubs: map[string]string{
+				"value":         "Value",
+				"value.u":       "Value_U",
+				"value.pts":     "Value_Pts",
+				"value.pts.pos": "Value_Pts_Pos",
+			},
+		},
+		{
+			name: "template and typeMap",
+			conf: &llcppg.Config{
+				NestedTypeName: "{parent}{field}",
+				TypeMap:        map[string]string{"value.u": "Variant"},
+			},
+			expected: `
+package testpkg
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Value struct {
+	Kind c.Int
+	U    Variant
+	Pts  [2]ValuePts
+}
+
+type Variant struct {
+	D c.Double
+}
+
+type ValuePts struct {
+	Pos ValuePtsPos
+}
+
+type ValuePtsPos struct {
+	X c.Int
+}
+`,
+			pubs: map[string]string{
+				"value":         "Value",
+				"value.u":       "Variant",
+				"value.pts":     "ValuePts",
+				"value.pts.pos": "ValuePtsPos",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nc := cltest.NC(tc.conf, nil, cltest.NewConvSym())
+			pkg, err := createTestPkg(nc, &convert.PackageConfig{})
+			if err != nil {
+				t.Fatal("NewPackage failed:", err)
+			}
+			SetTempFile(pkg)
+			if err := pkg.NewTypeDecl("Value", decl, nc); err != nil {
+				t.Fatal("NewTypeDecl failed:", err)
+			}
+			comparePackageOutput(t, pkg, tc.expected)
+			if !reflect.DeepEqual(pkg.Pubs, tc.pubs) {
+				t.Errorf("unexpected pubs: %v, expected: %v", pkg.Pubs, tc.pubs)
+			}
+		})
+	}
+}
+
 func TestStructDecl(t *testing.T) {
 	testCases := []genDeclTestCase{
 		// struct Foo {}
@@ -718,6 +1019,7 @@ func TestStructDecl(t *testing.T) {
 				},
 				Type: &ast.RecordType{
 					Tag:    ast.Struct,
+					HasDef: true,
 					Fields: &ast.FieldList{},
 				},
 			},
@@ -737,7 +1039,8 @@ type Foo struct {
 					Name: &ast.Ident{Name: "InvalidStruct"},
 				},
 				Type: &ast.RecordType{
-					Tag: ast.Struct,
+					Tag:    ast.Struct,
+					HasDef: true,
 					Fields: &ast.FieldList{
 						List: []*ast.Field{
 							{
@@ -758,7 +1061,8 @@ type Foo struct {
 					Name: &ast.Ident{Name: "Foo"},
 				},
 				Type: &ast.RecordType{
-					Tag: ast.Struct,
+					Tag:    ast.Struct,
+					HasDef: true,
 					Fields: &ast.FieldList{
 						List: []*ast.Field{
 							{
@@ -806,7 +1110,8 @@ type Foo struct {
 					Name: &ast.Ident{Name: "Foo"},
 				},
 				Type: &ast.RecordType{
-					Tag: ast.Struct,
+					Tag:    ast.Struct,
+					HasDef: true,
 					Fields: &ast.FieldList{
 						List: []*ast.Field{
 							{
@@ -867,7 +1172,8 @@ type Foo struct {
 					Name: &ast.Ident{Name: "Foo"},
 				},
 				Type: &ast.RecordType{
-					Tag: ast.Struct,
+					Tag:    ast.Struct,
+					HasDef: true,
 					Fields: &ast.FieldList{
 						List: []*ast.Field{
 							{
@@ -918,7 +1224,8 @@ type Foo struct {
 					Name: &ast.Ident{Name: "Foo"},
 				},
 				Type: &ast.RecordType{
-					Tag: ast.Struct,
+					Tag:    ast.Struct,
+					HasDef: true,
 					Fields: &ast.FieldList{
 						List: []*ast.Field{
 							{
@@ -969,7 +1276,8 @@ type Foo struct {
 					Name: &ast.Ident{Name: "Foo"},
 				},
 				Type: &ast.RecordType{
-					Tag: ast.Struct,
+					Tag:    ast.Struct,
+					HasDef: true,
 					Fields: &ast.FieldList{
 						List: []*ast.Field{
 							{
@@ -994,7 +1302,8 @@ type Foo struct {
 					Name: &ast.Ident{Name: "Foo"},
 				},
 				Type: &ast.RecordType{
-					Tag: ast.Struct,
+					Tag:    ast.Struct,
+					HasDef: true,
 					Fields: &ast.FieldList{
 						List: []*ast.Field{
 							{
@@ -1104,6 +1413,7 @@ func TestRedef(t *testing.T) {
 		},
 		Type: &ast.RecordType{
 			Tag:    ast.Struct,
+			HasDef: true,
 			Fields: flds,
 		},
 	}, nc)
@@ -1114,6 +1424,7 @@ func TestRedef(t *testing.T) {
 		},
 		Type: &ast.RecordType{
 			Tag:    ast.Struct,
+			HasDef: true,
 			Fields: flds,
 		},
 	}, nc)
@@ -1210,7 +1521,8 @@ func TestRedefEnum(t *testing.T) {
 			Name: &ast.Ident{Name: typeName},
 		},
 		Type: &ast.RecordType{
-			Tag: ast.Struct,
+			Tag:    ast.Struct,
+			HasDef: true,
 			Fields: &ast.FieldList{
 				List: []*ast.Field{
 					{Type: &ast.BuiltinType{Kind: ast.Int}},
@@ -1293,6 +1605,7 @@ func TestRedefTypedef(t *testing.T) {
 		},
 		Type: &ast.RecordType{
 			Tag:    ast.Struct,
+			HasDef: true,
 			Fields: &ast.FieldList{},
 		},
 	}, nc)
@@ -1328,6 +1641,7 @@ func TestRedefineFunc(t *testing.T) {
 		},
 		Type: &ast.RecordType{
 			Tag:    ast.Struct,
+			HasDef: true,
 			Fields: &ast.FieldList{},
 		},
 	}, nc)
@@ -1610,7 +1924,8 @@ func TestTypeAlias(t *testing.T) {
 			Name: &ast.Ident{Name: "Foo"},
 		},
 		Type: &ast.RecordType{
-			Tag: ast.Struct,
+			Tag:    ast.Struct,
+			HasDef: true,
 			Fields: &ast.FieldList{
 				List: []*ast.Field{
 					{
@@ -1681,7 +1996,8 @@ func TestForwardDecl(t *testing.T) {
 			Name: &ast.Ident{Name: "Foo"},
 		},
 		Type: &ast.RecordType{
-			Tag: ast.Struct,
+			Tag:    ast.Struct,
+			HasDef: true,
 			Fields: &ast.FieldList{
 				List: []*ast.Field{
 					{
@@ -1718,74 +2034,1104 @@ type Foo struct {
 	comparePackageOutput(t, pkg, expect)
 }
 
-type genDeclTestCase struct {
-	name        string
-	decl        ast.Decl
-	symbs       []llcppg.SymbolInfo
-	cppgconf    *llcppg.Config
-	expected    string
-	expectedErr string
-}
+func TestOpaqueType(t *testing.T) {
+	nc := cltest.NC(&llcppg.Config{}, nil, cltest.NewConvSym())
+	pkg, err := createTestPkg(nc, &convert.PackageConfig{
+		OpaqueExclude: []string{"Legacy"},
+	})
+	if err != nil {
+		t.Fatal("NewPackage failed:", err)
+	}
+	SetTempFile(pkg)
 
-func testGenDecl(t *testing.T, tc genDeclTestCase) {
-	t.Helper()
-	defer func() {
-		if r := recover(); r != nil {
-			t.Fatal("unexpect panic", r)
+	for _, decl := range []*ast.TypeDecl{
+		// struct Forward;
+		{
+			Object: ast.Object{Name: &ast.Ident{Name: "Forward"}},
+			Type:   &ast.RecordType{Tag: ast.Struct},
+		},
+		// struct Legacy;
+		{
+			Object: ast.Object{Name: &ast.Ident{Name: "Legacy"}},
+			Type:   &ast.RecordType{Tag: ast.Struct},
+		},
+		// struct Empty {};
+		{
+			Object: ast.Object{Name: &ast.Ident{Name: "Empty"}},
+			Type:   &ast.RecordType{Tag: ast.Struct, HasDef: true},
+		},
+	} {
+		if err := pkg.NewTypeDecl(decl.Name.Name, decl, nc); err != nil {
+			t.Fatalf("NewTypeDecl failed: %v", err)
 		}
-	}()
-	var libCommand string
-	var deps []string
-	if tc.cppgconf != nil {
-		libCommand = tc.cppgconf.Libs
-		deps = tc.cppgconf.Deps
 	}
-	if tc.cppgconf == nil {
-		tc.cppgconf = &llcppg.Config{Name: pkgname}
+	if err := pkg.Complete(); err != nil {
+		t.Fatalf("Complete failed: %v", err)
 	}
-	fileMap := make(map[string]*llcppg.FileInfo)
-	fileMap["/path/to/temp.h"] = &llcppg.FileInfo{
-		FileType: llcppg.Inter,
+
+	comparePackageOutput(t, pkg, `
+package testpkg
+
+import _ "unsafe"
+
+type Forward struct {
+	_ noCopy
+}
+
+type Legacy struct {
+	Unused [8]uint8
+}
+
+type Empty struct {
+}
+`)
+
+	var buf bytes.Buffer
+	err = pkg.Pkg().WriteTo(&buf, "testpkg_autogen_types.go")
+	if err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
 	}
+	expect := `
+package testpkg
+// noCopy marks the opaque structs, which must only be used through pointers.
+type noCopy struct {
+}
 
-	nc := cltest.NC(tc.cppgconf, fileMap, cltest.NewConvSym(tc.symbs...))
+func (*noCopy) Lock() {
+}
+func (*noCopy) Unlock() {
+}
+`
+	if strings.TrimSpace(expect) != strings.TrimSpace(buf.String()) {
+		t.Errorf("does not match expected.\nExpected:\n%s\nGot:\n%s", expect, buf.String())
+	}
+}
+
+func TestAliasTypedef(t *testing.T) {
+	nc := cltest.NC(&llcppg.Config{}, nil, cltest.NewConvSym())
 	pkg, err := createTestPkg(nc, &convert.PackageConfig{
-		LibCommand: libCommand,
-		PkgBase: convert.PkgBase{
-			Deps: deps,
-		},
+		GenConf:       &gogen.Config{EnableTypesalias: true},
+		AliasTypedefs: []string{"Bytef", "FooT", "BazT"},
+		AutoAlias:     true,
 	})
 	if err != nil {
 		t.Fatal("NewPackage failed:", err)
 	}
-	if pkg == nil {
-		t.Fatal("NewPackage failed")
-	}
-	goName, _, err := nc.ConvDecl("/path/to/temp.h", tc.decl)
+	SetTempFile(pkg)
+
+	err = pkg.NewTypeDecl("Foo", &ast.TypeDecl{
+		Object: ast.Object{Name: &ast.Ident{Name: "Foo"}},
+		Type: &ast.RecordType{
+			Tag:    ast.Struct,
+			HasDef: true,
+			Fields: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{{Name: "a"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+			}},
+		},
+	}, nc)
 	if err != nil {
-		t.Fatalf("ConvDecl failed: %v", err)
+		t.Fatal("NewTypeDecl failed:", err)
 	}
-	SetTempFile(pkg)
-	switch d := tc.decl.(type) {
-	case *ast.TypeDecl:
-		err = pkg.NewTypeDecl(goName, d, nc)
-	case *ast.TypedefDecl:
-		err = pkg.NewTypedefDecl(goName, d, nc)
-	case *ast.FuncDecl:
-		err = pkg.NewFuncDecl(goName, d)
-	case *ast.EnumTypeDecl:
-		err = pkg.NewEnumTypeDecl(goName, d, nc)
-	default:
-		t.Errorf("Unsupported declaration type: %T", tc.decl)
-		return
+	// struct Baz;
+	err = pkg.NewTypeDecl("Baz", &ast.TypeDecl{
+		Object: ast.Object{Name: &ast.Ident{Name: "Baz"}},
+		Type:   &ast.RecordType{Tag: ast.Struct},
+	}, nc)
+	if err != nil {
+		t.Fatal("NewTypeDecl failed:", err)
 	}
-	if tc.expectedErr != "" {
-		compareError(t, err, tc.expectedErr)
-	} else {
-		if err != nil {
-			t.Errorf("Declaration generation failed: %v", err)
-		} else {
-			comparePackageOutput(t, pkg, tc.expected)
+	for _, decl := range []*ast.TypedefDecl{
+		// // a byte
+		// typedef unsigned char Bytef;
+		{
+			Object: ast.Object{
+				Name: &ast.Ident{Name: "Bytef"},
+				Doc:  &ast.CommentGroup{List: []*ast.Comment{{Text: "// a byte"}}},
+			},
+			Type: &ast.BuiltinType{Kind: ast.Char, Flags: ast.Unsigned},
+		},
+		// typedef struct Foo FooT;
+		{
+			Object: ast.Object{Name: &ast.Ident{Name: "FooT"}},
+			Type:   &ast.TagExpr{Tag: ast.Struct, Name: &ast.Ident{Name: "Foo"}},
+		},
+		// typedef int *IntPtr;
+		{
+			Object: ast.Object{Name: &ast.Ident{Name: "IntPtr"}},
+			Type:   &ast.PointerType{X: &ast.BuiltinType{Kind: ast.Int}},
+		},
+		// typedef struct Foo Bar;
+		{
+			Object: ast.Object{Name: &ast.Ident{Name: "Bar"}},
+			Type:   &ast.TagExpr{Tag: ast.Struct, Name: &ast.Ident{Name: "Foo"}},
+		},
+		// typedef void (*Callback)(int);
+		{
+			Object: ast.Object{Name: &ast.Ident{Name: "Callback"}},
+			Type: &ast.PointerType{X: &ast.FuncType{
+				Params: &ast.FieldList{List: []*ast.Field{
+					{Type: &ast.BuiltinType{Kind: ast.Int}},
+				}},
+				Ret: &ast.BuiltinType{Kind: ast.Void},
+			}},
+		},
+		// typedef struct Baz BazT;
+		// where struct Baz is not complete yet
+		{
+			Object: ast.Object{Name: &ast.Ident{Name: "BazT"}},
+			Type:   &ast.TagExpr{Tag: ast.Struct, Name: &ast.Ident{Name: "Baz"}},
+		},
+	} {
+		if err := pkg.NewTypedefDecl(decl.Name.Name, decl, nc); err != nil {
+			t.Fatalf("NewTypedefDecl %s failed: %v", decl.Name.Name, err)
+		}
+	}
+
+	// the alias of a builtin type can't have methods
+	err = pkg.NewFuncDecl("Bytef.BytefInc", &ast.FuncDecl{
+		Object:      ast.Object{Name: &ast.Ident{Name: "bytef_inc"}},
+		MangledName: "bytef_inc",
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{{Name: "b"}}, Type: &ast.Ident{Name: "Bytef"}},
+			}},
+			Ret: &ast.Ident{Name: "Bytef"},
+		},
+	})
+	if err != nil {
+		t.Fatal("NewFuncDecl failed:", err)
+	}
+	// the alias of a struct of this package keeps the methods
+	err = pkg.NewFuncDecl("(*FooT).FooReset", &ast.FuncDecl{
+		Object:      ast.Object{Name: &ast.Ident{Name: "foo_reset"}},
+		MangledName: "foo_reset",
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{{Name: "f"}}, Type: &ast.PointerType{X: &ast.Ident{Name: "FooT"}}},
+			}},
+			Ret: &ast.BuiltinType{Kind: ast.Void},
+		},
+	})
+	if err != nil {
+		t.Fatal("NewFuncDecl failed:", err)
+	}
+
+	if err := pkg.Complete(); err != nil {
+		t.Fatal("Complete failed:", err)
+	}
+
+	comparePackageOutput(t, pkg, `
+package testpkg
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Foo struct {
+	A c.Int
+}
+
+type Baz struct {
+	_ noCopy
+}
+// a byte
+type Bytef = c.Char
+
+type FooT = Foo
+
+type IntPtr = *c.Int
+type Bar Foo
+// llgo:type C
+type Callback func(c.Int)
+type BazT Baz
+//go:linkname BytefInc C.bytef_inc
+func BytefInc(b Bytef) Bytef
+// llgo:link (*Foo).FooReset C.foo_reset
+func (recv_ *Foo) FooReset() {
+}
+`)
+}
+
+func TestGoDoc(t *testing.T) {
+	nc := cltest.NC(&llcppg.Config{}, nil, cltest.NewConvSym())
+	pkg, err := createTestPkg(nc, &convert.PackageConfig{GoDoc: true})
+	if err != nil {
+		t.Fatal("NewPackage failed:", err)
+	}
+	SetTempFile(pkg)
+
+	err = pkg.NewFuncDecl("NewPoint", &ast.FuncDecl{
+		Object: ast.Object{
+			Name: &ast.Ident{Name: "new_point"},
+			Doc: &ast.CommentGroup{List: []*ast.Comment{
+				{Text: "/**\n * Creates a point_t.\n * @param x the x coordinate\n * @deprecated\n */"},
+			}},
+		},
+		MangledName: "new_point",
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{{Name: "x"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+			}},
+			Ret: &ast.BuiltinType{Kind: ast.Void},
+		},
+	})
+	if err != nil {
+		t.Fatal("NewFuncDecl failed:", err)
+	}
+	err = pkg.NewTypeDecl("PointT", &ast.TypeDecl{
+		Object: ast.Object{
+			Name: &ast.Ident{Name: "point_t"},
+			Doc: &ast.CommentGroup{List: []*ast.Comment{
+				{Text: "/// \\brief A point, see new_point()."},
+			}},
+		},
+		Type: &ast.RecordType{
+			Tag:    ast.Struct,
+			HasDef: true,
+			Fields: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{{Name: "x"}}, Type: &ast.BuiltinType{Kind: ast.Int}},
+			}},
+		},
+	}, nc)
+	if err != nil {
+		t.Fatal("NewTypeDecl failed:", err)
+	}
+	if err := pkg.Complete(); err != nil {
+		t.Fatal("Complete failed:", err)
+	}
+
+	comparePackageOutput(t, pkg, `
+package testpkg
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+// NewPoint creates a [PointT].
+//
+// Parameters:
+//   - x: the x coordinate
+//
+// Deprecated: NewPoint is deprecated.
+//
+//go:linkname NewPoint C.new_point
+func NewPoint(x c.Int)
+// PointT is a point, see [NewPoint].
+type PointT struct {
+	X c.Int
+}
+`)
+}
+
+func TestClassLayout(t *testing.T) {
+	nc := cltest.NC(&llcppg.Config{}, nil, cltest.NewConvSym())
+	pkg, err := createTestPkg(nc, &convert.PackageConfig{})
+	if err != nil {
+		t.Fatal("NewPackage failed:", err)
+	}
+	SetTempFile(pkg)
+
+	field := func(name string, typ *ast.BuiltinType) *ast.Field {
+		return &ast.Field{Names: []*ast.Ident{{Name: name}}, Type: typ, Access: ast.Public}
+	}
+	intType := &ast.BuiltinType{Kind: ast.Int}
+	char := &ast.BuiltinType{Kind: ast.Char, Flags: ast.Signed}
+	base := func(name string) *ast.Field {
+		return &ast.Field{Type: &ast.Ident{Name: name}, Access: ast.Public, IsBase: true}
+	}
+	virtual := &ast.FuncDecl{
+		Object:    ast.Object{Name: &ast.Ident{Name: "f"}},
+		Type:      &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Void}},
+		IsVirtual: true,
+	}
+	class := func(name string, methods []*ast.FuncDecl, fields ...*ast.Field) *ast.TypeDecl {
+		return &ast.TypeDecl{
+			Object: ast.Object{Name: &ast.Ident{Name: name}},
+			Type:   &ast.RecordType{Tag: ast.Struct, HasDef: true, Fields: &ast.FieldList{List: fields}, Methods: methods},
+		}
+	}
+	for _, decl := range []*ast.TypeDecl{
+		// struct Plain { int x; char c; };
+		class("Plain", nil, field("x", intType), field("c", char)),
+		// struct Dynamic : Plain { virtual void f(); };
+		class("Dynamic", []*ast.FuncDecl{virtual}, base("Plain")),
+		// struct Derived : Plain, Dynamic { char d; };
+		class("Derived", nil, base("Plain"), base("Dynamic"), field("d", char)),
+		// struct PodDerived : Plain { char d; };
+		class("PodDerived", nil, base("Plain"), field("d", char)),
+	} {
+		if err := pkg.NewTypeDecl(decl.Name.Name, decl, nc); err != nil {
+			t.Fatal("NewTypeDecl failed:", err)
+		}
+	}
+
+	comparePackageOutput(t, pkg, `
+package testpkg
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Plain struct {
+	X c.Int
+	C c.Char
+}
+
+type Dynamic struct {
+	_ c.Pointer
+	Plain
+}
+
+type Derived struct {
+	Dynamic
+	Plain
+	D c.Char
+}
+
+type PodDerived struct {
+	Plain
+	D c.Char
+}
+`)
+}
+
+func TestClassMethod(t *testing.T) {
+	nc := cltest.NC(&llcppg.Config{}, nil, cltest.NewConvSym())
+	pkg, err := createTestPkg(nc, &convert.PackageConfig{})
+	if err != nil {
+		t.Fatal("NewPackage failed:", err)
+	}
+	SetTempFile(pkg)
+
+	intField := func(name string) *ast.Field {
+		return &ast.Field{Names: []*ast.Ident{{Name: name}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Private}
+	}
+	// class Shape {
+	// public:
+	//   Shape();
+	//   virtual ~Shape();
+	//   virtual double area() const;
+	//   static int count;
+	// private:
+	//   int id;
+	// };
+	shape := &ast.TypeDecl{
+		Object: ast.Object{Name: &ast.Ident{Name: "Shape"}},
+		Type: &ast.RecordType{
+			Tag:    ast.Class,
+			HasDef: true,
+			Fields: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{{Name: "count"}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Public, IsStatic: true},
+				intField("id"),
+			}},
+			Methods: []*ast.FuncDecl{
+				{
+					Object:        ast.Object{Name: &ast.Ident{Name: "Shape"}},
+					MangledName:   "_ZN5ShapeC1Ev",
+					Type:          &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Void}},
+					IsConstructor: true,
+				},
+				{
+					Object:       ast.Object{Name: &ast.Ident{Name: "~Shape"}},
+					MangledName:  "_ZN5ShapeD1Ev",
+					Type:         &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Void}},
+					IsDestructor: true,
+					IsVirtual:    true,
+				},
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "area"}},
+					MangledName: "_ZNK5Shape4areaEv",
+					Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Float, Flags: ast.Double}},
+					IsConst:     true,
+					IsVirtual:   true,
+				},
+			},
+		},
+	}
+	// class Square : public Shape {
+	// public:
+	//   Square(int side);
+	//   Square(int w, int h);
+	//   int side() const;
+	//   static Square *create(int side);
+	// private:
+	//   int side_;
+	// };
+	square := &ast.TypeDecl{
+		Object: ast.Object{Name: &ast.Ident{Name: "Square"}},
+		Type: &ast.RecordType{
+			Tag:    ast.Class,
+			HasDef: true,
+			Fields: &ast.FieldList{List: []*ast.Field{
+				{Type: &ast.Ident{Name: "Shape"}, Access: ast.Public, IsBase: true},
+				intField("side_"),
+			}},
+			Methods: []*ast.FuncDecl{
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "Square"}},
+					MangledName: "_ZN6SquareC1Ei",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{intField("side")}},
+						Ret:    &ast.BuiltinType{Kind: ast.Void},
+					},
+					IsConstructor: true,
+				},
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "Square"}},
+					MangledName: "_ZN6SquareC1Eii",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{intField("w"), intField("h")}},
+						Ret:    &ast.BuiltinType{Kind: ast.Void},
+					},
+					IsConstructor: true,
+				},
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "side"}},
+					MangledName: "_ZNK6Square4sideEv",
+					Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int}},
+					IsConst:     true,
+				},
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "create"}},
+					MangledName: "_ZN6Square6createEi",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{intField("side")}},
+						Ret:    &ast.PointerType{X: &ast.Ident{Name: "Square"}},
+					},
+					IsStatic: true,
+				},
+			},
+		},
+	}
+	goNames := map[string]string{
+		"_ZN5ShapeC1Ev":       "(*Shape).Init",
+		"_ZN5ShapeD1Ev":       "(*Shape).Dispose",
+		"_ZNK5Shape4areaEv":   "(*Shape).Area",
+		"_ZN6SquareC1Ei":      "(*Square).Init",
+		"_ZN6SquareC1Eii":     "(*Square).Init__1",
+		"_ZNK6Square4sideEv":  "(*Square).Side",
+		"_ZN6Square6createEi": "SquareCreate",
+	}
+	for _, decl := range []*ast.TypeDecl{shape, square} {
+		if err := pkg.NewTypeDecl(decl.Name.Name, decl, nc); err != nil {
+			t.Fatal("NewTypeDecl failed:", err)
+		}
+		for _, method := range decl.Type.Methods {
+			if err := pkg.NewMethodDecl(goNames[method.MangledName], decl, method); err != nil {
+				t.Fatal("NewMethodDecl failed:", err)
+			}
+		}
+	}
+
+	comparePackageOutput(t, pkg, `
+package testpkg
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Shape struct {
+	_  c.Pointer
+	Id c.Int
+}
+// llgo:link (*Shape).Init C._ZN5ShapeC1Ev
+func (recv_ *Shape) Init() {
+}
+// llgo:link (*Shape).Dispose C._ZN5ShapeD1Ev
+func (recv_ *Shape) Dispose() {
+}
+
+type Square struct {
+	Unused [2]uint64
+}
+// llgo:link (*Square).Init C._ZN6SquareC1Ei
+func (recv_ *Square) Init(side c.Int) {
+}
+// llgo:link (*Square).Init__1 C._ZN6SquareC1Eii
+func (recv_ *Square) Init__1(w c.Int, h c.Int) {
+}
+// llgo:link (*Square).Side C._ZNK6Square4sideEv
+func (recv_ *Square) Side() c.Int {
+	return 0
+}
+//go:linkname SquareCreate C._ZN6Square6createEi
+func SquareCreate(side c.Int) *Square
+`)
+}
+
+func TestVirtualMethod(t *testing.T) {
+	nc := cltest.NC(&llcppg.Config{}, nil, cltest.NewConvSym())
+	pkg, err := createTestPkg(nc, &convert.PackageConfig{
+		Includes: []string{"shape.h"},
+		CFlags:   "$(pkg-config --cflags shape)",
+	})
+	if err != nil {
+		t.Fatal("NewPackage failed:", err)
+	}
+	SetTempFile(pkg)
+
+	// class Shape {
+	// public:
+	//   Shape(int id);
+	//   virtual ~Shape();
+	//   virtual double area() const;
+	//   virtual void draw(const char *name, signed char mark) = 0;
+	//   int id() const;
+	// };
+	shape := &ast.TypeDecl{
+		Object: ast.Object{Name: &ast.Ident{Name: "Shape"}},
+		Type: &ast.RecordType{
+			Tag:    ast.Class,
+			HasDef: true,
+			Fields: &ast.FieldList{},
+			Methods: []*ast.FuncDecl{
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "Shape"}},
+					MangledName: "_ZN5ShapeC1Ei",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{{Name: "id"}}, Type: &ast.BuiltinType{Kind: ast.Int}}}},
+						Ret:    &ast.BuiltinType{Kind: ast.Void},
+					},
+					IsConstructor: true,
+				},
+				{
+					Object:       ast.Object{Name: &ast.Ident{Name: "~Shape"}},
+					MangledName:  "_ZN5ShapeD1Ev",
+					Type:         &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Void}},
+					IsDestructor: true,
+					IsVirtual:    true,
+				},
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "area"}},
+					MangledName: "_ZNK5Shape4areaEv",
+					Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Float, Flags: ast.Double}},
+					IsConst:     true,
+					IsVirtual:   true,
+				},
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "draw"}},
+					MangledName: "_ZN5Shape4drawEPKca",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "name"}}, Type: &ast.PointerType{X: &ast.BuiltinType{Kind: ast.Char, Flags: ast.Signed}, IsConst: true}},
+							{Names: []*ast.Ident{{Name: "mark"}}, Type: &ast.BuiltinType{Kind: ast.Char, Flags: ast.Signed | ast.ExplicitSign}},
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Void},
+					},
+					IsVirtual:     true,
+					IsPureVirtual: true,
+				},
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "id"}},
+					MangledName: "_ZNK5Shape2idEv",
+					Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: &ast.BuiltinType{Kind: ast.Int}},
+					IsConst:     true,
+				},
+			},
+		},
+	}
+	if err := pkg.NewTypeDecl("Shape", shape, nc); err != nil {
+		t.Fatal("NewTypeDecl failed:", err)
+	}
+	goNames := map[string]string{
+		"_ZN5ShapeC1Ei":   "(*Shape).Init",
+		"_ZN5ShapeD1Ev":   "(*Shape).Dispose",
+		"_ZNK5Shape2idEv": "(*Shape).Id",
+	}
+	var virtuals []*ast.FuncDecl
+	for _, method := range shape.Type.Methods {
+		if method.IsVirtual && !method.IsDestructor {
+			virtuals = append(virtuals, method)
+			continue
+		}
+		if err := pkg.NewMethodDecl(goNames[method.MangledName], shape, method); err != nil {
+			t.Fatal("NewMethodDecl failed:", err)
+		}
+	}
+	for _, method := range virtuals {
+		if err := pkg.NewVirtualMethodDecl("Shape", "", shape, method, nc); err != nil {
+			t.Fatal("NewVirtualMethodDecl failed:", err)
+		}
+	}
+	classes := map[string]*ast.TypeDecl{"Shape": shape}
+	if err := pkg.NewSubclassDecl("Shape", shape, convert.VirtualMethods(shape, classes), nc); err != nil {
+		t.Fatal("NewSubclassDecl failed:", err)
+	}
+	if err := pkg.Complete(); err != nil {
+		t.Fatal("Complete failed:", err)
+	}
+
+	comparePackageOutput(t, pkg, `
+package testpkg
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Shape struct {
+	_ c.Pointer
+}
+// llgo:link (*Shape).Init C._ZN5ShapeC1Ei
+func (recv_ *Shape) Init(id c.Int) {
+}
+// llgo:link (*Shape).Dispose C._ZN5ShapeD1Ev
+func (recv_ *Shape) Dispose() {
+}
+// llgo:link (*Shape).Id C._ZNK5Shape2idEv
+func (recv_ *Shape) Id() c.Int {
+	return 0
+}
+// llgo:link (*Shape).Area C.llcppg__ZNK5Shape4areaEv
+func (recv_ *Shape) Area() c.Double {
+	return 0
+}
+// llgo:link (*Shape).Draw C.llcppg__ZN5Shape4drawEPKca
+func (recv_ *Shape) Draw(name *c.Char, mark c.Char) {
+}
+// llgo:type C
+type ShapeAreaFunc func(obj c.Pointer) c.Double
+// llgo:type C
+type ShapeDrawFunc func(obj c.Pointer, name *c.Char, mark c.Char)
+
+type ShapeOverrides struct {
+	Area ShapeAreaFunc
+	Draw ShapeDrawFunc
+}
+//go:linkname NewShapeSubclass C.llcppg_Shape_subclass_new
+func NewShapeSubclass(overrides *ShapeOverrides, obj c.Pointer, id c.Int) *Shape
+//go:linkname DeleteShapeSubclass C.llcppg_Shape_subclass_delete
+func DeleteShapeSubclass(self *Shape)
+`)
+
+	var buf bytes.Buffer
+	if err := pkg.Pkg().WriteTo(&buf, pkgname+"_autogen_link.go"); err != nil {
+		t.Fatal("WriteTo failed:", err)
+	}
+	if !strings.Contains(buf.String(), `const LLGoFiles string = "$(pkg-config --cflags shape): testpkg_autogen_shim.cpp"`) {
+		t.Fatalf("LLGoFiles is not declared:\n%s", buf.String())
+	}
+
+	fname, src := pkg.ShimFile()
+	if fname != "testpkg_autogen_shim.cpp" {
+		t.Fatalf("ShimFile() = %q", fname)
+	}
+	expectShim := `#include <shape.h>
+
+extern "C" double llcppg__ZNK5Shape4areaEv(Shape *self) {
+	return self->area();
+}
+
+extern "C" void llcppg__ZN5Shape4drawEPKca(Shape *self, char const *p0, signed char p1) {
+	self->draw(p0, p1);
+}
+
+typedef double (*llcppg_Shape_area_fn)(void *);
+
+typedef void (*llcppg_Shape_draw_fn)(void *, char const *, signed char);
+
+struct llcppg_Shape_overrides {
+	llcppg_Shape_area_fn area;
+	llcppg_Shape_draw_fn draw;
+};
+
+class llcppg_Shape_subclass : public Shape {
+public:
+	llcppg_Shape_subclass(const llcppg_Shape_overrides *overrides, void *obj, int p0) : Shape(p0), overrides_(overrides), obj_(obj) {}
+	double area() const override {
+		if (overrides_->area) {
+			return overrides_->area(obj_);
+		}
+		return Shape::area();
+	}
+	void draw(char const *p0, signed char p1) override {
+		overrides_->draw(obj_, p0, p1);
+	}
+
+private:
+	const llcppg_Shape_overrides *overrides_;
+	void *obj_;
+};
+
+extern "C" Shape *llcppg_Shape_subclass_new(const llcppg_Shape_overrides *overrides, void *obj, int p0) {
+	return new llcppg_Shape_subclass(overrides, obj, p0);
+}
+
+extern "C" void llcppg_Shape_subclass_delete(Shape *self) {
+	delete static_cast<llcppg_Shape_subclass *>(self);
+}
+`
+	if string(src) != expectShim {
+		t.Fatalf("ShimFile() =\n%s\nwant:\n%s", src, expectShim)
+	}
+}
+
+func TestVirtualOperator(t *testing.T) {
+	nc := cltest.NC(&llcppg.Config{}, nil, cltest.NewConvSym())
+	pkg, err := createTestPkg(nc, &convert.PackageConfig{Includes: []string{"key.h"}})
+	if err != nil {
+		t.Fatal("NewPackage failed:", err)
+	}
+	SetTempFile(pkg)
+
+	boolType := &ast.BuiltinType{Kind: ast.Bool}
+	// class Key {
+	// public:
+	//   virtual bool operator<(const Key &other) const = 0;
+	//   virtual operator bool() const;
+	// };
+	key := &ast.TypeDecl{
+		Object: ast.Object{Name: &ast.Ident{Name: "Key"}},
+		Type: &ast.RecordType{
+			Tag:    ast.Class,
+			HasDef: true,
+			Fields: &ast.FieldList{},
+			Methods: []*ast.FuncDecl{
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "operator<"}},
+					MangledName: "_ZNK3KeyltERKS_",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							{Names: []*ast.Ident{{Name: "other"}}, Type: &ast.LvalueRefType{X: &ast.Ident{Name: "Key"}, IsConst: true}},
+						}},
+						Ret: boolType,
+					},
+					IsConst:       true,
+					IsVirtual:     true,
+					IsPureVirtual: true,
+				},
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "operator bool"}},
+					MangledName: "_ZNK3KeycvbEv",
+					Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: boolType},
+					IsConst:     true,
+					IsVirtual:   true,
+				},
+			},
+		},
+	}
+	if err := pkg.NewTypeDecl("Key", key, nc); err != nil {
+		t.Fatal("NewTypeDecl failed:", err)
+	}
+	for _, method := range key.Type.Methods {
+		if err := pkg.NewVirtualMethodDecl("Key", "", key, method, nc); err != nil {
+			t.Fatal("NewVirtualMethodDecl failed:", err)
+		}
+	}
+	if err := pkg.NewSubclassDecl("Key", key, key.Type.Methods, nc); err != nil {
+		t.Fatal("NewSubclassDecl failed:", err)
+	}
+
+	comparePackageOutput(t, pkg, `
+package testpkg
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Key struct {
+	_ c.Pointer
+}
+// The reference parameter other must not be nil.
+// llgo:link (*Key).Less C.llcppg__ZNK3KeyltERKS_
+func (recv_ *Key) Less(other *Key) bool {
+	return false
+}
+// llgo:type C
+type KeyLessFunc func(obj c.Pointer, other *Key) bool
+
+type KeyOverrides struct {
+	Less KeyLessFunc
+}
+//go:linkname NewKeySubclass C.llcppg_Key_subclass_new
+func NewKeySubclass(overrides *KeyOverrides, obj c.Pointer) *Key
+//go:linkname DeleteKeySubclass C.llcppg_Key_subclass_delete
+func DeleteKeySubclass(self *Key)
+`)
+	_, src := pkg.ShimFile()
+	expectShim := `#include <key.h>
+
+extern "C" bool llcppg__ZNK3KeyltERKS_(Key *self, Key const &p0) {
+	return self->operator<(p0);
+}
+
+typedef bool (*llcppg_Key_Less_fn)(void *, Key const &);
+
+struct llcppg_Key_overrides {
+	llcppg_Key_Less_fn Less;
+};
+
+class llcppg_Key_subclass : public Key {
+public:
+	llcppg_Key_subclass(const llcppg_Key_overrides *overrides, void *obj) : Key(), overrides_(overrides), obj_(obj) {}
+	bool operator<(Key const &p0) const override {
+		return overrides_->Less(obj_, p0);
+	}
+
+private:
+	const llcppg_Key_overrides *overrides_;
+	void *obj_;
+};
+
+extern "C" Key *llcppg_Key_subclass_new(const llcppg_Key_overrides *overrides, void *obj) {
+	return new llcppg_Key_subclass(overrides, obj);
+}
+
+extern "C" void llcppg_Key_subclass_delete(Key *self) {
+	delete static_cast<llcppg_Key_subclass *>(self);
+}
+`
+	if string(src) != expectShim {
+		t.Fatalf("ShimFile() =\n%s\nwant:\n%s", src, expectShim)
+	}
+}
+
+func TestRefParams(t *testing.T) {
+	nc := cltest.NC(&llcppg.Config{}, nil, cltest.NewConvSym())
+	pkg, err := createTestPkg(nc, &convert.PackageConfig{
+		Includes:        []string{"str.h"},
+		ConstRefByValue: 16,
+	})
+	if err != nil {
+		t.Fatal("NewPackage failed:", err)
+	}
+	SetTempFile(pkg)
+
+	voidType := &ast.BuiltinType{Kind: ast.Void}
+	param := func(name string, typ ast.Expr) *ast.Field {
+		return &ast.Field{Names: []*ast.Ident{{Name: name}}, Type: typ}
+	}
+	intField := func(name string) *ast.Field {
+		return &ast.Field{Names: []*ast.Ident{{Name: name}}, Type: &ast.BuiltinType{Kind: ast.Int}, Access: ast.Public}
+	}
+	constRef := func(x ast.Expr) ast.Expr {
+		return &ast.LvalueRefType{X: x, IsConst: true}
+	}
+	// struct Point {
+	//   int x, y;
+	//   static int dot(const Point &a, const Point &b);
+	// };
+	point := &ast.TypeDecl{
+		Object: ast.Object{Name: &ast.Ident{Name: "Point"}},
+		Type: &ast.RecordType{
+			Tag:    ast.Struct,
+			HasDef: true,
+			Fields: &ast.FieldList{List: []*ast.Field{intField("x"), intField("y")}},
+			Methods: []*ast.FuncDecl{
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "dot"}},
+					MangledName: "_ZN5Point3dotERKS_S1_",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							param("a", constRef(&ast.Ident{Name: "Point"})),
+							param("b", constRef(&ast.Ident{Name: "Point"})),
+						}},
+						Ret: &ast.BuiltinType{Kind: ast.Int},
+					},
+					IsStatic: true,
+				},
+			},
+		},
+	}
+	// class Str {
+	// public:
+	//   Str(Str &&other);
+	//   ~Str();
+	//   void swap(Str &other);
+	//   void append(const Str &s, const int &n);
+	//   char *data;
+	// };
+	str := &ast.TypeDecl{
+		Object: ast.Object{Name: &ast.Ident{Name: "Str"}},
+		Type: &ast.RecordType{
+			Tag:    ast.Class,
+			HasDef: true,
+			Fields: &ast.FieldList{List: []*ast.Field{
+				{Names: []*ast.Ident{{Name: "data"}}, Type: &ast.PointerType{X: &ast.BuiltinType{Kind: ast.Char, Flags: ast.Signed}}, Access: ast.Public},
+			}},
+			Methods: []*ast.FuncDecl{
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "Str"}},
+					MangledName: "_ZN3StrC1EOS_",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{param("other", &ast.RvalueRefType{X: &ast.Ident{Name: "Str"}})}},
+						Ret:    voidType,
+					},
+					IsConstructor: true,
+				},
+				{
+					Object:       ast.Object{Name: &ast.Ident{Name: "~Str"}},
+					MangledName:  "_ZN3StrD1Ev",
+					Type:         &ast.FuncType{Params: &ast.FieldList{}, Ret: voidType},
+					IsDestructor: true,
+				},
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "swap"}},
+					MangledName: "_ZN3Str4swapERS_",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{param("other", &ast.LvalueRefType{X: &ast.Ident{Name: "Str"}})}},
+						Ret:    voidType,
+					},
+				},
+				{
+					Object:      ast.Object{Name: &ast.Ident{Name: "append"}},
+					MangledName: "_ZN3Str6appendERKS_RKi",
+					Type: &ast.FuncType{
+						Params: &ast.FieldList{List: []*ast.Field{
+							param("s", constRef(&ast.Ident{Name: "Str"})),
+							param("n", constRef(&ast.BuiltinType{Kind: ast.Int})),
+						}},
+						Ret: voidType,
+					},
+				},
+			},
+		},
+	}
+	goNames := map[string]string{
+		"_ZN5Point3dotERKS_S1_":  "PointDot",
+		"_ZN3StrC1EOS_":          "(*Str).Init",
+		"_ZN3StrD1Ev":            "(*Str).Dispose",
+		"_ZN3Str4swapERS_":       "(*Str).Swap",
+		"_ZN3Str6appendERKS_RKi": "(*Str).Append",
+	}
+	for _, decl := range []*ast.TypeDecl{point, str} {
+		if err := pkg.NewTypeDecl(decl.Name.Name, decl, nc); err != nil {
+			t.Fatal("NewTypeDecl failed:", err)
+		}
+		for _, method := range decl.Type.Methods {
+			if err := pkg.NewMethodDecl(goNames[method.MangledName], decl, method); err != nil {
+				t.Fatal("NewMethodDecl failed:", err)
+			}
+		}
+	}
+
+	comparePackageOutput(t, pkg, `
+package testpkg
+
+import (
+	"github.com/goplus/lib/c"
+	_ "unsafe"
+)
+
+type Point struct {
+	X c.Int
+	Y c.Int
+}
+//go:linkname PointDot C.llcppg__ZN5Point3dotERKS_S1_
+func PointDot(a Point, b Point) c.Int
+
+type Str struct {
+	Data *c.Char
+}
+// The rvalue reference parameter other must not be nil, it may be moved from.
+// llgo:link (*Str).Init C.llcppg__ZN3StrC1EOS_
+func (recv_ *Str) Init(other *Str) {
+}
+// llgo:link (*Str).Dispose C._ZN3StrD1Ev
+func (recv_ *Str) Dispose() {
+}
+// The reference parameter other must not be nil.
+// llgo:link (*Str).Swap C._ZN3Str4swapERS_
+func (recv_ *Str) Swap(other *Str) {
+}
+// The reference parameter s must not be nil.
+// llgo:link (*Str).Append C.llcppg__ZN3Str6appendERKS_RKi
+func (recv_ *Str) Append(s *Str, n c.Int) {
+}
+`)
+	_, src := pkg.ShimFile()
+	expectShim := `#include <str.h>
+#include <new>
+
+extern "C" int llcppg__ZN5Point3dotERKS_S1_(Point p0, Point p1) {
+	return Point::dot(p0, p1);
+}
+
+extern "C" void llcppg__ZN3StrC1EOS_(Str *self, Str &&p0) {
+	new (self) Str(static_cast<Str &&>(p0));
+}
+
+extern "C" void llcppg__ZN3Str6appendERKS_RKi(Str *self, Str const &p0, int p1) {
+	self->append(p0, p1);
+}
+`
+	if string(src) != expectShim {
+		t.Fatalf("ShimFile() =\n%s\nwant:\n%s", src, expectShim)
+	}
+}
+
+type genDeclTestCase struct {
+	name        string
+	decl        ast.Decl
+	symbs       []llcppg.SymbolInfo
+	cppgconf    *llcppg.Config
+	expected    string
+	expectedErr string
+}
+
+func testGenDecl(t *testing.T, tc genDeclTestCase) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatal("unexpect panic", r)
+		}
+	}()
+	var libCommand string
+	var deps []string
+	if tc.cppgconf != nil {
+		libCommand = tc.cppgconf.Libs
+		deps = tc.cppgconf.Deps
+	}
+	if tc.cppgconf == nil {
+		tc.cppgconf = &llcppg.Config{Name: pkgname}
+	}
+	fileMap := make(map[string]*llcppg.FileInfo)
+	fileMap["/path/to/temp.h"] = &llcppg.FileInfo{
+		FileType: llcppg.Inter,
+	}
+
+	nc := cltest.NC(tc.cppgconf, fileMap, cltest.NewConvSym(tc.symbs...))
+	pkg, err := createTestPkg(nc, &convert.PackageConfig{
+		LibCommand: libCommand,
+		PkgBase: convert.PkgBase{
+			Deps: deps,
+		},
+	})
+	if err != nil {
+		t.Fatal("NewPackage failed:", err)
+	}
+	if pkg == nil {
+		t.Fatal("NewPackage failed")
+	}
+	goName, _, err := nc.ConvDecl("/path/to/temp.h", tc.decl)
+	if err != nil {
+		t.Fatalf("ConvDecl failed: %v", err)
+	}
+	SetTempFile(pkg)
+	switch d := tc.decl.(type) {
+	case *ast.TypeDecl:
+		err = pkg.NewTypeDecl(goName, d, nc)
+	case *ast.TypedefDecl:
+		err = pkg.NewTypedefDecl(goName, d, nc)
+	case *ast.FuncDecl:
+		err = pkg.NewFuncDecl(goName, d)
+	case *ast.EnumTypeDecl:
+		err = pkg.NewEnumTypeDecl(goName, d, nc)
+	default:
+		t.Errorf("Unsupported declaration type: %T", tc.decl)
+		return
+	}
+	if tc.expectedErr != "" {
+		compareError(t, err, tc.expectedErr)
+	} else {
+		if err != nil {
+			t.Errorf("Declaration generation failed: %v", err)
+		} else {
+			comparePackageOutput(t, pkg, tc.expected)
 		}
 	}
 }
@@ -1808,16 +3154,28 @@ func createTestPkg(nc nc.NodeConverter, cfg *convert.PackageConfig) (*convert.Pa
 	if cfg.LibCommand == "" {
 		cfg.LibCommand = "${pkg-config --libs xxx}"
 	}
+	if cfg.GenConf == nil {
+		cfg.GenConf = &gogen.Config{}
+	}
 	return convert.NewPackage(pnc, &convert.PackageConfig{
 		PkgBase: convert.PkgBase{
 			PkgPath: ".",
 			Deps:    cfg.Deps,
 			Pubs:    make(map[string]string),
 		},
-		Name:       pkgname,
-		GenConf:    &gogen.Config{},
-		OutputDir:  cfg.OutputDir,
-		LibCommand: cfg.LibCommand,
+		Name:          pkgname,
+		GenConf:       cfg.GenConf,
+		OutputDir:     cfg.OutputDir,
+		LibCommand:    cfg.LibCommand,
+		TypeSizes:     cfg.TypeSizes,
+		OpaqueExclude: cfg.OpaqueExclude,
+		AliasTypedefs: cfg.AliasTypedefs,
+		AutoAlias:     cfg.AutoAlias,
+		GoDoc:         cfg.GoDoc,
+		Includes:      cfg.Includes,
+		CFlags:        cfg.CFlags,
+
+		ConstRefByValue: cfg.ConstRefByValue,
 	})
 }
 
@@ -1872,6 +3230,7 @@ func TestTypeClean(t *testing.T) {
 					},
 					Type: &ast.RecordType{
 						Tag:    ast.Struct,
+						HasDef: true,
 						Fields: &ast.FieldList{}},
 				}, nc)
 			},
@@ -0,0 +1,185 @@
+package convert
+
+import (
+	goast "go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/goplus/llcppg/ast"
+)
+
+// refParams returns the parameters of a C++ function called through the C++ shim, where
+// the const reference parameters of the small trivially copyable types are passed by value
+// with the constRefByValue option. It reports whether the function needs a wrapper of the
+// shim, which is also the case for the rvalue reference parameters, whose objects are
+// passed as pointers from Go and moved to the function, and the bridged standard types
+// passed by value, like std::string, which are passed by const references instead.
+func (p *Package) refParams(typ *ast.FuncType) (params []*ast.Field, wrap bool) {
+	for _, param := range paramList(typ) {
+		switch t := param.Type.(type) {
+		case *ast.RvalueRefType:
+			wrap = true
+		case *ast.LvalueRefType:
+			if t.IsConst && p.byValue(t.X) {
+				byValue := *param
+				byValue.Type = t.X
+				param, wrap = &byValue, true
+			}
+		default:
+			if stdBridgeOf(t) != nil {
+				byRef := *param
+				byRef.Type = &ast.LvalueRefType{X: t, IsConst: true}
+				param, wrap = &byRef, true
+			}
+		}
+		params = append(params, param)
+	}
+	return
+}
+
+// refWrapper returns the function fn linked to a wrapper of the C++ shim if refParams
+// needs one, otherwise fn itself. class is the class of a method, nil for a function.
+func (p *Package) refWrapper(fn *ast.FuncDecl, class *ast.TypeDecl) *ast.FuncDecl {
+	params, wrap := p.refParams(fn.Type)
+	if !wrap {
+		return fn
+	}
+	return p.shimWrapper(fn, class, params, shimPrefix+fn.MangledName)
+}
+
+// shimWrapper returns the function fn linked to the wrapper symbol of the C++ shim, which
+// takes the parameters params and calls fn with them. class is the class of a method.
+func (p *Package) shimWrapper(fn *ast.FuncDecl, class *ast.TypeDecl, params []*ast.Field, symbol string) *ast.FuncDecl {
+	wrapper, fnType := *fn, *fn.Type
+	fnType.Params = &ast.FieldList{List: params}
+	wrapper.Type = &fnType
+	wrapper.MangledName = symbol
+
+	decls, args := cppParams(fnType.Params)
+	p.addShimCall(fn, class, decls, args, symbol)
+	return &wrapper
+}
+
+// addShimCall adds the extern "C" function symbol to the C++ shim, which takes the parameters
+// decls, preceded by self for a method, and calls fn with the arguments args.
+func (p *Package) addShimCall(fn *ast.FuncDecl, class *ast.TypeDecl, decls, args []string, symbol string) {
+	call := fn.QualifiedName() + "(" + strings.Join(args, ", ") + ")"
+	if class != nil {
+		switch {
+		case fn.IsConstructor:
+			p.shim.include("new")
+			call = "new (self) " + class.QualifiedName() + "(" + strings.Join(args, ", ") + ")"
+		case fn.IsStatic:
+			call = class.QualifiedName() + "::" + fn.Name.Name + "(" + strings.Join(args, ", ") + ")"
+		default:
+			call = "self->" + fn.Name.Name + "(" + strings.Join(args, ", ") + ")"
+		}
+		if !fn.IsStatic {
+			decls = append([]string{class.QualifiedName() + " *self"}, decls...)
+		}
+	}
+	p.shim.add("extern \"C\" %s {\n\t%s;\n}",
+		cppDecl(fn.Type.Ret, symbol+"("+strings.Join(decls, ", ")+")"),
+		cppReturn(fn.Type.Ret, call))
+}
+
+// byValue reports whether a const reference to the type is passed by value, which is
+// a trivially copyable type whose size is at most the constRefByValue option.
+func (p *Package) byValue(x ast.Expr) bool {
+	if p.conf.ConstRefByValue <= 0 {
+		return false
+	}
+	typ, err := p.ToType(x)
+	if err != nil || !p.isTrivial(typ) {
+		return false
+	}
+	return Sizeof(typ) <= int64(p.conf.ConstRefByValue)
+}
+
+// isTrivial reports whether the Go type of a C++ type is trivially copyable,
+// the records are the ones recorded by markTrivial.
+func (p *Package) isTrivial(typ types.Type) bool {
+	switch t := types.Unalias(typ).(type) {
+	case *types.Basic, *types.Pointer, *types.Signature:
+		return true
+	case *types.Array:
+		return p.isTrivial(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if !p.isTrivial(t.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	case *types.Named:
+		if _, ok := t.Underlying().(*types.Struct); ok {
+			return p.trivial[t.Obj()]
+		}
+		return p.isTrivial(t.Underlying())
+	}
+	return false
+}
+
+// markTrivial records the converted record named if it is trivially copyable, which is
+// a record without virtual methods, user declared destructor, copy or move operations,
+// whose fields and bases are trivially copyable.
+func (p *Package) markTrivial(named *types.Named, record *ast.RecordType) {
+	for _, method := range record.Methods {
+		if method.IsVirtual || method.IsDestructor || method.Name.Name == "operator=" {
+			return
+		}
+		// a copy or move constructor takes a reference to the record
+		if params := paramList(method.Type); method.IsConstructor && len(params) == 1 {
+			switch params[0].Type.(type) {
+			case *ast.LvalueRefType, *ast.RvalueRefType:
+				return
+			}
+		}
+	}
+	if p.isTrivial(named.Underlying()) {
+		p.trivial[named.Obj()] = true
+	}
+}
+
+// refDocComments returns the comments documenting the reference parameters of
+// a C++ function, which are converted to pointers that must not be nil.
+func refDocComments(funcType *ast.FuncType, sig *types.Signature) []*goast.Comment {
+	params := paramList(funcType)
+	if sig.Recv() != nil {
+		params = params[1:]
+	}
+	var lvalues, rvalues []string
+	for i, param := range params {
+		switch param.Type.(type) {
+		case *ast.LvalueRefType:
+			lvalues = append(lvalues, sig.Params().At(i).Name())
+		case *ast.RvalueRefType:
+			rvalues = append(rvalues, sig.Params().At(i).Name())
+		}
+	}
+	var list []*goast.Comment
+	if len(lvalues) > 0 {
+		list = append(list, &goast.Comment{Text: "// The " + refParamsText("reference", lvalues) + " must not be nil."})
+	}
+	if len(rvalues) > 0 {
+		pronoun := "it"
+		if len(rvalues) > 1 {
+			pronoun = "they"
+		}
+		list = append(list, &goast.Comment{Text: "// The " + refParamsText("rvalue reference", rvalues) +
+			" must not be nil, " + pronoun + " may be moved from."})
+	}
+	return list
+}
+
+// refParamsText spells the parameters of a kind, like `reference parameters a and b`,
+// the unnamed parameters are spelled without their names.
+func refParamsText(kind string, names []string) string {
+	if len(names) == 1 {
+		return strings.TrimSpace(kind + " parameter " + names[0])
+	}
+	if names[0] == "" {
+		return kind + " parameters"
+	}
+	return kind + " parameters " + strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
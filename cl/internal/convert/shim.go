@@ -0,0 +1,514 @@
+package convert
+
+import (
+	"fmt"
+	"go/types"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goplus/llcppg/ast"
+	"github.com/goplus/llcppg/cl/nc"
+	"github.com/goplus/llcppg/internal/name"
+)
+
+// shimPrefix is the prefix of the C names declared by the C++ shim.
+const shimPrefix = "llcppg_"
+
+// cppShim is the C++ shim of a package, a translation unit of extern "C" functions that
+// LLGo compiles with the package through the LLGoFiles constant. It calls the virtual
+// methods through the vtable, implements the Go subclasses of the C++ classes, and wraps
+// the functions taking the references which can't be passed from Go as they are.
+type cppShim struct {
+	headers []string // standard headers needed by the declarations
+	decls   []string // C++ declarations in order
+}
+
+func (p *cppShim) add(format string, args ...any) {
+	p.decls = append(p.decls, fmt.Sprintf(format, args...))
+}
+
+func (p *cppShim) include(header string) {
+	if !slices.Contains(p.headers, header) {
+		p.headers = append(p.headers, header)
+	}
+}
+
+func (p *Package) shimFile() string {
+	return p.conf.Name + "_autogen_shim.cpp"
+}
+
+// ShimFile returns the file name and the source of the C++ shim of the package,
+// the source is nil if the package needs no shim.
+func (p *Package) ShimFile() (fname string, src []byte) {
+	if len(p.shim.decls) == 0 {
+		return "", nil
+	}
+	var b strings.Builder
+	for _, inc := range append(slices.Clone(p.conf.Includes), p.shim.headers...) {
+		fmt.Fprintf(&b, "#include <%s>\n", inc)
+	}
+	for _, decl := range p.shim.decls {
+		b.WriteString("\n")
+		b.WriteString(decl)
+		b.WriteString("\n")
+	}
+	return p.shimFile(), []byte(b.String())
+}
+
+// completeShim declares the LLGoFiles constant, which makes LLGo compile the C++ shim with
+// the package and its cflags, like -I/opt/include or $(pkg-config --cflags xxx).
+func (p *Package) completeShim() {
+	if len(p.shim.decls) == 0 {
+		return
+	}
+	files := p.shimFile()
+	if p.conf.CFlags != "" {
+		files = p.conf.CFlags + ": " + files
+	}
+	p.setCurFile(p.autoLinkFile())
+	p.p.CB().NewConstStart(types.Typ[types.String], "LLGoFiles").Val(files).EndInit(1)
+}
+
+// NewVirtualMethodDecl converts a public virtual method of the C++ class to a Go method named
+// fnName, which comes from the symbol table, or is named after the method if it's empty.
+// Its mangled name calls the implementation of the class itself, so the Go method is linked
+// to a thunk of the C++ shim instead, which calls the method through the vtable.
+func (p *Package) NewVirtualMethodDecl(className, fnName string, class *ast.TypeDecl, method *ast.FuncDecl, pnc nc.NodeConverter) error {
+	if method.IsDestructor || isVariadic(method.Type) {
+		return nil
+	}
+	if fnName == "" {
+		var ok bool
+		if fnName, ok = virtualName(method, pnc); !ok {
+			log.Printf("NewVirtualMethodDecl: %s of %s has no Go name, ignored\n", method.Name.Name, className)
+			return nil
+		}
+	}
+	symbol := shimPrefix + method.MangledName
+	goName := "(*" + className + ")." + p.methodName(className, fnName)
+	// the thunk passes the const references by value like the wrappers of refWrapper
+	fn, fnType := *method, *method.Type
+	refs, _ := p.refParams(method.Type)
+	fnType.Params = &ast.FieldList{List: refs}
+	fn.Type = &fnType
+	if err := p.newMethodDecl(goName, symbol, class, &fn); err != nil {
+		return err
+	}
+	params, args := cppParams(fnType.Params)
+	params = append([]string{class.QualifiedName() + " *self"}, params...)
+	p.shim.add("extern \"C\" %s {\n\t%s;\n}",
+		cppDecl(method.Type.Ret, symbol+"("+strings.Join(params, ", ")+")"),
+		cppReturn(method.Type.Ret, "self->"+method.Name.Name+"("+strings.Join(args, ", ")+")"))
+	return nil
+}
+
+// NewSubclassDecl generates a Go subclass of the C++ class, which is a subclass in the C++ shim
+// overriding the virtual methods with the Go functions of an overrides struct. A nil function
+// calls the method of the class, except for a pure virtual method, whose function is required.
+// virtuals are the virtual methods of the class and the ones inherited from its bases, whose
+// functions are named like the Go methods of the symbol table.
+//
+// The objects are created with New{Class}Subclass for each constructor of the class, which
+// takes the overrides and a pointer passed to its functions, and deleted with Delete{Class}Subclass.
+func (p *Package) NewSubclassDecl(className string, class *ast.TypeDecl, virtuals []*ast.FuncDecl, pnc nc.NodeConverter) error {
+	cppName := class.QualifiedName()
+	cname := strings.ReplaceAll(cppName, "::", "_")
+	subclass := shimPrefix + cname + "_subclass"
+	overrides := shimPrefix + cname + "_overrides"
+	object := &ast.Ident{Name: "obj"}
+	voidPtr := &ast.PointerType{X: &ast.BuiltinType{Kind: ast.Void}}
+
+	var fields []*ast.Field
+	var cppFields, cppMethods []string
+	counts := make(map[string]int)
+	for _, method := range virtuals {
+		field, ok := virtualName(method, pnc)
+		if !ok {
+			continue
+		}
+		if goName, ok := virtualGoName(method, pnc); ok {
+			field = goName
+		} else if !name.IsOperator(method.Name.Name) {
+			field = method.Name.Name
+		}
+		if counts[field]++; counts[field] > 1 {
+			field += "_" + strconv.Itoa(counts[field]-1)
+		}
+		fnName := shimPrefix + cname + "_" + field + "_fn"
+		fnType := &ast.FuncType{
+			Params: &ast.FieldList{List: append([]*ast.Field{{Names: []*ast.Ident{object}, Type: voidPtr}}, paramList(method.Type)...)},
+			Ret:    method.Type.Ret,
+		}
+		fnDecl := &ast.TypedefDecl{
+			Object: ast.Object{Loc: class.Loc, Name: &ast.Ident{Name: fnName}},
+			Type:   &ast.PointerType{X: fnType},
+		}
+		if err := p.NewTypedefDecl(className+pnc.ConvFuncName(field)+"Func", fnDecl, pnc); err != nil {
+			return err
+		}
+		fields = append(fields, &ast.Field{Names: []*ast.Ident{{Name: field}}, Type: &ast.Ident{Name: fnName}, Access: ast.Public})
+		p.shim.add("typedef %s;", cppDecl(fnDecl.Type, fnName))
+		cppFields = append(cppFields, "\t"+fnName+" "+field+";\n")
+
+		params, args := cppParams(method.Type.Params)
+		decl := cppDecl(method.Type.Ret, method.Name.Name+"("+strings.Join(params, ", ")+")")
+		if method.IsConst {
+			decl += " const"
+		}
+		call := cppReturn(method.Type.Ret, "overrides_->"+field+"("+strings.Join(append([]string{"obj_"}, args...), ", ")+")")
+		if method.IsPureVirtual {
+			cppMethods = append(cppMethods, fmt.Sprintf("\t%s override {\n\t\t%s;\n\t}\n", decl, call))
+			continue
+		}
+		base := cppReturn(method.Type.Ret, cppName+"::"+method.Name.Name+"("+strings.Join(args, ", ")+")")
+		cppMethods = append(cppMethods, fmt.Sprintf("\t%s override {\n\t\tif (overrides_->%s) {\n\t\t\t%s;\n\t\t}\n\t\t%s;\n\t}\n", decl, field, call, base))
+	}
+
+	overridesDecl := &ast.TypeDecl{
+		Object: ast.Object{Loc: class.Loc, Name: &ast.Ident{Name: overrides}},
+		Type:   &ast.RecordType{Tag: ast.Struct, HasDef: true, Fields: &ast.FieldList{List: fields}},
+	}
+	if err := p.NewTypeDecl(className+"Overrides", overridesDecl, pnc); err != nil {
+		return err
+	}
+	p.shim.add("struct %s {\n%s};", overrides, strings.Join(cppFields, ""))
+
+	var ctors []*ast.FuncDecl
+	hasCtor := false
+	for _, method := range class.Type.Methods {
+		if method.IsConstructor {
+			hasCtor = true
+			// the copy and move constructors make no sense for a subclass
+			if !isCopyOrMove(method.Type, cppName) && !isVariadic(method.Type) {
+				ctors = append(ctors, method)
+			}
+		}
+	}
+	if !hasCtor {
+		ctors = append(ctors, &ast.FuncDecl{Type: &ast.FuncType{Params: &ast.FieldList{}}})
+	}
+
+	var cppCtors, cppNews []string
+	classPtr := &ast.PointerType{X: objectRef(&class.Object)}
+	for i, ctor := range ctors {
+		params, args := cppParams(ctor.Type.Params)
+		params = append([]string{"const " + overrides + " *overrides", "void *obj"}, params...)
+		cppCtors = append(cppCtors, fmt.Sprintf("\t%s(%s) : %s(%s), overrides_(overrides), obj_(obj) {}\n",
+			subclass, strings.Join(params, ", "), cppName, strings.Join(args, ", ")))
+
+		symbol, goName := subclass+"_new", "New"+className+"Subclass"
+		if i > 0 {
+			symbol += "__" + strconv.Itoa(i)
+			goName += "__" + strconv.Itoa(i)
+		}
+		fn := &ast.FuncDecl{
+			Object:      ast.Object{Loc: class.Loc, Name: &ast.Ident{Name: symbol}},
+			MangledName: symbol,
+			Type: &ast.FuncType{
+				Params: &ast.FieldList{List: append([]*ast.Field{
+					{Names: []*ast.Ident{{Name: "overrides"}}, Type: &ast.PointerType{X: &ast.Ident{Name: overrides}}},
+					{Names: []*ast.Ident{object}, Type: voidPtr},
+				}, paramList(ctor.Type)...)},
+				Ret: classPtr,
+			},
+		}
+		if err := p.NewFuncDecl(goName, fn); err != nil {
+			return err
+		}
+		cppNews = append(cppNews, fmt.Sprintf("extern \"C\" %s *%s(%s) {\n\treturn new %s(%s);\n}",
+			cppName, symbol, strings.Join(params, ", "), subclass, strings.Join(append([]string{"overrides", "obj"}, args...), ", ")))
+	}
+
+	p.shim.add("class %s : public %s {\npublic:\n%s%s\nprivate:\n\tconst %s *overrides_;\n\tvoid *obj_;\n};",
+		subclass, cppName, strings.Join(cppCtors, ""), strings.Join(cppMethods, ""), overrides)
+	p.shim.decls = append(p.shim.decls, cppNews...)
+
+	symbol := subclass + "_delete"
+	fn := &ast.FuncDecl{
+		Object:      ast.Object{Loc: class.Loc, Name: &ast.Ident{Name: symbol}},
+		MangledName: symbol,
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{{Name: "self"}}, Type: classPtr}}},
+			Ret:    &ast.BuiltinType{Kind: ast.Void},
+		},
+	}
+	if err := p.NewFuncDecl("Delete"+className+"Subclass", fn); err != nil {
+		return err
+	}
+	p.shim.add("extern \"C\" void %s(%s *self) {\n\tdelete static_cast<%s *>(self);\n}", symbol, cppName, subclass)
+	return nil
+}
+
+// VirtualMethods returns the virtual methods of the C++ class and the ones inherited from
+// its bases, which are found in classes, except the destructors and the overridden methods.
+func VirtualMethods(class *ast.TypeDecl, classes map[string]*ast.TypeDecl) []*ast.FuncDecl {
+	var list []*ast.FuncDecl
+	seen := make(map[string]bool)
+	var collect func(class *ast.TypeDecl)
+	collect = func(class *ast.TypeDecl) {
+		for _, method := range class.Type.Methods {
+			if !method.IsVirtual || method.IsDestructor || isVariadic(method.Type) {
+				continue
+			}
+			params, _ := cppParams(method.Type.Params)
+			sig := method.Name.Name + "(" + strings.Join(params, ", ") + ")"
+			if method.IsConst {
+				sig += " const"
+			}
+			if !seen[sig] {
+				seen[sig] = true
+				list = append(list, method)
+			}
+		}
+		if class.Type.Fields == nil {
+			return
+		}
+		for _, field := range class.Type.Fields.List {
+			if base, ok := classes[ast.QualifiedName(field.Type)]; ok && field.IsBase {
+				collect(base)
+			}
+		}
+	}
+	collect(class)
+	return list
+}
+
+// methodName returns the name fnName for a new method of the Go type typeName, which is
+// suffixed like the overloads named by llcppsymg if the type already has the method.
+func (p *Package) methodName(typeName, fnName string) string {
+	obj := p.p.Types.Scope().Lookup(typeName)
+	if obj == nil {
+		return fnName
+	}
+	named := getNamedType(obj.Type())
+	if named == nil {
+		return fnName
+	}
+	has := func(name string) bool {
+		for i := 0; i < named.NumMethods(); i++ {
+			if named.Method(i).Name() == name {
+				return true
+			}
+		}
+		return false
+	}
+	methodName := fnName
+	for i := 1; has(methodName); i++ {
+		methodName = fnName + "__" + strconv.Itoa(i)
+	}
+	return methodName
+}
+
+// virtualGoName returns the Go name of a virtual method in the symbol table, like Area of
+// (*Shape).Area, or a name resolving a collision.
+func virtualGoName(method *ast.FuncDecl, pnc nc.NodeConverter) (string, bool) {
+	if method.Loc == nil {
+		return "", false
+	}
+	goName, _, err := pnc.ConvDecl(method.Loc.File, method)
+	if err != nil {
+		return "", false
+	}
+	return goName[strings.LastIndex(goName, ".")+1:], true
+}
+
+// virtualName returns the Go name of a virtual method without a symbol, like a pure virtual
+// method, which is named by the converter, like Area of area and Equal of operator==.
+func virtualName(method *ast.FuncDecl, pnc nc.NodeConverter) (string, bool) {
+	if name.IsOperator(method.Name.Name) {
+		return name.OperatorName(method.Name.Name, len(paramList(method.Type))+1)
+	}
+	return pnc.ConvFuncName(method.Name.Name), true
+}
+
+func paramList(typ *ast.FuncType) []*ast.Field {
+	if typ.Params == nil {
+		return nil
+	}
+	return typ.Params.List
+}
+
+func isVariadic(typ *ast.FuncType) bool {
+	params := paramList(typ)
+	if len(params) == 0 {
+		return false
+	}
+	_, ok := params[len(params)-1].Type.(*ast.Variadic)
+	return ok
+}
+
+// isCopyOrMove reports whether a constructor of the class cppName is its copy or move
+// constructor, whose only parameter is a reference to the class.
+func isCopyOrMove(typ *ast.FuncType, cppName string) bool {
+	params := paramList(typ)
+	if len(params) != 1 {
+		return false
+	}
+	switch t := params[0].Type.(type) {
+	case *ast.LvalueRefType:
+		return ast.QualifiedName(t.X) == cppName
+	case *ast.RvalueRefType:
+		return ast.QualifiedName(t.X) == cppName
+	}
+	return false
+}
+
+// cppParams returns the C++ parameter declarations of a parameter list, named p0, p1, ...,
+// and the arguments to pass them to another function.
+func cppParams(params *ast.FieldList) (decls []string, args []string) {
+	if params == nil {
+		return
+	}
+	for i, param := range params.List {
+		arg := "p" + strconv.Itoa(i)
+		decls = append(decls, cppDecl(param.Type, arg))
+		if _, ok := param.Type.(*ast.RvalueRefType); ok {
+			// a named rvalue reference is an lvalue
+			arg = "static_cast<" + cppDecl(param.Type, "") + ">(" + arg + ")"
+		}
+		args = append(args, arg)
+	}
+	return
+}
+
+// cppReturn returns the statement returning the value of call, which is cast to the
+// pointer or reference type, because the const qualifiers of the nested types, like
+// the one of a const array, are not kept in the AST.
+func cppReturn(ret ast.Expr, call string) string {
+	switch ret.(type) {
+	case *ast.PointerType, *ast.LvalueRefType:
+		return "return (" + cppDecl(ret, "") + ")" + call
+	}
+	if Expr(ret).IsVoid() {
+		return call
+	}
+	return "return " + call
+}
+
+// cppDecl spells the declaration of name with the type in C++, like `int (*name)(int)`,
+// an empty name spells the type.
+func cppDecl(typ ast.Expr, name string) string {
+	switch t := typ.(type) {
+	case *ast.BuiltinType:
+		return joinCppDecl(cppBuiltinType(t), name)
+	case *ast.Ident, *ast.ScopingExpr, *ast.TagExpr:
+		return joinCppDecl(ast.QualifiedName(t), name)
+	case *ast.PointerType:
+		if _, ok := t.X.(*ast.FuncType); !ok && t.IsConst {
+			// the east const also qualifies a pointer, like `char *const *name`
+			return cppDecl(t.X, cppInnerDecl(t.X, "const *"+name))
+		}
+		return cppDecl(t.X, cppInnerDecl(t.X, "*"+name))
+	case *ast.LvalueRefType:
+		if _, ok := t.X.(*ast.ArrayType); !ok && t.IsConst {
+			// the east const also qualifies a pointer, like `int *const &name`
+			return cppDecl(t.X, cppInnerDecl(t.X, "const &"+name))
+		}
+		return cppDecl(t.X, cppInnerDecl(t.X, "&"+name))
+	case *ast.RvalueRefType:
+		return cppDecl(t.X, cppInnerDecl(t.X, "&&"+name))
+	case *ast.ArrayType:
+		var n string
+		if lit, ok := t.Len.(*ast.BasicLit); ok {
+			n = lit.Value
+		}
+		return cppDecl(t.Elt, name+"["+n+"]")
+	case *ast.FuncType:
+		var params []string
+		for _, param := range paramList(t) {
+			params = append(params, cppDecl(param.Type, ""))
+		}
+		return cppDecl(t.Ret, name+"("+strings.Join(params, ", ")+")")
+	case *ast.InstantiationType:
+		var args []string
+		for _, arg := range t.Args.List {
+			if lit, ok := arg.Type.(*ast.BasicLit); ok {
+				args = append(args, lit.Value)
+			} else {
+				args = append(args, cppDecl(arg.Type, ""))
+			}
+		}
+		return joinCppDecl(ast.QualifiedName(t.Template)+"<"+strings.Join(args, ", ")+">", name)
+	case *ast.Variadic:
+		return "..."
+	}
+	return name
+}
+
+func cppInnerDecl(x ast.Expr, decl string) string {
+	switch x.(type) {
+	case *ast.FuncType, *ast.ArrayType:
+		return "(" + decl + ")"
+	}
+	return decl
+}
+
+func joinCppDecl(typ, name string) string {
+	if name == "" {
+		return typ
+	}
+	return typ + " " + name
+}
+
+func cppBuiltinType(t *ast.BuiltinType) string {
+	var sign string
+	if t.Flags&ast.Unsigned != 0 {
+		sign = "unsigned "
+	}
+	switch t.Kind {
+	case ast.Void:
+		return "void"
+	case ast.Bool:
+		return "bool"
+	case ast.Char:
+		// the sign of the plain char is the one of the target
+		switch {
+		case t.Flags&ast.ExplicitSign == 0:
+			return "char"
+		case t.Flags&ast.Signed != 0:
+			return "signed char"
+		}
+		return "unsigned char"
+	case ast.Char16:
+		return "char16_t"
+	case ast.Char32:
+		return "char32_t"
+	case ast.WChar:
+		return "wchar_t"
+	case ast.Int:
+		switch {
+		case t.Flags&ast.Short != 0:
+			return sign + "short"
+		case t.Flags&ast.LongLong != 0:
+			return sign + "long long"
+		case t.Flags&ast.Long != 0:
+			return sign + "long"
+		}
+		return sign + "int"
+	case ast.Int128:
+		return sign + "__int128"
+	case ast.Float:
+		switch {
+		case t.Flags&ast.Long != 0:
+			return "long double"
+		case t.Flags&ast.Double != 0:
+			return "double"
+		}
+		return "float"
+	case ast.Float16:
+		return "_Float16"
+	case ast.Float128:
+		return "__float128"
+	case ast.Complex:
+		switch {
+		case t.Flags&ast.Long != 0:
+			return "_Complex long double"
+		case t.Flags&ast.Double != 0:
+			return "_Complex double"
+		}
+		return "_Complex float"
+	}
+	return "int"
+}
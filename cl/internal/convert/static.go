@@ -0,0 +1,94 @@
+package convert
+
+import (
+	"fmt"
+	goast "go/ast"
+	"go/token"
+	"log"
+	"strings"
+
+	"github.com/goplus/llcppg/ast"
+)
+
+// newStaticMembers declares the public static member variables of a C++ class, which are not
+// a part of its layout, named after the class and the member, like ShapeCount of Shape::count.
+// A member is a Go variable linked to its symbol, except a constexpr one, which may have no
+// symbol, so it's read by an accessor function linked to the C++ shim instead, like
+//
+//	//go:linkname ShapeCount _ZN5Shape5countE
+//	var ShapeCount c.Int
+//
+//	//go:linkname ShapeMax C.llcppg_Shape_max
+//	func ShapeMax() c.Int
+func (p *Package) newStaticMembers(className string, class *ast.TypeDecl) error {
+	if class.Type.Fields == nil {
+		return nil
+	}
+	for _, field := range class.Type.Fields.List {
+		if !field.IsStatic || field.Access != ast.Public || len(field.Names) == 0 {
+			continue
+		}
+		member := field.Names[0].Name
+		goName := className + p.cvt.pnc.ConvFieldName(member)
+		cname := class.QualifiedName() + "::" + member
+		var err error
+		if field.IsConstexpr {
+			err = p.newStaticAccessor(goName, cname, field)
+		} else {
+			err = p.newStaticVar(goName, cname, field)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newStaticVar declares a static member variable as a Go variable linked to its symbol.
+func (p *Package) newStaticVar(goName, cname string, field *ast.Field) error {
+	if field.MangledName == "" {
+		log.Printf("newStaticVar: %s has no symbol, ignored\n", cname)
+		return nil
+	}
+	name, _, exist, err := p.RegisterNode(Node{name: cname, kind: VarDecl}, goName, p.lookupPub)
+	if err != nil {
+		return fmt.Errorf("newStaticVar: %s fail: %w", cname, err)
+	}
+	if exist {
+		return nil
+	}
+	typ, err := p.ToType(field.Type)
+	if err != nil {
+		return fmt.Errorf("newStaticVar: fail to convert type of %s: %w", cname, err)
+	}
+	p.docs.names[cname] = name
+	symbol := field.MangledName
+	if version, ok := p.conf.SymVersions[symbol]; ok {
+		symbol += "@" + version
+	}
+	doc := p.newDocComment(name, field.Doc)
+	// the variables are linked to their symbols without the C. prefix of the functions
+	doc.List = append(doc.List, &goast.Comment{Text: "//go:linkname " + name + " " + symbol})
+	p.p.NewVarDefs(p.p.Types.Scope()).SetComments(doc).New(token.NoPos, typ, name)
+	return nil
+}
+
+// newStaticAccessor declares a constexpr static member variable as a Go function returning
+// its value, which is linked to a function of the C++ shim.
+func (p *Package) newStaticAccessor(goName, cname string, field *ast.Field) error {
+	if _, ok := field.Type.(*ast.ArrayType); ok {
+		log.Printf("newStaticAccessor: %s is an array, ignored\n", cname)
+		return nil
+	}
+	symbol := shimPrefix + strings.ReplaceAll(cname, "::", "_")
+	fn := &ast.FuncDecl{
+		Object:      ast.Object{Doc: field.Doc, Name: &ast.Ident{Name: symbol}},
+		MangledName: symbol,
+		Type:        &ast.FuncType{Params: &ast.FieldList{}, Ret: field.Type},
+	}
+	if err := p.NewFuncDecl(goName, fn); err != nil {
+		return err
+	}
+	p.shim.add("extern \"C\" %s {\n\t%s;\n}", cppDecl(field.Type, symbol+"()"), cppReturn(field.Type, cname))
+	return nil
+}
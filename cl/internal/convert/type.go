@@ -15,7 +15,7 @@ import (
 	"github.com/goplus/gogen"
 	"github.com/goplus/llcppg/ast"
 	"github.com/goplus/llcppg/cl/nc"
-	"github.com/goplus/llcppg/internal/name"
+	llcppg "github.com/goplus/llcppg/config"
 )
 
 type TypeContext int
@@ -49,17 +49,42 @@ type TypeConv struct {
 	ctx     TypeContext
 	pnc     nc.NodeConverter
 	lookup  func(name string, pnc nc.NodeConverter) (types.Type, error)
+	extType func(ext *ExtType) types.Type
+	nested  func(cname, goName string, recordType *ast.RecordType, pnc nc.NodeConverter) (types.Type, error)
+	bridge  func(expr ast.Expr) (types.Type, bool, error) // the standard C++ types bridged by the package
+	aligns  map[types.Type]int64                          // C alignments of the ext types greater than the Go ones
+	classes classMap                                      // layouts of the records, shared by the sub-packages
+
+	record *recordName // the named record whose fields are being converted
+	field  *recordName // the field whose anonymous record type is to be named
 }
 
-func NewConv(pkg *gogen.Package, types *types.Package, pnc nc.NodeConverter, lookup func(name string, pnc nc.NodeConverter) (types.Type, error)) *TypeConv {
+// classInfo is the layout of a record which matters to the C++ classes derived from it.
+type classInfo struct {
+	dynamic bool  // it has a vtable pointer, of its own or of its primary base
+	dsize   int64 // data size of a non-POD class, whose tail padding is reused by the derived classes
+}
+
+// classMap is the layouts of the records by their structs.
+type classMap map[types.Type]*classInfo
+
+// recordName is the C and Go name of a record, or of an anonymous record nested in a field.
+type recordName struct {
+	cname  string
+	goName string
+}
+
+func NewConv(pkg *gogen.Package, types *types.Package, sizes *llcppg.TypeSizes, pnc nc.NodeConverter, lookup func(name string, pnc nc.NodeConverter) (types.Type, error), extType func(ext *ExtType) types.Type) *TypeConv {
 	clib := pkg.Import("github.com/goplus/lib/c")
 	math := pkg.Import("math")
-	typeMap := NewBuiltinTypeMapWithPkgRefS(clib, math, pkg.Unsafe())
+	typeMap := NewBuiltinTypeMapWithPkgRefS(sizes, clib, math, pkg.Unsafe())
 	typeConv := &TypeConv{
 		typeMap: typeMap,
 		types:   types,
 		pnc:     pnc,
 		lookup:  lookup,
+		extType: extType,
+		classes: make(classMap),
 	}
 	return typeConv
 }
@@ -68,25 +93,60 @@ func NewConv(pkg *gogen.Package, types *types.Package, pnc nc.NodeConverter, loo
 func (p *TypeConv) ToType(expr ast.Expr) (types.Type, error) {
 	switch t := expr.(type) {
 	case *ast.BuiltinType:
+		if ext, ok := p.typeMap.FindExtType(*t); ok && p.extType != nil {
+			typ := p.extType(ext)
+			if ext.Align > std.Alignof(typ) {
+				if p.aligns == nil {
+					p.aligns = make(map[types.Type]int64)
+				}
+				p.aligns[typ] = ext.Align
+			}
+			return typ, nil
+		}
 		typ, err := p.typeMap.FindBuiltinType(*t)
 		return typ, err
 	case *ast.PointerType:
 		return p.handlePointerType(t)
+	case *ast.LvalueRefType:
+		// a C++ reference is passed as a pointer, which must not be nil
+		return p.handlePointerType(&ast.PointerType{X: t.X})
+	case *ast.RvalueRefType:
+		return p.handlePointerType(&ast.PointerType{X: t.X})
 	case *ast.ArrayType:
 		return p.handleArrayType(t)
 	case *ast.FuncType:
 		return p.ToSignature(t, nil)
 	case *ast.Ident, *ast.ScopingExpr, *ast.TagExpr:
+		if typ, ok, err := p.bridgeType(expr); ok {
+			return typ, err
+		}
 		return p.handleIdentRefer(expr)
+	case *ast.InstantiationType:
+		if typ, ok, err := p.bridgeType(expr); ok {
+			return typ, err
+		}
+		return nil, fmt.Errorf("%w: unsupported class template specialization of %s", ErrTypeConv, ast.QualifiedName(t.Template))
 	case *ast.Variadic:
 		return types.NewSlice(gogen.TyEmptyInterface), nil
 	case *ast.RecordType:
+		if p.field != nil && p.nested != nil {
+			field := p.field
+			p.field = nil
+			return p.nested(field.cname, field.goName, t, p.pnc)
+		}
 		return p.RecordTypeToStruct(t)
 	default:
 		return nil, fmt.Errorf("%w: unsupported type %T", ErrTypeConv, expr)
 	}
 }
 
+func (p *TypeConv) bridgeType(expr ast.Expr) (types.Type, bool, error) {
+	if p.bridge == nil {
+		return nil, false, nil
+	}
+	return p.bridge(expr)
+}
+
 func (p *TypeConv) handleArrayType(t *ast.ArrayType) (types.Type, error) {
 	elemType, err := p.ToType(t.Elt)
 	if err != nil {
@@ -157,23 +217,25 @@ func (p *TypeConv) handleIdentRefer(t ast.Expr) (types.Type, error) {
 		}
 		return typ, nil
 	case *ast.ScopingExpr:
-		// todo(zzy)
+		// the declarations in a namespace are registered with their qualified names
+		return lookup(ast.QualifiedName(t))
 	case *ast.TagExpr:
-		// todo(zzy):scoping expr
 		// FIXME(MeteorsLiu): when we support more tag type in the future,
 		//  we need to split this logic into a function for readability
 		switch nameType := t.Name.(type) {
 		case *ast.Ident:
 			return lookup(nameType.Name)
+		case *ast.ScopingExpr:
+			return lookup(ast.QualifiedName(nameType))
 		}
 	}
 	return nil, fmt.Errorf("unsupported refer type %T", t)
 }
 
 func (p *TypeConv) ToSignature(funcType *ast.FuncType, recv *types.Var) (*types.Signature, error) {
-	ctx := p.ctx
-	p.ctx = Param
-	defer func() { p.ctx = ctx }()
+	ctx, field := p.ctx, p.field
+	p.ctx, p.field = Param, nil
+	defer func() { p.ctx, p.field = ctx, field }()
 	var params *types.Tuple
 	var variadic bool
 	var err error
@@ -267,6 +329,16 @@ func (p *TypeConv) fieldToVar(field *ast.Field, hasNamedParam bool, argIndex int
 		return nil, fmt.Errorf("%w: unexpected nil field", ErrTypeConv)
 	}
 
+	if p.ctx == Record {
+		// static members are not a part of the layout
+		if field.IsStatic {
+			return nil, nil
+		}
+		if field.IsBase {
+			return p.baseToField(field)
+		}
+	}
+
 	//field without name
 	var name string
 	if len(field.Names) > 0 {
@@ -275,13 +347,20 @@ func (p *TypeConv) fieldToVar(field *ast.Field, hasNamedParam bool, argIndex int
 		name = fmt.Sprintf("__llgo_arg_%d", argIndex)
 	}
 
+	if p.ctx == Record && p.record != nil && name != "" {
+		// an anonymous record of the field is named after the parent and the field
+		goName := p.pnc.ConvNestedName(p.record.cname+"."+name, p.record.goName, p.pnc.ConvFieldName(name))
+		p.field = &recordName{cname: p.record.cname + "." + name, goName: goName}
+		defer func() { p.field = nil }()
+	}
+
 	typ, err := p.ToType(field.Type)
 	if err != nil {
 		return nil, err
 	}
 
 	if p.ctx == Record {
-		name = getFieldName(name)
+		name = p.pnc.ConvFieldName(name)
 	} else {
 		_, isVariadic := field.Type.(*ast.Variadic)
 		if isVariadic && hasNamedParam {
@@ -293,6 +372,19 @@ func (p *TypeConv) fieldToVar(field *ast.Field, hasNamedParam bool, argIndex int
 	return types.NewVar(token.NoPos, p.types, name, typ), nil
 }
 
+// baseToField converts a base class of a C++ class to an embedded field.
+func (p *TypeConv) baseToField(field *ast.Field) (*types.Var, error) {
+	typ, err := p.ToType(field.Type)
+	if err != nil {
+		return nil, err
+	}
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("%w: unexpected base class type %v", ErrTypeConv, typ)
+	}
+	return types.NewField(token.NoPos, p.types, named.Obj().Name(), typ, true), nil
+}
+
 func (p *TypeConv) RecordTypeToStruct(recordType *ast.RecordType) (types.Type, error) {
 	ctx := p.ctx
 	p.ctx = Record
@@ -302,9 +394,7 @@ func (p *TypeConv) RecordTypeToStruct(recordType *ast.RecordType) (types.Type, e
 	if err != nil {
 		return nil, err
 	}
-	if recordType.Tag != ast.Union {
-		fields = flds
-	} else {
+	if recordType.Tag == ast.Union {
 		var maxFld *types.Var
 		maxSize := int64(0)
 		for i := len(flds) - 1; i >= 0; i-- {
@@ -317,27 +407,210 @@ func (p *TypeConv) RecordTypeToStruct(recordType *ast.RecordType) (types.Type, e
 			}
 		}
 		if maxFld != nil {
-			fields = []*types.Var{maxFld}
+			align := int64(1)
+			for _, fld := range flds {
+				align = max(align, p.cAlignof(fld.Type()))
+			}
+			fields = p.alignFields([]*types.Var{maxFld}, align)
+		}
+		return types.NewStruct(fields, nil), nil
+	}
+	flds, dynamic := p.classFields(recordType, flds)
+	dsize, align := p.cLayout(flds)
+	if base := p.reusedBase(flds); base != nil {
+		// Go can't lay out the fields in the tail padding of a base, the class is left opaque
+		if p.record != nil {
+			log.Printf("RecordTypeToStruct: the fields of %s reuse the tail padding of its base %s, converted to an opaque struct\n", p.record.cname, base.Name())
+		}
+		elem := types.Typ[types.Uint8]
+		for _, t := range []types.BasicKind{types.Uint64, types.Uint32, types.Uint16} {
+			if align >= Sizeof(types.Typ[t]) {
+				elem = types.Typ[t]
+				break
+			}
 		}
+		fields = []*types.Var{
+			types.NewVar(token.NoPos, p.types, "Unused", types.NewArray(elem, alignUp(dsize, align)/Sizeof(elem))),
+		}
+	} else {
+		fields = p.alignFields(flds, 1)
+	}
+	st := types.NewStruct(fields, nil)
+	info := &classInfo{dynamic: dynamic}
+	if !p.isPOD(recordType, flds) {
+		info.dsize = dsize
 	}
-	return types.NewStruct(fields, nil), nil
+	p.classes[st] = info
+	return st, nil
+}
+
+// classFields lays out the bases and the vtable pointer of a C++ class like the Itanium C++ ABI.
+// The primary base, which is the first dynamic base, comes first and shares its vtable pointer
+// with the class. A class with virtual methods but without it has its own vtable pointer first.
+func (p *TypeConv) classFields(recordType *ast.RecordType, flds []*types.Var) (fields []*types.Var, dynamic bool) {
+	for i, fld := range flds {
+		if fld.Embedded() && p.isDynamic(fld.Type()) {
+			if i > 0 {
+				flds = append(append([]*types.Var{fld}, flds[:i]...), flds[i+1:]...)
+			}
+			return flds, true
+		}
+	}
+	for _, method := range recordType.Methods {
+		if method.IsVirtual {
+			vptr := types.NewField(token.NoPos, p.types, "_", p.typeMap.CType("Pointer"), false)
+			return append([]*types.Var{vptr}, flds...), true
+		}
+	}
+	return flds, false
+}
+
+// classOf returns the layout of the record of a Go type, which is nil for the other types
+// and the records of the dependent packages.
+func (p *TypeConv) classOf(typ types.Type) *classInfo {
+	return p.classes[typ.Underlying()]
+}
+
+// isDynamic reports whether the record of a Go type has a vtable pointer. The one of a
+// dependent package has it if its first field is the vtable pointer, or its primary base.
+func (p *TypeConv) isDynamic(typ types.Type) bool {
+	if info := p.classOf(typ); info != nil {
+		return info.dynamic
+	}
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok || st.NumFields() == 0 {
+		return false
+	}
+	fld := st.Field(0)
+	if fld.Embedded() {
+		return p.isDynamic(fld.Type())
+	}
+	return fld.Name() == "_" && types.Identical(fld.Type(), p.typeMap.CType("Pointer"))
+}
+
+// isPOD reports whether a C++ class is a POD for the purpose of layout, whose tail padding
+// is not reused by the classes derived from it. It has no constructors, destructor, virtual
+// methods, bases, non-public fields, nor fields of the non-POD classes.
+func (p *TypeConv) isPOD(recordType *ast.RecordType, flds []*types.Var) bool {
+	for _, method := range recordType.Methods {
+		if method.IsConstructor || method.IsDestructor || method.IsVirtual {
+			return false
+		}
+	}
+	if recordType.Fields != nil {
+		for _, field := range recordType.Fields.List {
+			if field.IsBase || !field.IsStatic && (field.Access == ast.Protected || field.Access == ast.Private) {
+				return false
+			}
+		}
+	}
+	for _, fld := range flds {
+		if info := p.classOf(fld.Type()); info != nil && info.dsize != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// cLayout returns the data size and the alignment of a C++ class of the fields, whose fields
+// following a non-POD base are laid out in its tail padding like the Itanium C++ ABI.
+func (p *TypeConv) cLayout(flds []*types.Var) (dsize, align int64) {
+	align = 1
+	for _, fld := range flds {
+		typ := fld.Type()
+		falign := p.cAlignof(typ)
+		align = max(align, falign)
+		offset := alignUp(dsize, falign)
+		dsize = offset + Sizeof(typ)
+		if info := p.classOf(typ); fld.Embedded() && info != nil && info.dsize != 0 {
+			dsize = offset + info.dsize
+		}
+	}
+	return
+}
+
+// reusedBase returns the non-POD base of a C++ class whose tail padding is reused by the
+// following field, which is nil if there is none.
+func (p *TypeConv) reusedBase(flds []*types.Var) *types.Var {
+	for i, fld := range flds {
+		info := p.classOf(fld.Type())
+		if !fld.Embedded() || info == nil || info.dsize == 0 || i+1 == len(flds) {
+			continue
+		}
+		if alignUp(info.dsize, p.cAlignof(flds[i+1].Type())) < Sizeof(fld.Type()) {
+			return fld
+		}
+	}
+	return nil
+}
+
+// alignFields pads the fields whose C alignment is greater than the Go one, like a
+// __int128 which is 16-byte aligned, so the offsets are the ones of C. The size is
+// padded to the C alignment of the record, which is at least align.
+func (p *TypeConv) alignFields(flds []*types.Var, align int64) []*types.Var {
+	var fields []*types.Var
+	var end int64 // end of the previous field, which is the same in C and Go
+	pad := func(n int64) {
+		fields = append(fields, types.NewField(token.NoPos, p.types, "_", types.NewArray(types.Typ[types.Byte], n), false))
+	}
+	for _, fld := range flds {
+		typ := fld.Type()
+		calign := p.cAlignof(typ)
+		align = max(align, calign)
+		if offset := alignUp(end, calign); offset > alignUp(end, std.Alignof(typ)) {
+			pad(offset - end)
+			end = offset
+		} else {
+			end = alignUp(end, std.Alignof(typ))
+		}
+		fields = append(fields, fld)
+		end += Sizeof(typ)
+	}
+	if size := alignUp(end, align); len(flds) > 0 && size > std.Sizeof(types.NewStruct(fields, nil)) {
+		pad(size - end)
+	}
+	return fields
+}
+
+// cAlignof returns the alignment of a Go type in C, which is greater than the Go one
+// for the ext types like __int128 and the records containing them.
+func (p *TypeConv) cAlignof(typ types.Type) int64 {
+	if align, ok := p.aligns[typ]; ok {
+		return align
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Struct:
+		align := int64(1)
+		for i := 0; i < t.NumFields(); i++ {
+			align = max(align, p.cAlignof(t.Field(i).Type()))
+		}
+		return align
+	case *types.Array:
+		return p.cAlignof(t.Elem())
+	}
+	return std.Alignof(typ)
+}
+
+func alignUp(n, align int64) int64 {
+	return (n + align - 1) / align * align
+}
+
+// NamedRecordTypeToStruct converts the record of a named type, its anonymous
+// nested records are declared as named types instead of inline struct types.
+func (p *TypeConv) NamedRecordTypeToStruct(cname, goName string, recordType *ast.RecordType) (types.Type, error) {
+	record := p.record
+	p.record = &recordName{cname: cname, goName: goName}
+	defer func() { p.record = record }()
+	return p.RecordTypeToStruct(recordType)
 }
 
 func (p *TypeConv) ToDefaultEnumType() types.Type {
 	return p.typeMap.CType("Int")
 }
 
-// todo(zzy): Current forward declaration detection is imprecise
-// It incorrectly treats both empty struct `struct a {}` and forward declaration `struct a` as the same
-// by only checking if Fields.List is empty
-// Should use recordType == nil to identify forward declarations, which requires llcppsigfetch support
+// A forward declaration `struct a;` has no definition, unlike an empty struct `struct a {}`
 func (p *TypeConv) inComplete(recordType *ast.RecordType) bool {
-	return recordType.Fields == nil
-}
-
-// The field name should be public if it's a record field
-func getFieldName(n string) string {
-	return name.PubName(n)
+	return !recordType.HasDef
 }
 
 func avoidKeyword(name string) string {
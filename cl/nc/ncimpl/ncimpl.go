@@ -10,6 +10,14 @@ import (
 	"github.com/goplus/llcppg/internal/name"
 )
 
+// DefaultNestedTypeName is the name template of anonymous nested records,
+// {parent} and {field} are the Go names of the parent type and the field.
+const DefaultNestedTypeName = "{parent}_{field}"
+
+// DefaultEnumItemName is the name template of the items of the scoped enums,
+// {enum} and {item} are the Go name of the enum and the name of the item.
+const DefaultEnumItemName = "{enum}{item}"
+
 type ThirdTypeLoc struct {
 	locMap map[string]string // type name from third package -> define location
 }
@@ -39,9 +47,20 @@ type Converter struct {
 	locMap ThirdTypeLoc // record third type's location
 
 	// CfgFile   string // llcppg.cfg
-	Pubs           map[string]string
-	TrimPrefixes   []string
-	KeepUnderScore bool
+	Pubs            map[string]string
+	TrimPrefixes    []string
+	KeepUnderScore  bool
+	NestedTypeName  string // name template of anonymous nested records, default is {parent}_{field}
+	EnumItemName    string // name template of the items of the scoped enums, default is {enum}{item}
+	PrefixEnumItems bool   // name the items of the unscoped named enums with EnumItemName too
+
+	NamespaceMode   string   // how the C++ namespaces map to Go, default is llconfig.NamespaceFlatten
+	StripNamespaces []string // namespaces left out of the Go names
+
+	Rules  *llconfig.NameRules // naming rules of llcppg.cfg, tried after the exact entries of Pubs
+	Naming *name.Policy        // naming policy of llcppg.cfg, nil for the default one
+
+	Collisions llconfig.Collisions // the C names renamed or ignored to resolve the name collisions
 }
 
 func (p *Converter) convFile(file string, obj *ast.Object) (goFile string, ok bool) {
@@ -77,13 +96,27 @@ func (p *Converter) ConvDecl(file string, decl ast.Decl) (goName, goFile string,
 			err = nc.ErrSkip
 			return
 		}
+		// the functions are named by the symbol table, which llcppsymg renames by the collisions
+		// before suffixing the overloads, the ignored ones are left out of the older tables too
+		if _, ignored, _ := p.Collisions.Resolve(obj.QualifiedName()); ignored {
+			goName, err = "", nc.ErrSkip
+			return
+		}
 	case *ast.EnumTypeDecl:
 		// support anonymous enum with empty name
 		if obj.Name != nil {
-			goName = p.declName(obj.Name.Name)
+			if p.ignored(llconfig.RuleType, obj.QualifiedName()) {
+				err = nc.ErrSkip
+				return
+			}
+			goName = p.declName(obj.QualifiedName())
 		}
 	default:
-		goName = p.declName(obj.Name.Name)
+		if p.ignored(llconfig.RuleType, obj.QualifiedName()) {
+			err = nc.ErrSkip
+			return
+		}
+		goName = p.declName(obj.QualifiedName())
 	}
 	return
 }
@@ -94,12 +127,38 @@ func (p *Converter) ConvMacro(file string, macro *ast.Macro) (goName, goFile str
 		err = nc.ErrSkip
 		return
 	}
-	goName = p.constName(macro.Name)
+	if p.ignored(llconfig.RuleMacro, macro.Name) {
+		err = nc.ErrSkip
+		return
+	}
+	goName = p.constName(llconfig.RuleMacro, macro.Name)
 	return
 }
 
 func (p *Converter) ConvEnumItem(decl *ast.EnumTypeDecl, item *ast.EnumItem) (goName string, err error) {
-	goName = p.constName(item.Name.Name)
+	// the items of an unscoped enum are in the scope of the enum declaration
+	cname := decl.ItemName(item)
+	scoped := decl.Type != nil && decl.Type.IsScoped
+	if p.ignored(llconfig.RuleEnumItem, cname) {
+		err = nc.ErrSkip
+		return
+	}
+	if decl.Name == nil || !scoped && !p.PrefixEnumItems {
+		goName = p.constName(llconfig.RuleEnumItem, cname)
+		return
+	}
+	if definedName, ok := p.definedName(llconfig.RuleEnumItem, cname); ok {
+		return definedName, nil
+	}
+	tmpl := p.EnumItemName
+	if tmpl == "" {
+		tmpl = DefaultEnumItemName
+	}
+	itemName := name.RemovePrefixedName(item.Name.Name, p.trimPrefixes())
+	if itemName == "" {
+		itemName = item.Name.Name
+	}
+	goName = strings.NewReplacer("{enum}", p.declName(decl.QualifiedName()), "{item}", p.Naming.ConstName(itemName)).Replace(tmpl)
 	return
 }
 
@@ -107,6 +166,25 @@ func (p *Converter) ConvTagExpr(cname string) string {
 	return p.declName(cname)
 }
 
+func (p *Converter) ConvNestedName(cname, parentName, fieldName string) string {
+	if definedName, ok := p.definedName(llconfig.RuleType, cname); ok {
+		return definedName
+	}
+	tmpl := p.NestedTypeName
+	if tmpl == "" {
+		tmpl = DefaultNestedTypeName
+	}
+	return strings.NewReplacer("{parent}", parentName, "{field}", fieldName).Replace(tmpl)
+}
+
+func (p *Converter) ConvFieldName(cname string) string {
+	return p.Naming.FieldName(cname)
+}
+
+func (p *Converter) ConvFuncName(cname string) string {
+	return p.Naming.FuncName(cname)
+}
+
 func (p *Converter) Lookup(name string) (locFile string, ok bool) {
 	return p.locMap.Lookup(name)
 }
@@ -115,44 +193,79 @@ func (p *Converter) IsPublic(cname string) bool {
 	return p.KeepUnderScore || rune(cname[0]) != '_'
 }
 
-// which is define in llcppg.cfg/typeMap
-func (p *Converter) definedName(name string) (string, bool) {
-	definedName, ok := p.Pubs[name]
+// which is define in llcppg.cfg/typeMap, renamed by llcppg.cfg/collisions, or named by the first rule of the kind matching it
+func (p *Converter) definedName(kind, cname string) (string, bool) {
+	definedName, ok := p.Pubs[cname]
 	if ok {
 		if definedName == "" {
-			return name, true
+			_, definedName = name.SplitScope(cname)
 		}
 		return definedName, true
 	}
-	return name, false
+	if goName, ignored, ok := p.Collisions.Resolve(cname); ok && !ignored {
+		return goName, true
+	}
+	if rule, goName, ok := p.Rules.Match(kind, cname); ok && rule.Action != llconfig.RuleIgnore {
+		return goName, true
+	}
+	return cname, false
+}
+
+// ignored reports whether a declaration is left out by llcppg.cfg/collisions, or by the first
+// rule of the kind matching it, the ones in llcppg.cfg/typeMap are never by the rules.
+func (p *Converter) ignored(kind, cname string) bool {
+	if _, ignored, ok := p.Collisions.Resolve(cname); ok {
+		return ignored
+	}
+	if _, ok := p.Pubs[cname]; ok {
+		return false
+	}
+	rule, _, ok := p.Rules.Match(kind, cname)
+	return ok && rule.Action == llconfig.RuleIgnore
 }
 
 type NameMethod func(name string) string
 
 // transformName handles identifier name conversion following these rules:
-// 1. First checks if the name exists in predefined mapping (in typeMap of llcppg.cfg)
+// 1. First checks if the name exists in predefined mapping (in typeMap of llcppg.cfg) or is named by the rules of the kind
 // 2. If not in predefined mapping, applies the transform function
 // 3. Before applying the transform function, removes specified prefixes (obtained via trimPrefixes)
+// 4. The namespaces of a qualified C++ name like ns::Foo become a prefix of the Go name, like NsFoo
+// 5. A class template instantiation like std::vector<int> is named after the template and its arguments, like VectorInt
 //
 // Parameters:
+//   - kind: Kind of the declaration matched by the rules, like llconfig.RuleType
 //   - name: Original C/C++ identifier name
-//   - transform: Name transformation function (like names.PubName or names.ExportName)
+//   - transform: Name transformation function (like TypeName or ConstName of the naming policy)
 //
 // Returns:
 //   - Transformed identifier name
-func (p *Converter) transformName(cname string, transform NameMethod) string {
-	if definedName, ok := p.definedName(cname); ok {
+func (p *Converter) transformName(kind, cname string, transform NameMethod) string {
+	if definedName, ok := p.definedName(kind, cname); ok {
 		return definedName
 	}
-	return transform(name.RemovePrefixedName(cname, p.trimPrefixes()))
+	if name.IsTemplateID(cname) {
+		return name.TemplateName(cname)
+	}
+	scopes, cname := name.SplitScope(cname)
+	return p.namespacePrefix(scopes) + transform(name.RemovePrefixedName(cname, p.trimPrefixes()))
+}
+
+// namespacePrefix returns the Go name prefix of the C++ namespaces, which is empty
+// in the package namespace mode, where the namespaces become Go packages.
+func (p *Converter) namespacePrefix(scopes []string) string {
+	if p.NamespaceMode == llconfig.NamespacePackage {
+		return ""
+	}
+	return p.Naming.NamespacePrefix(name.Namespaces(scopes, p.StripNamespaces))
 }
 
 func (p *Converter) declName(cname string) string {
-	return p.transformName(cname, name.PubName)
+	return p.transformName(llconfig.RuleType, cname, p.Naming.TypeName)
 }
 
-func (p *Converter) constName(cname string) string {
-	return p.transformName(cname, name.ExportName)
+func (p *Converter) constName(kind, cname string) string {
+	return p.transformName(kind, cname, p.Naming.ConstName)
 }
 
 func (p *Converter) trimPrefixes() []string {
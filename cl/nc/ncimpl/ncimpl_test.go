@@ -7,6 +7,7 @@ import (
 	"github.com/goplus/llcppg/ast"
 	"github.com/goplus/llcppg/cl/nc"
 	llconfig "github.com/goplus/llcppg/config"
+	"github.com/goplus/llcppg/internal/name"
 )
 
 func TestHeaderFile(t *testing.T) {
@@ -100,7 +101,7 @@ func TestConverterNameTransformation(t *testing.T) {
 		PkgName:        "testpkg",
 		TrimPrefixes:   []string{"prefix_"},
 		KeepUnderScore: false,
-		Pubs:           map[string]string{"predefined": "CustomName", "KEEP": ""},
+		Pubs:           map[string]string{"predefined": "CustomName", "KEEP": "", "ns::KEEP": "", "std::vector<float>": "FloatVec"},
 	}
 
 	testCases := []struct {
@@ -120,6 +121,14 @@ func TestConverterNameTransformation(t *testing.T) {
 		{"With underscore constName", "_hidden", "X_hidden", "constName"},
 		{"Keep Origin Name", "KEEP", "KEEP", "constName"},
 		{"Predefined constName", "predefined", "CustomName", "constName"},
+
+		{"Namespace declName", "ns::prefix_name", "NsName", "declName"},
+		{"Nested namespace declName", "a::b::simple_name", "ABSimpleName", "declName"},
+		{"Namespace constName", "ns::SIMPLE_NAME", "NsSIMPLE_NAME", "constName"},
+		{"Namespace Keep Origin Name", "ns::KEEP", "KEEP", "declName"},
+
+		{"Instantiation declName", "std::vector<int>", "VectorInt", "declName"},
+		{"Predefined instantiation declName", "std::vector<float>", "FloatVec", "declName"},
 	}
 
 	for _, tc := range testCases {
@@ -128,7 +137,7 @@ func TestConverterNameTransformation(t *testing.T) {
 			if tc.method == "declName" {
 				result = converter.declName(tc.input)
 			} else {
-				result = converter.constName(tc.input)
+				result = converter.constName(llconfig.RuleMacro, tc.input)
 			}
 
 			if result != tc.expected {
@@ -420,6 +429,64 @@ func TestConvEnumItem(t *testing.T) {
 	}
 }
 
+func TestConvScopedEnumItem(t *testing.T) {
+	enum := func(scoped bool) *ast.EnumTypeDecl {
+		return &ast.EnumTypeDecl{
+			Object: ast.Object{
+				Name:   &ast.Ident{Name: "Color"},
+				Parent: &ast.Ident{Name: "gfx"},
+				Loc:    &ast.Location{File: interFile},
+			},
+			Type: &ast.EnumType{IsScoped: scoped},
+		}
+	}
+	testCases := []struct {
+		name     string
+		cvt      *Converter
+		scoped   bool
+		item     string
+		expected string
+	}{
+		{"scoped", &Converter{}, true, "None", "GfxColorNone"},
+		{"template", &Converter{EnumItemName: "{enum}_{item}"}, true, "None", "GfxColor_None"},
+		{"typeMap", &Converter{Pubs: map[string]string{"gfx::Color::None": "NoColor"}}, true, "None", "NoColor"},
+		{"stripNamespaces", &Converter{StripNamespaces: []string{"gfx"}}, true, "red", "ColorRed"},
+		{"unscoped", &Converter{}, false, "None", "GfxNone"},
+		{"prefixEnumItems", &Converter{PrefixEnumItems: true, TrimPrefixes: []string{"COLOR_"}}, false, "COLOR_RED", "GfxColorRED"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			goName, err := tc.cvt.ConvEnumItem(enum(tc.scoped), &ast.EnumItem{Name: &ast.Ident{Name: tc.item}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if goName != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, goName)
+			}
+		})
+	}
+}
+
+func TestConvNestedName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cvt      *Converter
+		expected string
+	}{
+		{"default", &Converter{}, "Outer_U"},
+		{"template", &Converter{NestedTypeName: "{parent}{field}T"}, "OuterUT"},
+		{"typeMap", &Converter{Pubs: map[string]string{"outer.u": "OuterValue"}}, "OuterValue"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			goName := tc.cvt.ConvNestedName("outer.u", "Outer", "U")
+			if goName != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, goName)
+			}
+		})
+	}
+}
+
 func TestConvTagExpr(t *testing.T) {
 	cvt := &Converter{
 		PkgName: "testpkg",
@@ -431,3 +498,200 @@ func TestConvTagExpr(t *testing.T) {
 		t.Errorf("Expected TypeName, got %s", goName)
 	}
 }
+
+func TestConvNaming(t *testing.T) {
+	cvt := &Converter{
+		PkgName:         "testpkg",
+		FileMap:         fileMap,
+		ConvSym:         mockSymConv,
+		TrimPrefixes:    []string{"xml"},
+		PrefixEnumItems: true,
+		Pubs:            map[string]string{"xmlUrlKept": "UrlKept"},
+		Naming: name.NewPolicy(&llconfig.Naming{
+			Initialisms: []string{"HTTP", "URL", "ID", "JSON"},
+			Consts:      llconfig.NamingTitle,
+		}),
+	}
+	typeDecl := func(cname string) *ast.TypeDecl {
+		return &ast.TypeDecl{Object: ast.Object{Name: &ast.Ident{Name: cname}, Loc: &ast.Location{File: interFile}}}
+	}
+	testCases := []struct {
+		name     string
+		conv     func() (string, error)
+		expected string
+	}{
+		{"type", func() (string, error) {
+			goName, _, err := cvt.ConvDecl(interFile, typeDecl("xmlHttpClient"))
+			return goName, err
+		}, "HTTPClient"},
+		{"typeMap first", func() (string, error) {
+			goName, _, err := cvt.ConvDecl(interFile, typeDecl("xmlUrlKept"))
+			return goName, err
+		}, "UrlKept"},
+		{"macro", func() (string, error) {
+			goName, _, err := cvt.ConvMacro(interFile, &ast.Macro{Name: "XML_PARSE_JSON_ID"})
+			return goName, err
+		}, "XmlParseJSONID"},
+		{"enum item", func() (string, error) {
+			return cvt.ConvEnumItem(&ast.EnumTypeDecl{Object: ast.Object{Name: &ast.Ident{Name: "xmlUrlKind"}, Loc: &ast.Location{File: interFile}}},
+				&ast.EnumItem{Name: &ast.Ident{Name: "URL_HTTP"}})
+		}, "URLKindURLHTTP"},
+		{"field", func() (string, error) {
+			return cvt.ConvFieldName("base_url"), nil
+		}, "BaseURL"},
+		{"func", func() (string, error) {
+			return cvt.ConvFuncName("get_url"), nil
+		}, "GetURL"},
+		{"namespace", func() (string, error) {
+			return cvt.ConvTagExpr("json::id_map"), nil
+		}, "JSONIDMap"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			goName, err := tc.conv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if goName != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, goName)
+			}
+		})
+	}
+}
+
+func TestConvCollisions(t *testing.T) {
+	rules, err := llconfig.CompileRules([]llconfig.NameRule{{Match: "foo*", Name: "Rule$1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cvt := &Converter{
+		PkgName: "testpkg",
+		FileMap: fileMap,
+		ConvSym: func(name *ast.Object, mangleName string) (string, error) {
+			return "Sym" + mangleName, nil
+		},
+		Rules: rules,
+		Collisions: llconfig.Collisions{
+			"Foo": {Prefer: "foo", Rename: map[string]string{"FOO": "FooValue", "fooFn": "FooFunc"}, Ignore: []string{"Foo", "foo_fn"}},
+		},
+	}
+	typeDecl := func(cname string) *ast.TypeDecl {
+		return &ast.TypeDecl{Object: ast.Object{Name: &ast.Ident{Name: cname}, Loc: &ast.Location{File: interFile}}}
+	}
+	funcDecl := func(cname string) *ast.FuncDecl {
+		return &ast.FuncDecl{Object: ast.Object{Name: &ast.Ident{Name: cname}, Loc: &ast.Location{File: interFile}}, MangledName: cname}
+	}
+	testCases := []struct {
+		name      string
+		conv      func() (string, error)
+		expected  string
+		expectErr error
+	}{
+		{"macro rename", func() (string, error) {
+			goName, _, err := cvt.ConvMacro(interFile, &ast.Macro{Name: "FOO"})
+			return goName, err
+		}, "FooValue", nil},
+		{"type ignore", func() (string, error) {
+			goName, _, err := cvt.ConvDecl(interFile, typeDecl("Foo"))
+			return goName, err
+		}, "", nc.ErrSkip},
+		{"preferred by rules", func() (string, error) {
+			goName, _, err := cvt.ConvDecl(interFile, typeDecl("foo"))
+			return goName, err
+		}, "Rule", nil},
+		{"func renamed by the symbol table", func() (string, error) {
+			goName, _, err := cvt.ConvDecl(interFile, funcDecl("fooFn"))
+			return goName, err
+		}, "SymfooFn", nil},
+		{"func ignore", func() (string, error) {
+			goName, _, err := cvt.ConvDecl(interFile, funcDecl("foo_fn"))
+			return goName, err
+		}, "", nc.ErrSkip},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			goName, err := tc.conv()
+			if !errors.Is(err, tc.expectErr) {
+				t.Fatalf("Expected error %v, got %v", tc.expectErr, err)
+			}
+			if goName != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, goName)
+			}
+		})
+	}
+}
+
+func TestConvRules(t *testing.T) {
+	rules, err := llconfig.CompileRules([]llconfig.NameRule{
+		{Match: "xml_internal_*", Action: llconfig.RuleIgnore},
+		{Regexp: `XML_(\w+)_DEBUG`, Kinds: []string{llconfig.RuleMacro}, Name: "Debug$1"},
+		{Match: "xml*Ptr", Kinds: []string{llconfig.RuleType}, Name: "${1}Ref"},
+		{Match: "COLOR_*", Kinds: []string{llconfig.RuleEnumItem}, Name: "Color$1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cvt := &Converter{
+		PkgName: "testpkg",
+		FileMap: fileMap,
+		ConvSym: mockSymConv,
+		Pubs:    map[string]string{"xmlKeepPtr": "KeepPtr", "xml_internal_kept": "Kept"},
+		Rules:   rules,
+	}
+	typeDecl := func(cname string) *ast.TypeDecl {
+		return &ast.TypeDecl{Object: ast.Object{Name: &ast.Ident{Name: cname}, Loc: &ast.Location{File: interFile}}}
+	}
+	testCases := []struct {
+		name      string
+		conv      func() (string, error)
+		expected  string
+		expectErr error
+	}{
+		{"type rename", func() (string, error) {
+			goName, _, err := cvt.ConvDecl(interFile, typeDecl("xmlDocPtr"))
+			return goName, err
+		}, "DocRef", nil},
+		{"typeMap first", func() (string, error) {
+			goName, _, err := cvt.ConvDecl(interFile, typeDecl("xmlKeepPtr"))
+			return goName, err
+		}, "KeepPtr", nil},
+		{"type ignore", func() (string, error) {
+			goName, _, err := cvt.ConvDecl(interFile, typeDecl("xml_internal_state"))
+			return goName, err
+		}, "", nc.ErrSkip},
+		{"typeMap not ignored", func() (string, error) {
+			goName, _, err := cvt.ConvDecl(interFile, typeDecl("xml_internal_kept"))
+			return goName, err
+		}, "Kept", nil},
+		{"macro rename", func() (string, error) {
+			goName, _, err := cvt.ConvMacro(interFile, &ast.Macro{Name: "XML_PARSER_DEBUG"})
+			return goName, err
+		}, "DebugPARSER", nil},
+		{"macro other kind", func() (string, error) {
+			goName, _, err := cvt.ConvMacro(interFile, &ast.Macro{Name: "COLOR_RED"})
+			return goName, err
+		}, "COLOR_RED", nil},
+		{"macro ignore", func() (string, error) {
+			goName, _, err := cvt.ConvMacro(interFile, &ast.Macro{Name: "xml_internal_FLAG"})
+			return goName, err
+		}, "", nc.ErrSkip},
+		{"enum item rename", func() (string, error) {
+			return cvt.ConvEnumItem(&ast.EnumTypeDecl{Object: ast.Object{Loc: &ast.Location{File: interFile}}},
+				&ast.EnumItem{Name: &ast.Ident{Name: "COLOR_RED"}})
+		}, "ColorRED", nil},
+		{"tag expr", func() (string, error) {
+			return cvt.ConvTagExpr("xmlNodePtr"), nil
+		}, "NodeRef", nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			goName, err := tc.conv()
+			if err != tc.expectErr {
+				t.Fatalf("Expected %v, got %v", tc.expectErr, err)
+			}
+			if goName != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, goName)
+			}
+		})
+	}
+}
@@ -16,6 +16,14 @@ type NodeConverter interface {
 	ConvMacro(file string, macro *ast.Macro) (goName, goFile string, err error)
 	ConvEnumItem(decl *ast.EnumTypeDecl, item *ast.EnumItem) (goName string, err error)
 	ConvTagExpr(cname string) string
+	// ConvNestedName returns the Go type name of an anonymous record nested in a field,
+	// cname is its dotted C path like `outer.field`
+	ConvNestedName(cname, parentName, fieldName string) string
+	// ConvFieldName returns the Go name of a record field, which is public
+	ConvFieldName(cname string) string
+	// ConvFuncName returns the Go name of a function or a method named after a C name rather
+	// than by the symbol table, like a pure virtual method, which is public
+	ConvFuncName(cname string) string
 	Lookup(name string) (locFile string, ok bool)
 	IsPublic(cname string) bool
 }
@@ -24,15 +24,10 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/goplus/gogen"
-	"github.com/goplus/llcppg/ast"
 	"github.com/goplus/llcppg/cl"
-	"github.com/goplus/llcppg/cl/nc/ncimpl"
 	"github.com/goplus/llcppg/cmd/gogensig/unmarshal"
 	llcppg "github.com/goplus/llcppg/config"
 	args "github.com/goplus/llcppg/internal/arg"
-	"github.com/goplus/llcppg/internal/gowrite"
-	"github.com/qiniu/x/errors"
 )
 
 func main() {
@@ -80,56 +75,39 @@ func main() {
 	symbTable, err := llcppg.GetSymTableFromFile(symbFile)
 	check(err)
 
-	pkg, err := cl.Convert(&cl.ConvConfig{
-		PkgName: conf.Name,
-		Pkg:     convertPkg.File,
-		NC: &ncimpl.Converter{
-			PkgName: conf.Name,
-			Pubs:    conf.TypeMap,
-			ConvSym: func(name *ast.Object, mangleName string) (goName string, err error) {
-				item, err := symbTable.LookupSymbol(mangleName)
-				if err != nil {
-					return
-				}
-				return item.Go, nil
-			},
-			FileMap:        convertPkg.FileMap,
-			TrimPrefixes:   conf.TrimPrefixes,
-			KeepUnderScore: conf.KeepUnderScore,
-		},
-		Deps: conf.Deps,
-		Libs: conf.Libs,
-	})
+	lockFile := filepath.Join(wd, llcppg.LLCPPG_LOCK)
+	var lock *llcppg.NameLock
+	if conf.LockNames {
+		lock, err = llcppg.ReadNameLock(lockFile)
+		check(err)
+	}
+
+	convConf, err := cl.NewConvConfig(&conf, convertPkg, symbTable, lock)
 	check(err)
 
-	err = llcppg.WritePubFile(filepath.Join(outputDir, llcppg.LLCPPG_PUB), pkg.Pubs)
+	pkg, err := cl.Convert(convConf)
 	check(err)
 
-	err = writePkg(pkg.Package, outputDir)
+	if report := cl.CollisionReport(pkg.Collisions); report != "" {
+		fmt.Fprint(os.Stderr, report)
+	}
+
+	err = cl.WriteOutput(pkg, outputDir)
 	check(err)
 
-	err = runCommand(outputDir, "go", "fmt", ".")
+	if lock != nil {
+		lock.Names = pkg.Names
+		err = llcppg.WriteNameLock(lockFile, lock)
+		check(err)
+	}
+
+	err = runCommand(outputDir, "go", "fmt", "./...")
 	check(err)
 
 	err = runCommand(outputDir, "go", "mod", "tidy")
 	check(err)
 }
 
-// Write all files in the package to the output directory
-func writePkg(pkg *gogen.Package, outDir string) error {
-	var errs errors.List
-	pkg.ForEachFile(func(fname string, _ *gogen.File) {
-		if fname != "" { // gogen default fname
-			outFile := filepath.Join(outDir, fname)
-			e := gowrite.WriteFile(pkg, outFile, fname)
-			if e != nil {
-				errs.Add(e)
-			}
-		}
-	})
-	return errs.ToError()
-}
-
 func check(err error) {
 	if err != nil {
 		panic(err)
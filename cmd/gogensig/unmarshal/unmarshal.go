@@ -10,8 +10,9 @@ import (
 
 func Pkg(data []byte) (*llcppg.Pkg, error) {
 	type pkgTemp struct {
-		File    json.RawMessage
-		FileMap map[string]*llcppg.FileInfo
+		File      json.RawMessage
+		FileMap   map[string]*llcppg.FileInfo
+		TypeSizes *llcppg.TypeSizes
 	}
 	var pkgData pkgTemp
 
@@ -23,7 +24,8 @@ func Pkg(data []byte) (*llcppg.Pkg, error) {
 		return nil, fmt.Errorf("unmarshal error in Pkg when converting File of unmarshal.pkgTemp: %w", err)
 	}
 	return &llcppg.Pkg{
-		File:    file,
-		FileMap: pkgData.FileMap,
+		File:      file,
+		FileMap:   pkgData.FileMap,
+		TypeSizes: pkgData.TypeSizes,
 	}, nil
 }
@@ -73,6 +73,10 @@ func TestUnmarshalPkg(t *testing.T) {
     "/opt/homebrew/include/lua/luaconf.h": {
       "FileType":1
     }
+  },
+  "TypeSizes": {
+    "wchar": 4,
+    "longDouble": 8
   }
 }
 `
@@ -88,7 +92,8 @@ func TestUnmarshalPkg(t *testing.T) {
 						Name: &ast.Ident{Name: "lua_State"},
 					},
 					Type: &ast.RecordType{
-						Tag: 0,
+						Tag:    0,
+						HasDef: true,
 						Fields: &ast.FieldList{
 							List: []*ast.Field{},
 						},
@@ -124,6 +129,7 @@ func TestUnmarshalPkg(t *testing.T) {
 				FileType: llcppg.Inter,
 			},
 		},
+		TypeSizes: &llcppg.TypeSizes{WChar: 4, LongDouble: 8},
 	}
 
 	fileSet, err := unmarshal.Pkg([]byte(files))
@@ -24,16 +24,11 @@ import (
 	"os/exec"
 	"path/filepath"
 
-	"github.com/goplus/gogen"
 	"github.com/goplus/llcppg/_xtool/parse"
 	"github.com/goplus/llcppg/_xtool/symg"
-	"github.com/goplus/llcppg/ast"
 	"github.com/goplus/llcppg/cl"
-	"github.com/goplus/llcppg/cl/nc/ncimpl"
 	llcppg "github.com/goplus/llcppg/config"
-	"github.com/goplus/llcppg/internal/gowrite"
 	"github.com/goplus/llgo/xtool/env"
-	"github.com/qiniu/x/errors"
 
 	// import to make it linked in go.mod
 	_ "github.com/goplus/lib/c"
@@ -44,7 +39,8 @@ type modeFlags int
 const (
 	ModeCodegen modeFlags = 1 << iota
 	ModeSymbGen
-	ModeAll = ModeCodegen | ModeSymbGen
+	ModeSymbReport // only report the symbols left out of the symbol table
+	ModeAll        = ModeCodegen | ModeSymbGen
 )
 
 type verboseFlags int
@@ -56,15 +52,12 @@ const (
 	VerboseAll = VerboseSymg | VerboseSigfetch | VerboseGogen
 )
 
-func buildSymbolTable(conf *llcppg.Config, v verboseFlags) error {
-	if (v & VerboseSymg) != 0 {
-		symg.SetDebug(symg.DbgFlagAll)
-	}
+func symgConfig(conf *llcppg.Config) *symg.Config {
 	libMode := symg.ModeDynamic
 	if conf.StaticLib {
 		libMode = symg.ModeStatic
 	}
-	symbolTable, err := symg.Do(&symg.Config{
+	return &symg.Config{
 		Libs:         conf.Libs,
 		CFlags:       conf.CFlags,
 		Includes:     conf.Include,
@@ -74,7 +67,32 @@ func buildSymbolTable(conf *llcppg.Config, v verboseFlags) error {
 		IsCpp:        conf.Cplusplus,
 		HeaderOnly:   conf.HeaderOnly,
 		LibMode:      libMode,
-	})
+		Namespaces: symg.Namespaces{
+			Mode:  conf.NamespaceMode,
+			Strip: conf.StripNamespaces,
+		},
+		Instantiate: conf.Instantiate,
+		SymVersions: conf.SymVersions,
+		Rules:       conf.Rules,
+		Naming:      conf.Naming,
+		Collisions:  conf.Collisions,
+	}
+}
+
+func buildSymbolTable(conf *llcppg.Config, v verboseFlags) error {
+	if (v & VerboseSymg) != 0 {
+		symg.SetDebug(symg.DbgFlagAll)
+	}
+	symgConf := symgConfig(conf)
+	var lock *llcppg.NameLock
+	if conf.LockNames {
+		var err error
+		if lock, err = llcppg.ReadNameLock(llcppg.LLCPPG_LOCK); err != nil {
+			return err
+		}
+		symgConf.Locked = lock.Symbols
+	}
+	symbolTable, err := symg.Do(symgConf)
 	if err != nil {
 		return err
 	}
@@ -82,7 +100,35 @@ func buildSymbolTable(conf *llcppg.Config, v verboseFlags) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(llcppg.LLCPPG_SYMB, jsonData, os.ModePerm)
+	if err := os.WriteFile(llcppg.LLCPPG_SYMB, jsonData, os.ModePerm); err != nil {
+		return err
+	}
+	if lock != nil {
+		lock.Symbols = symg.GoNames(symbolTable)
+		return llcppg.WriteNameLock(llcppg.LLCPPG_LOCK, lock)
+	}
+	return nil
+}
+
+// buildSymbolReport writes the report of the symbols left out of the symbol table to
+// llcppg.symreport.json, and prints the human-readable one.
+func buildSymbolReport(conf *llcppg.Config, v verboseFlags) error {
+	if (v & VerboseSymg) != 0 {
+		symg.SetDebug(symg.DbgFlagAll)
+	}
+	report, err := symg.DoReport(symgConfig(conf))
+	if err != nil {
+		return err
+	}
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(llcppg.LLCPPG_SYMREPORT, jsonData, 0644); err != nil {
+		return err
+	}
+	fmt.Print(report)
+	return nil
 }
 
 func parseHeaders(conf *llcppg.Config, v verboseFlags) (*llcppg.Pkg, error) {
@@ -124,48 +170,46 @@ func gengo(conf *llcppg.Config, in *llcppg.Pkg, modulePath string, v verboseFlag
 	if err != nil {
 		return err
 	}
-	pkg, err := cl.Convert(&cl.ConvConfig{
-		OutputDir: outputDir,
-		PkgName:   conf.Name,
-		Pkg:       in.File,
-		NC: &ncimpl.Converter{
-			PkgName: conf.Name,
-			Pubs:    conf.TypeMap,
-			ConvSym: func(name *ast.Object, mangleName string) (goName string, err error) {
-				item, err := symbTable.LookupSymbol(mangleName)
-				if err != nil {
-					return "", err
-				}
-				return item.Go, nil
-			},
-			FileMap:        in.FileMap,
-			TrimPrefixes:   conf.TrimPrefixes,
-			KeepUnderScore: conf.KeepUnderScore,
-		},
-		Deps: conf.Deps,
-		Libs: conf.Libs,
-	})
+	lockFile := filepath.Join(wd, llcppg.LLCPPG_LOCK)
+	var lock *llcppg.NameLock
+	if conf.LockNames {
+		if lock, err = llcppg.ReadNameLock(lockFile); err != nil {
+			return err
+		}
+	}
+	convConf, err := cl.NewConvConfig(conf, in, symbTable, lock)
 	if err != nil {
 		return err
 	}
-	if err := llcppg.WritePubFile(filepath.Join(outputDir, llcppg.LLCPPG_PUB), pkg.Pubs); err != nil {
+	convConf.OutputDir = outputDir
+	pkg, err := cl.Convert(convConf)
+	if err != nil {
 		return err
 	}
-	if err := writePkg(pkg.Package, outputDir); err != nil {
+	if report := cl.CollisionReport(pkg.Collisions); report != "" {
+		fmt.Fprint(os.Stderr, report)
+	}
+	if err := cl.WriteOutput(pkg, outputDir); err != nil {
 		return err
 	}
-	if err := runCommand(outputDir, "go", "fmt", "."); err != nil {
+	if lock != nil {
+		lock.Names = pkg.Names
+		if err := llcppg.WriteNameLock(lockFile, lock); err != nil {
+			return err
+		}
+	}
+	if err := runCommand(outputDir, "go", "fmt", "./..."); err != nil {
 		return err
 	}
 	return runCommand(outputDir, "go", "mod", "tidy")
 }
 
 func main() {
-	var symbGen, codeGen, help bool
+	var symbGen, codeGen, symReport, help bool
 	var vSymg, vSigfetch, vGogen, vAll bool
 	var modulePath string
 	flag.Usage = func() {
-		fmt.Fprintln(os.Stderr, "Usage: llcppg [-v|-vfetch|-vsymg|-vgogen] [-symbgen] [-codegen] [-h|--help] [config-file]")
+		fmt.Fprintln(os.Stderr, "Usage: llcppg [-v|-vfetch|-vsymg|-vgogen] [-symbgen] [-codegen] [-symreport] [-h|--help] [config-file]")
 		fmt.Fprintln(os.Stderr, "Options:")
 		flag.PrintDefaults()
 	}
@@ -175,6 +219,7 @@ func main() {
 	flag.BoolVar(&vGogen, "vgogen", false, "Enable verbose of gogensig")
 	flag.BoolVar(&symbGen, "symbgen", false, "Only use llcppsymg to generate llcppg.symb.json")
 	flag.BoolVar(&codeGen, "codegen", false, "Only use (llcppsigfetch & gogensig) to generate go code binding")
+	flag.BoolVar(&symReport, "symreport", false, "Only report the symbols declared but not exported, exported but not declared and ignored by symMap to llcppg.symreport.json")
 	flag.BoolVar(&help, "h", false, "Display help information")
 	flag.BoolVar(&help, "help", false, "Display help information")
 	flag.StringVar(&modulePath, "mod", "", "The module path of the generated code,if not set,will not init a new module")
@@ -202,6 +247,9 @@ func main() {
 	if symbGen {
 		mode = ModeSymbGen
 	}
+	if symReport {
+		mode = ModeSymbReport
+	}
 
 	if help {
 		flag.Usage()
@@ -229,12 +277,17 @@ func do(cfgFile string, mode modeFlags, verbose verboseFlags, modulePath string)
 	err = json.NewDecoder(f).Decode(&conf)
 	check(err)
 
-	// Keep original libs expression for generated LLGoPackage, but use expanded
-	// values for symbol extraction and header parsing passes.
-	rawLibs := conf.Libs
+	// Keep original libs and cflags expressions for generated LLGoPackage and LLGoFiles,
+	// but use expanded values for symbol extraction and header parsing passes.
+	rawLibs, rawCFlags := conf.Libs, conf.CFlags
 	conf.CFlags = env.ExpandEnv(conf.CFlags)
 	conf.Libs = env.ExpandEnv(conf.Libs)
 
+	if mode&ModeSymbReport != 0 {
+		err = buildSymbolReport(&conf, verbose)
+		check(err)
+	}
+
 	if mode&ModeSymbGen != 0 {
 		// Pass 1: build native symbol table.
 		err = buildSymbolTable(&conf, verbose)
@@ -248,6 +301,7 @@ func do(cfgFile string, mode modeFlags, verbose verboseFlags, modulePath string)
 		// Pass 3: convert C header AST information into corresponding LLGo bindings.
 		codegenConf := conf
 		codegenConf.Libs = rawLibs
+		codegenConf.CFlags = rawCFlags
 		err = gengo(&codegenConf, pkg, modulePath, verbose)
 		check(err)
 	}
@@ -260,19 +314,6 @@ func prepareEnv(outputDir string, deps []string, modulePath string) error {
 	return cl.ModInit(deps, outputDir, modulePath)
 }
 
-func writePkg(pkg *gogen.Package, outDir string) error {
-	var errs errors.List
-	pkg.ForEachFile(func(fname string, _ *gogen.File) {
-		if fname != "" {
-			outFile := filepath.Join(outDir, fname)
-			if err := gowrite.WriteFile(pkg, outFile, fname); err != nil {
-				errs.Add(err)
-			}
-		}
-	})
-	return errs.ToError()
-}
-
 func runCommand(dir, cmdName string, args ...string) error {
 	execCmd := exec.Command(cmdName, args...)
 	execCmd.Stdout = os.Stdout
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Collision resolves the C declarations converted to the same Go name, which are suffixed
+// in the order of the declarations otherwise, like Foo and Foo__1 of struct foo and macro FOO.
+type Collision struct {
+	// Prefer is the C name keeping the Go name wherever it's declared, the others are suffixed
+	Prefer string `json:"prefer,omitempty"`
+	// Rename names the other C names, like {"FOO": "FooValue"}
+	Rename map[string]string `json:"rename,omitempty"`
+	// Ignore are the C names left out of the Go package
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// Collisions are the resolutions of the name collisions of Config.Collisions, keyed by
+// the Go names.
+type Collisions map[string]Collision
+
+// Resolve returns the Go name of a C name renamed by the collisions, or ignored
+// if it's left out, ok is false if it's in none of them.
+func (c Collisions) Resolve(cname string) (goName string, ignored, ok bool) {
+	for _, coll := range c {
+		if goName, ok := coll.Rename[cname]; ok {
+			return goName, false, true
+		}
+		if slices.Contains(coll.Ignore, cname) {
+			return "", true, true
+		}
+	}
+	return "", false, false
+}
+
+// Preferred returns the C names keeping the Go names, keyed by the Go names.
+func (c Collisions) Preferred() map[string]string {
+	var preferred map[string]string
+	for goName, coll := range c {
+		if coll.Prefer == "" {
+			continue
+		}
+		if preferred == nil {
+			preferred = make(map[string]string)
+		}
+		preferred[goName] = coll.Prefer
+	}
+	return preferred
+}
+
+// check reports the C names resolved twice, and the empty Go names.
+func (c Collisions) check() error {
+	seen := make(map[string]string)
+	resolve := func(goName, cname string) error {
+		if prev, ok := seen[cname]; ok {
+			return fmt.Errorf("%s is resolved by the collisions of both %s and %s", cname, prev, goName)
+		}
+		seen[cname] = goName
+		return nil
+	}
+	for goName, coll := range c {
+		if coll.Prefer != "" {
+			if err := resolve(goName, coll.Prefer); err != nil {
+				return err
+			}
+		}
+		for cname, newName := range coll.Rename {
+			if newName == "" || newName == goName {
+				return fmt.Errorf("invalid rename %q of %s in the collision of %s", newName, cname, goName)
+			}
+			if err := resolve(goName, cname); err != nil {
+				return err
+			}
+		}
+		for _, cname := range coll.Ignore {
+			if err := resolve(goName, cname); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -3,14 +3,17 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/goplus/llcppg/ast"
 )
 
 const LLCPPG_CFG = "llcppg.cfg"
 const LLCPPG_SYMB = "llcppg.symb.json"
+const LLCPPG_SYMREPORT = "llcppg.symreport.json"
 const LLCPPG_SIGFETCH = "llcppg.sigfetch.json"
 const LLCPPG_PUB = "llcppg.pub"
+const LLCPPG_LOCK = "llcppg.lock.json"
 
 type Condition struct {
 	OS   []string `json:"os"`
@@ -39,8 +42,89 @@ type Config struct {
 	TypeMap        map[string]string `json:"typeMap,omitempty"`
 	StaticLib      bool              `json:"staticLib,omitempty"`
 	HeaderOnly     bool              `json:"headerOnly,omitempty"`
+	OpaqueExclude  []string          `json:"opaqueExclude,omitempty"`
+	NestedTypeName string            `json:"nestedTypeName,omitempty"`
+	AliasTypedefs  []string          `json:"aliasTypedefs,omitempty"`
+	AutoAlias      bool              `json:"autoAlias,omitempty"`
+	GoDoc          bool              `json:"goDoc,omitempty"`
+	GoSubclass     []string          `json:"goSubclass,omitempty"`
+	// ConstRefByValue is the max size in bytes of the trivially copyable types whose const
+	// reference parameters are passed by value through the C++ shim, 0 keeps them pointers
+	ConstRefByValue int `json:"constRefByValue,omitempty"`
+	// NamespaceMode is how the C++ namespaces map to Go, see NamespaceFlatten and NamespacePackage
+	NamespaceMode   string   `json:"namespaceMode,omitempty"`
+	StripNamespaces []string `json:"stripNamespaces,omitempty"` // namespaces left out of the Go names and packages
+	// Instantiate lists the class template instantiations to bind, like std::vector<int>
+	Instantiate []string `json:"instantiate,omitempty"`
+	// StdWrappers generates the Go wrappers of the functions taking or returning std::string,
+	// std::string_view and std::vector, which use Go strings and slices instead
+	StdWrappers bool `json:"stdWrappers,omitempty"`
+	// DefaultArgs maps the C++ functions with default arguments, like draw or Canvas::draw,
+	// to how the Go callers omit them, see DefaultArgsOverloads and DefaultArgsOptions
+	DefaultArgs map[string]string `json:"defaultArgs,omitempty"`
+	// EnumItemName is the name template of the items of the scoped enums, like {enum}{item}
+	EnumItemName    string `json:"enumItemName,omitempty"`
+	PrefixEnumItems bool   `json:"prefixEnumItems,omitempty"` // name the items of the unscoped enums with EnumItemName too
+	// SymVersions pins the ELF symbol versions the symbols are linked to, like foo to VERS_1
+	// of foo@VERS_1, instead of the default ones
+	SymVersions map[string]string `json:"symVersions,omitempty"`
+	// SplitLibs generates the functions of each library of libs but the first one in a Go
+	// sub-package of its own, which links only that library, like foo/bar of -lfoo -lbar
+	SplitLibs bool `json:"splitLibs,omitempty"`
+	// LockNames keeps the Go names of the previous runs recorded in llcppg.lock.json, the new
+	// symbols and declarations are named without renaming the locked ones, see NameLock
+	LockNames bool `json:"lockNames,omitempty"`
+	// Rules name or ignore the functions, types, enum items and macros matched by the
+	// patterns, after the exact entries of symMap and typeMap, see NameRule
+	Rules []NameRule `json:"rules,omitempty"`
+	// Naming is the naming policy of the Go identifiers, like the initialisms and the styles
+	// of the types, the functions, the constants and the fields
+	Naming *Naming `json:"naming,omitempty"`
+	// Collisions resolve the C declarations converted to the same Go name by preferring,
+	// renaming or ignoring them, keyed by the Go names, see Collision
+	Collisions Collisions `json:"collisions,omitempty"`
 }
 
+// Naming is the naming policy of the Go identifiers converted from the C names, the styles are
+// NamingCamel, NamingTitle and NamingPreserve.
+type Naming struct {
+	// Initialisms are the words spelled in upper case, like XML and HTTP of XMLHTTPClient for
+	// xml_http_client or XmlHttpClient, which aren't applied to the preserve style
+	Initialisms []string `json:"initialisms,omitempty"`
+	Types       string   `json:"types,omitempty"`  // style of the types, camel by default
+	Funcs       string   `json:"funcs,omitempty"`  // style of the functions and the methods, camel by default
+	Consts      string   `json:"consts,omitempty"` // style of the macros and the enum items, preserve by default
+	Fields      string   `json:"fields,omitempty"` // style of the record fields, camel by default
+}
+
+const (
+	// NamingCamel joins the words of snake_case and camelCase names with their first letters in
+	// upper case, like SqliteFile of sqlite_file and CJSON of cJSON
+	NamingCamel = "camel"
+	// NamingTitle is NamingCamel with the other letters of the words in lower case, like
+	// ParseRecover of PARSE_RECOVER
+	NamingTitle = "title"
+	// NamingPreserve keeps the original casing with the first letter in upper case, like
+	// PARSE_RECOVER and Sqlite_file
+	NamingPreserve = "preserve"
+)
+
+const (
+	// NamespaceFlatten prefixes the Go names with their namespaces, like NsFoo for ns::Foo, it's the default
+	NamespaceFlatten = "flatten"
+	// NamespacePackage generates a Go sub-package of each namespace, like ns.Foo for ns::Foo
+	NamespacePackage = "package"
+)
+
+const (
+	// DefaultArgsOverloads generates a Go function for each number of the default arguments
+	// passed, like Draw and DrawWithColor
+	DefaultArgsOverloads = "overloads"
+	// DefaultArgsOptions generates a Go function taking the default arguments as a trailing
+	// options struct, like DrawWithOptions and DrawOptions
+	DefaultArgsOptions = "options"
+)
+
 // json middleware for validating
 func (c *Config) UnmarshalJSON(data []byte) error {
 	// create a new type here to avoid unmarshalling infinite loop.
@@ -62,6 +146,50 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("%w: libs must not be empty", ErrConfig)
 	}
 
+	switch c.NamespaceMode {
+	case "", NamespaceFlatten, NamespacePackage:
+	default:
+		return fmt.Errorf("%w: unknown namespaceMode %q", ErrConfig, c.NamespaceMode)
+	}
+
+	if len(c.Instantiate) > 0 && !c.Cplusplus {
+		return fmt.Errorf("%w: instantiate needs cplusplus", ErrConfig)
+	}
+
+	for fn, mode := range c.DefaultArgs {
+		if mode != DefaultArgsOverloads && mode != DefaultArgsOptions {
+			return fmt.Errorf("%w: unknown defaultArgs %q of %s", ErrConfig, mode, fn)
+		}
+	}
+
+	if c.SplitLibs && c.NamespaceMode == NamespacePackage {
+		return fmt.Errorf("%w: splitLibs can't be used with the package namespaceMode", ErrConfig)
+	}
+
+	if _, err := CompileRules(c.Rules); err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+
+	if n := c.Naming; n != nil {
+		for _, style := range []string{n.Types, n.Funcs, n.Consts, n.Fields} {
+			switch style {
+			case "", NamingCamel, NamingTitle, NamingPreserve:
+			default:
+				return fmt.Errorf("%w: unknown naming style %q", ErrConfig, style)
+			}
+		}
+	}
+
+	if err := c.Collisions.check(); err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+
+	for sym, version := range c.SymVersions {
+		if version == "" || strings.Contains(version, "@") {
+			return fmt.Errorf("%w: invalid symVersions %q of %s", ErrConfig, version, sym)
+		}
+	}
+
 	return nil
 }
 
@@ -73,6 +201,12 @@ type SymbolInfo struct {
 	Mangle string `json:"mangle"` // C++ Symbol
 	CPP    string `json:"c++"`    // C++ function name
 	Go     string `json:"go"`     // Go function name
+	// Version is the default ELF symbol version, like VERS_2 of foo@@VERS_2, which is linked
+	// by the name, and Versions are the other ones, which are linked only when pinned
+	Version  string   `json:"version,omitempty"`
+	Versions []string `json:"versions,omitempty"`
+	// Lib is the -l name of the library providing the symbol, like cjson of -lcjson
+	Lib string `json:"lib,omitempty"`
 }
 
 // for better debug
@@ -96,7 +230,31 @@ type FileInfo struct {
 	FileType FileType
 }
 
+// TypeSizes records the size in bytes of the builtin C types and the standard C++ types
+// whose width depends on the target, as reported by clang while parsing the headers.
+// A zero value means the type was not seen and the host default applies.
+type TypeSizes struct {
+	WChar      int64 `json:"wchar,omitempty"`
+	LongDouble int64 `json:"longDouble,omitempty"`
+	StdString  int64 `json:"stdString,omitempty"` // std::string, which depends on the C++ library
+	// WCharUnsigned is whether a 32-bit wchar_t is unsigned, see WCharUnsigned
+	WCharUnsigned bool `json:"wcharUnsigned,omitempty"`
+}
+
+// WCharUnsigned reports whether wchar_t is unsigned on a target, which clang doesn't report.
+// It is on arm and arm64 except darwin, like linux/arm64, and on windows, where it is 16-bit.
+func WCharUnsigned(goos, goarch string) bool {
+	switch goos {
+	case "windows":
+		return true
+	case "darwin", "ios":
+		return false
+	}
+	return goarch == "arm" || goarch == "arm64"
+}
+
 type Pkg struct {
-	File    *ast.File
-	FileMap map[string]*FileInfo
+	File      *ast.File
+	FileMap   map[string]*FileInfo
+	TypeSizes *TypeSizes
 }
@@ -6,6 +6,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	llconfig "github.com/goplus/llcppg/config"
@@ -166,6 +167,280 @@ func TestGetConfByByte(t *testing.T) {
 			mode:      useFile,
 		},
 
+		{
+			name: "Namespace configuration",
+			input: `{
+		  "name": "mylib",
+		  "include": ["mylib.h"],
+		  "headerOnly": true,
+		  "cplusplus": true,
+		  "namespaceMode": "package",
+		  "stripNamespaces": ["mylib", "std::__1"]
+		}`,
+			expect: llconfig.Config{
+				Name:            "mylib",
+				Include:         []string{"mylib.h"},
+				HeaderOnly:      true,
+				Cplusplus:       true,
+				NamespaceMode:   llconfig.NamespacePackage,
+				StripNamespaces: []string{"mylib", "std::__1"},
+			},
+			mode: useFile,
+		},
+		{
+			name: "Unknown namespaceMode",
+			input: `{
+		  "name": "mylib",
+		  "include": ["mylib.h"],
+		  "headerOnly": true,
+		  "namespaceMode": "nested"
+		}`,
+			expectErr: true,
+			mode:      useFile,
+		},
+		{
+			name: "Instantiate configuration",
+			input: `{
+		  "name": "mylib",
+		  "include": ["mylib.h"],
+		  "headerOnly": true,
+		  "cplusplus": true,
+		  "instantiate": ["std::vector<int>", "Eigen::Matrix<float,3,3>"]
+		}`,
+			expect: llconfig.Config{
+				Name:        "mylib",
+				Include:     []string{"mylib.h"},
+				HeaderOnly:  true,
+				Cplusplus:   true,
+				Instantiate: []string{"std::vector<int>", "Eigen::Matrix<float,3,3>"},
+			},
+			mode: useFile,
+		},
+		{
+			name: "Instantiate without cplusplus",
+			input: `{
+		  "name": "mylib",
+		  "include": ["mylib.h"],
+		  "headerOnly": true,
+		  "instantiate": ["std::vector<int>"]
+		}`,
+			expectErr: true,
+			mode:      useFile,
+		},
+		{
+			name: "DefaultArgs configuration",
+			input: `{
+		  "name": "mylib",
+		  "include": ["mylib.h"],
+		  "headerOnly": true,
+		  "cplusplus": true,
+		  "defaultArgs": {"draw": "overloads", "Canvas::fill": "options"}
+		}`,
+			expect: llconfig.Config{
+				Name:        "mylib",
+				Include:     []string{"mylib.h"},
+				HeaderOnly:  true,
+				Cplusplus:   true,
+				DefaultArgs: map[string]string{"draw": llconfig.DefaultArgsOverloads, "Canvas::fill": llconfig.DefaultArgsOptions},
+			},
+			mode: useFile,
+		},
+		{
+			name: "Unknown defaultArgs",
+			input: `{
+		  "name": "mylib",
+		  "include": ["mylib.h"],
+		  "headerOnly": true,
+		  "defaultArgs": {"draw": "struct"}
+		}`,
+			expectErr: true,
+			mode:      useFile,
+		},
+		{
+			name: "SymVersions configuration",
+			input: `{
+		  "name": "mylib",
+		  "include": ["mylib.h"],
+		  "libs": "-lmylib",
+		  "symVersions": {"foo": "VERS_1"}
+		}`,
+			expect: llconfig.Config{
+				Name:        "mylib",
+				Include:     []string{"mylib.h"},
+				Libs:        "-lmylib",
+				SymVersions: map[string]string{"foo": "VERS_1"},
+			},
+			mode: useFile,
+		},
+		{
+			name: "Invalid symVersions",
+			input: `{
+		  "name": "mylib",
+		  "include": ["mylib.h"],
+		  "libs": "-lmylib",
+		  "symVersions": {"foo": "foo@VERS_1"}
+		}`,
+			expectErr: true,
+			mode:      useFile,
+		},
+		{
+			name: "SplitLibs configuration",
+			input: `{
+		  "name": "mylib",
+		  "include": ["mylib.h"],
+		  "libs": "-lmylib -lmylib_ext",
+		  "splitLibs": true
+		}`,
+			expect: llconfig.Config{
+				Name:      "mylib",
+				Include:   []string{"mylib.h"},
+				Libs:      "-lmylib -lmylib_ext",
+				SplitLibs: true,
+			},
+			mode: useFile,
+		},
+		{
+			name: "LockNames configuration",
+			input: `{
+		  "name": "mylib",
+		  "include": ["mylib.h"],
+		  "libs": "-lmylib",
+		  "lockNames": true
+		}`,
+			expect: llconfig.Config{
+				Name:      "mylib",
+				Include:   []string{"mylib.h"},
+				Libs:      "-lmylib",
+				LockNames: true,
+			},
+			mode: useFile,
+		},
+		{
+			name: "SplitLibs with package namespaceMode",
+			input: `{
+		  "name": "mylib",
+		  "include": ["mylib.h"],
+		  "libs": "-lmylib -lmylib_ext",
+		  "cplusplus": true,
+		  "namespaceMode": "package",
+		  "splitLibs": true
+		}`,
+			expectErr: true,
+			mode:      useFile,
+		},
+		{
+			name: "Rules configuration",
+			input: `{
+		  "name": "libxml",
+		  "include": ["xmlversion.h"],
+		  "libs": "-lxml2",
+		  "rules": [
+		    {"match": "xmlDebug*", "kinds": ["func"], "name": "Debug$1"},
+		    {"regexp": "xml(\\w+)Ptr", "kinds": ["type"], "action": "rename", "name": "${1}Ref"},
+		    {"match": "*_internal", "action": "ignore"}
+		  ]
+		}`,
+			expect: llconfig.Config{
+				Name:    "libxml",
+				Include: []string{"xmlversion.h"},
+				Libs:    "-lxml2",
+				Rules: []llconfig.NameRule{
+					{Match: "xmlDebug*", Kinds: []string{llconfig.RuleFunc}, Name: "Debug$1"},
+					{Regexp: `xml(\w+)Ptr`, Kinds: []string{llconfig.RuleType}, Action: llconfig.RuleRename, Name: "${1}Ref"},
+					{Match: "*_internal", Action: llconfig.RuleIgnore},
+				},
+			},
+			mode: useFile,
+		},
+		{
+			name: "Rules with invalid regexp",
+			input: `{
+		  "name": "libxml",
+		  "include": ["xmlversion.h"],
+		  "libs": "-lxml2",
+		  "rules": [{"regexp": "xml(", "name": "Xml"}]
+		}`,
+			expectErr: true,
+			mode:      useFile,
+		},
+		{
+			name: "Naming configuration",
+			input: `{
+		  "name": "libxml",
+		  "include": ["xmlversion.h"],
+		  "libs": "-lxml2",
+		  "naming": {
+		    "initialisms": ["XML", "HTTP", "URL", "ID", "JSON"],
+		    "consts": "title",
+		    "fields": "preserve"
+		  }
+		}`,
+			expect: llconfig.Config{
+				Name:    "libxml",
+				Include: []string{"xmlversion.h"},
+				Libs:    "-lxml2",
+				Naming: &llconfig.Naming{
+					Initialisms: []string{"XML", "HTTP", "URL", "ID", "JSON"},
+					Consts:      llconfig.NamingTitle,
+					Fields:      llconfig.NamingPreserve,
+				},
+			},
+			mode: useFile,
+		},
+		{
+			name: "Naming with unknown style",
+			input: `{
+		  "name": "libxml",
+		  "include": ["xmlversion.h"],
+		  "libs": "-lxml2",
+		  "naming": {"types": "snake"}
+		}`,
+			expectErr: true,
+			mode:      useFile,
+		},
+		{
+			name: "Collisions configuration",
+			input: `{
+		  "name": "libxml",
+		  "include": ["xmlversion.h"],
+		  "libs": "-lxml2",
+		  "collisions": {
+		    "Foo": {"prefer": "foo", "rename": {"FOO": "FooValue"}, "ignore": ["Foo"]}
+		  }
+		}`,
+			expect: llconfig.Config{
+				Name:    "libxml",
+				Include: []string{"xmlversion.h"},
+				Libs:    "-lxml2",
+				Collisions: llconfig.Collisions{
+					"Foo": {Prefer: "foo", Rename: map[string]string{"FOO": "FooValue"}, Ignore: []string{"Foo"}},
+				},
+			},
+			mode: useFile,
+		},
+		{
+			name: "Collisions resolving a C name twice",
+			input: `{
+		  "name": "libxml",
+		  "include": ["xmlversion.h"],
+		  "libs": "-lxml2",
+		  "collisions": {"Foo": {"prefer": "foo", "ignore": ["foo"]}}
+		}`,
+			expectErr: true,
+			mode:      useFile,
+		},
+		{
+			name: "Collisions renaming to the Go name",
+			input: `{
+		  "name": "libxml",
+		  "include": ["xmlversion.h"],
+		  "libs": "-lxml2",
+		  "collisions": {"Foo": {"rename": {"FOO": "Foo"}}}
+		}`,
+			expectErr: true,
+			mode:      useFile,
+		},
+
 		{
 			name:      "Invalid JSON",
 			input:     `{invalid json}`,
@@ -354,6 +629,46 @@ stdio`
 	}
 }
 
+func TestNameLock(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), llconfig.LLCPPG_LOCK)
+	lock, err := llconfig.ReadNameLock(lockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock.Symbols != nil || lock.Names != nil {
+		t.Fatalf("ReadNameLock() of a nonexistent file = %v, want an empty lock", lock)
+	}
+	lock = &llconfig.NameLock{
+		Symbols: map[string]string{"foo": "Foo", "foo_int": "Foo__1"},
+		Names:   map[string]string{"bar": "Bar"},
+	}
+	if err := llconfig.WriteNameLock(lockFile, lock); err != nil {
+		t.Fatal(err)
+	}
+	got, err := llconfig.ReadNameLock(lockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, lock) {
+		t.Errorf("ReadNameLock() = %v, want %v", got, lock)
+	}
+
+	if err := os.WriteFile(lockFile, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := llconfig.ReadNameLock(lockFile); err == nil {
+		t.Error("ReadNameLock() of an invalid file, expected an error")
+	}
+
+	if err := llconfig.CheckLocked(lock.Symbols, map[string]string{"foo": "Foo", "foo_int": "Foo__1", "baz": "Baz"}); err != nil {
+		t.Error(err)
+	}
+	err = llconfig.CheckLocked(lock.Symbols, map[string]string{"foo": "Foo"})
+	if err == nil || !strings.Contains(err.Error(), "foo_int (Foo__1)") {
+		t.Errorf("CheckLocked() = %v, want the error of foo_int", err)
+	}
+}
+
 func TestSymbolInfo(t *testing.T) {
 	info := &llconfig.SymbolInfo{Mangle: "aaaa", Go: "bbbb", CPP: "cccc"}
 
@@ -361,3 +676,103 @@ func TestSymbolInfo(t *testing.T) {
 		t.Errorf("unexpected content: want: %s got: %s", "Go: bbbb CPP: cccc Mangle: aaaa", info.String())
 	}
 }
+
+func TestCollisions(t *testing.T) {
+	collisions := llconfig.Collisions{
+		"Foo": {Prefer: "foo", Rename: map[string]string{"FOO": "FooValue"}, Ignore: []string{"Foo"}},
+		"Bar": {Ignore: []string{"bar"}},
+	}
+	testCases := []struct {
+		cname   string
+		goName  string
+		ignored bool
+		ok      bool
+	}{
+		{"FOO", "FooValue", false, true},
+		{"Foo", "", true, true},
+		{"bar", "", true, true},
+		{"foo", "", false, false},
+		{"baz", "", false, false},
+	}
+	for _, tc := range testCases {
+		goName, ignored, ok := collisions.Resolve(tc.cname)
+		if goName != tc.goName || ignored != tc.ignored || ok != tc.ok {
+			t.Errorf("Resolve(%s) = %s, %v, %v, want %s, %v, %v", tc.cname, goName, ignored, ok, tc.goName, tc.ignored, tc.ok)
+		}
+	}
+	if preferred := collisions.Preferred(); !reflect.DeepEqual(preferred, map[string]string{"Foo": "foo"}) {
+		t.Errorf("Preferred() = %v", preferred)
+	}
+	var none llconfig.Collisions
+	if _, _, ok := none.Resolve("foo"); ok || none.Preferred() != nil {
+		t.Errorf("the nil collisions resolve nothing")
+	}
+}
+
+func TestCompileRules(t *testing.T) {
+	testCases := []struct {
+		name string
+		rule llconfig.NameRule
+	}{
+		{"no pattern", llconfig.NameRule{Name: "Foo"}},
+		{"both patterns", llconfig.NameRule{Match: "foo*", Regexp: "foo.*", Name: "Foo"}},
+		{"invalid regexp", llconfig.NameRule{Regexp: "foo(", Name: "Foo"}},
+		{"unknown kind", llconfig.NameRule{Match: "foo*", Kinds: []string{"var"}, Name: "Foo"}},
+		{"unknown action", llconfig.NameRule{Match: "foo*", Action: "drop"}},
+		{"rename without name", llconfig.NameRule{Match: "foo*"}},
+		{"method without name", llconfig.NameRule{Match: "foo*", Kinds: []string{llconfig.RuleFunc}, Action: llconfig.RuleMethod}},
+		{"method of types", llconfig.NameRule{Match: "foo*", Kinds: []string{llconfig.RuleType}, Action: llconfig.RuleMethod, Name: "Foo"}},
+		{"recv without method", llconfig.NameRule{Match: "foo*", Name: "Foo", Recv: "Bar"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := llconfig.CompileRules([]llconfig.NameRule{tc.rule}); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+	if rules, err := llconfig.CompileRules(nil); rules != nil || err != nil {
+		t.Fatalf("expected no rules, got %v, %v", rules, err)
+	}
+}
+
+func TestMatchRules(t *testing.T) {
+	rules, err := llconfig.CompileRules([]llconfig.NameRule{
+		{Match: "xmlDebug*", Kinds: []string{llconfig.RuleFunc}, Name: "Debug$1"},
+		{Match: "xml*Dump?", Kinds: []string{llconfig.RuleFunc}, Action: llconfig.RuleMethod, Name: "Dump$2", Recv: "Doc"},
+		{Regexp: `xml(?P<base>\w+)Ptr`, Name: "${base}Ref"},
+		{Match: "*_internal", Action: llconfig.RuleIgnore},
+		{Match: "xml*", Name: "X$1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		kind, cname string
+		action      string
+		goName      string
+		ok          bool
+	}{
+		{llconfig.RuleFunc, "xmlDebugDumpNode", llconfig.RuleRename, "DebugDumpNode", true},
+		{llconfig.RuleType, "xmlDebugDumpNode", llconfig.RuleRename, "XDebugDumpNode", true},
+		{llconfig.RuleFunc, "xmlDocDumpF", llconfig.RuleMethod, "DumpF", true},
+		{llconfig.RuleType, "xmlNodePtr", llconfig.RuleRename, "NodeRef", true},
+		{llconfig.RuleMacro, "xml_internal", llconfig.RuleIgnore, "", true},
+		{llconfig.RuleMacro, "XML_VERSION", "", "", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.kind+" "+tc.cname, func(t *testing.T) {
+			rule, goName, ok := rules.Match(tc.kind, tc.cname)
+			if ok != tc.ok || goName != tc.goName {
+				t.Fatalf("expected %q, %v, got %q, %v", tc.goName, tc.ok, goName, ok)
+			}
+			if ok && rule.Action != tc.action && !(tc.action == llconfig.RuleRename && rule.Action == "") {
+				t.Fatalf("expected action %q, got %q", tc.action, rule.Action)
+			}
+		})
+	}
+	var none *llconfig.NameRules
+	if _, _, ok := none.Match(llconfig.RuleFunc, "xmlDebugDumpNode"); ok {
+		t.Fatal("expected no match of nil rules")
+	}
+}
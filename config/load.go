@@ -70,6 +70,67 @@ func (t *SymTable) LookupSymbol(mangle string) (*SymbolInfo, error) {
 	return nil, fmt.Errorf("symbol %s not found", mangle)
 }
 
+// Libs returns the -l names of the libraries providing the symbols, like cjson, by their mangled names.
+func (t *SymTable) Libs() map[string]string {
+	libs := make(map[string]string)
+	for mangle, sym := range t.t {
+		if sym.Lib != "" {
+			libs[mangle] = sym.Lib
+		}
+	}
+	return libs
+}
+
+// llcppg.lock.json
+
+// NameLock records the Go names assigned by the previous runs, which are kept by the later
+// ones, so the suffixes like __1 of the overloads and the colliding names are stable.
+type NameLock struct {
+	Symbols map[string]string `json:"symbols,omitempty"` // mangled name -> Go name of the symbol table
+	Names   map[string]string `json:"names,omitempty"`   // kind and C name like "typedef foo" -> Go name of the types, enum items, macros and variables
+}
+
+// ReadNameLock reads a name lock file, which is an empty lock if the file doesn't exist.
+func ReadNameLock(lockFile string) (*NameLock, error) {
+	lock := &NameLock{}
+	b, err := os.ReadFile(lockFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lock, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(b, lock); err != nil {
+		return nil, fmt.Errorf("%s: %w", lockFile, err)
+	}
+	return lock, nil
+}
+
+// WriteNameLock writes a name lock file, the names are sorted to keep the diffs small.
+func WriteNameLock(lockFile string, lock *NameLock) error {
+	b, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(lockFile, append(b, '\n'), 0644)
+}
+
+// CheckLocked reports an error of the locked names absent from the names, like the ones of
+// the symbols removed from the libraries, which are dropped by removing them from the lock file.
+func CheckLocked(locked, names map[string]string) error {
+	var missing []string
+	for name, goName := range locked {
+		if _, ok := names[name]; !ok {
+			missing = append(missing, name+" ("+goName+")")
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("locked names not found, remove them from %s to drop them: %s", LLCPPG_LOCK, strings.Join(missing, ", "))
+}
+
 // llcppg.pub
 func ReadPubFile(pubfile string) (ret map[string]string, err error) {
 	b, err := os.ReadFile(pubfile)
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// The kinds of the declarations a NameRule applies to.
+const (
+	RuleFunc     = "func"
+	RuleType     = "type"
+	RuleEnumItem = "enumItem"
+	RuleMacro    = "macro"
+)
+
+// The actions of a NameRule.
+const (
+	// RuleRename names the declarations with Name, it's the default
+	RuleRename = "rename"
+	// RuleMethod makes the functions the methods named Name of their first parameters,
+	// like the method of the Go name .Name of symMap, or of the receiver Recv
+	RuleMethod = "method"
+	// RuleIgnore leaves the declarations out of the Go package, like the Go name - of symMap
+	RuleIgnore = "ignore"
+)
+
+// NameRule names or ignores the declarations whose C names match a pattern, like all the
+// functions of xmlDebug*. The rules of Config.Rules are tried in order after the exact
+// entries of symMap and typeMap, the first one matching a declaration applies.
+type NameRule struct {
+	// Match is a glob pattern of the whole C name, like xmlDebug* or *_internal, where
+	// * matches any characters and ? matches one, which are the captures $1, $2 and so on
+	Match string `json:"match,omitempty"`
+	// Regexp is a regular expression of the whole C name instead of Match, like xml(\w+)Debug,
+	// whose captures are $1 or ${name} of the named ones
+	Regexp string `json:"regexp,omitempty"`
+	// Kinds are the kinds of the declarations it applies to, like func and type,
+	// all of them if empty, see RuleFunc, RuleType, RuleEnumItem and RuleMacro
+	Kinds []string `json:"kinds,omitempty"`
+	// Action is what it does, see RuleRename, RuleMethod and RuleIgnore
+	Action string `json:"action,omitempty"`
+	// Name is the template of the Go name, like Debug$1, which is expanded with the captures
+	Name string `json:"name,omitempty"`
+	// Recv is the Go type of the receiver of RuleMethod, like Doc, which the first parameter
+	// must point to or be, the one of the first parameter if empty
+	Recv string `json:"recv,omitempty"`
+}
+
+// NameRules are the compiled rules of Config.Rules.
+type NameRules struct {
+	rules []*nameRule
+}
+
+type nameRule struct {
+	*NameRule
+	re *regexp.Regexp
+}
+
+// CompileRules compiles the rules in order, which are nil if there are none.
+func CompileRules(rules []NameRule) (*NameRules, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	compiled := &NameRules{}
+	for i := range rules {
+		rule := &rules[i]
+		re, err := rule.compile()
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		compiled.rules = append(compiled.rules, &nameRule{NameRule: rule, re: re})
+	}
+	return compiled, nil
+}
+
+func (r *NameRule) compile() (*regexp.Regexp, error) {
+	for _, kind := range r.Kinds {
+		switch kind {
+		case RuleFunc, RuleType, RuleEnumItem, RuleMacro:
+		default:
+			return nil, fmt.Errorf("unknown kind %q", kind)
+		}
+	}
+	switch r.Action {
+	case "", RuleRename:
+		if r.Name == "" {
+			return nil, fmt.Errorf("rename needs a name")
+		}
+	case RuleMethod:
+		if r.Name == "" {
+			return nil, fmt.Errorf("method needs a name")
+		}
+		if len(r.Kinds) != 1 || r.Kinds[0] != RuleFunc {
+			return nil, fmt.Errorf("method applies to the kind %s only", RuleFunc)
+		}
+	case RuleIgnore:
+	default:
+		return nil, fmt.Errorf("unknown action %q", r.Action)
+	}
+	if r.Recv != "" && r.Action != RuleMethod {
+		return nil, fmt.Errorf("recv needs the action %s", RuleMethod)
+	}
+	switch {
+	case r.Match != "" && r.Regexp != "":
+		return nil, fmt.Errorf("both match and regexp are set")
+	case r.Match != "":
+		return regexp.Compile(globRegexp(r.Match))
+	case r.Regexp != "":
+		return regexp.Compile("^(?:" + r.Regexp + ")$")
+	}
+	return nil, fmt.Errorf("match or regexp needed")
+}
+
+// globRegexp returns the regular expression of a glob pattern, whose wildcards are the captures.
+func globRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString("(.*)")
+		case '?':
+			b.WriteString("(.)")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// Match returns the first rule of the kind matching the C name, and the Go name it
+// expands to, which is empty for RuleIgnore.
+func (r *NameRules) Match(kind, cname string) (rule *NameRule, goName string, ok bool) {
+	if r == nil {
+		return nil, "", false
+	}
+	for _, rule := range r.rules {
+		if len(rule.Kinds) > 0 && !slices.Contains(rule.Kinds, kind) {
+			continue
+		}
+		match := rule.re.FindStringSubmatchIndex(cname)
+		if match == nil {
+			continue
+		}
+		if rule.Action != RuleIgnore {
+			goName = string(rule.re.ExpandString(nil, rule.Name, cname, match))
+		}
+		return rule.NameRule, goName, true
+	}
+	return nil, "", false
+}
@@ -2,7 +2,9 @@ package name
 
 import (
 	"fmt"
+	"go/token"
 	"path/filepath"
+	"slices"
 	"strings"
 	"unicode"
 )
@@ -96,3 +98,76 @@ func SuffixCount(name string, count int) string {
 	}
 	return name
 }
+
+// SplitScope splits a qualified C++ name like a::b::Foo to its scopes and the name,
+// the scopes of the template arguments like the ones of std::vector<std::string> are kept.
+func SplitScope(cname string) (scopes []string, name string) {
+	depth, start := 0, 0
+	for i := 0; i < len(cname); i++ {
+		switch cname[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ':':
+			if depth <= 0 && strings.HasPrefix(cname[i:], "::") {
+				scopes = append(scopes, cname[start:i])
+				i++
+				start = i + 1
+			}
+		}
+	}
+	return scopes, cname[start:]
+}
+
+// Namespaces returns the namespaces which remain in the Go names, without the anonymous ones
+// and the ones listed in strip, which match either a namespace or a qualified one like std::__1.
+func Namespaces(scopes []string, strip []string) []string {
+	var list []string
+	for i, scope := range scopes {
+		if scope == "" || strings.HasPrefix(scope, "(") {
+			continue
+		}
+		qualified := strings.Join(scopes[:i+1], "::")
+		if slices.Contains(strip, scope) || slices.Contains(strip, qualified) {
+			continue
+		}
+		list = append(list, scope)
+	}
+	return list
+}
+
+// StdName returns a qualified C++ name of the standard library without its inline namespaces,
+// like std::string of std::__1::string and std::__cxx11::basic_string.
+func StdName(cname string) string {
+	scopes, name := SplitScope(cname)
+	if len(scopes) == 0 || scopes[0] != "std" {
+		return cname
+	}
+	list := scopes[:1]
+	for _, scope := range scopes[1:] {
+		if !strings.HasPrefix(scope, "__") {
+			list = append(list, scope)
+		}
+	}
+	return strings.Join(append(list, name), "::")
+}
+
+// NamespacePrefix returns the prefix of the flattened Go names of the namespaces,
+// like Ns of NsFoo for ns::Foo.
+func NamespacePrefix(namespaces []string) string {
+	var prefix strings.Builder
+	for _, ns := range namespaces {
+		prefix.WriteString(PubName(ns))
+	}
+	return prefix.String()
+}
+
+// PackageName returns the Go package name of a namespace.
+func PackageName(ns string) string {
+	pkgName := strings.ToLower(strings.Trim(ns, "_"))
+	if pkgName == "" || unicode.IsDigit(rune(pkgName[0])) || token.IsKeyword(pkgName) {
+		pkgName = "x" + pkgName
+	}
+	return pkgName
+}
@@ -2,8 +2,10 @@ package name_test
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
+	llcppg "github.com/goplus/llcppg/config"
 	"github.com/goplus/llcppg/internal/name"
 )
 
@@ -115,3 +117,205 @@ func TestHeaderFileToGo(t *testing.T) {
 		})
 	}
 }
+
+func TestNamespaces(t *testing.T) {
+	testCases := []struct {
+		cname  string
+		strip  []string
+		prefix string
+		pkg    string
+	}{
+		{"Foo", nil, "", ""},
+		{"ns::Foo", nil, "Ns", "ns"},
+		{"a::b_c::Foo", nil, "ABC", "b_c"},
+		{"std::__1::vector", []string{"std::__1"}, "Std", "std"},
+		{"detail::impl::Foo", []string{"detail"}, "Impl", "impl"},
+		{"::Foo", nil, "", ""},
+		{"type::Foo", nil, "Type", "xtype"},
+		{"std::vector<std::string>", nil, "Std", "std"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.cname, func(t *testing.T) {
+			scopes, _ := name.SplitScope(tc.cname)
+			namespaces := name.Namespaces(scopes, tc.strip)
+			if prefix := name.NamespacePrefix(namespaces); prefix != tc.prefix {
+				t.Fatalf("NamespacePrefix(%v) = %q, want %q", namespaces, prefix, tc.prefix)
+			}
+			var pkg string
+			if len(namespaces) > 0 {
+				pkg = name.PackageName(namespaces[len(namespaces)-1])
+			}
+			if pkg != tc.pkg {
+				t.Fatalf("PackageName(%v) = %q, want %q", namespaces, pkg, tc.pkg)
+			}
+		})
+	}
+}
+
+func TestStdName(t *testing.T) {
+	testCases := map[string]string{
+		"std::string":                        "std::string",
+		"std::__1::string":                   "std::string",
+		"std::__cxx11::basic_string":         "std::basic_string",
+		"std::vector":                        "std::vector",
+		"ns::__detail::Foo":                  "ns::__detail::Foo",
+		"Foo":                                "Foo",
+		"std::__1::vector<std::__1::string>": "std::vector<std::__1::string>",
+	}
+	for cname, want := range testCases {
+		if got := name.StdName(cname); got != want {
+			t.Errorf("StdName(%q) = %q, want %q", cname, got, want)
+		}
+	}
+}
+
+func TestOperatorName(t *testing.T) {
+	testCases := []struct {
+		cname  string
+		arity  int
+		goName string
+		ok     bool
+	}{
+		{"operator+", 2, "Add", true},
+		{"operator+", 1, "Pos", true},
+		{"operator-", 1, "Neg", true},
+		{"operator==", 2, "Equal", true},
+		{"operator<", 2, "Less", true},
+		{"operator<=>", 2, "Compare", true},
+		{"operator[]", 2, "Index", true},
+		{"operator()", 3, "Call", true},
+		{"operator++", 1, "Inc", true},
+		{"operator++", 2, "PostInc", true},
+		{"operator~", 1, "Not", true},
+		{"operator<<=", 2, "ShlAssign", true},
+		{"operator new", 1, "", false},
+		{"operator delete[]", 1, "", false},
+		{"operator int", 1, "", false},
+		{`operator""_km`, 1, "", false},
+		{"operator,", 2, "", false},
+		{"operators", 1, "", false},
+		{"operator", 0, "", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.cname, func(t *testing.T) {
+			goName, ok := name.OperatorName(tc.cname, tc.arity)
+			if goName != tc.goName || ok != tc.ok {
+				t.Fatalf("OperatorName(%q, %d) = %q, %v, want %q, %v", tc.cname, tc.arity, goName, ok, tc.goName, tc.ok)
+			}
+		})
+	}
+	if name.IsOperator("operators") || !name.IsOperator("operator new") {
+		t.Fatal("IsOperator() reports the wrong operators")
+	}
+}
+
+func TestTemplateName(t *testing.T) {
+	testCases := []struct {
+		cname  string
+		goName string
+	}{
+		{"std::vector<int>", "VectorInt"},
+		{"Eigen::Matrix<float,3,3>", "MatrixFloat33"},
+		{"std::map<std::string, unsigned int>", "MapStringUnsignedInt"},
+		{"std::vector<std::vector<double> >", "VectorVectorDouble"},
+		{"std::vector<const char *>", "VectorConstCharPtr"},
+		{"Pair<uint8_t, Foo&>", "PairUint8TFooRef"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.cname, func(t *testing.T) {
+			if goName := name.TemplateName(tc.cname); goName != tc.goName {
+				t.Fatalf("TemplateName(%q) = %q, want %q", tc.cname, goName, tc.goName)
+			}
+			if !name.IsTemplateID(tc.cname) {
+				t.Fatalf("IsTemplateID(%q) = false", tc.cname)
+			}
+		})
+	}
+	for _, cname := range []string{"Foo", "ns::operator<=>", "Foo::operator->", "operator<"} {
+		if name.IsTemplateID(cname) {
+			t.Fatalf("IsTemplateID(%q) = true", cname)
+		}
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	testCases := []struct {
+		input  string
+		expect []string
+	}{
+		{"xmlHttpClient", []string{"xml", "Http", "Client"}},
+		{"XMLHttp", []string{"XML", "Http"}},
+		{"utf8String", []string{"utf8", "String"}},
+		{"sqlite3", []string{"sqlite3"}},
+		{"URL", []string{"URL"}},
+		{"", nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			if result := name.SplitWords(tc.input); !reflect.DeepEqual(result, tc.expect) {
+				t.Fatalf("expected %v, got %v", tc.expect, result)
+			}
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	initialisms := []string{"xml", "HTTP", "URL", "ID", "JSON"}
+	testCases := []struct {
+		naming *llcppg.Naming
+		input  string
+		types  string
+		funcs  string
+		consts string
+		fields string
+	}{
+		// the default policy
+		{nil, "xml_http_request", "XmlHttpRequest", "XmlHttpRequest", "Xml_http_request", "XmlHttpRequest"},
+		{&llcppg.Naming{}, "_gmp_err", "X_gmpErr", "X_gmpErr", "X_gmp_err", "X_gmpErr"},
+		{&llcppg.Naming{}, "xmlHTTPGet", "XmlHTTPGet", "XmlHTTPGet", "XmlHTTPGet", "XmlHTTPGet"},
+		// the initialisms
+		{&llcppg.Naming{Initialisms: initialisms}, "xml_http_request", "XMLHTTPRequest", "XMLHTTPRequest", "Xml_http_request", "XMLHTTPRequest"},
+		{&llcppg.Naming{Initialisms: initialisms}, "xmlHttpGetUrl", "XMLHTTPGetURL", "XMLHTTPGetURL", "XmlHttpGetUrl", "XMLHTTPGetURL"},
+		{&llcppg.Naming{Initialisms: initialisms}, "user_id_", "UserID_", "UserID_", "User_id_", "UserID_"},
+		{&llcppg.Naming{Initialisms: initialisms}, "_json_id", "X_jsonID", "X_jsonID", "X_json_id", "X_jsonID"},
+		{&llcppg.Naming{Initialisms: initialisms}, "2d_url", "X2dURL", "X2dURL", "X2d_url", "X2dURL"},
+		{&llcppg.Naming{Initialisms: initialisms}, "__", "X__", "X__", "X__", "X__"},
+		// the styles
+		{
+			&llcppg.Naming{Initialisms: initialisms, Types: llcppg.NamingTitle, Funcs: llcppg.NamingPreserve, Consts: llcppg.NamingCamel, Fields: llcppg.NamingTitle},
+			"XML_PARSE_NOENT", "XMLParseNoent", "XML_PARSE_NOENT", "XMLPARSENOENT", "XMLParseNoent",
+		},
+		{
+			&llcppg.Naming{Types: llcppg.NamingTitle, Consts: llcppg.NamingCamel},
+			"LUA_MULTRET", "LuaMultret", "LUAMULTRET", "LUAMULTRET", "LUAMULTRET",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("case %d", i), func(t *testing.T) {
+			p := name.NewPolicy(tc.naming)
+			if result := p.TypeName(tc.input); result != tc.types {
+				t.Fatalf("TypeName: expected %s, got %s", tc.types, result)
+			}
+			if result := p.FuncName(tc.input); result != tc.funcs {
+				t.Fatalf("FuncName: expected %s, got %s", tc.funcs, result)
+			}
+			if result := p.ConstName(tc.input); result != tc.consts {
+				t.Fatalf("ConstName: expected %s, got %s", tc.consts, result)
+			}
+			if result := p.FieldName(tc.input); result != tc.fields {
+				t.Fatalf("FieldName: expected %s, got %s", tc.fields, result)
+			}
+		})
+	}
+}
+
+func TestPolicyNamespacePrefix(t *testing.T) {
+	p := name.NewPolicy(&llcppg.Naming{Initialisms: []string{"IO"}})
+	if result := p.NamespacePrefix([]string{"io", "detail"}); result != "IODetail" {
+		t.Fatalf("expected IODetail, got %s", result)
+	}
+	var nilPolicy *name.Policy
+	if result := nilPolicy.NamespacePrefix([]string{"io", "detail"}); result != "IoDetail" {
+		t.Fatalf("expected IoDetail, got %s", result)
+	}
+}
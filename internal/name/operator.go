@@ -0,0 +1,81 @@
+package name
+
+import "strings"
+
+// operatorNames are the Go names of the C++ overloaded operators, the ones of the binary
+// operators and the postfix increments, whose arity is 2, and the ones of the unary operators.
+var operatorNames = map[string]struct{ binary, unary string }{
+	"+":   {"Add", "Pos"},
+	"-":   {"Sub", "Neg"},
+	"*":   {"Mul", "Deref"},
+	"/":   {"Div", ""},
+	"%":   {"Rem", ""},
+	"^":   {"Xor", ""},
+	"&":   {"And", "Addr"},
+	"|":   {"Or", ""},
+	"~":   {"", "Not"},
+	"!":   {"", "LogicalNot"},
+	"&&":  {"LogicalAnd", ""},
+	"||":  {"LogicalOr", ""},
+	"<<":  {"Shl", ""},
+	">>":  {"Shr", ""},
+	"=":   {"Assign", ""},
+	"+=":  {"AddAssign", ""},
+	"-=":  {"SubAssign", ""},
+	"*=":  {"MulAssign", ""},
+	"/=":  {"DivAssign", ""},
+	"%=":  {"RemAssign", ""},
+	"^=":  {"XorAssign", ""},
+	"&=":  {"AndAssign", ""},
+	"|=":  {"OrAssign", ""},
+	"<<=": {"ShlAssign", ""},
+	">>=": {"ShrAssign", ""},
+	"==":  {"Equal", ""},
+	"!=":  {"NotEqual", ""},
+	"<":   {"Less", ""},
+	">":   {"Greater", ""},
+	"<=":  {"LessEqual", ""},
+	">=":  {"GreaterEqual", ""},
+	"<=>": {"Compare", ""},
+	"++":  {"PostInc", "Inc"},
+	"--":  {"PostDec", "Dec"},
+}
+
+// IsOperator reports whether a C++ function name is an overloaded operator like `operator+`,
+// a conversion operator like `operator int` or a literal operator like `operator""_km`.
+func IsOperator(cname string) bool {
+	rest, ok := strings.CutPrefix(cname, "operator")
+	if !ok || rest == "" {
+		return false
+	}
+	c := rest[0]
+	return !(c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z')
+}
+
+// OperatorName returns the Go name of a C++ overloaded operator, like Add of `operator+`,
+// arity is the number of the operands, including the object of a member operator.
+// It reports false for the operators without a Go name, like `operator new` and the
+// conversion operators, which are to be named by the symMap configuration.
+func OperatorName(cname string, arity int) (string, bool) {
+	if !IsOperator(cname) {
+		return "", false
+	}
+	op := strings.ReplaceAll(strings.TrimPrefix(cname, "operator"), " ", "")
+	switch op {
+	case "[]":
+		return "Index", true
+	case "()":
+		return "Call", true
+	case "->":
+		return "Arrow", true
+	}
+	names, ok := operatorNames[op]
+	if !ok {
+		return "", false
+	}
+	goName := names.binary
+	if arity == 1 || goName == "" {
+		goName = names.unary
+	}
+	return goName, goName != ""
+}
@@ -0,0 +1,156 @@
+package name
+
+import (
+	"strings"
+	"unicode"
+
+	llcppg "github.com/goplus/llcppg/config"
+)
+
+// Policy names the Go identifiers of the C names of a package, see llcppg.Naming. The nil
+// policy is the default one, which names the types, the functions and the fields by PubName,
+// and the constants by ExportName.
+type Policy struct {
+	initialisms map[string]bool
+	types       string
+	funcs       string
+	consts      string
+	fields      string
+}
+
+// NewPolicy returns the policy of the naming of llcppg.cfg, which is nil if it's not given.
+func NewPolicy(naming *llcppg.Naming) *Policy {
+	if naming == nil {
+		return nil
+	}
+	p := &Policy{
+		initialisms: make(map[string]bool),
+		types:       naming.Types,
+		funcs:       naming.Funcs,
+		consts:      naming.Consts,
+		fields:      naming.Fields,
+	}
+	for _, word := range naming.Initialisms {
+		p.initialisms[strings.ToUpper(word)] = true
+	}
+	return p
+}
+
+// TypeName returns the Go name of a type.
+func (p *Policy) TypeName(name string) string {
+	if p == nil {
+		return PubName(name)
+	}
+	return p.styleName(p.types, llcppg.NamingCamel, name)
+}
+
+// FuncName returns the Go name of a function or a method.
+func (p *Policy) FuncName(name string) string {
+	if p == nil {
+		return PubName(name)
+	}
+	return p.styleName(p.funcs, llcppg.NamingCamel, name)
+}
+
+// ConstName returns the Go name of a macro or an enum item.
+func (p *Policy) ConstName(name string) string {
+	if p == nil {
+		return ExportName(name)
+	}
+	return p.styleName(p.consts, llcppg.NamingPreserve, name)
+}
+
+// FieldName returns the Go name of a record field.
+func (p *Policy) FieldName(name string) string {
+	if p == nil {
+		return PubName(name)
+	}
+	return p.styleName(p.fields, llcppg.NamingCamel, name)
+}
+
+// NamespacePrefix returns the Go prefix of the namespaces, each converted by the type naming rule.
+func (p *Policy) NamespacePrefix(namespaces []string) string {
+	var prefix strings.Builder
+	for _, ns := range namespaces {
+		prefix.WriteString(p.TypeName(ns))
+	}
+	return prefix.String()
+}
+
+func (p *Policy) styleName(style, defaultStyle, name string) string {
+	if style == "" {
+		style = defaultStyle
+	}
+	switch style {
+	case llcppg.NamingPreserve:
+		return ExportName(name)
+	case llcppg.NamingTitle:
+		return p.camelName(name, true)
+	}
+	if len(p.initialisms) == 0 {
+		return PubName(name)
+	}
+	return p.camelName(name, false)
+}
+
+// camelName is PubName splitting the words of camelCase too, like xml, Http and Client of
+// xmlHttpClient, whose initialisms are in upper case. The other letters of the words are
+// in lower case if title is true.
+func (p *Policy) camelName(name string, title bool) string {
+	if len(name) == 0 {
+		return name
+	}
+	baseName := strings.Trim(name, "_")
+	if len(baseName) == 0 {
+		return "X" + name
+	}
+	var b strings.Builder
+	// like PubName, the first word of a name starting with _ or a digit is kept after X
+	keepFirst := len(preUScore(name)) != 0 || unicode.IsDigit(rune(baseName[0]))
+	if keepFirst {
+		b.WriteString("X" + preUScore(name))
+	}
+	for _, part := range strings.Split(baseName, "_") {
+		for _, word := range SplitWords(part) {
+			switch upper := strings.ToUpper(word); {
+			case keepFirst:
+				b.WriteString(word)
+			case p.initialisms[upper]:
+				b.WriteString(upper)
+			case title:
+				b.WriteString(UpperFirst(strings.ToLower(word)))
+			default:
+				b.WriteString(UpperFirst(word))
+			}
+			keepFirst = false
+		}
+	}
+	b.WriteString(sufUScore(name))
+	return b.String()
+}
+
+// SplitWords splits a camelCase or PascalCase name to its words, like xml, Http and Client
+// of xmlHttpClient, and XML and Http of XMLHttp. The digits belong to the words before them,
+// like sqlite3.
+func SplitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		switch {
+		case unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			// xmlHttp, utf8String
+		case unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// XMLHttp
+		default:
+			continue
+		}
+		words = append(words, string(runes[start:i]))
+		start = i
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
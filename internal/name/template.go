@@ -0,0 +1,46 @@
+package name
+
+import "strings"
+
+// TemplateName returns the Go name of a class template instantiation, which is named after
+// the template and its arguments without their scopes, like VectorInt of std::vector<int>
+// and MatrixFloat33 of Eigen::Matrix<float, 3, 3>. The pointers and references of the
+// arguments are spelled Ptr and Ref, like VectorCharPtr of std::vector<char *>.
+func TemplateName(cname string) string {
+	var b strings.Builder
+	for i := 0; i < len(cname); {
+		c := cname[i]
+		switch {
+		case isIdentChar(c):
+			j := i
+			for j < len(cname) && isIdentChar(cname[j]) {
+				j++
+			}
+			switch word := cname[i:j]; {
+			case strings.HasPrefix(cname[j:], "::"):
+				// the scopes are left out
+			case c >= '0' && c <= '9':
+				b.WriteString(word)
+			default:
+				b.WriteString(PubName(word))
+			}
+			i = j
+			continue
+		case c == '*':
+			b.WriteString("Ptr")
+		case c == '&':
+			b.WriteString("Ref")
+		}
+		i++
+	}
+	return b.String()
+}
+
+// IsTemplateID reports whether a C++ name is a template-id like std::vector<int>.
+func IsTemplateID(cname string) bool {
+	return strings.HasSuffix(cname, ">") && strings.Contains(cname, "<") && !IsOperator(cname[strings.LastIndex(cname, ":")+1:])
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
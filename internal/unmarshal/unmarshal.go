@@ -33,6 +33,10 @@ func init() {
 		"FieldList":   FieldList,
 		"ScopingExpr": ScopingExpr,
 		"TagExpr":     TagExpr,
+
+		"InstantiationType": InstantiationType,
+		"RawExpr":           RawExpr,
+
 		"EnumItem":    EnumItem,
 		"EnumType":    EnumType,
 		"FuncType":    FuncType,
@@ -194,11 +198,30 @@ func XType(data []byte, xType ast.Node) (ast.Node, error) {
 }
 
 func PointerType(data []byte) (ast.Node, error) {
-	return XType(data, &ast.PointerType{})
+	type pointerTemp struct {
+		IsConst bool
+	}
+	ptr := &ast.PointerType{}
+	if _, err := XType(data, ptr); err != nil {
+		return nil, err
+	}
+	var ptrData pointerTemp
+	if err := json.Unmarshal(data, &ptrData); err != nil {
+		return nil, newDeserializeError("PointerType", ptrData, data, err)
+	}
+	ptr.IsConst = ptrData.IsConst
+	return ptr, nil
 }
 
 func LvalueRefType(data []byte) (ast.Node, error) {
-	return XType(data, &ast.LvalueRefType{})
+	type lvalueRefTemp struct {
+		IsConst bool
+	}
+	var refData lvalueRefTemp
+	if err := json.Unmarshal(data, &refData); err != nil {
+		return nil, newDeserializeError("LvalueRefType", refData, data, err)
+	}
+	return XType(data, &ast.LvalueRefType{IsConst: refData.IsConst})
 }
 
 func RvalueRefType(data []byte) (ast.Node, error) {
@@ -256,6 +279,11 @@ func Field(data []byte) (ast.Node, error) {
 		Comment  *ast.CommentGroup
 		Access   ast.AccessSpecifier
 		IsStatic bool
+		IsBase   bool
+		Default  json.RawMessage
+
+		MangledName string
+		IsConstexpr bool
 	}
 	var fieldData fieldTemp
 	if err := json.Unmarshal(data, &fieldData); err != nil {
@@ -275,13 +303,31 @@ func Field(data []byte) (ast.Node, error) {
 		}
 	}
 
+	var def ast.Expr
+	if len(fieldData.Default) > 0 && !isJSONNull(fieldData.Default) {
+		defNode, err := Node(fieldData.Default)
+		if err != nil {
+			return nil, newUnmarshalFieldError("Field", fieldData, "Default", data, err)
+		}
+		var ok bool
+		def, ok = defNode.(ast.Expr)
+		if !ok {
+			return nil, newUnexpectTypeError("Field", defNode, "ast.Expr")
+		}
+	}
+
 	return &ast.Field{
 		Doc:      fieldData.Doc,
 		Names:    fieldData.Names,
 		Comment:  fieldData.Comment,
 		Access:   fieldData.Access,
 		IsStatic: fieldData.IsStatic,
+		IsBase:   fieldData.IsBase,
 		Type:     typ,
+		Default:  def,
+
+		MangledName: fieldData.MangledName,
+		IsConstexpr: fieldData.IsConstexpr,
 	}, nil
 }
 
@@ -403,7 +449,8 @@ func EnumItem(data []byte) (ast.Node, error) {
 
 func EnumType(data []byte) (ast.Node, error) {
 	type enumTypeTemp struct {
-		Items []json.RawMessage
+		Items    []json.RawMessage
+		IsScoped bool
 	}
 	var enumTypeData enumTypeTemp
 	if err := json.Unmarshal(data, &enumTypeData); err != nil {
@@ -424,7 +471,8 @@ func EnumType(data []byte) (ast.Node, error) {
 	}
 
 	return &ast.EnumType{
-		Items: items,
+		Items:    items,
+		IsScoped: enumTypeData.IsScoped,
 	}, nil
 }
 
@@ -433,6 +481,7 @@ func RecordType(data []byte) (ast.Node, error) {
 		Tag     ast.Tag
 		Fields  json.RawMessage
 		Methods []json.RawMessage
+		HasDef  *bool
 	}
 	var recordTypeData recordTypeTemp
 	if err := json.Unmarshal(data, &recordTypeData); err != nil {
@@ -465,10 +514,62 @@ func RecordType(data []byte) (ast.Node, error) {
 		methods = append(methods, method)
 	}
 
+	// output of an older llcppsigfetch has no HasDef, only a definition has fields
+	hasDef := fields != nil
+	if recordTypeData.HasDef != nil {
+		hasDef = *recordTypeData.HasDef
+	}
+
 	return &ast.RecordType{
 		Tag:     recordTypeData.Tag,
 		Fields:  fields,
 		Methods: methods,
+		HasDef:  hasDef,
+	}, nil
+}
+
+func RawExpr(data []byte) (ast.Node, error) {
+	var node ast.RawExpr
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, newDeserializeError("RawExpr", node, data, err)
+	}
+	return &node, nil
+}
+
+func InstantiationType(data []byte) (ast.Node, error) {
+	type instantiationTemp struct {
+		Template json.RawMessage
+		Args     json.RawMessage
+	}
+	var instData instantiationTemp
+	if err := json.Unmarshal(data, &instData); err != nil {
+		return nil, newDeserializeError("InstantiationType", instData, data, err)
+	}
+
+	tmplNode, err := Node(instData.Template)
+	if err != nil {
+		return nil, newUnmarshalFieldError("InstantiationType", instData, "Template", data, err)
+	}
+	tmpl, ok := tmplNode.(ast.Expr)
+	if !ok {
+		return nil, newUnexpectTypeError("InstantiationType", tmplNode, "ast.Expr")
+	}
+
+	var args *ast.FieldList
+	if !isJSONNull(instData.Args) {
+		argsNode, err := Node(instData.Args)
+		if err != nil {
+			return nil, newUnmarshalFieldError("InstantiationType", instData, "Args", data, err)
+		}
+		args, ok = argsNode.(*ast.FieldList)
+		if !ok {
+			return nil, newUnexpectTypeError("InstantiationType", argsNode, &ast.FieldList{})
+		}
+	}
+
+	return &ast.InstantiationType{
+		Template: tmpl,
+		Args:     args,
 	}, nil
 }
 
@@ -525,6 +626,7 @@ func FuncDecl(data []byte) (ast.Node, error) {
 		IsConstructor bool
 		IsDestructor  bool
 		IsVirtual     bool
+		IsPureVirtual bool
 		IsOverride    bool
 	}
 	var funcDeclData funcDeclTemp
@@ -561,6 +663,7 @@ func FuncDecl(data []byte) (ast.Node, error) {
 		IsConstructor: funcDeclData.IsConstructor,
 		IsDestructor:  funcDeclData.IsDestructor,
 		IsVirtual:     funcDeclData.IsVirtual,
+		IsPureVirtual: funcDeclData.IsPureVirtual,
 		IsOverride:    funcDeclData.IsOverride,
 	}, nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/goplus/llcppg/ast"
 	"github.com/goplus/llcppg/internal/unmarshal"
+	"github.com/goplus/llcppg/token"
 )
 
 func TestUnmarshalFile(t *testing.T) {
@@ -338,6 +339,21 @@ func TestUnmarshalNode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ConstLvalueRefType",
+			json: `{
+						"_Type":	"LvalueRefType",
+						"X":	{
+							"_Type":	"Ident",
+							"Name":	"Foo"
+						},
+						"IsConst":	true
+					}`,
+			expected: &ast.LvalueRefType{
+				X:       &ast.Ident{Name: "Foo"},
+				IsConst: true,
+			},
+		},
 		{
 			name: "BlockPointerType",
 			json: `{
@@ -425,6 +441,157 @@ func TestUnmarshalNode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "InstantiationType",
+			json: `{
+						"_Type":	"InstantiationType",
+						"Template":	{
+							"_Type":	"ScopingExpr",
+							"X":	{
+								"_Type":	"Ident",
+								"Name":	"vector"
+							},
+							"Parent":	{
+								"_Type":	"Ident",
+								"Name":	"std"
+							}
+						},
+						"Args":	{
+							"_Type":	"FieldList",
+							"List":	[{
+								"_Type":	"Field",
+								"Type":	{
+									"_Type":	"BuiltinType",
+									"Kind":	6,
+									"Flags":	0
+								}
+							}]
+						}
+					}`,
+			expected: &ast.InstantiationType{
+				Template: &ast.ScopingExpr{
+					Parent: &ast.Ident{Name: "std"},
+					X:      &ast.Ident{Name: "vector"},
+				},
+				Args: &ast.FieldList{
+					List: []*ast.Field{{Type: &ast.BuiltinType{Kind: 6}}},
+				},
+			},
+		},
+		{
+			name: "EnumType IsScoped",
+			json: `{
+						"_Type":	"EnumType",
+						"Items":	[{
+							"_Type":	"EnumItem",
+							"Name":	{
+								"_Type":	"Ident",
+								"Name":	"Red"
+							},
+							"Value":	{
+								"_Type":	"BasicLit",
+								"Kind":	0,
+								"Value":	"0"
+							}
+						}],
+						"IsScoped":	true
+					}`,
+			expected: &ast.EnumType{
+				Items: []*ast.EnumItem{{
+					Name:  &ast.Ident{Name: "Red"},
+					Value: &ast.BasicLit{Kind: ast.IntLit, Value: "0"},
+				}},
+				IsScoped: true,
+			},
+		},
+		{
+			name: "Field Default",
+			json: `{
+						"_Type":	"Field",
+						"Type":	{
+							"_Type":	"Ident",
+							"Name":	"Color"
+						},
+						"Names":	[{
+							"_Type":	"Ident",
+							"Name":	"c"
+						}],
+						"Access":	1,
+						"Default":	{
+							"_Type":	"ScopingExpr",
+							"X":	{
+								"_Type":	"Ident",
+								"Name":	"Black"
+							},
+							"Parent":	{
+								"_Type":	"Ident",
+								"Name":	"Color"
+							}
+						}
+					}`,
+			expected: &ast.Field{
+				Type:   &ast.Ident{Name: "Color"},
+				Names:  []*ast.Ident{{Name: "c"}},
+				Access: ast.Public,
+				Default: &ast.ScopingExpr{
+					Parent: &ast.Ident{Name: "Color"},
+					X:      &ast.Ident{Name: "Black"},
+				},
+			},
+		},
+		{
+			name: "Field static",
+			json: `{
+						"_Type":	"Field",
+						"Type":	{
+							"_Type":	"BuiltinType",
+							"Kind":	6,
+							"Flags":	0
+						},
+						"Names":	[{
+							"_Type":	"Ident",
+							"Name":	"max"
+						}],
+						"Access":	1,
+						"IsStatic":	true,
+						"MangledName":	"_ZN5Shape3maxE",
+						"IsConstexpr":	true
+					}`,
+			expected: &ast.Field{
+				Type:        &ast.BuiltinType{Kind: ast.Int},
+				Names:       []*ast.Ident{{Name: "max"}},
+				Access:      ast.Public,
+				IsStatic:    true,
+				MangledName: "_ZN5Shape3maxE",
+				IsConstexpr: true,
+			},
+		},
+		{
+			name: "RawExpr",
+			json: `{
+						"_Type":	"RawExpr",
+						"Tokens":	[{
+							"_Type":	"Token",
+							"Token":	4,
+							"Lit":	"1"
+						}, {
+							"_Type":	"Token",
+							"Token":	1,
+							"Lit":	"<<"
+						}, {
+							"_Type":	"Token",
+							"Token":	4,
+							"Lit":	"4"
+						}]
+					}`,
+			expected: &ast.RawExpr{
+				Tokens: []*ast.Token{
+					{Token: token.LITERAL, Lit: "1"},
+					{Token: token.PUNCT, Lit: "<<"},
+					{Token: token.LITERAL, Lit: "4"},
+				},
+			},
+		},
 		{
 			name: "Ident",
 			json: `{
@@ -538,6 +705,24 @@ func TestUnmarshalNode(t *testing.T) {
 				Names:    []*ast.Ident{{Name: "a"}},
 			},
 		},
+		{
+			name: "BaseField",
+			json: `{
+                "_Type": "Field",
+                "Type": {"_Type": "Ident", "Name": "Base"},
+                "Doc": null,
+                "Comment": null,
+                "IsStatic": false,
+                "IsBase": true,
+                "Access": 1,
+                "Names": null
+            }`,
+			expected: &ast.Field{
+				Type:   &ast.Ident{Name: "Base"},
+				IsBase: true,
+				Access: ast.Public,
+			},
+		},
 		{
 			name: "FieldList",
 			json: `{
@@ -724,7 +909,8 @@ func TestUnmarshalNode(t *testing.T) {
 				"IsExplicit":	false,
 				"IsConstructor":	false,
 				"IsDestructor":	false,
-				"IsVirtual":	false,
+				"IsVirtual":	true,
+				"IsPureVirtual":	true,
 				"IsOverride":	false
 			}`,
 			expected: &ast.FuncDecl{
@@ -765,6 +951,8 @@ func TestUnmarshalNode(t *testing.T) {
 						Flags: 0,
 					},
 				},
+				IsVirtual:     true,
+				IsPureVirtual: true,
 			},
 		},
 		{
@@ -901,7 +1089,8 @@ func TestUnmarshalNode(t *testing.T) {
 						}]
 				}`,
 			expected: &ast.RecordType{
-				Tag: 3,
+				Tag:    3,
+				HasDef: true,
 				Fields: &ast.FieldList{
 					List: []*ast.Field{
 						{
@@ -1008,6 +1197,33 @@ func TestUnmarshalNode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "RecordTypeForwardDecl",
+			json: `{
+					"_Type":	"RecordType",
+					"Tag":	0,
+					"Fields":	null,
+					"HasDef":	false,
+					"Methods":	null
+				}`,
+			expected: &ast.RecordType{
+				Tag: ast.Struct,
+			},
+		},
+		{
+			name: "RecordTypeEmptyDef",
+			json: `{
+					"_Type":	"RecordType",
+					"Tag":	0,
+					"Fields":	null,
+					"HasDef":	true,
+					"Methods":	null
+				}`,
+			expected: &ast.RecordType{
+				Tag:    ast.Struct,
+				HasDef: true,
+			},
+		},
 		{
 			name: "TypedefDecl",
 			json: `{